@@ -22,38 +22,50 @@ package main
 
 import (
 	"context"
-	"fmt"
-	"log"
-	"time"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/joho/godotenv"
 
 	_ "github.com/tabrezdn1/dune-form-analytics/api/docs"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/cli"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/container"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/version"
 )
 
 func main() {
-	// Initialize structured logging
-	logPrefix := fmt.Sprintf("[DUNE-API] %s", time.Now().Format("2006-01-02 15:04:05"))
-	log.SetPrefix(logPrefix + " ")
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	// Records before the container starts use the default logger; the container
+	// replaces it with the configured structured logger.
 
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
-		log.Println("INFO: No .env file found, using system environment variables")
+		slog.Info("No .env file found, using system environment variables")
 	}
 
-	log.Println("INFO: Starting Dune Form Analytics API with dependency injection")
+	// Administrative commands run instead of the server
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		code := cli.New().Run(ctx, os.Args[1:])
+		stop()
+		os.Exit(code)
+	}
+
+	build := version.Get()
+	slog.Info("Starting Dune Form Analytics API with dependency injection",
+		"version", build.Version, "revision", build.Revision, "go_version", build.GoVersion)
 
 	// Create and start the application using dependency injection
 	app := container.NewContainer()
 
 	// Run the application
 	if err := app.Start(context.Background()); err != nil {
-		log.Fatalf("ERROR: Failed to start application: %v", err)
+		slog.Error("Failed to start application", "error", err)
+		os.Exit(1)
 	}
 
 	// Wait for the application to stop
 	<-app.Done()
-	log.Println("INFO: Application stopped")
+	slog.Info("Application stopped")
 }
@@ -24,6 +24,26 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Public keys that verify access tokens signed with RS256 or EdDSA, selected by the \"kid\" token header.\nLists the current key, the next key ahead of its activation and previous keys until their tokens have\nexpired. Access tokens have the \"typ\" header \"at+jwt\" and the issuer \"dune-form-analytics\". Empty while\ntokens are signed with HS256. Served at the root, not under /api.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "JSON Web Key Set",
+                "responses": {
+                    "200": {
+                        "description": "Public keys",
+                        "schema": {
+                            "$ref": "#/definitions/models.JSONWebKeySet"
+                        }
+                    }
+                }
+            }
+        },
         "/analytics/summary": {
             "get": {
                 "security": [
@@ -42,6 +62,14 @@ const docTemplate = `{
                     "Analytics"
                 ],
                 "summary": "Get analytics summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID, or \\",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "Analytics summary retrieved successfully",
@@ -67,9 +95,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/login": {
+        "/auth/2fa/disable": {
             "post": {
-                "description": "Authenticate user with email and password",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Turn two-factor authentication off after confirming the password and a TOTP or recovery code",
                 "consumes": [
                     "application/json"
                 ],
@@ -79,23 +112,24 @@ const docTemplate = `{
                 "tags": [
                     "Authentication"
                 ],
-                "summary": "User login",
+                "summary": "Disable two-factor authentication",
                 "parameters": [
                     {
-                        "description": "Login credentials",
-                        "name": "credentials",
+                        "description": "Password and code",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.LoginRequest"
+                            "$ref": "#/definitions/models.DisableTwoFactorRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Login successful",
+                        "description": "Two-factor authentication disabled",
                         "schema": {
-                            "$ref": "#/definitions/models.AuthResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -106,50 +140,28 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Invalid credentials",
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "403": {
+                        "description": "Wrong password or code",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
-                    }
-                }
-            }
-        },
-        "/auth/logout": {
-            "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Log out the authenticated user",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Authentication"
-                ],
-                "summary": "User logout",
-                "responses": {
-                    "200": {
-                        "description": "Logged out successfully",
+                    },
+                    "409": {
+                        "description": "Two-factor authentication not enabled",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -158,14 +170,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/me": {
-            "get": {
+        "/auth/2fa/enable": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get information about the authenticated user",
+                "description": "Confirm the secret from /auth/2fa/setup with a code from the authenticator app. The recovery codes are only returned this once.",
                 "consumes": [
                     "application/json"
                 ],
@@ -175,12 +187,30 @@ const docTemplate = `{
                 "tags": [
                     "Authentication"
                 ],
-                "summary": "Get current user",
+                "summary": "Enable two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "TOTP code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "User information retrieved successfully",
+                        "description": "Recovery codes",
                         "schema": {
-                            "$ref": "#/definitions/models.UserResponse"
+                            "$ref": "#/definitions/models.TwoFactorRecoveryCodes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid code or setup not started",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "401": {
@@ -190,8 +220,8 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "409": {
+                        "description": "Two-factor authentication already enabled",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -207,9 +237,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/refresh": {
+        "/auth/2fa/setup": {
             "post": {
-                "description": "Refresh access token using refresh token",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generate a TOTP secret and its otpauth:// provisioning URI. Two-factor authentication is enabled once /auth/2fa/enable receives a code.",
                 "consumes": [
                     "application/json"
                 ],
@@ -219,34 +254,23 @@ const docTemplate = `{
                 "tags": [
                     "Authentication"
                 ],
-                "summary": "Refresh JWT token",
-                "parameters": [
-                    {
-                        "description": "Refresh token",
-                        "name": "token",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.RefreshTokenRequest"
-                        }
-                    }
-                ],
+                "summary": "Start two-factor setup",
                 "responses": {
                     "200": {
-                        "description": "Token refreshed successfully",
+                        "description": "Secret and provisioning URI",
                         "schema": {
-                            "$ref": "#/definitions/models.AuthResponse"
+                            "$ref": "#/definitions/models.TwoFactorSetup"
                         }
                     },
-                    "400": {
-                        "description": "Bad request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "Invalid token",
+                    "409": {
+                        "description": "Two-factor authentication already enabled",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -262,9 +286,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/signup": {
+        "/auth/2fa/verify": {
             "post": {
-                "description": "Register a new user account",
+                "description": "Exchange the twoFactorToken returned by login and a TOTP or recovery code for tokens",
                 "consumes": [
                     "application/json"
                 ],
@@ -274,21 +298,21 @@ const docTemplate = `{
                 "tags": [
                     "Authentication"
                 ],
-                "summary": "User registration",
+                "summary": "Complete two-factor login",
                 "parameters": [
                     {
-                        "description": "User registration data",
-                        "name": "user",
+                        "description": "Pending login and code",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.CreateUserRequest"
+                            "$ref": "#/definitions/models.TwoFactorLoginRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User registered successfully",
+                    "200": {
+                        "description": "Login successful",
                         "schema": {
                             "$ref": "#/definitions/models.AuthResponse"
                         }
@@ -300,8 +324,15 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "409": {
-                        "description": "User already exists",
+                    "401": {
+                        "description": "Invalid code or expired login",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed login attempts, see Retry-After",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -317,14 +348,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/forms": {
+        "/auth/api-keys": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get paginated list of forms for the authenticated user",
+                "description": "List the API keys of the authenticated user that have not been revoked, newest first.\nThe keys themselves are never returned, only their prefix.",
                 "consumes": [
                     "application/json"
                 ],
@@ -332,38 +363,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
-                ],
-                "summary": "List user forms",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
-                    }
+                    "Authentication"
                 ],
+                "summary": "List API keys",
                 "responses": {
                     "200": {
-                        "description": "Forms retrieved successfully",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request",
+                        "description": "API keys retrieved successfully",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIKey"
+                            }
                         }
                     },
                     "401": {
@@ -388,7 +398,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new form with the provided form data",
+                "description": "Create a personal API key for scripts and integrations. The key is only returned in this response;\nstore it securely. Send it as \"Authorization: Bearer dune_...\" to endpoints that accept its scopes.",
                 "consumes": [
                     "application/json"
                 ],
@@ -396,25 +406,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "Authentication"
                 ],
-                "summary": "Create a new form",
+                "summary": "Create API key",
                 "parameters": [
                     {
-                        "description": "Form creation data",
-                        "name": "form",
+                        "description": "Name, scopes and optional expiry",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.CreateFormRequest"
+                            "$ref": "#/definitions/models.CreateAPIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Form created successfully",
+                        "description": "API key created",
                         "schema": {
-                            "$ref": "#/definitions/models.FormResponse"
+                            "$ref": "#/definitions/models.CreatedAPIKey"
                         }
                     },
                     "400": {
@@ -431,6 +441,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "409": {
+                        "description": "Too many API keys",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -441,9 +458,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/forms/slug/{slug}": {
-            "get": {
-                "description": "Retrieve a published form using its slug (public access)",
+        "/auth/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke an API key of the authenticated user so that it stops working immediately",
                 "consumes": [
                     "application/json"
                 ],
@@ -451,34 +473,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "Authentication"
                 ],
-                "summary": "Get public form by slug",
+                "summary": "Revoke API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Form slug",
-                        "name": "slug",
+                        "description": "API key ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Form retrieved successfully",
+                        "description": "API key revoked",
                         "schema": {
-                            "$ref": "#/definitions/models.Form"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Bad request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "Form not found or not published",
+                        "description": "API key not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -494,14 +517,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/forms/{id}": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Retrieve form details by form ID",
+        "/auth/email/resend": {
+            "post": {
+                "description": "Email a new verification link to the account with this address if it is not verified yet. The response is the same whether or not the account exists.",
                 "consumes": [
                     "application/json"
                 ],
@@ -509,41 +527,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "Authentication"
                 ],
-                "summary": "Get a form by ID",
+                "summary": "Resend verification email",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Email address of the account",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ResendVerificationRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Form retrieved successfully",
-                        "schema": {
-                            "$ref": "#/definitions/models.Form"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
+                    "202": {
+                        "description": "Verification link sent if the account exists",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Form not found",
+                    "400": {
+                        "description": "Bad request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -557,14 +564,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Delete a form permanently",
+            }
+        },
+        "/auth/email/verify": {
+            "post": {
+                "description": "Confirm an email address with the token of a verification link",
                 "consumes": [
                     "application/json"
                 ],
@@ -572,42 +576,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "Authentication"
                 ],
-                "summary": "Delete form",
+                "summary": "Verify email address",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Verification token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.VerifyEmailRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Form deleted successfully",
+                        "description": "Email address verified",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.UserResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "404": {
-                        "description": "Form not found",
+                        "description": "Invalid request or expired token",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -621,14 +612,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Update an existing form's details",
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Authenticate user with email and password. Users with two-factor authentication get a twoFactorToken instead of tokens, to be completed at /auth/2fa/verify.",
                 "consumes": [
                     "application/json"
                 ],
@@ -636,32 +624,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "Authentication"
                 ],
-                "summary": "Update form",
+                "summary": "User login",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Form update data",
-                        "name": "form",
+                        "description": "Login credentials",
+                        "name": "credentials",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateFormRequest"
+                            "$ref": "#/definitions/models.LoginRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Form updated successfully",
+                        "description": "Login successful",
                         "schema": {
-                            "$ref": "#/definitions/models.Form"
+                            "$ref": "#/definitions/models.AuthResponse"
                         }
                     },
                     "400": {
@@ -672,14 +653,21 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Invalid credentials",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Form not found",
+                    "403": {
+                        "description": "Email address not verified",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed login attempts, see Retry-After",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -695,14 +683,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/forms/{id}/analytics": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Retrieve comprehensive analytics data for a specific form",
+        "/auth/logout": {
+            "post": {
+                "description": "Revoke the session of a refresh token so that it can no longer be refreshed",
                 "consumes": [
                     "application/json"
                 ],
@@ -710,21 +693,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Analytics"
+                    "Authentication"
                 ],
-                "summary": "Get form analytics",
+                "summary": "User logout",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Refresh token of the session",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshTokenRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Analytics retrieved successfully",
+                        "description": "Logged out successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -738,21 +723,7 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "404": {
-                        "description": "Form not found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
+                        "description": "Invalid token",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -761,42 +732,27 @@ const docTemplate = `{
                 }
             }
         },
-        "/forms/{id}/analytics.csv": {
-            "get": {
+        "/auth/logout-all": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Export form analytics data as a CSV file",
+                "description": "Revoke every session of the authenticated user. Access tokens of the sessions are rejected too, by\nother API instances within 30 seconds.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "text/csv"
+                    "application/json"
                 ],
                 "tags": [
-                    "Analytics"
-                ],
-                "summary": "Export analytics as CSV",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Authentication"
                 ],
+                "summary": "Log out all devices",
                 "responses": {
                     "200": {
-                        "description": "CSV file content",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request",
+                        "description": "Logged out of all devices",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -809,13 +765,6 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Form not found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -826,14 +775,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/forms/{id}/analytics/compute": {
-            "post": {
+        "/auth/me": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Trigger computation of analytics data for a specific form",
+                "description": "Get information about the authenticated user",
                 "consumes": [
                     "application/json"
                 ],
@@ -841,31 +790,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Analytics"
-                ],
-                "summary": "Compute form analytics",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Authentication"
                 ],
+                "summary": "Get current user",
                 "responses": {
                     "200": {
-                        "description": "Analytics computed successfully",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request",
+                        "description": "User information retrieved successfully",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.UserResponse"
                         }
                     },
                     "401": {
@@ -876,7 +808,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Form not found",
+                        "description": "User not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -892,38 +824,100 @@ const docTemplate = `{
                 }
             }
         },
-        "/forms/{id}/export.csv": {
+        "/auth/oidc": {
             "get": {
-                "security": [
+                "description": "Report whether single sign-on is configured and the provider name to show on the sign-in button",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Single sign-on provider",
+                "responses": {
+                    "200": {
+                        "description": "Single sign-on settings",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/callback": {
+            "get": {
+                "description": "Redirect target registered with the identity provider. Verifies the state and ID token, links or\ncreates the user by verified email address and redirects to the web app's /sso/callback page with a\none-time ticket, or to /login?error=... when single sign-on failed.",
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Single sign-on callback",
+                "parameters": [
                     {
-                        "BearerAuth": []
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "State of the login",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Error reported by the provider",
+                        "name": "error",
+                        "in": "query"
                     }
                 ],
-                "description": "Export all form responses as a CSV file",
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the web app",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Single sign-on is not configured",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/exchange": {
+            "post": {
+                "description": "Exchange the one-time ticket the callback passed to the web app for access and refresh tokens.\nTickets expire after one minute. Users with two-factor authentication get twoFactorRequired and a\ntwoFactorToken instead, to be completed with POST /auth/2fa/verify.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "text/csv"
+                    "application/json"
                 ],
                 "tags": [
-                    "Responses"
+                    "Authentication"
                 ],
-                "summary": "Export responses as CSV",
+                "summary": "Complete single sign-on",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Login ticket",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OIDCExchangeRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "CSV file content",
+                        "description": "Login successful",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.AuthResponse"
                         }
                     },
                     "400": {
@@ -934,14 +928,14 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Invalid, expired or used ticket",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "Form not found",
+                        "description": "Single sign-on is not configured",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -957,14 +951,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/forms/{id}/metrics": {
+        "/auth/oidc/login": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Retrieve real-time analytics metrics for a specific form",
+                "description": "Redirect the browser to the identity provider (authorization code flow with PKCE). The state of\nthe login is kept in an encrypted, HTTP-only cookie until the provider redirects back.",
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Start single sign-on",
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the identity provider",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Single sign-on is not configured",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Identity provider unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/forgot": {
+            "post": {
+                "description": "Email a password reset link to the account with this address. The response is the same whether or not the account exists.",
                 "consumes": [
                     "application/json"
                 ],
@@ -972,21 +992,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Analytics"
+                    "Authentication"
                 ],
-                "summary": "Get real-time metrics",
+                "summary": "Request password reset",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Email address of the account",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ForgotPasswordRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Real-time metrics retrieved successfully",
+                    "202": {
+                        "description": "Reset link sent if the account exists",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -999,15 +1021,50 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/reset": {
+            "post": {
+                "description": "Set a new password with the token of a password reset link. All sessions of the user are revoked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "Reset token and new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password reset",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Form not found",
+                    "400": {
+                        "description": "Invalid request or expired token",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1023,14 +1080,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/forms/{id}/publish": {
+        "/auth/refresh": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Publish a form to make it accessible via public slug",
+                "description": "Exchange a refresh token for new tokens. Refresh tokens are single use; reusing one revokes its session.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1038,23 +1090,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Forms"
+                    "Authentication"
                 ],
-                "summary": "Publish form",
+                "summary": "Refresh JWT token",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Refresh token",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshTokenRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Form published successfully",
+                        "description": "Token refreshed successfully",
                         "schema": {
-                            "$ref": "#/definitions/models.Form"
+                            "$ref": "#/definitions/models.AuthResponse"
                         }
                     },
                     "400": {
@@ -1065,14 +1119,52 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Invalid token",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Form not found",
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the active sessions of the authenticated user, most recently used first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "List sessions",
+                "responses": {
+                    "200": {
+                        "description": "Sessions retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SessionResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1088,14 +1180,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/forms/{id}/responses": {
-            "get": {
+        "/auth/sessions/{id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve paginated responses for a specific form",
+                "description": "Revoke a session of the authenticated user so that its refresh token stops working",
                 "consumes": [
                     "application/json"
                 ],
@@ -1103,42 +1195,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Responses"
+                    "Authentication"
                 ],
-                "summary": "Get form responses",
+                "summary": "Revoke session",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Form ID",
+                        "description": "Session ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Responses retrieved successfully",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request",
+                        "description": "Session revoked",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1152,7 +1223,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Form not found",
+                        "description": "Session not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1168,9 +1239,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/forms/{id}/submit": {
+        "/auth/signup": {
             "post": {
-                "description": "Submit a response to a published form",
+                "description": "Register a new user account",
                 "consumes": [
                     "application/json"
                 ],
@@ -1178,33 +1249,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Responses"
+                    "Authentication"
                 ],
-                "summary": "Submit form response",
+                "summary": "User registration",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Form response data",
-                        "name": "response",
+                        "description": "User registration data",
+                        "name": "user",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.SubmitResponseRequest"
+                            "$ref": "#/definitions/models.CreateUserRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Response submitted successfully",
+                        "description": "User registered successfully; without tokens when verified email addresses are required",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.AuthResponse"
                         }
                     },
                     "400": {
@@ -1214,8 +1277,8 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Form not found",
+                    "409": {
+                        "description": "User already exists",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1231,14 +1294,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/forms/{id}/trends": {
+        "/forms": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve trend analytics data for specific form fields over time",
+                "description": "Get paginated list of forms for the authenticated user",
                 "consumes": [
                     "application/json"
                 ],
@@ -1246,34 +1309,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Analytics"
+                    "Forms"
                 ],
-                "summary": "Get trend analytics",
+                "summary": "List user forms",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Organization ID, or \\",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
                     },
                     {
-                        "type": "string",
-                        "description": "Specific field to analyze",
-                        "name": "field",
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "default": "\"30d\"",
-                        "description": "Time period (7d, 30d, 90d)",
-                        "name": "period",
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Trend analytics retrieved successfully",
+                        "description": "Forms retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1293,13 +1356,6 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Form not found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -1308,16 +1364,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/forms/{id}/unpublish": {
+            },
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Unpublish a form to remove public access via slug",
+                "description": "Create a new form with the provided form data",
                 "consumes": [
                     "application/json"
                 ],
@@ -1327,19 +1381,145 @@ const docTemplate = `{
                 "tags": [
                     "Forms"
                 ],
-                "summary": "Unpublish form",
+                "summary": "Create a new form",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Form ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Organization ID, or \\",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Form creation data",
+                        "name": "form",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateFormRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Form unpublished successfully",
+                    "201": {
+                        "description": "Form created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.FormResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow changing forms",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/slug/{slug}": {
+            "get": {
+                "description": "Retrieve a published form using its slug (public access). Forms with anti-abuse\nprotection include a signed submission challenge that must be echoed back on submit.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Forms"
+                ],
+                "summary": "Get public form by slug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Form retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Form"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found or not published",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve form details by form ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Forms"
+                ],
+                "summary": "Get a form by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Form retrieved successfully",
                         "schema": {
                             "$ref": "#/definitions/models.Form"
                         }
@@ -1373,10 +1553,1961 @@ const docTemplate = `{
                         }
                     }
                 }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a form permanently",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Forms"
+                ],
+                "summary": "Delete form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Form deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow changing forms",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing form's details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Forms"
+                ],
+                "summary": "Update form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Form update data",
+                        "name": "form",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateFormRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Form updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Form"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow changing forms",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/{id}/analytics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve comprehensive analytics data for a specific form",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Get form analytics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Analytics retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/{id}/analytics.csv": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Export form analytics data as a CSV file",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Export analytics as CSV",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "CSV file content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/{id}/analytics/compute": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Trigger computation of analytics data for a specific form",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Compute form analytics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Analytics computed successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/{id}/export.csv": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Export all form responses as a CSV file",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "Responses"
+                ],
+                "summary": "Export responses as CSV",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "CSV file content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/{id}/metrics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve real-time analytics metrics for a specific form",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Get real-time metrics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Real-time metrics retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/{id}/presence": {
+            "post": {
+                "description": "Sent by an open public form page every heartbeatInterval seconds. The state is \"viewing\" until\nthe respondent starts answering (\"filling\") and \"left\" when the page is closed or submitted.\nChanges in the live audience are pushed to the form owner's dashboards as presence:update messages.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Responses"
+                ],
+                "summary": "Presence heartbeat",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Presence heartbeat",
+                        "name": "heartbeat",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PresenceHeartbeatRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Heartbeat recorded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/{id}/publish": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Publish a form to make it accessible via public slug",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Forms"
+                ],
+                "summary": "Publish form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Form published successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Form"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow changing forms",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/{id}/responses": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve paginated responses for a specific form",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Responses"
+                ],
+                "summary": "Get form responses",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Responses retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/{id}/submit": {
+            "post": {
+                "description": "Submit a response to a published form",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Responses"
+                ],
+                "summary": "Submit form response",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes client retries safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Form response data",
+                        "name": "response",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SubmitResponseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Response submitted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request or form not published",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Sign-in required to respond",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Submission rejected by anti-abuse checks",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Already responded or request in progress",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency key reused with a different request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/{id}/transfer": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move one of your personal forms into an organization where your role allows creating forms.\nThe form, its responses and analytics are then shared with the organization's members. You\nremain its creator (ownerId). Forms cannot be moved out of an organization.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Forms"
+                ],
+                "summary": "Transfer form to an organization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Target organization",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TransferFormRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Form transferred successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.FormResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow creating forms in the organization",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form or organization not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Form already belongs to an organization",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/{id}/trends": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve trend analytics data for specific form fields over time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Get trend analytics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Specific field to analyze",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "\"30d\"",
+                        "description": "Time period (7d, 30d, 90d)",
+                        "name": "period",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trend analytics retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/forms/{id}/unpublish": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Unpublish a form to remove public access via slug",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Forms"
+                ],
+                "summary": "Unpublish form",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Form ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Form unpublished successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Form"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow changing forms",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Form not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/invitations/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Join an organization with the token from an invitation email. The invitation must have been sent\nto the email address of the authenticated user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Accept organization invitation",
+                "parameters": [
+                    {
+                        "description": "Invitation token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AcceptInvitationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Invitation accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Invitation was sent to another email address",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Invitation not found or expired",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "User is already a member",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the organizations the authenticated user is a member of, with their role, by name.\nTogether with the personal workspace these are the workspaces the user can switch between.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "List organizations",
+                "responses": {
+                    "200": {
+                        "description": "Organizations retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrganizationResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create an organization whose forms are shared by its members. You become its owner.\nSelect it with the X-Workspace-ID header to create and list its forms.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Create organization",
+                "parameters": [
+                    {
+                        "description": "Organization name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateOrganizationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Organization created",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get an organization the authenticated user is a member of, with their role",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Get organization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Organization retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Organization not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete an organization and its memberships. Only owners may delete it, and only once it owns no forms.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Delete organization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Organization deleted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow this action",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Organization not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Organization still owns forms",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rename an organization. Only owners may rename it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Rename organization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateOrganizationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Organization renamed",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow this action",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Organization not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{id}/invitations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the pending invitations of an organization, newest first. Owners and admins may list them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "List organization invitations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Invitations retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Invitation"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow this action",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Organization not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Email an invitation to join an organization. Owners and admins may invite, with a role no higher\nthan their own. The response does not tell whether the address belongs to an account; the\ninvitation only grants membership once the owner of the address accepts it. Inviting an address\nagain replaces its pending invitation.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Invite organization member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Email to invite and the offered role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.InviteMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Invitation sent",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow this action",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Organization not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{id}/invitations/{invitationId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Withdraw a pending invitation so its link no longer works. Owners and admins may revoke invitations.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Revoke organization invitation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Invitation ID",
+                        "name": "invitationId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Invitation revoked",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow this action",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Organization or invitation not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{id}/members": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the members of an organization the authenticated user is a member of, by email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "List organization members",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Members retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.MemberResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Organization not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{id}/members/{userId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a member from an organization. Members may leave by removing themselves; owners and admins\nmay remove members up to their own role. The last owner cannot leave.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Remove organization member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID of the member",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Member removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow this action",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Organization or member not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Organization needs at least one owner",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the role of a member. Owners and admins may change the roles of members up to their own role.\nThe last owner cannot be demoted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Change member role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID of the member",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Role changed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow this action",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Organization or member not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Organization needs at least one owner",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "lastUsedIp": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Start of the key, to recognise it in lists",
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.APIKeyScope"
+                    }
+                }
             }
-        }
-    },
-    "definitions": {
+        },
+        "models.APIKeyScope": {
+            "type": "string",
+            "enum": [
+                "forms:read",
+                "responses:read",
+                "responses:export",
+                "analytics:read"
+            ],
+            "x-enum-comments": {
+                "ScopeAnalyticsRead": "Read analytics, metrics and trends",
+                "ScopeFormsRead": "List and read forms",
+                "ScopeResponsesExport": "Download responses as CSV",
+                "ScopeResponsesRead": "Read responses"
+            },
+            "x-enum-descriptions": [
+                "List and read forms",
+                "Read responses",
+                "Download responses as CSV",
+                "Read analytics, metrics and trends"
+            ],
+            "x-enum-varnames": [
+                "ScopeFormsRead",
+                "ScopeResponsesRead",
+                "ScopeResponsesExport",
+                "ScopeAnalyticsRead"
+            ]
+        },
+        "models.AcceptInvitationRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Answer": {
             "type": "object",
             "required": [
@@ -1390,6 +3521,27 @@ const docTemplate = `{
                 "value": {}
             }
         },
+        "models.AntiAbuseSettings": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "minFillSeconds": {
+                    "type": "integer",
+                    "maximum": 3600,
+                    "minimum": 0
+                },
+                "powDifficulty": {
+                    "type": "integer",
+                    "maximum": 32,
+                    "minimum": 1
+                },
+                "proofOfWork": {
+                    "type": "boolean"
+                }
+            }
+        },
         "models.AuthResponse": {
             "type": "object",
             "properties": {
@@ -1399,11 +3551,53 @@ const docTemplate = `{
                 "refreshToken": {
                     "type": "string"
                 },
+                "twoFactorRequired": {
+                    "type": "boolean"
+                },
+                "twoFactorToken": {
+                    "type": "string"
+                },
                 "user": {
                     "$ref": "#/definitions/models.UserResponse"
                 }
             }
         },
+        "models.ChallengeSolution": {
+            "type": "object",
+            "properties": {
+                "nonce": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expiresAt": {
+                    "description": "Never expires when omitted",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.APIKeyScope"
+                    }
+                }
+            }
+        },
         "models.CreateFormRequest": {
             "type": "object",
             "required": [
@@ -1423,6 +3617,9 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Field"
                     }
                 },
+                "settings": {
+                    "$ref": "#/definitions/models.FormSettings"
+                },
                 "title": {
                     "type": "string",
                     "maxLength": 200,
@@ -1430,6 +3627,19 @@ const docTemplate = `{
                 }
             }
         },
+        "models.CreateOrganizationRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                }
+            }
+        },
         "models.CreateUserRequest": {
             "type": "object",
             "required": [
@@ -1452,6 +3662,60 @@ const docTemplate = `{
                 }
             }
         },
+        "models.CreatedAPIKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "lastUsedIp": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Start of the key, to recognise it in lists",
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.APIKeyScope"
+                    }
+                }
+            }
+        },
+        "models.DisableTwoFactorRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "password"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Field": {
             "type": "object",
             "required": [
@@ -1515,6 +3779,17 @@ const docTemplate = `{
                 "FieldTypeRating"
             ]
         },
+        "models.ForgotPasswordRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Form": {
             "type": "object",
             "required": [
@@ -1542,80 +3817,210 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Field"
                     }
                 },
+                "organizationId": {
+                    "description": "Organization whose members share the form",
+                    "type": "string"
+                },
+                "ownerId": {
+                    "description": "Creator; owns the form unless it belongs to an organization",
+                    "type": "string"
+                },
+                "settings": {
+                    "$ref": "#/definitions/models.FormSettings"
+                },
+                "shareSlug": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 3
+                },
+                "status": {
+                    "enum": [
+                        "draft",
+                        "published"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.FormStatus"
+                        }
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "minLength": 1
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FormResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Field"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "organizationId": {
+                    "type": "string"
+                },
                 "ownerId": {
                     "type": "string"
                 },
-                "shareSlug": {
-                    "type": "string",
-                    "maxLength": 50,
-                    "minLength": 3
-                },
-                "status": {
+                "settings": {
+                    "$ref": "#/definitions/models.FormSettings"
+                },
+                "shareSlug": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FormSettings": {
+            "type": "object",
+            "properties": {
+                "antiAbuse": {
+                    "$ref": "#/definitions/models.AntiAbuseSettings"
+                },
+                "limitOneResponse": {
+                    "type": "boolean"
+                },
+                "respondentIdentity": {
+                    "enum": [
+                        "user",
+                        "cookie",
+                        "fingerprint"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RespondentIdentity"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.FormStatus": {
+            "type": "string",
+            "enum": [
+                "draft",
+                "published"
+            ],
+            "x-enum-varnames": [
+                "FormStatusDraft",
+                "FormStatusPublished"
+            ]
+        },
+        "models.Invitation": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Lower case",
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "description": "Removed by a TTL index",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "invitedBy": {
+                    "type": "string"
+                },
+                "organizationId": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/models.OrganizationRole"
+                }
+            }
+        },
+        "models.InviteMemberRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "role"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "role": {
                     "enum": [
-                        "draft",
-                        "published"
+                        "owner",
+                        "admin",
+                        "editor",
+                        "viewer"
                     ],
                     "allOf": [
                         {
-                            "$ref": "#/definitions/models.FormStatus"
+                            "$ref": "#/definitions/models.OrganizationRole"
                         }
                     ]
-                },
-                "title": {
-                    "type": "string",
-                    "maxLength": 200,
-                    "minLength": 1
-                },
-                "updatedAt": {
-                    "type": "string"
                 }
             }
         },
-        "models.FormResponse": {
+        "models.JSONWebKey": {
             "type": "object",
             "properties": {
-                "createdAt": {
+                "alg": {
                     "type": "string"
                 },
-                "description": {
+                "crv": {
                     "type": "string"
                 },
-                "fields": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Field"
-                    }
-                },
-                "id": {
+                "e": {
                     "type": "string"
                 },
-                "ownerId": {
+                "kid": {
                     "type": "string"
                 },
-                "shareSlug": {
+                "kty": {
                     "type": "string"
                 },
-                "status": {
+                "n": {
                     "type": "string"
                 },
-                "title": {
+                "use": {
                     "type": "string"
                 },
-                "updatedAt": {
+                "x": {
                     "type": "string"
                 }
             }
         },
-        "models.FormStatus": {
-            "type": "string",
-            "enum": [
-                "draft",
-                "published"
-            ],
-            "x-enum-varnames": [
-                "FormStatusDraft",
-                "FormStatusPublished"
-            ]
+        "models.JSONWebKeySet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.JSONWebKey"
+                    }
+                }
+            }
         },
         "models.LoginRequest": {
             "type": "object",
@@ -1632,6 +4037,37 @@ const docTemplate = `{
                 }
             }
         },
+        "models.MemberResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "joinedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/models.OrganizationRole"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OIDCExchangeRequest": {
+            "type": "object",
+            "required": [
+                "ticket"
+            ],
+            "properties": {
+                "ticket": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Option": {
             "type": "object",
             "required": [
@@ -1651,6 +4087,100 @@ const docTemplate = `{
                 }
             }
         },
+        "models.OrganizationResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "Role of the requesting user",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.OrganizationRole"
+                        }
+                    ]
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrganizationRole": {
+            "type": "string",
+            "enum": [
+                "owner",
+                "admin",
+                "editor",
+                "viewer"
+            ],
+            "x-enum-comments": {
+                "RoleAdmin": "Manage forms and members",
+                "RoleEditor": "Create, edit, publish and delete forms",
+                "RoleOwner": "Everything, including renaming and deleting the organization",
+                "RoleViewer": "Read forms, responses and analytics"
+            },
+            "x-enum-descriptions": [
+                "Everything, including renaming and deleting the organization",
+                "Manage forms and members",
+                "Create, edit, publish and delete forms",
+                "Read forms, responses and analytics"
+            ],
+            "x-enum-varnames": [
+                "RoleOwner",
+                "RoleAdmin",
+                "RoleEditor",
+                "RoleViewer"
+            ]
+        },
+        "models.PresenceHeartbeatRequest": {
+            "type": "object",
+            "required": [
+                "sessionId",
+                "state"
+            ],
+            "properties": {
+                "sessionId": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 16
+                },
+                "state": {
+                    "enum": [
+                        "viewing",
+                        "filling",
+                        "left"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PresenceState"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.PresenceState": {
+            "type": "string",
+            "enum": [
+                "viewing",
+                "filling",
+                "left"
+            ],
+            "x-enum-varnames": [
+                "PresenceViewing",
+                "PresenceFilling",
+                "PresenceLeft"
+            ]
+        },
         "models.RefreshTokenRequest": {
             "type": "object",
             "required": [
@@ -1662,6 +4192,46 @@ const docTemplate = `{
                 }
             }
         },
+        "models.ResendVerificationRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RespondentIdentity": {
+            "type": "string",
+            "enum": [
+                "user",
+                "cookie",
+                "fingerprint"
+            ],
+            "x-enum-varnames": [
+                "RespondentIdentityUser",
+                "RespondentIdentityCookie",
+                "RespondentIdentityFingerprint"
+            ]
+        },
         "models.ResponseMeta": {
             "type": "object",
             "properties": {
@@ -1676,6 +4246,33 @@ const docTemplate = `{
                 }
             }
         },
+        "models.SessionResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "current": {
+                    "description": "The session of the access token making the request",
+                    "type": "boolean"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "userAgent": {
+                    "type": "string"
+                }
+            }
+        },
         "models.SubmitResponseRequest": {
             "type": "object",
             "required": [
@@ -1689,11 +4286,73 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Answer"
                     }
                 },
+                "challenge": {
+                    "$ref": "#/definitions/models.ChallengeSolution"
+                },
                 "meta": {
                     "$ref": "#/definitions/models.ResponseMeta"
                 }
             }
         },
+        "models.TransferFormRequest": {
+            "type": "object",
+            "required": [
+                "organizationId"
+            ],
+            "properties": {
+                "organizationId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TwoFactorCodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TwoFactorLoginRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "twoFactorToken"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "twoFactorToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TwoFactorRecoveryCodes": {
+            "type": "object",
+            "properties": {
+                "recoveryCodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.TwoFactorSetup": {
+            "type": "object",
+            "properties": {
+                "provisioningUri": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
         "models.UpdateFormRequest": {
             "type": "object",
             "properties": {
@@ -1709,6 +4368,9 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Field"
                     }
                 },
+                "settings": {
+                    "$ref": "#/definitions/models.FormSettings"
+                },
                 "status": {
                     "enum": [
                         "draft",
@@ -1727,6 +4389,40 @@ const docTemplate = `{
                 }
             }
         },
+        "models.UpdateMemberRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "enum": [
+                        "owner",
+                        "admin",
+                        "editor",
+                        "viewer"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.OrganizationRole"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.UpdateOrganizationRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                }
+            }
+        },
         "models.UserResponse": {
             "type": "object",
             "properties": {
@@ -1736,12 +4432,18 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
+                "emailVerified": {
+                    "type": "boolean"
+                },
                 "id": {
                     "type": "string"
                 },
                 "name": {
                     "type": "string"
                 },
+                "twoFactorEnabled": {
+                    "type": "boolean"
+                },
                 "updatedAt": {
                     "type": "string"
                 }
@@ -1773,6 +4475,17 @@ const docTemplate = `{
                 }
             }
         },
+        "models.VerifyEmailRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
         "models.VisibilityCondition": {
             "type": "object",
             "required": [
@@ -0,0 +1,258 @@
+// Package cli implements the administrative subcommands of the API binary. They
+// use the same configuration, database and services as the server.
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	validator "github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/config"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/interfaces"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/logging"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/migrations"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+)
+
+// errUsage reports invalid arguments; the command's usage has already been printed
+var errUsage = errors.New("invalid arguments")
+
+// UserService manages user accounts
+type UserService interface {
+	CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	SetPassword(ctx context.Context, userID primitive.ObjectID, password string) error
+	LogoutAll(ctx context.Context, userID string) (int64, error)
+	MarkEmailVerified(ctx context.Context, userID primitive.ObjectID) error
+	ResetTwoFactor(ctx context.Context, userID primitive.ObjectID) error
+}
+
+// Env holds the configuration, database and services commands work with
+type Env struct {
+	Config    *config.Config
+	Database  interfaces.DatabaseInterface
+	Users     UserService
+	Forms     interfaces.FormServiceInterface
+	Responses interfaces.ResponseServiceInterface
+	Analytics interfaces.AnalyticsServiceInterface
+	Migrator  *migrations.Migrator
+}
+
+// command is an administrative subcommand
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(ctx context.Context, c *CLI, args []string) error
+}
+
+// commands lists the subcommands in the order they are shown in the usage
+var commands = []command{
+	{"create-user", "-email EMAIL -name NAME [-password-stdin]", "Create a user account", createUser},
+	{"reset-password", "-email EMAIL [-password-stdin]", "Set a new password for a user and sign out all their sessions", resetPassword},
+	{"reset-2fa", "-email EMAIL", "Turn off two-factor authentication for a user who lost their authenticator", resetTwoFactor},
+	{"list-forms", "[-owner EMAIL] [-page N] [-limit N] [-json]", "List forms, newest first", listForms},
+	{"recompute-analytics", "-form ID | -all", "Recompute analytics from stored responses", recomputeAnalytics},
+	{"export-responses", "-form ID -out FILE [-from DATE] [-to DATE]", "Export a form's responses as CSV", exportResponses},
+	{"reindex", "", "Create missing database indexes and verify them", reindex},
+	{"migrate", "run|rollback|status|seed [flags]", "Apply, roll back or inspect schema migrations", migrate},
+}
+
+// CLI runs administrative subcommands
+type CLI struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Connect opens the database and creates the services. Commands call it after
+	// their arguments are validated; the returned function releases the resources.
+	Connect func(ctx context.Context) (*Env, func(), error)
+
+	validator *validator.Validate
+	current   command
+}
+
+// New creates a CLI on the process's standard streams that connects to the
+// configured database
+func New() *CLI {
+	return &CLI{
+		Stdin:   os.Stdin,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+		Connect: Connect,
+	}
+}
+
+// IsCommand reports whether name is a subcommand
+func IsCommand(name string) bool {
+	if name == "help" || name == "-h" || name == "--help" {
+		return true
+	}
+	_, ok := lookup(name)
+	return ok
+}
+
+// lookup returns the subcommand with the given name
+func lookup(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// Run runs the subcommand named by args[0] and returns the process exit code:
+// 0 on success, 1 when the command failed and 2 for invalid arguments
+func (c *CLI) Run(ctx context.Context, args []string) int {
+	if c.validator == nil {
+		c.validator = validator.New()
+	}
+
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.usage()
+		return 2
+	}
+
+	cmd, ok := lookup(args[0])
+	if !ok {
+		fmt.Fprintf(c.Stderr, "Unknown command %q\n\n", args[0])
+		c.usage()
+		return 2
+	}
+
+	c.current = cmd
+	err := cmd.run(ctx, c, args[1:])
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		return 2
+	default:
+		fmt.Fprintln(c.Stderr, "Error:", err)
+		return 1
+	}
+}
+
+// usage prints the available subcommands
+func (c *CLI) usage() {
+	fmt.Fprintln(c.Stderr, "Usage: server [command] [flags]")
+	fmt.Fprintln(c.Stderr)
+	fmt.Fprintln(c.Stderr, "Without a command, the API server starts. Commands:")
+	width := 0
+	for _, cmd := range commands {
+		if len(cmd.name) > width {
+			width = len(cmd.name)
+		}
+	}
+	for _, cmd := range commands {
+		fmt.Fprintf(c.Stderr, "  %-*s  %s\n", width, cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(c.Stderr)
+	fmt.Fprintln(c.Stderr, "Run 'server <command> -h' for the flags of a command.")
+}
+
+// flags returns a flag set for the running command that prints its usage to stderr
+func (c *CLI) flags() *flag.FlagSet {
+	cmd := c.current
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.SetOutput(c.Stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.Stderr, "Usage: server %s %s\n\n%s\n", cmd.name, cmd.args, cmd.summary)
+		if hasFlags(flags) {
+			fmt.Fprintln(c.Stderr)
+			flags.PrintDefaults()
+		}
+	}
+	return flags
+}
+
+// hasFlags reports whether any flags are defined
+func hasFlags(flags *flag.FlagSet) bool {
+	found := false
+	flags.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+// parse parses a command's flags, rejecting positional arguments. The flag
+// package has already printed parse errors and the usage.
+func (c *CLI) parse(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(c.Stderr, "Unexpected argument %q\n", flags.Arg(0))
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// usageError prints a message and the command's usage
+func (c *CLI) usageError(flags *flag.FlagSet, format string, args ...interface{}) error {
+	fmt.Fprintf(c.Stderr, format+"\n", args...)
+	flags.Usage()
+	return errUsage
+}
+
+// readPassword reads a password from the first line of stdin
+func (c *CLI) readPassword() (string, error) {
+	line, err := bufio.NewReader(c.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Connect loads the configuration, connects to the database and creates the services
+func Connect(ctx context.Context) (*Env, func(), error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Logs go to stderr so that command output can be piped
+	logger, err := logging.NewWithWriter(os.Stderr, cfg.Log)
+	if err != nil {
+		return nil, nil, err
+	}
+	slog.SetDefault(logger)
+
+	db, err := database.Connect(cfg.Database.URI)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	collections := db.GetCollections()
+	migrator, err := migrations.NewMigrator(migrations.NewMongoStore(collections), collections, migrations.All())
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	authz := services.NewAuthorizer(collections)
+	env := &Env{
+		Config:    cfg,
+		Database:  db,
+		Users:     services.NewAuthService(collections, cfg.Auth.AccessTokenSecret, cfg.Auth.RefreshTokenSecret),
+		Forms:     services.NewFormService(collections, authz),
+		Responses: services.NewResponseService(collections, authz),
+		Analytics: services.NewAnalyticsService(collections, authz),
+		Migrator:  migrator,
+	}
+	return env, func() { db.Close() }, nil
+}
@@ -0,0 +1,440 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/config"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/interfaces"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+)
+
+const testFormID = "507f1f77bcf86cd799439011"
+
+// fakeUsers is an in-memory UserService
+type fakeUsers struct {
+	users    map[string]*models.User
+	sessions map[string]int64
+}
+
+func (f *fakeUsers) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	if _, ok := f.users[req.Email]; ok {
+		return nil, errors.New("user with this email already exists")
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.MinCost)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{ID: primitive.NewObjectID(), Email: req.Email, Name: req.Name, Password: string(hashed)}
+	f.users[req.Email] = user
+	return user, nil
+}
+
+func (f *fakeUsers) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	if user, ok := f.users[email]; ok {
+		return user, nil
+	}
+	return nil, errors.New("user not found")
+}
+
+func (f *fakeUsers) SetPassword(ctx context.Context, userID primitive.ObjectID, password string) error {
+	for _, user := range f.users {
+		if user.ID == userID {
+			hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+			user.Password = string(hashed)
+			return err
+		}
+	}
+	return errors.New("user not found")
+}
+
+func (f *fakeUsers) MarkEmailVerified(ctx context.Context, userID primitive.ObjectID) error {
+	for _, user := range f.users {
+		if user.ID == userID {
+			user.EmailVerified = true
+			return nil
+		}
+	}
+	return errors.New("user not found")
+}
+
+func (f *fakeUsers) ResetTwoFactor(ctx context.Context, userID primitive.ObjectID) error {
+	for _, user := range f.users {
+		if user.ID == userID {
+			user.TwoFactor = nil
+			return nil
+		}
+	}
+	return errors.New("user not found")
+}
+
+func (f *fakeUsers) LogoutAll(ctx context.Context, userID string) (int64, error) {
+	revoked := f.sessions[userID]
+	delete(f.sessions, userID)
+	return revoked, nil
+}
+
+// fakeForms lists and gets forms; other methods are not used by the CLI
+type fakeForms struct {
+	interfaces.FormServiceInterface
+	forms []*models.FormResponse
+}
+
+func (f *fakeForms) ListForms(ctx context.Context, actor *services.Actor, page, limit int) ([]*models.FormResponse, int64, error) {
+	var matching []*models.FormResponse
+	for _, form := range f.forms {
+		if actor == nil || (form.OwnerID != nil && *form.OwnerID == actor.UserID) {
+			matching = append(matching, form)
+		}
+	}
+	start := (page - 1) * limit
+	if start >= len(matching) {
+		return nil, int64(len(matching)), nil
+	}
+	end := start + limit
+	if end > len(matching) {
+		end = len(matching)
+	}
+	return matching[start:end], int64(len(matching)), nil
+}
+
+func (f *fakeForms) GetFormByID(ctx context.Context, formID string, actor *services.Actor) (*models.FormResponse, error) {
+	for _, form := range f.forms {
+		if form.ID == formID {
+			return form, nil
+		}
+	}
+	return nil, errors.New("form not found")
+}
+
+// fakeAnalytics records recomputed forms
+type fakeAnalytics struct {
+	interfaces.AnalyticsServiceInterface
+	recomputed []string
+	failing    map[string]bool
+}
+
+func (f *fakeAnalytics) ComputeAnalytics(ctx context.Context, formID string, startDate, endDate *time.Time, fields []string, actor *services.Actor) (*models.AnalyticsResponse, error) {
+	if f.failing[formID] {
+		return nil, errors.New("compute failed")
+	}
+	f.recomputed = append(f.recomputed, formID)
+	return &models.AnalyticsResponse{FormID: formID, TotalResponses: 3}, nil
+}
+
+// fakeResponses returns fixed responses for export
+type fakeResponses struct {
+	interfaces.ResponseServiceInterface
+	responses []*models.ResponseData
+	startDate *time.Time
+	endDate   *time.Time
+}
+
+func (f *fakeResponses) GetResponsesForExport(ctx context.Context, formID string, startDate, endDate *time.Time, actor *services.Actor) ([]*models.ResponseData, error) {
+	f.startDate, f.endDate = startDate, endDate
+	return f.responses, nil
+}
+
+// fakeDatabase reports missing indexes until EnsureIndexes runs
+type fakeDatabase struct {
+	interfaces.DatabaseInterface
+	ensured bool
+	broken  []string
+}
+
+func (f *fakeDatabase) EnsureIndexes() error {
+	f.ensured = true
+	return nil
+}
+
+func (f *fakeDatabase) MissingIndexes(ctx context.Context) ([]string, error) {
+	if !f.ensured {
+		return []string{"forms.ownerId_1"}, nil
+	}
+	return f.broken, nil
+}
+
+// testCLI is a CLI on buffers whose environment holds fakes
+type testCLI struct {
+	*CLI
+	stdout    *bytes.Buffer
+	stderr    *bytes.Buffer
+	env       *Env
+	connected bool
+}
+
+func newTestCLI() *testCLI {
+	owner := primitive.NewObjectID()
+	ownerID := owner.Hex()
+	env := &Env{
+		Config: &config.Config{Environment: "production"},
+		Users: &fakeUsers{
+			users: map[string]*models.User{
+				"owner@example.com": {ID: owner, Email: "owner@example.com", Name: "Owner"},
+			},
+			sessions: map[string]int64{ownerID: 2},
+		},
+		Forms: &fakeForms{forms: []*models.FormResponse{
+			{ID: testFormID, OwnerID: &ownerID, Title: "Survey", Status: "published", Fields: []models.Field{{ID: "q1", Label: "Question"}}},
+			{ID: "507f1f77bcf86cd799439012", Title: "Orphan", Status: "draft"},
+		}},
+		Responses: &fakeResponses{},
+		Analytics: &fakeAnalytics{failing: map[string]bool{}},
+		Database:  &fakeDatabase{},
+	}
+
+	t := &testCLI{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}, env: env}
+	t.CLI = &CLI{
+		Stdin:  strings.NewReader(""),
+		Stdout: t.stdout,
+		Stderr: t.stderr,
+		Connect: func(context.Context) (*Env, func(), error) {
+			t.connected = true
+			return env, func() {}, nil
+		},
+	}
+	return t
+}
+
+func (t *testCLI) run(args ...string) int {
+	return t.Run(context.Background(), args)
+}
+
+func TestRun_Usage(t *testing.T) {
+	cli := newTestCLI()
+	assert.Equal(t, 2, cli.run())
+	for _, cmd := range commands {
+		assert.Contains(t, cli.stderr.String(), cmd.name)
+	}
+
+	cli = newTestCLI()
+	assert.Equal(t, 2, cli.run("frobnicate"))
+	assert.Contains(t, cli.stderr.String(), `Unknown command "frobnicate"`)
+}
+
+func TestIsCommand(t *testing.T) {
+	assert.True(t, IsCommand("migrate"))
+	assert.True(t, IsCommand("help"))
+	assert.False(t, IsCommand("serve"))
+}
+
+func TestRun_InvalidArgumentsDoNotConnect(t *testing.T) {
+	tests := [][]string{
+		{"create-user", "-name", "Ada"},
+		{"create-user", "-email", "ada@example.com", "-name", "Ada", "extra"},
+		{"reset-password"},
+		{"reset-2fa"},
+		{"list-forms", "-limit", "0"},
+		{"recompute-analytics"},
+		{"recompute-analytics", "-form", testFormID, "-all"},
+		{"recompute-analytics", "-form", "not-an-id"},
+		{"export-responses", "-form", testFormID},
+		{"export-responses", "-form", testFormID, "-out", "-", "-from", "15/01/2025"},
+		{"migrate"},
+		{"migrate", "sideways"},
+		{"migrate", "rollback", "-steps", "0"},
+		{"reindex", "-unknown"},
+	}
+
+	for _, args := range tests {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			cli := newTestCLI()
+			assert.Equal(t, 2, cli.run(args...))
+			assert.False(t, cli.connected)
+			assert.Contains(t, cli.stderr.String(), "Usage: server "+args[0])
+		})
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	t.Run("generates a password", func(t *testing.T) {
+		cli := newTestCLI()
+		require.Equal(t, 0, cli.run("create-user", "-email", "ada@example.com", "-name", "Ada"))
+
+		user := cli.env.Users.(*fakeUsers).users["ada@example.com"]
+		require.NotNil(t, user)
+		assert.Contains(t, cli.stdout.String(), "Created user ada@example.com ("+user.ID.Hex()+")")
+		assert.True(t, user.EmailVerified)
+
+		var password string
+		for _, line := range strings.Split(cli.stdout.String(), "\n") {
+			if strings.HasPrefix(line, "Password: ") {
+				password = strings.TrimPrefix(line, "Password: ")
+			}
+		}
+		require.NotEmpty(t, password)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)))
+	})
+
+	t.Run("reads the password from stdin", func(t *testing.T) {
+		cli := newTestCLI()
+		cli.Stdin = strings.NewReader("s3cret-pass\n")
+		require.Equal(t, 0, cli.run("create-user", "-email", "ada@example.com", "-name", "Ada", "-password-stdin"))
+
+		assert.NotContains(t, cli.stdout.String(), "Password:")
+		user := cli.env.Users.(*fakeUsers).users["ada@example.com"]
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("s3cret-pass")))
+	})
+
+	t.Run("rejects short passwords", func(t *testing.T) {
+		cli := newTestCLI()
+		cli.Stdin = strings.NewReader("abc\n")
+		assert.Equal(t, 2, cli.run("create-user", "-email", "ada@example.com", "-name", "Ada", "-password-stdin"))
+		assert.False(t, cli.connected)
+	})
+
+	t.Run("fails for existing users", func(t *testing.T) {
+		cli := newTestCLI()
+		assert.Equal(t, 1, cli.run("create-user", "-email", "owner@example.com", "-name", "Owner"))
+		assert.Contains(t, cli.stderr.String(), "already exists")
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	cli := newTestCLI()
+	cli.Stdin = strings.NewReader("new-password\n")
+	require.Equal(t, 0, cli.run("reset-password", "-email", "owner@example.com", "-password-stdin"))
+
+	users := cli.env.Users.(*fakeUsers)
+	user := users.users["owner@example.com"]
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new-password")))
+	assert.Contains(t, cli.stdout.String(), "2 sessions revoked")
+	assert.Empty(t, users.sessions)
+
+	cli = newTestCLI()
+	assert.Equal(t, 1, cli.run("reset-password", "-email", "nobody@example.com"))
+	assert.Contains(t, cli.stderr.String(), "user not found")
+}
+
+func TestResetTwoFactor(t *testing.T) {
+	cli := newTestCLI()
+	users := cli.env.Users.(*fakeUsers)
+	users.users["owner@example.com"].TwoFactor = &models.TwoFactor{Enabled: true, Secret: "encrypted"}
+
+	require.Equal(t, 0, cli.run("reset-2fa", "-email", "owner@example.com"))
+	assert.Nil(t, users.users["owner@example.com"].TwoFactor)
+	assert.Contains(t, cli.stdout.String(), "Two-factor authentication turned off for owner@example.com")
+
+	cli = newTestCLI()
+	require.Equal(t, 0, cli.run("reset-2fa", "-email", "owner@example.com"))
+	assert.Contains(t, cli.stdout.String(), "owner@example.com does not use two-factor authentication")
+
+	cli = newTestCLI()
+	assert.Equal(t, 1, cli.run("reset-2fa", "-email", "nobody@example.com"))
+	assert.Contains(t, cli.stderr.String(), "user not found")
+}
+
+func TestListForms(t *testing.T) {
+	t.Run("table", func(t *testing.T) {
+		cli := newTestCLI()
+		require.Equal(t, 0, cli.run("list-forms"))
+
+		lines := strings.Split(strings.TrimSpace(cli.stdout.String()), "\n")
+		require.Len(t, lines, 3)
+		assert.True(t, strings.HasPrefix(lines[0], "ID"))
+		assert.Contains(t, lines[1], "Survey")
+		assert.Contains(t, lines[2], "Orphan")
+		assert.Contains(t, cli.stderr.String(), "Page 1 of 1, 2 forms in total")
+	})
+
+	t.Run("by owner as JSON", func(t *testing.T) {
+		cli := newTestCLI()
+		require.Equal(t, 0, cli.run("list-forms", "-owner", "owner@example.com", "-json"))
+
+		var forms []models.FormResponse
+		require.NoError(t, json.Unmarshal(cli.stdout.Bytes(), &forms))
+		require.Len(t, forms, 1)
+		assert.Equal(t, "Survey", forms[0].Title)
+	})
+
+	t.Run("unknown owner", func(t *testing.T) {
+		cli := newTestCLI()
+		assert.Equal(t, 1, cli.run("list-forms", "-owner", "nobody@example.com"))
+	})
+}
+
+func TestRecomputeAnalytics(t *testing.T) {
+	t.Run("one form", func(t *testing.T) {
+		cli := newTestCLI()
+		require.Equal(t, 0, cli.run("recompute-analytics", "-form", testFormID))
+		assert.Equal(t, []string{testFormID}, cli.env.Analytics.(*fakeAnalytics).recomputed)
+	})
+
+	t.Run("all forms across pages", func(t *testing.T) {
+		cli := newTestCLI()
+		forms := cli.env.Forms.(*fakeForms)
+		forms.forms = nil
+		for i := 0; i < recomputePageSize+5; i++ {
+			forms.forms = append(forms.forms, &models.FormResponse{ID: fmt.Sprintf("form-%d", i)})
+		}
+		analytics := cli.env.Analytics.(*fakeAnalytics)
+		analytics.failing["form-3"] = true
+
+		assert.Equal(t, 1, cli.run("recompute-analytics", "-all"))
+		assert.Len(t, analytics.recomputed, recomputePageSize+4, "other forms are recomputed despite failures")
+		assert.Contains(t, cli.stderr.String(), "Failed to recompute form form-3")
+		assert.Contains(t, cli.stderr.String(), "1 forms failed")
+	})
+}
+
+func TestExportResponses(t *testing.T) {
+	cli := newTestCLI()
+	responses := cli.env.Responses.(*fakeResponses)
+	responses.responses = []*models.ResponseData{{
+		ID:          "r1",
+		SubmittedAt: time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC),
+		Answers:     []models.Answer{{FieldID: "q1", Value: "yes"}},
+	}}
+
+	out := filepath.Join(t.TempDir(), "responses.csv")
+	require.Equal(t, 0, cli.run("export-responses", "-form", testFormID, "-out", out, "-from", "2025-01-01", "-to", "2025-01-31"))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "Response ID,Submitted At,Question\nr1,2025-01-15 10:30:00,yes\n", string(data))
+
+	info, err := os.Stat(out)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), *responses.startDate)
+	assert.Equal(t, time.Date(2025, 1, 31, 23, 59, 59, 999999999, time.UTC), *responses.endDate, "the end date is inclusive")
+
+	t.Run("to stdout", func(t *testing.T) {
+		cli := newTestCLI()
+		require.Equal(t, 0, cli.run("export-responses", "-form", testFormID, "-out", "-"))
+		assert.Equal(t, "Response ID,Submitted At,Question\n", cli.stdout.String())
+	})
+}
+
+func TestReindex(t *testing.T) {
+	cli := newTestCLI()
+	require.Equal(t, 0, cli.run("reindex"))
+	assert.True(t, cli.env.Database.(*fakeDatabase).ensured)
+	assert.Contains(t, cli.stdout.String(), "All indexes are in place")
+
+	cli = newTestCLI()
+	cli.env.Database.(*fakeDatabase).broken = []string{"users.email_1"}
+	assert.Equal(t, 1, cli.run("reindex"))
+	assert.Contains(t, cli.stderr.String(), "Missing index users.email_1")
+}
+
+func TestMigrateSeedOnlyInDevelopment(t *testing.T) {
+	cli := newTestCLI()
+	assert.Equal(t, 1, cli.run("migrate", "seed"))
+	assert.Contains(t, cli.stderr.String(), "only created in the development environment")
+}
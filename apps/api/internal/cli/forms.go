@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+// recomputePageSize is how many forms recompute-analytics loads at a time
+const recomputePageSize = 100
+
+// listForms prints a page of forms, optionally the personal forms of one user
+func listForms(ctx context.Context, c *CLI, args []string) error {
+	flags := c.flags()
+	ownerEmail := flags.String("owner", "", "only list the personal forms of the user with this email")
+	page := flags.Int("page", 1, "page to list")
+	limit := flags.Int("limit", 50, "forms per page (1-1000)")
+	asJSON := flags.Bool("json", false, "print forms as JSON")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if *page < 1 {
+		return c.usageError(flags, "-page must be at least 1")
+	}
+	if *limit < 1 || *limit > 1000 {
+		return c.usageError(flags, "-limit must be between 1 and 1000")
+	}
+
+	env, closeEnv, err := c.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeEnv()
+
+	var actor *services.Actor
+	if *ownerEmail != "" {
+		owner, err := env.Users.GetUserByEmail(ctx, strings.TrimSpace(*ownerEmail))
+		if err != nil {
+			return fmt.Errorf("owner %s: %w", *ownerEmail, err)
+		}
+		actor = &services.Actor{UserID: owner.ID.Hex()}
+	}
+
+	forms, total, err := env.Forms.ListForms(ctx, actor, *page, *limit)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(c.Stdout)
+		encoder.SetIndent("", "  ")
+		if forms == nil {
+			forms = []*models.FormResponse{}
+		}
+		return encoder.Encode(forms)
+	}
+
+	printForms(c.Stdout, forms)
+	pages := (total + int64(*limit) - 1) / int64(*limit)
+	fmt.Fprintf(c.Stderr, "Page %d of %d, %d forms in total\n", *page, pages, total)
+	return nil
+}
+
+// printForms writes forms as a table
+func printForms(w io.Writer, forms []*models.FormResponse) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tSTATUS\tOWNER\tCREATED\tTITLE")
+	for _, form := range forms {
+		owner := "-"
+		if form.OwnerID != nil && *form.OwnerID != "" {
+			owner = *form.OwnerID
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n",
+			form.ID, form.Status, owner, form.CreatedAt.UTC().Format(time.RFC3339), form.Title)
+	}
+	table.Flush()
+}
+
+// recomputeAnalytics recomputes the stored analytics of one form or of every form
+// from its responses. With -all, failures are reported and the remaining forms
+// are still recomputed.
+func recomputeAnalytics(ctx context.Context, c *CLI, args []string) error {
+	flags := c.flags()
+	formID := flags.String("form", "", "ID of the form to recompute")
+	all := flags.Bool("all", false, "recompute every form")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if (*formID == "") == !*all {
+		return c.usageError(flags, "Pass either -form or -all")
+	}
+	if *formID != "" && !utils.IsValidObjectID(*formID) {
+		return c.usageError(flags, "Invalid form ID %q", *formID)
+	}
+
+	env, closeEnv, err := c.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeEnv()
+
+	if *formID != "" {
+		analytics, err := env.Analytics.ComputeAnalytics(ctx, *formID, nil, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.Stdout, "Recomputed analytics of form %s from %d responses\n", *formID, analytics.TotalResponses)
+		return nil
+	}
+
+	var recomputed, failed int
+	for page := 1; ; page++ {
+		forms, total, err := env.Forms.ListForms(ctx, nil, page, recomputePageSize)
+		if err != nil {
+			return err
+		}
+		for _, form := range forms {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if _, err := env.Analytics.ComputeAnalytics(ctx, form.ID, nil, nil, nil, nil); err != nil {
+				fmt.Fprintf(c.Stderr, "Failed to recompute form %s: %v\n", form.ID, err)
+				failed++
+				continue
+			}
+			recomputed++
+		}
+		if len(forms) < recomputePageSize || int64(page*recomputePageSize) >= total {
+			break
+		}
+	}
+
+	fmt.Fprintf(c.Stdout, "Recomputed analytics of %d forms\n", recomputed)
+	if failed > 0 {
+		return fmt.Errorf("%d forms failed", failed)
+	}
+	return nil
+}
+
+// exportResponses writes a form's responses as CSV to a file, or to stdout when
+// the file is "-"
+func exportResponses(ctx context.Context, c *CLI, args []string) error {
+	flags := c.flags()
+	formID := flags.String("form", "", "ID of the form to export")
+	out := flags.String("out", "", `file to write, or "-" for stdout`)
+	from := flags.String("from", "", "only export responses submitted on or after this date (YYYY-MM-DD)")
+	to := flags.String("to", "", "only export responses submitted on or before this date (YYYY-MM-DD)")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if !utils.IsValidObjectID(*formID) {
+		return c.usageError(flags, "A valid -form ID is required")
+	}
+	if *out == "" {
+		return c.usageError(flags, "-out is required")
+	}
+	startDate, err := parseDate(*from)
+	if err != nil {
+		return c.usageError(flags, "Invalid -from date: %v", err)
+	}
+	endDate, err := parseDate(*to)
+	if err != nil {
+		return c.usageError(flags, "Invalid -to date: %v", err)
+	}
+	if endDate != nil {
+		// Include the whole end day
+		end := endDate.Add(24*time.Hour - time.Nanosecond)
+		endDate = &end
+	}
+
+	env, closeEnv, err := c.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeEnv()
+
+	form, err := env.Forms.GetFormByID(ctx, *formID, nil)
+	if err != nil {
+		return err
+	}
+	responses, err := env.Responses.GetResponsesForExport(ctx, *formID, startDate, endDate, nil)
+	if err != nil {
+		return err
+	}
+
+	if *out == "-" {
+		return services.WriteResponsesCSV(c.Stdout, form.Fields, responses)
+	}
+
+	// Exports contain respondent data, so only the operator may read them
+	file, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := services.WriteResponsesCSV(file, form.Fields, responses); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Stderr, "Exported %d responses of %q to %s\n", len(responses), form.Title, *out)
+	return nil
+}
+
+// parseDate parses an optional YYYY-MM-DD date as UTC midnight
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
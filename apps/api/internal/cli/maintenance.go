@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/migrations"
+)
+
+// reindex creates missing indexes and verifies that every required index exists
+func reindex(ctx context.Context, c *CLI, args []string) error {
+	flags := c.flags()
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+
+	env, closeEnv, err := c.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeEnv()
+
+	if err := env.Database.EnsureIndexes(); err != nil {
+		return err
+	}
+	missing, err := env.Database.MissingIndexes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		for _, index := range missing {
+			fmt.Fprintf(c.Stderr, "Missing index %s\n", index)
+		}
+		return fmt.Errorf("%d indexes are still missing", len(missing))
+	}
+
+	fmt.Fprintln(c.Stdout, "All indexes are in place")
+	return nil
+}
+
+// migrateCommands are the subcommands of migrate
+const migrateCommands = "run, rollback, status or seed"
+
+// migrate applies, rolls back or lists schema migrations, or seeds development data
+func migrate(ctx context.Context, c *CLI, args []string) error {
+	flags := c.flags()
+	to := flags.Int("to", 0, "run: version to migrate up to (default latest)")
+	steps := flags.Int("steps", 1, "rollback: number of migrations to roll back")
+	if len(args) == 0 {
+		return c.usageError(flags, "Pass a migrate command: %s", migrateCommands)
+	}
+	action := args[0]
+	if err := c.parse(flags, args[1:]); err != nil {
+		return err
+	}
+
+	switch action {
+	case "run", "status", "seed":
+	case "rollback":
+		if *steps < 1 {
+			return c.usageError(flags, "-steps must be at least 1")
+		}
+	default:
+		return c.usageError(flags, "Unknown migrate command %q, use %s", action, migrateCommands)
+	}
+
+	env, closeEnv, err := c.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeEnv()
+
+	switch action {
+	case "run":
+		applied, err := env.Migrator.Up(ctx, *to)
+		for _, migration := range applied {
+			fmt.Fprintf(c.Stdout, "Applied %d %s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(c.Stdout, "No pending migrations")
+		}
+
+	case "rollback":
+		rolledBack, err := env.Migrator.Down(ctx, *steps)
+		for _, migration := range rolledBack {
+			fmt.Fprintf(c.Stdout, "Rolled back %d %s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+	case "status":
+		statuses, err := env.Migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(c.Stdout, statuses)
+
+	case "seed":
+		if env.Config.Environment != "development" {
+			return fmt.Errorf("seed data is only created in the development environment, not %s", env.Config.Environment)
+		}
+		if err := migrations.Seed(ctx, env.Database.GetCollections()); err != nil {
+			return err
+		}
+		fmt.Fprintln(c.Stdout, "Development data seeded")
+	}
+	return nil
+}
+
+// printMigrationStatus writes the migrations as a table
+func printMigrationStatus(w io.Writer, statuses []migrations.Status) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		if status.Applied {
+			state = "applied"
+			appliedAt = status.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		if !status.Known {
+			state += " (unknown to this build)"
+		}
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	table.Flush()
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+// generatedPasswordBytes is the entropy of passwords generated for users
+const generatedPasswordBytes = 12
+
+// createUser creates a user account. Without -password-stdin a random password
+// is generated and printed once. The administrator vouches for the email address,
+// so it is marked as verified.
+func createUser(ctx context.Context, c *CLI, args []string) error {
+	flags := c.flags()
+	email := flags.String("email", "", "email address of the user")
+	name := flags.String("name", "", "display name of the user")
+	passwordStdin := flags.Bool("password-stdin", false, "read the password from the first line of stdin instead of generating one")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+
+	password, generated, err := c.password(*passwordStdin)
+	if err != nil {
+		return err
+	}
+
+	req := &models.CreateUserRequest{
+		Email:    strings.TrimSpace(*email),
+		Name:     strings.TrimSpace(*name),
+		Password: password,
+	}
+	if err := c.validator.Struct(req); err != nil {
+		return c.usageError(flags, "Invalid user: %v", err)
+	}
+
+	env, closeEnv, err := c.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeEnv()
+
+	user, err := env.Users.CreateUser(ctx, req)
+	if err != nil {
+		return err
+	}
+	if err := env.Users.MarkEmailVerified(ctx, user.ID); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Stdout, "Created user %s (%s)\n", user.Email, user.ID.Hex())
+	if generated {
+		fmt.Fprintf(c.Stdout, "Password: %s\n", password)
+	}
+	return nil
+}
+
+// resetPassword sets a new password for a user and revokes their sessions. Without
+// -password-stdin a random password is generated and printed once.
+func resetPassword(ctx context.Context, c *CLI, args []string) error {
+	flags := c.flags()
+	email := flags.String("email", "", "email address of the user")
+	passwordStdin := flags.Bool("password-stdin", false, "read the password from the first line of stdin instead of generating one")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(*email) == "" {
+		return c.usageError(flags, "-email is required")
+	}
+	password, generated, err := c.password(*passwordStdin)
+	if err != nil {
+		return err
+	}
+	if err := c.validator.Var(password, "required,min=6"); err != nil {
+		return c.usageError(flags, "The password must have at least 6 characters")
+	}
+
+	env, closeEnv, err := c.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeEnv()
+
+	user, err := env.Users.GetUserByEmail(ctx, strings.TrimSpace(*email))
+	if err != nil {
+		return err
+	}
+	if err := env.Users.SetPassword(ctx, user.ID, password); err != nil {
+		return err
+	}
+	revoked, err := env.Users.LogoutAll(ctx, user.ID.Hex())
+	if err != nil {
+		return fmt.Errorf("password was reset but sessions were not revoked: %w", err)
+	}
+
+	fmt.Fprintf(c.Stdout, "Password reset for %s, %d sessions revoked\n", user.Email, revoked)
+	if generated {
+		fmt.Fprintf(c.Stdout, "Password: %s\n", password)
+	}
+	return nil
+}
+
+// resetTwoFactor turns two-factor authentication off for a user who can no longer
+// produce a code, e.g. after losing both their phone and recovery codes
+func resetTwoFactor(ctx context.Context, c *CLI, args []string) error {
+	flags := c.flags()
+	email := flags.String("email", "", "email address of the user")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(*email) == "" {
+		return c.usageError(flags, "-email is required")
+	}
+
+	env, closeEnv, err := c.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeEnv()
+
+	user, err := env.Users.GetUserByEmail(ctx, strings.TrimSpace(*email))
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled() {
+		fmt.Fprintf(c.Stdout, "%s does not use two-factor authentication\n", user.Email)
+		return nil
+	}
+	if err := env.Users.ResetTwoFactor(ctx, user.ID); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Stdout, "Two-factor authentication turned off for %s\n", user.Email)
+	return nil
+}
+
+// password reads the password from stdin or generates one, reporting whether it was generated
+func (c *CLI) password(fromStdin bool) (string, bool, error) {
+	if fromStdin {
+		password, err := c.readPassword()
+		return password, false, err
+	}
+	password, err := utils.GenerateSecureToken(generatedPasswordBytes)
+	return password, true, err
+}
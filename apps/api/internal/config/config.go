@@ -3,13 +3,15 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	URI string `mapstructure:"uri" validate:"required"`
+	URI         string `mapstructure:"uri" validate:"required"`
+	AutoMigrate bool   `mapstructure:"auto_migrate"`
 }
 
 // ServerConfig holds server configuration
@@ -27,27 +29,131 @@ type CORSConfig struct {
 	AllowCredentials bool   `mapstructure:"allow_credentials"`
 }
 
-// WebSocketConfig holds WebSocket configuration
+// WebSocketConfig holds WebSocket configuration.
+// Allowed origins are shared with CORS (see Config.WebSocketOrigins).
 type WebSocketConfig struct {
-	BufferSize      int `mapstructure:"buffer_size" validate:"min=1"`
-	ReadBufferSize  int `mapstructure:"read_buffer_size" validate:"min=1"`
-	WriteBufferSize int `mapstructure:"write_buffer_size" validate:"min=1"`
+	BufferSize            int           `mapstructure:"buffer_size" validate:"min=1"`
+	ReadBufferSize        int           `mapstructure:"read_buffer_size" validate:"min=1"`
+	WriteBufferSize       int           `mapstructure:"write_buffer_size" validate:"min=1"`
+	SendQueueSize         int           `mapstructure:"send_queue_size" validate:"min=1"`
+	MaxMessageSize        int64         `mapstructure:"max_message_size" validate:"min=1"`
+	MaxConnectionsPerRoom int           `mapstructure:"max_connections_per_room" validate:"min=0"`
+	MaxConnectionsPerIP   int           `mapstructure:"max_connections_per_ip" validate:"min=0"`
+	MaxSubscriptions      int           `mapstructure:"max_subscriptions" validate:"min=0"`
+	ReplayBufferSize      int           `mapstructure:"replay_buffer_size" validate:"min=0"`
+	ReplayRetention       time.Duration `mapstructure:"replay_retention" validate:"min=0"`
+	AuthTimeout           time.Duration `mapstructure:"auth_timeout" validate:"min=1"`
+	ReadTimeout           time.Duration `mapstructure:"read_timeout" validate:"min=1"`
+	WriteTimeout          time.Duration `mapstructure:"write_timeout" validate:"min=1"`
+	PingInterval          time.Duration `mapstructure:"ping_interval" validate:"min=1,ltfield=ReadTimeout"`
+	StreamHeartbeat       time.Duration `mapstructure:"stream_heartbeat" validate:"min=1"`
+	AnalyticsRate         int           `mapstructure:"analytics_rate" validate:"min=0"`
+	AnalyticsSnapshot     time.Duration `mapstructure:"analytics_snapshot_interval" validate:"min=1"`
+	Backplane             string        `mapstructure:"backplane" validate:"oneof=memory mongodb"`
+	BackplaneSize         int64         `mapstructure:"backplane_size" validate:"min=4096"`
 }
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	AccessTokenSecret  string `mapstructure:"access_token_secret" validate:"required,min=32"`
-	RefreshTokenSecret string `mapstructure:"refresh_token_secret" validate:"required,min=32"`
+	JWTAlgorithm         string        `mapstructure:"jwt_algorithm" validate:"oneof=HS256 RS256 EdDSA"`
+	AccessTokenSecret    string        `mapstructure:"access_token_secret" validate:"required,min=32"`
+	RefreshTokenSecret   string        `mapstructure:"refresh_token_secret" validate:"required,min=32"`
+	AccountTokenSecret   string        `mapstructure:"account_token_secret" validate:"required,min=32"`
+	PasswordResetTTL     time.Duration `mapstructure:"password_reset_ttl" validate:"min=1"`
+	EmailVerificationTTL time.Duration `mapstructure:"email_verification_ttl" validate:"min=1"`
+	InvitationTTL        time.Duration `mapstructure:"invitation_ttl" validate:"min=1"`
+	RequireVerifiedEmail bool          `mapstructure:"require_verified_email"`
+	LoginMaxAttempts     int           `mapstructure:"login_max_attempts" validate:"min=0"`
+	LoginIPMaxAttempts   int           `mapstructure:"login_ip_max_attempts" validate:"min=0"`
+	LoginAttemptWindow   time.Duration `mapstructure:"login_attempt_window" validate:"min=1"`
+	LoginLockoutDuration time.Duration `mapstructure:"login_lockout_duration" validate:"min=1"`
+	LoginDelay           time.Duration `mapstructure:"login_delay" validate:"min=0"`
+	FailedLoginRetention time.Duration `mapstructure:"failed_login_retention" validate:"min=1"`
+	TwoFactorSecret      string        `mapstructure:"two_factor_secret" validate:"required,min=32"`
+	TwoFactorIssuer      string        `mapstructure:"two_factor_issuer" validate:"required"`
+	TwoFactorLoginTTL    time.Duration `mapstructure:"two_factor_login_ttl" validate:"min=1"`
+	OIDCIssuer           string        `mapstructure:"oidc_issuer" validate:"omitempty,url"` // Empty disables single sign-on
+	OIDCClientID         string        `mapstructure:"oidc_client_id" validate:"required_with=OIDCIssuer"`
+	OIDCClientSecret     string        `mapstructure:"oidc_client_secret"`
+	OIDCRedirectURL      string        `mapstructure:"oidc_redirect_url" validate:"required_with=OIDCIssuer,omitempty,url"`
+	OIDCScopes           []string      `mapstructure:"oidc_scopes"`
+	OIDCProviderName     string        `mapstructure:"oidc_provider_name"`
+	OIDCStateSecret      string        `mapstructure:"oidc_state_secret" validate:"required,min=32"`
+	OIDCStateTTL         time.Duration `mapstructure:"oidc_state_ttl" validate:"min=1"`
+	SigningKeySecret     string        `mapstructure:"signing_key_secret" validate:"required,min=32"`
+	SigningKeyRotation   time.Duration `mapstructure:"signing_key_rotation" validate:"min=1"`
+	SigningKeyPublish    time.Duration `mapstructure:"signing_key_publish" validate:"min=1,ltfield=SigningKeyRotation"`
+	HS256Switchover      string        `mapstructure:"hs256_switchover" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"` // RFC 3339; empty rejects HS256 tokens with signing keys
+}
+
+// MailConfig holds outgoing email configuration
+type MailConfig struct {
+	Driver       string `mapstructure:"driver" validate:"oneof=log smtp"`
+	From         string `mapstructure:"from" validate:"required"`
+	AppURL       string `mapstructure:"app_url" validate:"required,url"`
+	Dir          string `mapstructure:"dir"`
+	SMTPHost     string `mapstructure:"smtp_host" validate:"required_if=Driver smtp"`
+	SMTPPort     int    `mapstructure:"smtp_port" validate:"min=1,max=65535"`
+	SMTPUsername string `mapstructure:"smtp_username"`
+	SMTPPassword string `mapstructure:"smtp_password"`
+}
+
+// SubmissionConfig holds public form submission configuration
+type SubmissionConfig struct {
+	TokenSecret    string        `mapstructure:"token_secret" validate:"required,min=32"`
+	CookieName     string        `mapstructure:"cookie_name" validate:"required"`
+	CookieSecure   bool          `mapstructure:"cookie_secure"`
+	IdempotencyTTL time.Duration `mapstructure:"idempotency_ttl" validate:"min=1"`
+	ChallengeTTL   time.Duration `mapstructure:"challenge_ttl" validate:"min=1"`
+}
+
+// LogConfig holds logging configuration
+type LogConfig struct {
+	Level  string `mapstructure:"level" validate:"oneof=debug info warn error"`
+	Format string `mapstructure:"format" validate:"oneof=json text"`
+	Redact bool   `mapstructure:"redact"`
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Exporter    string  `mapstructure:"exporter" validate:"oneof=otlp stdout"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	SampleRatio float64 `mapstructure:"sample_ratio" validate:"min=0,max=1"`
+	ServiceName string  `mapstructure:"service_name" validate:"required"`
+}
+
+// HealthConfig holds readiness check thresholds
+type HealthConfig struct {
+	Timeout            time.Duration `mapstructure:"timeout" validate:"min=1"`
+	DatabaseLatency    time.Duration `mapstructure:"database_latency" validate:"min=1"`
+	RealtimeStaleAfter time.Duration `mapstructure:"realtime_stale_after" validate:"min=1"`
+	BroadcastBacklog   int           `mapstructure:"broadcast_backlog" validate:"min=0"`
+	AnalyticsBacklog   int64         `mapstructure:"analytics_backlog" validate:"min=0"`
+}
+
+// MetricsConfig holds Prometheus metrics configuration
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path" validate:"required,startswith=/"`
+	Token   string `mapstructure:"token"`
 }
 
 // Config holds all application configuration
 type Config struct {
-	Environment string          `mapstructure:"environment" validate:"required,oneof=development staging production"`
-	Database    DatabaseConfig  `mapstructure:"database"`
-	Server      ServerConfig    `mapstructure:"server"`
-	CORS        CORSConfig      `mapstructure:"cors"`
-	WebSocket   WebSocketConfig `mapstructure:"websocket"`
-	Auth        AuthConfig      `mapstructure:"auth"`
+	Environment string           `mapstructure:"environment" validate:"required,oneof=development staging production"`
+	Database    DatabaseConfig   `mapstructure:"database"`
+	Server      ServerConfig     `mapstructure:"server"`
+	CORS        CORSConfig       `mapstructure:"cors"`
+	WebSocket   WebSocketConfig  `mapstructure:"websocket"`
+	Auth        AuthConfig       `mapstructure:"auth"`
+	Submission  SubmissionConfig `mapstructure:"submission"`
+	Mail        MailConfig       `mapstructure:"mail"`
+	Metrics     MetricsConfig    `mapstructure:"metrics"`
+	Log         LogConfig        `mapstructure:"log"`
+	Tracing     TracingConfig    `mapstructure:"tracing"`
+	Health      HealthConfig     `mapstructure:"health"`
 }
 
 // Load loads configuration from environment variables and files
@@ -88,6 +194,7 @@ func setDefaults() {
 
 	// Database
 	viper.SetDefault("database.uri", "mongodb://localhost:27017/dune_forms?authSource=admin")
+	viper.SetDefault("database.auto_migrate", true) // Apply pending migrations on startup
 
 	// Server
 	viper.SetDefault("server.port", "8080")
@@ -97,17 +204,121 @@ func setDefaults() {
 	// CORS
 	viper.SetDefault("cors.allow_origins", "http://localhost:3000")
 	viper.SetDefault("cors.allow_methods", "GET,POST,PATCH,DELETE,OPTIONS")
-	viper.SetDefault("cors.allow_headers", "Origin,Content-Type,Accept,Authorization")
+	viper.SetDefault("cors.allow_headers", "Origin,Content-Type,Accept,Authorization,Idempotency-Key,Last-Event-ID,X-Workspace-ID")
 	viper.SetDefault("cors.allow_credentials", true)
 
 	// WebSocket
 	viper.SetDefault("websocket.buffer_size", 256)
 	viper.SetDefault("websocket.read_buffer_size", 1024)
 	viper.SetDefault("websocket.write_buffer_size", 1024)
+	viper.SetDefault("websocket.send_queue_size", 1024)
+	viper.SetDefault("websocket.max_message_size", 4096)
+	viper.SetDefault("websocket.max_connections_per_room", 100) // 0 disables the limit
+	viper.SetDefault("websocket.max_connections_per_ip", 20)    // 0 disables the limit
+	viper.SetDefault("websocket.max_subscriptions", 100)        // Rooms per connection, 0 disables the limit
+	viper.SetDefault("websocket.replay_buffer_size", 100)       // Events kept per form for resuming clients, 0 disables replay
+	viper.SetDefault("websocket.replay_retention", 15*time.Minute)
+	viper.SetDefault("websocket.auth_timeout", 10*time.Second)
+	viper.SetDefault("websocket.read_timeout", 60*time.Second)
+	viper.SetDefault("websocket.write_timeout", 10*time.Second)
+	viper.SetDefault("websocket.ping_interval", 50*time.Second) // Must be shorter than read_timeout
+	viper.SetDefault("websocket.stream_heartbeat", 15*time.Second)
+	viper.SetDefault("websocket.analytics_rate", 2) // Analytics broadcasts per form and second, 0 disables throttling
+	viper.SetDefault("websocket.analytics_snapshot_interval", 30*time.Second)
+	viper.SetDefault("websocket.backplane", "memory")          // Use "mongodb" when running several replicas
+	viper.SetDefault("websocket.backplane_size", 16*1024*1024) // 16MB capped collection
 
 	// Auth (use strong default secrets for development)
+	viper.SetDefault("auth.jwt_algorithm", "HS256") // RS256 or EdDSA sign with rotating keys published at /.well-known/jwks.json
 	viper.SetDefault("auth.access_token_secret", "dune_form_analytics_access_secret_key_32_chars_minimum_dev")
 	viper.SetDefault("auth.refresh_token_secret", "dune_form_analytics_refresh_secret_key_32_chars_minimum_dev")
+	viper.SetDefault("auth.account_token_secret", "dune_form_analytics_account_secret_key_32_chars_minimum_dev") // Signs password reset and verification links
+	viper.SetDefault("auth.password_reset_ttl", time.Hour)
+	viper.SetDefault("auth.email_verification_ttl", 72*time.Hour)
+	viper.SetDefault("auth.invitation_ttl", 7*24*time.Hour)
+	viper.SetDefault("auth.require_verified_email", false) // Refuse logins until the email address is verified
+	viper.SetDefault("auth.login_max_attempts", 5)         // Failed logins per account within the window before it is locked, 0 disables
+	viper.SetDefault("auth.login_ip_max_attempts", 20)     // Failed logins per client IP within the window before it is locked, 0 disables
+	viper.SetDefault("auth.login_attempt_window", 15*time.Minute)
+	viper.SetDefault("auth.login_lockout_duration", 15*time.Minute)
+	viper.SetDefault("auth.login_delay", time.Second) // Wait after a failed login to an account, doubled by each further failure, 0 disables
+	viper.SetDefault("auth.failed_login_retention", 30*24*time.Hour)
+	viper.SetDefault("auth.two_factor_secret", "dune_form_analytics_two_factor_secret_key_32_chars_minimum_dev") // Encrypts TOTP secrets and signs login challenges
+	viper.SetDefault("auth.two_factor_issuer", "Dune Forms")
+	viper.SetDefault("auth.two_factor_login_ttl", 5*time.Minute) // Time to enter a code after the password
+
+	// Single sign-on with an OpenID Connect provider, disabled while oidc_issuer is empty
+	viper.SetDefault("auth.oidc_issuer", "")
+	viper.SetDefault("auth.oidc_client_id", "")
+	viper.SetDefault("auth.oidc_client_secret", "") // Empty for public clients, which rely on PKCE alone
+	viper.SetDefault("auth.oidc_redirect_url", "http://localhost:8080/api/auth/oidc/callback")
+	viper.SetDefault("auth.oidc_scopes", []string{"openid", "email", "profile"})
+	viper.SetDefault("auth.oidc_provider_name", "SSO")
+	viper.SetDefault("auth.oidc_state_secret", "dune_form_analytics_oidc_state_secret_key_32_chars_minimum_dev") // Encrypts the state cookie and signs login tickets
+	viper.SetDefault("auth.oidc_state_ttl", 10*time.Minute)
+
+	// Signing keys of RS256 and EdDSA tokens. Each key signs for signing_key_rotation
+	// and is published signing_key_publish before, for services caching the JWKS.
+	viper.SetDefault("auth.signing_key_secret", "dune_form_analytics_signing_key_secret_32_chars_minimum_dev") // Encrypts private keys in the database
+	viper.SetDefault("auth.signing_key_rotation", 30*24*time.Hour)
+	viper.SetDefault("auth.signing_key_publish", 24*time.Hour)
+	// When moving from HS256 to signing keys, the time of the change: HS256 tokens
+	// issued before it keep working for at most the refresh token lifetime after it
+	viper.SetDefault("auth.hs256_switchover", "")
+
+	// Mail
+	viper.SetDefault("mail.driver", "log") // "smtp" delivers email, "log" only logs it
+	viper.SetDefault("mail.from", "Dune Forms <no-reply@localhost>")
+	viper.SetDefault("mail.app_url", "http://localhost:3000") // Web app that email links point to
+	viper.SetDefault("mail.dir", "")                          // log driver: also write messages to this directory
+	viper.SetDefault("mail.smtp_host", "")
+	viper.SetDefault("mail.smtp_port", 587)
+	viper.SetDefault("mail.smtp_username", "")
+	viper.SetDefault("mail.smtp_password", "")
+
+	// Submission
+	viper.SetDefault("submission.token_secret", "dune_form_analytics_submission_secret_key_32_chars_minimum_dev")
+	viper.SetDefault("submission.cookie_name", "dune_respondent")
+	viper.SetDefault("submission.cookie_secure", false)
+	viper.SetDefault("submission.idempotency_ttl", 24*time.Hour)
+	viper.SetDefault("submission.challenge_ttl", 12*time.Hour)
+
+	// Logging
+	viper.SetDefault("log.level", "info")
+	viper.SetDefault("log.format", "json") // "text" is easier to read locally
+	viper.SetDefault("log.redact", true)   // Mask IP and email addresses
+
+	// Tracing
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.exporter", "otlp")  // "stdout" prints spans for local debugging
+	viper.SetDefault("tracing.endpoint", "")      // OTLP/HTTP collector, defaults to localhost:4318 or OTEL_EXPORTER_OTLP_ENDPOINT
+	viper.SetDefault("tracing.insecure", false)   // Send OTLP over plain HTTP
+	viper.SetDefault("tracing.sample_ratio", 1.0) // Fraction of new traces that are recorded
+	viper.SetDefault("tracing.service_name", "dune-api")
+
+	// Health
+	viper.SetDefault("health.timeout", 3*time.Second)                 // Per readiness check
+	viper.SetDefault("health.database_latency", 250*time.Millisecond) // Slower pings report degraded
+	viper.SetDefault("health.realtime_stale_after", 10*time.Second)   // Realtime loop without a heartbeat reports down
+	viper.SetDefault("health.broadcast_backlog", 128)                 // Queued broadcasts before degraded
+	viper.SetDefault("health.analytics_backlog", 500)                 // Pending analytics updates before degraded
+
+	// Metrics
+	viper.SetDefault("metrics.enabled", true)
+	viper.SetDefault("metrics.path", "/metrics")
+	viper.SetDefault("metrics.token", "") // When set, scrapers must send it as a bearer token
+}
+
+// WebSocketOrigins returns the origins allowed to open WebSocket connections.
+// They are the same origins CORS allows for the REST API.
+func (c *Config) WebSocketOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(c.CORS.AllowOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
 }
 
 // GetMongoURIForLogging returns a masked MongoDB URI for logging
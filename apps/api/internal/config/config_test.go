@@ -147,6 +147,42 @@ func TestWebSocketConfig_Structure(t *testing.T) {
 	})
 }
 
+func TestConfig_WebSocketOrigins(t *testing.T) {
+	tests := []struct {
+		name     string
+		origins  string
+		expected []string
+	}{
+		{
+			name:     "Single origin",
+			origins:  "http://localhost:3000",
+			expected: []string{"http://localhost:3000"},
+		},
+		{
+			name:     "Multiple origins with spaces",
+			origins:  "https://forms.example.com, https://admin.example.com ,",
+			expected: []string{"https://forms.example.com", "https://admin.example.com"},
+		},
+		{
+			name:     "Wildcard",
+			origins:  "*",
+			expected: []string{"*"},
+		},
+		{
+			name:     "Empty",
+			origins:  "",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{CORS: CORSConfig{AllowOrigins: tt.origins}}
+			assert.Equal(t, tt.expected, config.WebSocketOrigins())
+		})
+	}
+}
+
 func TestAuthConfig_Structure(t *testing.T) {
 	t.Run("Create auth config", func(t *testing.T) {
 		config := AuthConfig{
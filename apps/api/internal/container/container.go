@@ -2,21 +2,33 @@ package container
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/config"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/handlers"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/health"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/interfaces"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/logging"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/mail"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/metrics"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/middleware"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/migrations"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/oidc"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/realtime"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/tracing"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/version"
 
 	validator "github.com/go-playground/validator/v10"
 	fiber "github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
 )
 
 // Container holds all application dependencies
@@ -43,6 +55,8 @@ type HandlerContainer struct {
 	ResponseHandler  *handlers.ResponseHandler
 	AnalyticsHandler *handlers.AnalyticsHandler
 	AuthHandler      *handlers.AuthHandler
+	APIKeyHandler    *handlers.APIKeyHandler
+	PresenceHandler  *handlers.PresenceHandler
 }
 
 // NewContainer creates a new dependency injection container
@@ -50,25 +64,53 @@ func NewContainer() *fx.App {
 	return fx.New(
 		// Configuration
 		fx.Provide(config.Load),
+		fx.Provide(logging.New),
+		fx.Invoke(logging.SetDefault),
+		fx.WithLogger(func(logger *slog.Logger) fxevent.Logger {
+			return &logging.FxLogger{Logger: logger}
+		}),
 		fx.Provide(validator.New),
+		fx.Provide(metrics.New),
+		fx.Invoke(StartTracing),
 
 		// Database
 		fx.Provide(NewDatabase),
+		fx.Provide(NewMigrator),
 
 		// Services
 		fx.Provide(NewFormService),
 		fx.Provide(NewResponseService),
 		fx.Provide(NewAnalyticsService),
+		fx.Provide(NewTwoFactorService),
+		fx.Provide(NewSigningKeyService),
 		fx.Provide(NewAuthService),
+		fx.Provide(NewMailer),
+		fx.Provide(NewAccountService),
+		fx.Provide(NewAPIKeyService),
+		fx.Provide(NewAuthorizer),
+		fx.Provide(NewOrganizationService),
+		fx.Provide(NewOIDCService),
+		fx.Provide(NewIdempotencyService),
+		fx.Provide(NewAbuseService),
+		fx.Provide(NewPresenceService),
 
 		// WebSocket
+		fx.Provide(NewBackplane),
 		fx.Provide(NewWebSocketManager),
+		fx.Invoke(WatchAccess),
 
 		// Handlers
 		fx.Provide(NewFormHandler),
 		fx.Provide(NewResponseHandler),
 		fx.Provide(NewAnalyticsHandler),
 		fx.Provide(NewAuthHandler),
+		fx.Provide(NewAPIKeyHandler),
+		fx.Provide(NewOrganizationHandler),
+		fx.Provide(NewJWKSHandler),
+		fx.Provide(NewPresenceHandler),
+
+		// Health
+		fx.Provide(NewHealthChecker),
 
 		// Fiber App
 		fx.Provide(NewFiberApp),
@@ -82,33 +124,105 @@ func NewContainer() *fx.App {
 }
 
 // NewDatabase creates a new database connection
-func NewDatabase(cfg *config.Config) (interfaces.DatabaseInterface, error) {
-	return database.Connect(cfg.Database.URI)
+func NewDatabase(cfg *config.Config, m *metrics.Metrics) (interfaces.DatabaseInterface, error) {
+	return database.Connect(cfg.Database.URI, m.CommandMonitor(), tracing.CommandMonitor())
+}
+
+// NewMigrator creates the schema migrator for the database
+func NewMigrator(db interfaces.DatabaseInterface) (*migrations.Migrator, error) {
+	collections := db.GetCollections()
+	return migrations.NewMigrator(migrations.NewMongoStore(collections), collections, migrations.All())
+}
+
+// StartTracing installs the tracer provider and flushes pending spans on shutdown.
+// It runs before the server starts so that spans of startup work are exported too.
+func StartTracing(lc fx.Lifecycle, cfg *config.Config) error {
+	shutdown, err := tracing.Setup(context.Background(), cfg.Tracing, cfg.Environment)
+	if err != nil {
+		return err
+	}
+	lc.Append(fx.Hook{OnStop: shutdown})
+	return nil
 }
 
 // NewFormService creates a new form service
-func NewFormService(db interfaces.DatabaseInterface) interfaces.FormServiceInterface {
-	return services.NewFormService(db.GetCollections())
+func NewFormService(db interfaces.DatabaseInterface, authorizer *services.Authorizer) interfaces.FormServiceInterface {
+	return services.NewFormService(db.GetCollections(), authorizer)
 }
 
 // NewResponseService creates a new response service
-func NewResponseService(db interfaces.DatabaseInterface) interfaces.ResponseServiceInterface {
-	return services.NewResponseService(db.GetCollections())
+func NewResponseService(db interfaces.DatabaseInterface, authorizer *services.Authorizer) interfaces.ResponseServiceInterface {
+	return services.NewResponseService(db.GetCollections(), authorizer)
 }
 
 // NewAnalyticsService creates a new analytics service
-func NewAnalyticsService(db interfaces.DatabaseInterface) interfaces.AnalyticsServiceInterface {
-	return services.NewAnalyticsService(db.GetCollections())
+func NewAnalyticsService(db interfaces.DatabaseInterface, authorizer *services.Authorizer) interfaces.AnalyticsServiceInterface {
+	return services.NewAnalyticsService(db.GetCollections(), authorizer)
+}
+
+// NewPresenceService creates a new presence service
+func NewPresenceService(db interfaces.DatabaseInterface) interfaces.PresenceServiceInterface {
+	return services.NewPresenceService(db.GetCollections())
+}
+
+// NewBackplane creates the backplane that fans WebSocket broadcasts out to all API instances
+func NewBackplane(cfg *config.Config, db interfaces.DatabaseInterface) realtime.Backplane {
+	if cfg.WebSocket.Backplane == "mongodb" {
+		return realtime.NewMongoBackplane(db.GetCollections().RealtimeEvents, cfg.WebSocket.BackplaneSize)
+	}
+	return realtime.NewMemoryBackplane()
 }
 
 // NewWebSocketManager creates a new WebSocket manager
-func NewWebSocketManager() interfaces.WebSocketManagerInterface {
-	return realtime.NewWebSocketManager()
+func NewWebSocketManager(
+	cfg *config.Config,
+	backplane realtime.Backplane,
+	authService *services.AuthService,
+	formService interfaces.FormServiceInterface,
+	m *metrics.Metrics,
+) interfaces.WebSocketManagerInterface {
+	manager := realtime.NewWebSocketManager(NewRealtimeConfig(cfg), backplane, authService, formService)
+	m.RegisterRealtime(manager)
+	return manager
+}
+
+// WatchAccess tells the WebSocket manager about access changes so that it drops
+// subscriptions of users who can no longer read a form
+func WatchAccess(authorizer *services.Authorizer, wsManager interfaces.WebSocketManagerInterface) {
+	authorizer.SetAccessListener(wsManager)
+}
+
+// NewRealtimeConfig maps the application configuration to WebSocket manager settings
+func NewRealtimeConfig(cfg *config.Config) realtime.Config {
+	return realtime.Config{
+		Origins:               cfg.WebSocketOrigins(),
+		ReadBufferSize:        cfg.WebSocket.ReadBufferSize,
+		WriteBufferSize:       cfg.WebSocket.WriteBufferSize,
+		BroadcastQueueSize:    cfg.WebSocket.BufferSize,
+		SendQueueSize:         cfg.WebSocket.SendQueueSize,
+		MaxMessageSize:        cfg.WebSocket.MaxMessageSize,
+		MaxConnectionsPerRoom: cfg.WebSocket.MaxConnectionsPerRoom,
+		MaxConnectionsPerIP:   cfg.WebSocket.MaxConnectionsPerIP,
+		MaxSubscriptions:      cfg.WebSocket.MaxSubscriptions,
+		ReplayBufferSize:      cfg.WebSocket.ReplayBufferSize,
+		ReplayRetention:       cfg.WebSocket.ReplayRetention,
+		AuthTimeout:           cfg.WebSocket.AuthTimeout,
+		ReadTimeout:           cfg.WebSocket.ReadTimeout,
+		WriteTimeout:          cfg.WebSocket.WriteTimeout,
+		PingInterval:          cfg.WebSocket.PingInterval,
+		AnalyticsRate:         cfg.WebSocket.AnalyticsRate,
+		SnapshotInterval:      cfg.WebSocket.AnalyticsSnapshot,
+		StreamHeartbeat:       cfg.WebSocket.StreamHeartbeat,
+	}
 }
 
 // NewFormHandler creates a new form handler
-func NewFormHandler(formService interfaces.FormServiceInterface, validator *validator.Validate) *handlers.FormHandler {
-	return handlers.NewFormHandler(formService, validator)
+func NewFormHandler(
+	formService interfaces.FormServiceInterface,
+	abuseService interfaces.AbuseServiceInterface,
+	validator *validator.Validate,
+) *handlers.FormHandler {
+	return handlers.NewFormHandler(formService, abuseService, validator)
 }
 
 // NewResponseHandler creates a new response handler
@@ -116,10 +230,22 @@ func NewResponseHandler(
 	responseService interfaces.ResponseServiceInterface,
 	analyticsService interfaces.AnalyticsServiceInterface,
 	formService interfaces.FormServiceInterface,
+	abuseService interfaces.AbuseServiceInterface,
 	wsManager interfaces.WebSocketManagerInterface,
 	validator *validator.Validate,
+	cfg *config.Config,
+	m *metrics.Metrics,
 ) *handlers.ResponseHandler {
-	return handlers.NewResponseHandler(responseService, analyticsService, formService, wsManager, validator)
+	return handlers.NewResponseHandler(responseService, analyticsService, formService, abuseService, wsManager, validator, cfg.Submission, m)
+}
+
+// NewPresenceHandler creates a new presence handler
+func NewPresenceHandler(
+	presenceService interfaces.PresenceServiceInterface,
+	wsManager interfaces.WebSocketManagerInterface,
+	validator *validator.Validate,
+) *handlers.PresenceHandler {
+	return handlers.NewPresenceHandler(presenceService, wsManager, validator)
 }
 
 // NewAnalyticsHandler creates a new analytics handler
@@ -127,8 +253,26 @@ func NewAnalyticsHandler(analyticsService interfaces.AnalyticsServiceInterface,
 	return handlers.NewAnalyticsHandler(analyticsService, validator)
 }
 
+// NewHealthChecker creates the readiness checker for the database, the realtime
+// event loop and the analytics updates of submissions
+func NewHealthChecker(
+	cfg *config.Config,
+	db interfaces.DatabaseInterface,
+	migrator *migrations.Migrator,
+	wsManager interfaces.WebSocketManagerInterface,
+	responseHandler *handlers.ResponseHandler,
+) *health.Checker {
+	checker := health.NewChecker(version.String(), cfg.Health.Timeout)
+	checker.Register("database", health.Database(db, cfg.Health.DatabaseLatency))
+	checker.Register("indexes", health.Indexes(db))
+	checker.Register("migrations", health.Migrations(migrator, migrator.Latest()))
+	checker.Register("realtime", health.Realtime(wsManager, cfg.Health.RealtimeStaleAfter, cfg.Health.BroadcastBacklog))
+	checker.Register("analytics", health.AnalyticsWorker(responseHandler, cfg.Health.AnalyticsBacklog))
+	return checker
+}
+
 // NewFiberApp creates a new Fiber application with all middleware
-func NewFiberApp(cfg *config.Config) *fiber.App {
+func NewFiberApp(cfg *config.Config, m *metrics.Metrics) *fiber.App {
 	app := fiber.New(fiber.Config{
 		ErrorHandler: middleware.ErrorHandler,
 		BodyLimit:    cfg.Server.BodyLimit,
@@ -136,17 +280,21 @@ func NewFiberApp(cfg *config.Config) *fiber.App {
 		ServerHeader: "Dune-API",
 	})
 
+	// Request metrics come first so they include every other middleware
+	if cfg.Metrics.Enabled {
+		app.Use(middleware.MetricsMiddleware(m))
+	}
+
+	// Request IDs and spans are assigned before anything logs
+	app.Use(middleware.RequestIDMiddleware())
+	app.Use(middleware.TracingMiddleware())
+	app.Use(middleware.RequestLogger())
+
 	// Global middleware
 	app.Use(recover.New(recover.Config{
 		EnableStackTrace: true,
 	}))
 
-	// Production-level logging
-	app.Use(logger.New(logger.Config{
-		Format:     "${time} [${ip}] ${status} - ${method} ${path} (${latency}) ${error}\n",
-		TimeFormat: "2006-01-02 15:04:05",
-	}))
-
 	// CORS middleware
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     cfg.CORS.AllowOrigins,
@@ -164,13 +312,31 @@ func StartServer(
 	app *fiber.App,
 	cfg *config.Config,
 	db interfaces.DatabaseInterface,
+	migrator *migrations.Migrator,
 	formHandler *handlers.FormHandler,
 	responseHandler *handlers.ResponseHandler,
 	analyticsHandler *handlers.AnalyticsHandler,
 	authHandler *handlers.AuthHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	organizationHandler *handlers.OrganizationHandler,
+	jwksHandler *handlers.JWKSHandler,
+	presenceHandler *handlers.PresenceHandler,
 	authService *services.AuthService,
+	signingKeys *services.SigningKeyService,
+	apiKeyService *services.APIKeyService,
+	authorizer *services.Authorizer,
+	idempotencyService *services.IdempotencyService,
+	presenceService interfaces.PresenceServiceInterface,
 	wsManager interfaces.WebSocketManagerInterface,
+	healthChecker *health.Checker,
+	m *metrics.Metrics,
 ) {
+	// Cancelled on shutdown to stop background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+
+	// Closed once the WebSocket manager has disconnected its clients
+	wsStopped := make(chan struct{})
+
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			// Ensure database indexes
@@ -178,16 +344,42 @@ func StartServer(
 				return err
 			}
 
+			// Load the token signing keys, creating the first one, and rotate them on schedule
+			if signingKeys != nil {
+				if err := signingKeys.Start(ctx); err != nil {
+					return err
+				}
+				go signingKeys.Run(workerCtx, services.SigningKeyCheckInterval)
+			}
+
 			// Start WebSocket manager
-			go wsManager.Run()
+			go func() {
+				defer close(wsStopped)
+				wsManager.Run(workerCtx)
+			}()
 
-			// Run database migrations (create test user)
-			if err := db.RunMigrations(); err != nil {
-				return err
+			// Push presence changes of sessions that time out to owner dashboards
+			go presenceService.Watch(workerCtx, services.PresenceHeartbeatInterval, func(counts *models.PresenceCounts) {
+				wsManager.Broadcast(counts.FormID, "presence:update", counts)
+			})
+
+			// Apply pending migrations; replicas starting together wait for the first one.
+			// With auto-migration disabled, run "server migrate run" before deploying.
+			if cfg.Database.AutoMigrate {
+				if _, err := migrator.Up(ctx, 0); err != nil {
+					return err
+				}
+			}
+
+			// Development databases get a user to log in with
+			if cfg.Environment == "development" {
+				if err := migrations.Seed(ctx, db.GetCollections()); err != nil {
+					return err
+				}
 			}
 
 			// Setup routes
-			setupRoutes(app, cfg, db, formHandler, responseHandler, analyticsHandler, authHandler, authService, wsManager)
+			setupRoutes(app, cfg, db, formHandler, responseHandler, analyticsHandler, authHandler, apiKeyHandler, organizationHandler, jwksHandler, presenceHandler, authService, apiKeyService, authorizer, idempotencyService, wsManager, healthChecker, m)
 
 			// Start server in goroutine
 			go func() {
@@ -199,7 +391,17 @@ func StartServer(
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
-			if err := app.Shutdown(); err != nil {
+			stopWorkers()
+
+			// Let realtime clients receive their close frames before the
+			// server stops accepting and closes connections
+			select {
+			case <-wsStopped:
+			case <-ctx.Done():
+				slog.Warn("Timed out waiting for realtime clients to disconnect")
+			}
+
+			if err := app.ShutdownWithContext(ctx); err != nil {
 				return err
 			}
 			return db.Close()
@@ -207,13 +409,167 @@ func StartServer(
 	})
 }
 
+// NewTwoFactorService creates the TOTP two-factor authentication service
+func NewTwoFactorService(cfg *config.Config, db interfaces.DatabaseInterface) *services.TwoFactorService {
+	return services.NewTwoFactorService(db.GetCollections(), services.TwoFactorConfig{
+		Secret:   cfg.Auth.TwoFactorSecret,
+		Issuer:   cfg.Auth.TwoFactorIssuer,
+		LoginTTL: cfg.Auth.TwoFactorLoginTTL,
+	})
+}
+
+// NewSigningKeyService creates the service managing the keys of RS256 and EdDSA
+// tokens, or returns nil when tokens are signed with the HS256 secrets
+func NewSigningKeyService(cfg *config.Config, db interfaces.DatabaseInterface) *services.SigningKeyService {
+	if cfg.Auth.JWTAlgorithm == "HS256" {
+		return nil
+	}
+
+	return services.NewSigningKeyService(db.GetCollections(), services.SigningKeyConfig{
+		Algorithm:        cfg.Auth.JWTAlgorithm,
+		Secret:           cfg.Auth.SigningKeySecret,
+		RotationInterval: cfg.Auth.SigningKeyRotation,
+		PublishAhead:     cfg.Auth.SigningKeyPublish,
+		TokenTTL:         services.RefreshTokenTTL,
+	})
+}
+
 // NewAuthService creates a new authentication service
-func NewAuthService(cfg *config.Config, db interfaces.DatabaseInterface) *services.AuthService {
+func NewAuthService(
+	cfg *config.Config,
+	db interfaces.DatabaseInterface,
+	twoFactorService *services.TwoFactorService,
+	signingKeys *services.SigningKeyService,
+) (*services.AuthService, error) {
 	collections := db.GetCollections()
-	return services.NewAuthService(collections, cfg.Auth.AccessTokenSecret, cfg.Auth.RefreshTokenSecret)
+	authService := services.NewAuthService(collections, cfg.Auth.AccessTokenSecret, cfg.Auth.RefreshTokenSecret)
+	authService.SetRequireVerifiedEmail(cfg.Auth.RequireVerifiedEmail)
+	authService.SetLockout(services.NewLockoutService(collections, services.LockoutConfig{
+		MaxAttempts:     cfg.Auth.LoginMaxAttempts,
+		IPMaxAttempts:   cfg.Auth.LoginIPMaxAttempts,
+		Window:          cfg.Auth.LoginAttemptWindow,
+		LockoutDuration: cfg.Auth.LoginLockoutDuration,
+		Delay:           cfg.Auth.LoginDelay,
+		AuditRetention:  cfg.Auth.FailedLoginRetention,
+	}))
+	authService.SetTwoFactor(twoFactorService)
+	authService.SetSigningKeys(signingKeys)
+	if cfg.Auth.HS256Switchover != "" {
+		switchover, err := time.Parse(time.RFC3339, cfg.Auth.HS256Switchover)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HS256 switchover time: %w", err)
+		}
+		authService.SetHS256Switchover(switchover)
+	}
+	return authService, nil
+}
+
+// NewMailer creates the configured mailer
+func NewMailer(cfg *config.Config) (mail.Mailer, error) {
+	return mail.New(cfg.Mail)
+}
+
+// NewAccountService creates the password reset and email verification service.
+// Emails still being sent are given until shutdown times out.
+func NewAccountService(lc fx.Lifecycle, cfg *config.Config, db interfaces.DatabaseInterface, authService *services.AuthService, mailer mail.Mailer) *services.AccountService {
+	accountService := services.NewAccountService(db.GetCollections(), authService, mailer, services.AccountConfig{
+		Secret:               cfg.Auth.AccountTokenSecret,
+		PasswordResetTTL:     cfg.Auth.PasswordResetTTL,
+		EmailVerificationTTL: cfg.Auth.EmailVerificationTTL,
+		AppURL:               cfg.Mail.AppURL,
+	})
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			done := make(chan struct{})
+			go func() {
+				accountService.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-ctx.Done():
+				slog.Warn("Timed out waiting for emails to be sent")
+			}
+			return nil
+		},
+	})
+	return accountService
+}
+
+// NewAPIKeyService creates the personal API key service
+func NewAPIKeyService(db interfaces.DatabaseInterface) *services.APIKeyService {
+	return services.NewAPIKeyService(db.GetCollections())
+}
+
+// NewAuthorizer creates the authorizer that resolves the workspace of requests
+func NewAuthorizer(db interfaces.DatabaseInterface) *services.Authorizer {
+	return services.NewAuthorizer(db.GetCollections())
+}
+
+// NewOrganizationService creates the organization service
+func NewOrganizationService(cfg *config.Config, db interfaces.DatabaseInterface, authorizer *services.Authorizer, mailer mail.Mailer) *services.OrganizationService {
+	return services.NewOrganizationService(db.GetCollections(), authorizer, mailer, services.OrganizationConfig{
+		InvitationTTL: cfg.Auth.InvitationTTL,
+		AppURL:        cfg.Mail.AppURL,
+	})
+}
+
+// NewOIDCService creates the single sign-on service, or returns nil when no
+// provider is configured
+func NewOIDCService(cfg *config.Config, db interfaces.DatabaseInterface, authService *services.AuthService) *services.OIDCService {
+	if cfg.Auth.OIDCIssuer == "" {
+		return nil
+	}
+
+	provider := oidc.New(oidc.Config{
+		Issuer:       cfg.Auth.OIDCIssuer,
+		ClientID:     cfg.Auth.OIDCClientID,
+		ClientSecret: cfg.Auth.OIDCClientSecret,
+		RedirectURL:  cfg.Auth.OIDCRedirectURL,
+		Scopes:       cfg.Auth.OIDCScopes,
+	})
+	return services.NewOIDCService(db.GetCollections(), authService, provider, services.OIDCConfig{
+		ProviderName: cfg.Auth.OIDCProviderName,
+		StateSecret:  cfg.Auth.OIDCStateSecret,
+		StateTTL:     cfg.Auth.OIDCStateTTL,
+		RedirectURL:  cfg.Auth.OIDCRedirectURL,
+		AppURL:       cfg.Mail.AppURL,
+	})
+}
+
+// NewIdempotencyService creates a new idempotency service
+func NewIdempotencyService(cfg *config.Config, db interfaces.DatabaseInterface) *services.IdempotencyService {
+	return services.NewIdempotencyService(db.GetCollections(), cfg.Submission.IdempotencyTTL)
+}
+
+// NewAbuseService creates a new anti-abuse service
+func NewAbuseService(cfg *config.Config, db interfaces.DatabaseInterface) interfaces.AbuseServiceInterface {
+	return services.NewAbuseService(db.GetCollections(), cfg.Submission.TokenSecret, cfg.Submission.ChallengeTTL)
 }
 
 // NewAuthHandler creates a new authentication handler
-func NewAuthHandler(authService *services.AuthService, validator *validator.Validate) *handlers.AuthHandler {
-	return handlers.NewAuthHandler(authService, validator)
+func NewAuthHandler(
+	authService *services.AuthService,
+	accountService *services.AccountService,
+	twoFactorService *services.TwoFactorService,
+	oidcService *services.OIDCService,
+	validator *validator.Validate,
+) *handlers.AuthHandler {
+	return handlers.NewAuthHandler(authService, accountService, twoFactorService, oidcService, validator)
+}
+
+// NewJWKSHandler creates the handler publishing the token signing keys
+func NewJWKSHandler(signingKeys *services.SigningKeyService) *handlers.JWKSHandler {
+	return handlers.NewJWKSHandler(signingKeys)
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService, validator *validator.Validate) *handlers.APIKeyHandler {
+	return handlers.NewAPIKeyHandler(apiKeyService, validator)
+}
+
+// NewOrganizationHandler creates a new organization handler
+func NewOrganizationHandler(organizationService *services.OrganizationService, validator *validator.Validate) *handlers.OrganizationHandler {
+	return handlers.NewOrganizationHandler(organizationService, validator)
 }
@@ -5,9 +5,13 @@ import (
 
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/config"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/handlers"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/health"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/interfaces"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/metrics"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/middleware"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/version"
 
 	fiber "github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/monitor"
@@ -24,38 +28,76 @@ func setupRoutes(
 	responseHandler *handlers.ResponseHandler,
 	analyticsHandler *handlers.AnalyticsHandler,
 	authHandler *handlers.AuthHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	organizationHandler *handlers.OrganizationHandler,
+	jwksHandler *handlers.JWKSHandler,
+	presenceHandler *handlers.PresenceHandler,
 	authService *services.AuthService,
+	apiKeyService *services.APIKeyService,
+	authorizer *services.Authorizer,
+	idempotencyService *services.IdempotencyService,
 	wsManager interfaces.WebSocketManagerInterface,
+	healthChecker *health.Checker,
+	m *metrics.Metrics,
 ) {
 	// Health check endpoint
 	// @Summary Health check
-	// @Description Check API health status and database connectivity
+	// @Description Check API health. Runs the readiness checks and reports the overall and per-component status.
 	// @Tags System
 	// @Accept json
 	// @Produce json
-	// @Success 200 {object} map[string]interface{} "Service is healthy"
-	// @Failure 503 {object} map[string]interface{} "Service is unhealthy"
+	// @Success 200 {object} map[string]interface{} "Service is up or degraded"
+	// @Failure 503 {object} map[string]interface{} "Service is down"
 	// @Router /health [get]
 	app.Get("/health", func(c *fiber.Ctx) error {
-		if err := db.HealthCheck(); err != nil {
-			return c.Status(503).JSON(fiber.Map{
-				"status":      "unhealthy",
-				"service":     "dune-form-analytics-api",
-				"version":     "1.0.0",
-				"timestamp":   time.Now().UTC().Format(time.RFC3339),
-				"environment": cfg.Environment,
-				"error":       err.Error(),
-			})
-		}
-		return c.JSON(fiber.Map{
-			"status":      "healthy",
+		report := healthChecker.Check(c.UserContext())
+		return c.Status(healthStatusCode(report)).JSON(fiber.Map{
+			"status":      report.Status,
 			"service":     "dune-form-analytics-api",
-			"version":     "1.0.0",
-			"timestamp":   time.Now().UTC().Format(time.RFC3339),
+			"version":     report.Version,
+			"timestamp":   report.Timestamp,
 			"environment": cfg.Environment,
+			"components":  report.Components,
+		})
+	})
+
+	// @Summary Liveness probe
+	// @Description Report that the process is running. Does not check dependencies, so orchestrators
+	// @Description only restart the instance when the process itself is stuck.
+	// @Tags System
+	// @Produce json
+	// @Success 200 {object} map[string]interface{} "Process is alive"
+	// @Router /health/live [get]
+	app.Get("/health/live", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"status":    health.StatusUp,
+			"version":   version.String(),
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
 		})
 	})
 
+	// @Summary Readiness probe
+	// @Description Check whether the instance should receive traffic: MongoDB latency, index presence,
+	// @Description migration version, realtime event loop liveness and the analytics update backlog.
+	// @Description A degraded instance keeps serving; a down instance returns 503.
+	// @Tags System
+	// @Produce json
+	// @Success 200 {object} health.Report "Instance is ready (up or degraded)"
+	// @Failure 503 {object} health.Report "Instance is not ready"
+	// @Router /health/ready [get]
+	app.Get("/health/ready", func(c *fiber.Ctx) error {
+		report := healthChecker.Check(c.UserContext())
+		return c.Status(healthStatusCode(report)).JSON(report)
+	})
+
+	// Keys verifying the API's tokens, for other services
+	app.Get("/.well-known/jwks.json", jwksHandler.JWKS)
+
+	// Prometheus metrics, available in every environment
+	if cfg.Metrics.Enabled {
+		app.Get(cfg.Metrics.Path, middleware.MetricsTokenMiddleware(cfg.Metrics.Token), m.Handler())
+	}
+
 	// Configure development tools and port-based routing
 	if cfg.Environment == "development" {
 		setupDevelopmentTools(app)
@@ -71,18 +113,26 @@ func setupRoutes(
 	// @Success 200 {object} map[string]interface{} "API information"
 	// @Router / [get]
 	app.Get("/", func(c *fiber.Ctx) error {
+		endpoints := fiber.Map{
+			"health":    "/health",
+			"liveness":  "/health/live",
+			"readiness": "/health/ready",
+			"jwks":      "/.well-known/jwks.json",
+			"api":       "/api",
+			"websocket": "/ws/forms/:id",
+		}
+		if cfg.Metrics.Enabled {
+			endpoints["metrics"] = cfg.Metrics.Path
+		}
+
 		response := fiber.Map{
 			"service":     "Dune Form Analytics API",
-			"version":     "1.0.0",
+			"version":     version.String(),
 			"description": "Professional form builder with real-time analytics",
 			"environment": cfg.Environment,
 			"status":      "operational",
 			"timestamp":   time.Now().UTC().Format(time.RFC3339),
-			"endpoints": fiber.Map{
-				"health":    "/health",
-				"api":       "/api",
-				"websocket": "/ws/forms/:id",
-			},
+			"endpoints":   endpoints,
 		}
 
 		// Add development tools info only in development environment
@@ -100,8 +150,15 @@ func setupRoutes(
 	// API routes group
 	api := app.Group("/api")
 
-	// Authentication middleware
-	authMiddleware := middleware.AuthMiddleware(authService)
+	// Authentication middleware. API keys are refused unless a route names the
+	// scopes that allow them.
+	authMiddleware := middleware.AuthMiddleware(authService, apiKeyService)
+	scoped := func(scopes ...models.APIKeyScope) fiber.Handler {
+		return middleware.AuthMiddleware(authService, apiKeyService, scopes...)
+	}
+
+	// Selects the workspace from the X-Workspace-ID header, after authentication
+	workspace := middleware.WorkspaceMiddleware(authorizer)
 
 	// Authentication routes (public)
 	auth := api.Group("/auth")
@@ -109,46 +166,119 @@ func setupRoutes(
 	auth.Post("/login", authHandler.Login)
 	auth.Post("/refresh", authHandler.RefreshToken)
 	auth.Post("/logout", authHandler.Logout)
+	auth.Post("/logout-all", authMiddleware, authHandler.LogoutAll)
+	auth.Post("/password/forgot", authHandler.ForgotPassword)
+	auth.Post("/password/reset", authHandler.ResetPassword)
+	auth.Post("/email/resend", authHandler.ResendVerification)
+	auth.Post("/email/verify", authHandler.VerifyEmail)
+	auth.Get("/oidc", authHandler.OIDCProvider)
+	auth.Get("/oidc/login", authHandler.OIDCLogin)
+	auth.Get("/oidc/callback", authHandler.OIDCCallback)
+	auth.Post("/oidc/exchange", authHandler.OIDCExchange)
+	auth.Post("/2fa/verify", authHandler.VerifyTwoFactor)
+	auth.Post("/2fa/setup", authMiddleware, authHandler.SetupTwoFactor)
+	auth.Post("/2fa/enable", authMiddleware, authHandler.EnableTwoFactor)
+	auth.Post("/2fa/disable", authMiddleware, authHandler.DisableTwoFactor)
+	auth.Get("/sessions", authMiddleware, authHandler.ListSessions)
+	auth.Delete("/sessions/:id", authMiddleware, authHandler.RevokeSession)
+	auth.Get("/api-keys", authMiddleware, apiKeyHandler.ListAPIKeys)
+	auth.Post("/api-keys", authMiddleware, apiKeyHandler.CreateAPIKey)
+	auth.Delete("/api-keys/:id", authMiddleware, apiKeyHandler.RevokeAPIKey)
 	auth.Get("/me", authMiddleware, authHandler.GetMe)
 
+	// Organization routes (require authentication)
+	api.Post("/organizations", authMiddleware, organizationHandler.CreateOrganization)
+	api.Get("/organizations", authMiddleware, organizationHandler.ListOrganizations)
+	api.Get("/organizations/:id", authMiddleware, organizationHandler.GetOrganization)
+	api.Patch("/organizations/:id", authMiddleware, organizationHandler.UpdateOrganization)
+	api.Delete("/organizations/:id", authMiddleware, organizationHandler.DeleteOrganization)
+	api.Get("/organizations/:id/members", authMiddleware, organizationHandler.ListMembers)
+	api.Patch("/organizations/:id/members/:userId", authMiddleware, organizationHandler.UpdateMember)
+	api.Delete("/organizations/:id/members/:userId", authMiddleware, organizationHandler.RemoveMember)
+	api.Get("/organizations/:id/invitations", authMiddleware, organizationHandler.ListInvitations)
+	api.Post("/organizations/:id/invitations", authMiddleware, organizationHandler.InviteMember)
+	api.Delete("/organizations/:id/invitations/:invitationId", authMiddleware, organizationHandler.RevokeInvitation)
+	api.Post("/invitations/accept", authMiddleware, organizationHandler.AcceptInvitation)
+
 	// Protected form routes (require authentication)
-	api.Post("/forms", authMiddleware, formHandler.CreateForm)
-	api.Get("/forms/:id", authMiddleware, formHandler.GetForm)
-	api.Patch("/forms/:id", authMiddleware, formHandler.UpdateForm)
-	api.Delete("/forms/:id", authMiddleware, formHandler.DeleteForm)
-	api.Get("/forms", authMiddleware, formHandler.ListForms)
-	api.Post("/forms/:id/publish", authMiddleware, formHandler.PublishForm)
-	api.Post("/forms/:id/unpublish", authMiddleware, formHandler.UnpublishForm)
+	api.Post("/forms", authMiddleware, workspace, formHandler.CreateForm)
+	api.Get("/forms/:id", scoped(models.ScopeFormsRead), workspace, formHandler.GetForm)
+	api.Patch("/forms/:id", authMiddleware, workspace, formHandler.UpdateForm)
+	api.Delete("/forms/:id", authMiddleware, workspace, formHandler.DeleteForm)
+	api.Get("/forms", scoped(models.ScopeFormsRead), workspace, formHandler.ListForms)
+	api.Post("/forms/:id/publish", authMiddleware, workspace, formHandler.PublishForm)
+	api.Post("/forms/:id/unpublish", authMiddleware, workspace, formHandler.UnpublishForm)
+	api.Post("/forms/:id/transfer", authMiddleware, workspace, formHandler.TransferForm)
 
 	// Public form routes
 	api.Get("/forms/slug/:slug", formHandler.GetPublicForm)
 
 	// Response routes (submit is public, others require auth)
-	api.Post("/forms/:id/submit", responseHandler.SubmitResponse)
-	api.Get("/forms/:id/responses", authMiddleware, responseHandler.GetResponses)
-	api.Get("/forms/:id/export.csv", authMiddleware, responseHandler.ExportCSV)
-	api.Get("/forms/:id/analytics.csv", authMiddleware, responseHandler.ExportAnalyticsCSV)
+	api.Post("/forms/:id/submit",
+		middleware.OptionalAuthMiddleware(authService),
+		middleware.IdempotencyMiddleware(idempotencyService),
+		responseHandler.SubmitResponse,
+	)
+	api.Post("/forms/:id/presence", presenceHandler.Heartbeat)
+	api.Get("/forms/:id/responses", scoped(models.ScopeResponsesRead), workspace, responseHandler.GetResponses)
+	api.Get("/forms/:id/export.csv", scoped(models.ScopeResponsesExport), workspace, responseHandler.ExportCSV)
+	api.Get("/forms/:id/analytics.csv", scoped(models.ScopeAnalyticsRead), workspace, responseHandler.ExportAnalyticsCSV)
 
 	// Analytics routes (require authentication)
-	api.Get("/forms/:id/analytics", authMiddleware, analyticsHandler.GetAnalytics)
-	api.Post("/forms/:id/analytics/compute", authMiddleware, analyticsHandler.ComputeAnalytics)
-	api.Get("/forms/:id/metrics", authMiddleware, analyticsHandler.GetRealTimeMetrics)
-	api.Get("/analytics/summary", authMiddleware, analyticsHandler.GetAnalyticsSummary)
-	api.Get("/forms/:id/trends", authMiddleware, analyticsHandler.GetTrendAnalytics)
+	api.Get("/forms/:id/analytics", scoped(models.ScopeAnalyticsRead), workspace, analyticsHandler.GetAnalytics)
+	api.Post("/forms/:id/analytics/compute", authMiddleware, workspace, analyticsHandler.ComputeAnalytics)
+	api.Get("/forms/:id/metrics", scoped(models.ScopeAnalyticsRead), workspace, analyticsHandler.GetRealTimeMetrics)
+	api.Get("/analytics/summary", scoped(models.ScopeAnalyticsRead), workspace, analyticsHandler.GetAnalyticsSummary)
+	api.Get("/forms/:id/trends", scoped(models.ScopeAnalyticsRead), workspace, analyticsHandler.GetTrendAnalytics)
+
+	// @Summary Stream real-time analytics
+	// @Description Server-Sent Events fallback for clients that cannot open WebSockets. Emits the same
+	// @Description messages as the WebSocket (analytics:update, presence:update) as data lines with their
+	// @Description event ID, plus heartbeat comments. Resume with the Last-Event-ID header.
+	// @Tags Analytics
+	// @Produce text/event-stream
+	// @Security BearerAuth
+	// @Param id path string true "Form ID"
+	// @Param Last-Event-ID header string false "ID of the last event received"
+	// @Success 200 {string} string "Event stream"
+	// @Failure 400 {object} map[string]interface{} "Invalid form ID or event ID"
+	// @Failure 401 {object} map[string]interface{} "Unauthorized"
+	// @Failure 404 {object} map[string]interface{} "Form not found"
+	// @Failure 429 {object} map[string]interface{} "Too many connections"
+	// @Router /api/forms/{id}/analytics/stream [get]
+	api.Get("/forms/:id/analytics/stream", scoped(models.ScopeAnalyticsRead), wsManager.HandleStream)
 
 	// WebSocket routes for real-time analytics
 	// @Summary WebSocket connection
-	// @Description Establish WebSocket connection for real-time form analytics
+	// @Description Establish WebSocket connection for real-time form analytics.
+	// @Description The access token is passed in the token query parameter or, preferably, as the first
+	// @Description message {"type":"auth","token":"..."}. Only the form owner, or members of the organization owning the form, may subscribe. Connections are
+	// @Description closed with code 4401 when the token is invalid or expires (send another auth message to
+	// @Description refresh it) and 4403 when the form is not accessible.
 	// @Tags WebSocket
 	// @Accept json
 	// @Produce json
 	// @Param id path string true "Form ID"
+	// @Param token query string false "Access token"
+	// @Param lastEventId query string false "ID of the last event received, to replay missed events"
 	// @Success 101 {string} string "WebSocket connection established"
 	// @Failure 400 {object} map[string]interface{} "Bad request"
+	// @Failure 401 {object} map[string]interface{} "Invalid or expired token"
 	// @Failure 404 {object} map[string]interface{} "Form not found"
 	// @Router /ws/forms/{id} [get]
 	app.Get("/ws/forms/:id", wsManager.HandleConnection)
 
+	// @Summary WebSocket analytics subscriptions
+	// @Description Upgrade to a WebSocket connection that is not bound to a form. Clients authenticate as on
+	// @Description /ws/forms/{id} and then send {"type":"subscribe","formIds":[...],"allForms":true,"lastEventId":"..."}
+	// @Description to follow several forms, or every personal form they own, over a single connection.
+	// @Tags WebSocket
+	// @Param token query string false "Access token"
+	// @Success 101 {string} string "WebSocket connection established"
+	// @Failure 401 {object} map[string]interface{} "Invalid or expired token"
+	// @Router /ws [get]
+	app.Get("/ws", wsManager.HandleConnection)
+
 	// @Summary WebSocket statistics
 	// @Description Get WebSocket connection statistics
 	// @Tags WebSocket
@@ -163,6 +293,8 @@ func setupRoutes(
 
 		stats := fiber.Map{
 			"totalConnections": wsManager.GetTotalConnections(),
+			"droppedMessages":  wsManager.GetDroppedMessages(),
+			"coalescedUpdates": wsManager.GetCoalescedUpdates(),
 		}
 
 		if formID != "" {
@@ -176,6 +308,15 @@ func setupRoutes(
 	})
 }
 
+// healthStatusCode maps a health report to its HTTP status. Degraded instances
+// still receive traffic, so only a down report is unavailable.
+func healthStatusCode(report *health.Report) int {
+	if report.Status == health.StatusDown {
+		return fiber.StatusServiceUnavailable
+	}
+	return fiber.StatusOK
+}
+
 // setupDevelopmentTools configures development-only tools
 func setupDevelopmentTools(app *fiber.App) {
 	// Swagger API documentation
@@ -3,10 +3,10 @@ package database
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -19,16 +19,35 @@ type Database struct {
 
 // Collections holds references to MongoDB collections
 type Collections struct {
-	Users     *mongo.Collection
-	Forms     *mongo.Collection
-	Responses *mongo.Collection
-	Analytics *mongo.Collection
+	Users                *mongo.Collection
+	Forms                *mongo.Collection
+	Responses            *mongo.Collection
+	Analytics            *mongo.Collection
+	IdempotencyKeys      *mongo.Collection
+	SubmissionChallenges *mongo.Collection
+	QuarantinedResponses *mongo.Collection
+	RealtimeEvents       *mongo.Collection
+	Presence             *mongo.Collection
+	SchemaMigrations     *mongo.Collection
+	MigrationLocks       *mongo.Collection
+	Sessions             *mongo.Collection
+	UsedAccountTokens    *mongo.Collection
+	LoginAttempts        *mongo.Collection
+	FailedLogins         *mongo.Collection
+	APIKeys              *mongo.Collection
+	SigningKeys          *mongo.Collection
+	Organizations        *mongo.Collection
+	Memberships          *mongo.Collection
+	Invitations          *mongo.Collection
 }
 
-// Connect establishes a connection to MongoDB
-func Connect(mongoURI string) (*Database, error) {
+// Connect establishes a connection to MongoDB. The monitors observe every command.
+func Connect(mongoURI string, monitors ...*event.CommandMonitor) (*Database, error) {
 	// Set client options
 	clientOptions := options.Client().ApplyURI(mongoURI)
+	if len(monitors) > 0 {
+		clientOptions.SetMonitor(combineMonitors(monitors))
+	}
 
 	// Set connection timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -45,7 +64,7 @@ func Connect(mongoURI string) (*Database, error) {
 		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
 	}
 
-	log.Println("INFO: MongoDB connection established")
+	slog.Info("MongoDB connection established")
 
 	// Get database instance
 	db := client.Database("dune_forms")
@@ -56,13 +75,56 @@ func Connect(mongoURI string) (*Database, error) {
 	}, nil
 }
 
+// combineMonitors returns a command monitor notifying each of the monitors in turn
+func combineMonitors(monitors []*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			for _, monitor := range monitors {
+				if monitor.Started != nil {
+					monitor.Started(ctx, evt)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			for _, monitor := range monitors {
+				if monitor.Succeeded != nil {
+					monitor.Succeeded(ctx, evt)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			for _, monitor := range monitors {
+				if monitor.Failed != nil {
+					monitor.Failed(ctx, evt)
+				}
+			}
+		},
+	}
+}
+
 // GetCollections returns references to all collections
 func (d *Database) GetCollections() *Collections {
 	return &Collections{
-		Users:     d.DB.Collection("users"),
-		Forms:     d.DB.Collection("forms"),
-		Responses: d.DB.Collection("responses"),
-		Analytics: d.DB.Collection("analytics"),
+		Users:                d.DB.Collection("users"),
+		Forms:                d.DB.Collection("forms"),
+		Responses:            d.DB.Collection("responses"),
+		Analytics:            d.DB.Collection("analytics"),
+		IdempotencyKeys:      d.DB.Collection("idempotency_keys"),
+		SubmissionChallenges: d.DB.Collection("submission_challenges"),
+		QuarantinedResponses: d.DB.Collection("quarantined_responses"),
+		RealtimeEvents:       d.DB.Collection("realtime_events"),
+		Presence:             d.DB.Collection("presence"),
+		SchemaMigrations:     d.DB.Collection("schema_migrations"),
+		MigrationLocks:       d.DB.Collection("migration_locks"),
+		Sessions:             d.DB.Collection("sessions"),
+		UsedAccountTokens:    d.DB.Collection("used_account_tokens"),
+		LoginAttempts:        d.DB.Collection("login_attempts"),
+		FailedLogins:         d.DB.Collection("failed_logins"),
+		APIKeys:              d.DB.Collection("api_keys"),
+		SigningKeys:          d.DB.Collection("signing_keys"),
+		Organizations:        d.DB.Collection("organizations"),
+		Memberships:          d.DB.Collection("memberships"),
+		Invitations:          d.DB.Collection("invitations"),
 	}
 }
 
@@ -71,10 +133,14 @@ func (d *Database) HealthCheck() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	return d.Ping(ctx)
+}
+
+// Ping checks that the server answers within the deadline of ctx
+func (d *Database) Ping(ctx context.Context) error {
 	if err := d.Client.Ping(ctx, nil); err != nil {
 		return fmt.Errorf("database health check failed: %w", err)
 	}
-
 	return nil
 }
 
@@ -87,76 +153,6 @@ func (d *Database) Close() error {
 		return fmt.Errorf("failed to disconnect from MongoDB: %w", err)
 	}
 
-	log.Println("INFO: Disconnected from MongoDB")
-	return nil
-}
-
-// EnsureIndexes creates necessary indexes for better performance
-func (d *Database) EnsureIndexes() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	collections := d.GetCollections()
-
-	// Users collection indexes
-	usersIndexes := []mongo.IndexModel{
-		{
-			Keys:    bson.D{bson.E{Key: "email", Value: 1}},
-			Options: options.Index().SetUnique(true),
-		},
-		{
-			Keys: bson.D{bson.E{Key: "createdAt", Value: 1}},
-		},
-	}
-
-	_, err := collections.Users.Indexes().CreateMany(ctx, usersIndexes)
-	if err != nil {
-		return fmt.Errorf("failed to create users indexes: %w", err)
-	}
-	log.Println("INFO: Users collection indexes created")
-
-	// Forms collection indexes
-	formsIndexes := []mongo.IndexModel{
-		{
-			Keys:    bson.D{bson.E{Key: "shareSlug", Value: 1}},
-			Options: options.Index().SetUnique(true),
-		},
-		{
-			Keys: bson.D{bson.E{Key: "ownerId", Value: 1}},
-		},
-		{
-			Keys: bson.D{bson.E{Key: "status", Value: 1}},
-		},
-		{
-			Keys: bson.D{bson.E{Key: "createdAt", Value: 1}},
-		},
-	}
-
-	_, err = collections.Forms.Indexes().CreateMany(ctx, formsIndexes)
-	if err != nil {
-		return fmt.Errorf("failed to create forms indexes: %w", err)
-	}
-
-	// Responses collection indexes
-	responsesIndexes := []mongo.IndexModel{
-		{
-			Keys: bson.D{bson.E{Key: "formId", Value: 1}},
-		},
-		{
-			Keys: bson.D{bson.E{Key: "submittedAt", Value: 1}},
-		},
-		{
-			Keys: bson.D{bson.E{Key: "formId", Value: 1}, bson.E{Key: "submittedAt", Value: -1}},
-		},
-	}
-
-	_, err = collections.Responses.Indexes().CreateMany(ctx, responsesIndexes)
-	if err != nil {
-		return fmt.Errorf("failed to create responses indexes: %w", err)
-	}
-
-	// Analytics collection - _id is already unique by default, no additional indexes needed
-
-	log.Println("INFO: Database indexes verified")
+	slog.Info("Disconnected from MongoDB")
 	return nil
 }
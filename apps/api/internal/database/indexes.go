@@ -0,0 +1,290 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collectionIndexes are the indexes a collection needs
+type collectionIndexes struct {
+	collection *mongo.Collection
+	models     []mongo.IndexModel
+}
+
+// requiredIndexes returns the indexes of every collection. Indexes use the
+// server's default names, which indexName reproduces.
+func requiredIndexes(collections *Collections) []collectionIndexes {
+	return []collectionIndexes{
+		{
+			collection: collections.Users,
+			models: []mongo.IndexModel{
+				{
+					Keys:    bson.D{bson.E{Key: "email", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys: bson.D{bson.E{Key: "createdAt", Value: 1}},
+				},
+				{
+					// One account per single sign-on identity
+					Keys: bson.D{bson.E{Key: "oidc_issuer", Value: 1}, bson.E{Key: "oidc_subject", Value: 1}},
+					Options: options.Index().
+						SetUnique(true).
+						SetPartialFilterExpression(bson.M{"oidc_subject": bson.M{"$exists": true}}),
+				},
+			},
+		},
+		{
+			collection: collections.Forms,
+			models: []mongo.IndexModel{
+				{
+					Keys:    bson.D{bson.E{Key: "shareSlug", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys: bson.D{bson.E{Key: "ownerId", Value: 1}},
+				},
+				{
+					Keys: bson.D{bson.E{Key: "organizationId", Value: 1}, bson.E{Key: "createdAt", Value: -1}},
+				},
+				{
+					Keys: bson.D{bson.E{Key: "status", Value: 1}},
+				},
+				{
+					Keys: bson.D{bson.E{Key: "createdAt", Value: 1}},
+				},
+			},
+		},
+		{
+			collection: collections.Responses,
+			models: []mongo.IndexModel{
+				{
+					Keys: bson.D{bson.E{Key: "formId", Value: 1}},
+				},
+				{
+					Keys: bson.D{bson.E{Key: "submittedAt", Value: 1}},
+				},
+				{
+					Keys: bson.D{bson.E{Key: "formId", Value: 1}, bson.E{Key: "submittedAt", Value: -1}},
+				},
+				{
+					// Enforces one response per respondent for forms that limit responses
+					Keys: bson.D{bson.E{Key: "formId", Value: 1}, bson.E{Key: "respondentKey", Value: 1}},
+					Options: options.Index().
+						SetUnique(true).
+						SetPartialFilterExpression(bson.M{"respondentKey": bson.M{"$exists": true}}),
+				},
+			},
+		},
+		{
+			// Sessions are removed once their refresh tokens can no longer be used
+			collection: collections.Sessions,
+			models: []mongo.IndexModel{
+				{
+					Keys: bson.D{bson.E{Key: "userId", Value: 1}},
+				},
+				{
+					Keys:    bson.D{bson.E{Key: "expiresAt", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				},
+			},
+		},
+		{
+			// Used password reset and verification tokens are kept until the token expires
+			collection: collections.UsedAccountTokens,
+			models: []mongo.IndexModel{
+				{
+					Keys:    bson.D{bson.E{Key: "expiresAt", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				},
+			},
+		},
+		{
+			// Failed login counters are forgotten once their window and any lockout have passed
+			collection: collections.LoginAttempts,
+			models: []mongo.IndexModel{
+				{
+					Keys:    bson.D{bson.E{Key: "expiresAt", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				},
+			},
+		},
+		{
+			// Failed login audit records are kept for the configured retention
+			collection: collections.FailedLogins,
+			models: []mongo.IndexModel{
+				{
+					Keys: bson.D{bson.E{Key: "email", Value: 1}, bson.E{Key: "createdAt", Value: -1}},
+				},
+				{
+					Keys: bson.D{bson.E{Key: "ip", Value: 1}, bson.E{Key: "createdAt", Value: -1}},
+				},
+				{
+					Keys:    bson.D{bson.E{Key: "expiresAt", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				},
+			},
+		},
+		{
+			// API keys are looked up by the digest of the presented key
+			collection: collections.APIKeys,
+			models: []mongo.IndexModel{
+				{
+					Keys:    bson.D{bson.E{Key: "keyHash", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys: bson.D{bson.E{Key: "userId", Value: 1}, bson.E{Key: "createdAt", Value: -1}},
+				},
+			},
+		},
+		{
+			// Instances rotating together compute the same activation time, so only one
+			// inserts the next key; keys are removed once their last token has expired
+			collection: collections.SigningKeys,
+			models: []mongo.IndexModel{
+				{
+					Keys:    bson.D{bson.E{Key: "activatesAt", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys:    bson.D{bson.E{Key: "expiresAt", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				},
+			},
+		},
+		{
+			// A user belongs to an organization at most once; the workspace switcher
+			// lists the organizations of a user
+			collection: collections.Memberships,
+			models: []mongo.IndexModel{
+				{
+					Keys:    bson.D{bson.E{Key: "organizationId", Value: 1}, bson.E{Key: "userId", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys: bson.D{bson.E{Key: "userId", Value: 1}},
+				},
+			},
+		},
+		{
+			// An address has at most one pending invitation per organization; invitations
+			// are accepted by the digest of their token and expire automatically
+			collection: collections.Invitations,
+			models: []mongo.IndexModel{
+				{
+					Keys:    bson.D{bson.E{Key: "organizationId", Value: 1}, bson.E{Key: "email", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys:    bson.D{bson.E{Key: "tokenHash", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys:    bson.D{bson.E{Key: "expiresAt", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				},
+			},
+		},
+		{
+			// Idempotency keys expire automatically once their replay window has passed
+			collection: collections.IdempotencyKeys,
+			models: []mongo.IndexModel{
+				{
+					Keys:    bson.D{bson.E{Key: "expiresAt", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				},
+			},
+		},
+		{
+			// Used submission challenges are kept until the challenge token expires
+			collection: collections.SubmissionChallenges,
+			models: []mongo.IndexModel{
+				{
+					Keys:    bson.D{bson.E{Key: "expiresAt", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				},
+			},
+		},
+		{
+			collection: collections.QuarantinedResponses,
+			models: []mongo.IndexModel{
+				{
+					Keys: bson.D{bson.E{Key: "formId", Value: 1}, bson.E{Key: "receivedAt", Value: -1}},
+				},
+				{
+					Keys: bson.D{bson.E{Key: "reason", Value: 1}},
+				},
+			},
+		},
+		{
+			// Presence sessions expire shortly after their last heartbeat
+			collection: collections.Presence,
+			models: []mongo.IndexModel{
+				{
+					Keys: bson.D{bson.E{Key: "formId", Value: 1}, bson.E{Key: "lastSeen", Value: -1}},
+				},
+				{
+					Keys:    bson.D{bson.E{Key: "expiresAt", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				},
+			},
+		},
+		// Analytics collection - _id is already unique by default, no additional indexes needed
+	}
+}
+
+// EnsureIndexes creates necessary indexes for better performance
+func (d *Database) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, required := range requiredIndexes(d.GetCollections()) {
+		if _, err := required.collection.Indexes().CreateMany(ctx, required.models); err != nil {
+			return fmt.Errorf("failed to create %s indexes: %w", required.collection.Name(), err)
+		}
+	}
+
+	slog.Info("Database indexes verified")
+	return nil
+}
+
+// MissingIndexes returns the required indexes the database does not have, as
+// "collection.index" names
+func (d *Database) MissingIndexes(ctx context.Context) ([]string, error) {
+	var missing []string
+	for _, required := range requiredIndexes(d.GetCollections()) {
+		specs, err := required.collection.Indexes().ListSpecifications(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s indexes: %w", required.collection.Name(), err)
+		}
+
+		existing := make(map[string]bool, len(specs))
+		for _, spec := range specs {
+			existing[spec.Name] = true
+		}
+		for _, model := range required.models {
+			name := indexName(model.Keys.(bson.D))
+			if !existing[name] {
+				missing = append(missing, required.collection.Name()+"."+name)
+			}
+		}
+	}
+	return missing, nil
+}
+
+// indexName returns the default name MongoDB gives an index on keys, e.g. "formId_1_submittedAt_-1"
+func indexName(keys bson.D) string {
+	parts := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		parts = append(parts, key.Key, fmt.Sprint(key.Value))
+	}
+	return strings.Join(parts, "_")
+}
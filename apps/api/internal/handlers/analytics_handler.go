@@ -48,15 +48,9 @@ func (h *AnalyticsHandler) GetAnalytics(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
-	analytics, err := h.analyticsService.GetAnalytics(c.Context(), formID, ownerID)
+	analytics, err := h.analyticsService.GetAnalytics(c.UserContext(), formID, actor)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "Analytics not found",
@@ -123,23 +117,22 @@ func (h *AnalyticsHandler) ComputeAnalytics(c *fiber.Ctx) error {
 		}
 	}
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
 	analytics, err := h.analyticsService.ComputeAnalytics(
-		c.Context(),
+		c.UserContext(),
 		formID,
 		req.StartDate,
 		req.EndDate,
 		req.Fields,
-		ownerID,
+		actor,
 	)
 	if err != nil {
+		if status, message := formAccessStatus(err); status != 0 {
+			return c.Status(status).JSON(fiber.Map{
+				"error": message,
+			})
+		}
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to compute analytics",
 		})
@@ -174,15 +167,9 @@ func (h *AnalyticsHandler) GetRealTimeMetrics(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
-	metrics, err := h.analyticsService.GetRealTimeMetrics(c.Context(), formID, ownerID)
+	metrics, err := h.analyticsService.GetRealTimeMetrics(c.UserContext(), formID, actor)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "Failed to get real-time metrics",
@@ -201,21 +188,16 @@ func (h *AnalyticsHandler) GetRealTimeMetrics(c *fiber.Ctx) error {
 // @Tags Analytics
 // @Accept json
 // @Produce json
+// @Param X-Workspace-ID header string false "Organization ID, or \"personal\" (default)"
 // @Success 200 {object} map[string]interface{} "Analytics summary retrieved successfully"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
 // @Router /analytics/summary [get]
 func (h *AnalyticsHandler) GetAnalyticsSummary(c *fiber.Ctx) error {
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
-	summaries, err := h.analyticsService.GetAnalyticsSummary(c.Context(), ownerID)
+	summaries, err := h.analyticsService.GetAnalyticsSummary(c.UserContext(), actor)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to get analytics summary",
@@ -269,22 +251,16 @@ func (h *AnalyticsHandler) GetTrendAnalytics(c *fiber.Ctx) error {
 	endDate := time.Now()
 	startDate := endDate.AddDate(0, 0, -days)
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
 	// Compute analytics for the specific field and date range
 	analytics, err := h.analyticsService.ComputeAnalytics(
-		c.Context(),
+		c.UserContext(),
 		formID,
 		&startDate,
 		&endDate,
 		[]string{fieldID},
-		ownerID,
+		actor,
 	)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
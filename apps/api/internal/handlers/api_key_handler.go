@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/interfaces"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+
+	validator "github.com/go-playground/validator/v10"
+	fiber "github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHandler handles the personal API keys of the authenticated user
+type APIKeyHandler struct {
+	apiKeyService interfaces.APIKeyServiceInterface
+	validator     *validator.Validate
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyService interfaces.APIKeyServiceInterface, validator *validator.Validate) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		validator:     validator,
+	}
+}
+
+// CreateAPIKey handles creating an API key
+// @Summary Create API key
+// @Description Create a personal API key for scripts and integrations. The key is only returned in this response;
+// @Description store it securely. Send it as "Authorization: Bearer dune_..." to endpoints that accept its scopes.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.CreateAPIKeyRequest true "Name, scopes and optional expiry"
+// @Success 201 {object} models.CreatedAPIKey "API key created"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 409 {object} map[string]interface{} "Too many API keys"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /auth/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	apiKey, err := h.apiKeyService.Create(c.UserContext(), userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAPIKeyExpiryInPast):
+			return c.Status(400).JSON(fiber.Map{
+
+				"error": err.Error(),
+			})
+		case errors.Is(err, services.ErrTooManyAPIKeys):
+			return c.Status(409).JSON(fiber.Map{
+
+				"error": err.Error(),
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to create API key",
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "API key created, copy it now as it will not be shown again",
+		"data":    apiKey,
+	})
+}
+
+// ListAPIKeys handles listing the user's API keys
+// @Summary List API keys
+// @Description List the API keys of the authenticated user that have not been revoked, newest first.
+// @Description The keys themselves are never returned, only their prefix.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.APIKey "API keys retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /auth/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	apiKeys, err := h.apiKeyService.List(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to list API keys",
+		})
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"data":    apiKeys,
+	})
+}
+
+// RevokeAPIKey handles revoking one of the user's API keys
+// @Summary Revoke API key
+// @Description Revoke an API key of the authenticated user so that it stops working immediately
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} map[string]interface{} "API key revoked"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "API key not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /auth/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	if err := h.apiKeyService.Revoke(c.UserContext(), userID, c.Params("id")); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+
+				"error": "API key not found",
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to revoke API key",
+		})
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "API key revoked",
+	})
+}
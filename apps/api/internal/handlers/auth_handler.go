@@ -1,6 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+
 	validator "github.com/go-playground/validator/v10"
 	fiber "github.com/gofiber/fiber/v2"
 
@@ -10,15 +16,24 @@ import (
 
 // AuthHandler handles authentication-related HTTP requests
 type AuthHandler struct {
-	authService *services.AuthService
-	validator   *validator.Validate
+	authService      *services.AuthService
+	accountService   *services.AccountService
+	twoFactorService *services.TwoFactorService
+	oidcService      *services.OIDCService // nil when single sign-on is not configured
+	validator        *validator.Validate
 }
 
+// oidcStateCookie holds the encrypted state of a single sign-on in progress
+const oidcStateCookie = "dune_oidc_state"
+
 // NewAuthHandler creates a new authentication handler
-func NewAuthHandler(authService *services.AuthService, validator *validator.Validate) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, accountService *services.AccountService, twoFactorService *services.TwoFactorService, oidcService *services.OIDCService, validator *validator.Validate) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		validator:   validator,
+		authService:      authService,
+		accountService:   accountService,
+		twoFactorService: twoFactorService,
+		oidcService:      oidcService,
+		validator:        validator,
 	}
 }
 
@@ -29,7 +44,7 @@ func NewAuthHandler(authService *services.AuthService, validator *validator.Vali
 // @Accept json
 // @Produce json
 // @Param user body models.CreateUserRequest true "User registration data"
-// @Success 201 {object} models.AuthResponse "User registered successfully"
+// @Success 201 {object} models.AuthResponse "User registered successfully; without tokens when verified email addresses are required"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 409 {object} map[string]interface{} "User already exists"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
@@ -53,7 +68,7 @@ func (h *AuthHandler) Signup(c *fiber.Ctx) error {
 	}
 
 	// Create user
-	user, err := h.authService.CreateUser(c.Context(), &req)
+	user, err := h.authService.CreateUser(c.UserContext(), &req)
 	if err != nil {
 		return c.Status(409).JSON(fiber.Map{
 
@@ -61,8 +76,22 @@ func (h *AuthHandler) Signup(c *fiber.Ctx) error {
 		})
 	}
 
-	// Generate tokens
-	accessToken, refreshToken, err := h.authService.GenerateTokens(user)
+	// Send the verification link; the account works without it unless verification is required
+	if err := h.accountService.SendVerificationEmail(c.UserContext(), user); err != nil {
+		slog.ErrorContext(c.UserContext(), "Failed to send verification email", "user_id", user.ID.Hex(), "error", err)
+	}
+	if h.authService.RequiresVerifiedEmail() {
+		return c.Status(201).JSON(fiber.Map{
+			"success": true,
+			"message": "Check your email to verify your address, then log in",
+			"data": fiber.Map{
+				"user": user.ToUserResponse(),
+			},
+		})
+	}
+
+	// Start a session
+	authResponse, err := h.authService.CreateSession(c.UserContext(), user, clientInfo(c))
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 
@@ -73,17 +102,13 @@ func (h *AuthHandler) Signup(c *fiber.Ctx) error {
 	// Return response
 	return c.Status(201).JSON(fiber.Map{
 		"success": true,
-		"data": models.AuthResponse{
-			User:         user.ToUserResponse(),
-			AccessToken:  accessToken,
-			RefreshToken: refreshToken,
-		},
+		"data":    authResponse,
 	})
 }
 
 // Login handles user authentication
 // @Summary User login
-// @Description Authenticate user with email and password
+// @Description Authenticate user with email and password. Users with two-factor authentication get a twoFactorToken instead of tokens, to be completed at /auth/2fa/verify.
 // @Tags Authentication
 // @Accept json
 // @Produce json
@@ -91,6 +116,8 @@ func (h *AuthHandler) Signup(c *fiber.Ctx) error {
 // @Success 200 {object} models.AuthResponse "Login successful"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 401 {object} map[string]interface{} "Invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Email address not verified"
+// @Failure 429 {object} map[string]interface{} "Too many failed login attempts, see Retry-After"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
@@ -112,12 +139,9 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	}
 
 	// Authenticate user
-	authResponse, err := h.authService.LoginUser(c.Context(), &req)
+	authResponse, err := h.authService.LoginUser(c.UserContext(), &req, clientInfo(c))
 	if err != nil {
-		return c.Status(401).JSON(fiber.Map{
-
-			"error": err.Error(),
-		})
+		return loginFailed(c, err)
 	}
 
 	// Return response
@@ -129,7 +153,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 
 // RefreshToken handles token refresh
 // @Summary Refresh JWT token
-// @Description Refresh access token using refresh token
+// @Description Exchange a refresh token for new tokens. Refresh tokens are single use; reusing one revokes its session.
 // @Tags Authentication
 // @Accept json
 // @Produce json
@@ -158,7 +182,7 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 	}
 
 	// Refresh tokens
-	authResponse, err := h.authService.RefreshTokens(c.Context(), req.RefreshToken)
+	authResponse, err := h.authService.RefreshTokens(c.UserContext(), req.RefreshToken, clientInfo(c))
 	if err != nil {
 		return c.Status(401).JSON(fiber.Map{
 
@@ -196,7 +220,7 @@ func (h *AuthHandler) GetMe(c *fiber.Ctx) error {
 	}
 
 	// Get user from database
-	user, err := h.authService.GetUserByID(c.Context(), userID.(string))
+	user, err := h.authService.GetUserByID(c.UserContext(), userID.(string))
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{
 
@@ -211,21 +235,767 @@ func (h *AuthHandler) GetMe(c *fiber.Ctx) error {
 	})
 }
 
-// Logout handles user logout (for future token blacklisting)
+// Logout handles user logout
 // @Summary User logout
-// @Description Log out the authenticated user
+// @Description Revoke the session of a refresh token so that it can no longer be refreshed
 // @Tags Authentication
 // @Accept json
 // @Produce json
+// @Param token body models.RefreshTokenRequest true "Refresh token of the session"
 // @Success 200 {object} map[string]interface{} "Logged out successfully"
-// @Failure 401 {object} map[string]interface{} "Unauthorized"
-// @Security BearerAuth
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Invalid token"
 // @Router /auth/logout [post]
 func (h *AuthHandler) Logout(c *fiber.Ctx) error {
-	// For now, just return success
-	// In the future, we could implement token blacklisting here
+	var req models.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	// Revoke the session
+	if err := h.authService.Logout(c.UserContext(), req.RefreshToken); err != nil {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": err.Error(),
+		})
+	}
+
 	return c.Status(200).JSON(fiber.Map{
 		"success": true,
 		"message": "Logged out successfully",
 	})
 }
+
+// LogoutAll handles logging out of every device
+// @Summary Log out all devices
+// @Description Revoke every session of the authenticated user. Access tokens of the sessions are rejected too, by
+// @Description other API instances within 30 seconds.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Logged out of all devices"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	revoked, err := h.authService.LogoutAll(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to log out",
+		})
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Logged out of all devices",
+		"data": fiber.Map{
+			"revokedSessions": revoked,
+		},
+	})
+}
+
+// ListSessions handles listing the devices the user is signed in on
+// @Summary List sessions
+// @Description List the active sessions of the authenticated user, most recently used first
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.SessionResponse "Sessions retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /auth/sessions [get]
+func (h *AuthHandler) ListSessions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+	currentID, _ := c.Locals("sessionID").(string)
+
+	sessions, err := h.authService.ListSessions(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to list sessions",
+		})
+	}
+
+	responses := make([]*models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, session.ToSessionResponse(currentID))
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"data":    responses,
+	})
+}
+
+// RevokeSession handles signing out one of the user's sessions
+// @Summary Revoke session
+// @Description Revoke a session of the authenticated user so that its refresh token stops working
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]interface{} "Session revoked"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Session not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	if err := h.authService.RevokeSession(c.UserContext(), userID, c.Params("id")); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+
+				"error": "Session not found",
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to revoke session",
+		})
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Session revoked",
+	})
+}
+
+// ForgotPassword handles password reset requests
+// @Summary Request password reset
+// @Description Email a password reset link to the account with this address. The response is the same whether or not the account exists.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.ForgotPasswordRequest true "Email address of the account"
+// @Success 202 {object} map[string]interface{} "Reset link sent if the account exists"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req models.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	if err := h.accountService.RequestPasswordReset(c.UserContext(), req.Email); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to request password reset",
+		})
+	}
+
+	return c.Status(202).JSON(fiber.Map{
+		"success": true,
+		"message": "If an account exists for this email address, a reset link has been sent",
+	})
+}
+
+// ResetPassword handles setting a new password with a reset token
+// @Summary Reset password
+// @Description Set a new password with the token of a password reset link. All sessions of the user are revoked.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} map[string]interface{} "Password reset"
+// @Failure 400 {object} map[string]interface{} "Invalid request or expired token"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var req models.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	if err := h.accountService.ResetPassword(c.UserContext(), req.Token, req.Password); err != nil {
+		if errors.Is(err, services.ErrInvalidAccountToken) {
+			return c.Status(400).JSON(fiber.Map{
+
+				"error": "The reset link is invalid or has expired",
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to reset password",
+		})
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Password reset, log in with your new password",
+	})
+}
+
+// ResendVerification handles requests for a new verification email
+// @Summary Resend verification email
+// @Description Email a new verification link to the account with this address if it is not verified yet. The response is the same whether or not the account exists.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.ResendVerificationRequest true "Email address of the account"
+// @Success 202 {object} map[string]interface{} "Verification link sent if the account exists"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/email/resend [post]
+func (h *AuthHandler) ResendVerification(c *fiber.Ctx) error {
+	var req models.ResendVerificationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	if err := h.accountService.RequestEmailVerification(c.UserContext(), req.Email); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to send verification email",
+		})
+	}
+
+	return c.Status(202).JSON(fiber.Map{
+		"success": true,
+		"message": "If an unverified account exists for this email address, a verification link has been sent",
+	})
+}
+
+// VerifyEmail handles email address confirmation
+// @Summary Verify email address
+// @Description Confirm an email address with the token of a verification link
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} models.UserResponse "Email address verified"
+// @Failure 400 {object} map[string]interface{} "Invalid request or expired token"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/email/verify [post]
+func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req models.VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	user, err := h.accountService.VerifyEmail(c.UserContext(), req.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAccountToken) {
+			return c.Status(400).JSON(fiber.Map{
+
+				"error": "The verification link is invalid or has expired",
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to verify email address",
+		})
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"data":    user.ToUserResponse(),
+	})
+}
+
+// VerifyTwoFactor handles the second step of a login with two-factor authentication
+// @Summary Complete two-factor login
+// @Description Exchange the twoFactorToken returned by login and a TOTP or recovery code for tokens
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorLoginRequest true "Pending login and code"
+// @Success 200 {object} models.AuthResponse "Login successful"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Invalid code or expired login"
+// @Failure 429 {object} map[string]interface{} "Too many failed login attempts, see Retry-After"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/2fa/verify [post]
+func (h *AuthHandler) VerifyTwoFactor(c *fiber.Ctx) error {
+	var req models.TwoFactorLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	authResponse, err := h.authService.CompleteTwoFactorLogin(c.UserContext(), &req, clientInfo(c))
+	if err != nil {
+		return loginFailed(c, err)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"data":    authResponse,
+	})
+}
+
+// SetupTwoFactor handles starting two-factor enrollment
+// @Summary Start two-factor setup
+// @Description Generate a TOTP secret and its otpauth:// provisioning URI. Two-factor authentication is enabled once /auth/2fa/enable receives a code.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.TwoFactorSetup "Secret and provisioning URI"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 409 {object} map[string]interface{} "Two-factor authentication already enabled"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /auth/2fa/setup [post]
+func (h *AuthHandler) SetupTwoFactor(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	setup, err := h.twoFactorService.Setup(c.UserContext(), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+			return c.Status(409).JSON(fiber.Map{
+
+				"error": err.Error(),
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to start two-factor setup",
+		})
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"data":    setup,
+	})
+}
+
+// EnableTwoFactor handles confirming two-factor enrollment
+// @Summary Enable two-factor authentication
+// @Description Confirm the secret from /auth/2fa/setup with a code from the authenticator app. The recovery codes are only returned this once.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} models.TwoFactorRecoveryCodes "Recovery codes"
+// @Failure 400 {object} map[string]interface{} "Invalid code or setup not started"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 409 {object} map[string]interface{} "Two-factor authentication already enabled"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /auth/2fa/enable [post]
+func (h *AuthHandler) EnableTwoFactor(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	codes, err := h.twoFactorService.Enable(c.UserContext(), userID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTwoFactorCode), errors.Is(err, services.ErrTwoFactorSetupRequired):
+			return c.Status(400).JSON(fiber.Map{
+
+				"error": err.Error(),
+			})
+		case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+			return c.Status(409).JSON(fiber.Map{
+
+				"error": err.Error(),
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to enable two-factor authentication",
+		})
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Two-factor authentication enabled, store the recovery codes somewhere safe",
+		"data":    codes,
+	})
+}
+
+// DisableTwoFactor handles turning two-factor authentication off
+// @Summary Disable two-factor authentication
+// @Description Turn two-factor authentication off after confirming the password and a TOTP or recovery code
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.DisableTwoFactorRequest true "Password and code"
+// @Success 200 {object} map[string]interface{} "Two-factor authentication disabled"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Wrong password or code"
+// @Failure 409 {object} map[string]interface{} "Two-factor authentication not enabled"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /auth/2fa/disable [post]
+func (h *AuthHandler) DisableTwoFactor(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	var req models.DisableTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	err := h.twoFactorService.Disable(c.UserContext(), userID, req.Password, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials), errors.Is(err, services.ErrInvalidTwoFactorCode):
+			// Not 401, which clients treat as an expired access token
+			return c.Status(403).JSON(fiber.Map{
+
+				"error": "Wrong password or two-factor code",
+			})
+		case errors.Is(err, services.ErrTwoFactorNotEnabled):
+			return c.Status(409).JSON(fiber.Map{
+
+				"error": err.Error(),
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to disable two-factor authentication",
+		})
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// OIDCProvider handles describing the single sign-on provider
+// @Summary Single sign-on provider
+// @Description Report whether single sign-on is configured and the provider name to show on the sign-in button
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Single sign-on settings"
+// @Router /auth/oidc [get]
+func (h *AuthHandler) OIDCProvider(c *fiber.Ctx) error {
+	data := fiber.Map{"enabled": h.oidcService != nil}
+	if h.oidcService != nil {
+		data["name"] = h.oidcService.ProviderName()
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// OIDCLogin handles starting a single sign-on
+// @Summary Start single sign-on
+// @Description Redirect the browser to the identity provider (authorization code flow with PKCE). The state of
+// @Description the login is kept in an encrypted, HTTP-only cookie until the provider redirects back.
+// @Tags Authentication
+// @Success 302 {string} string "Redirect to the identity provider"
+// @Failure 404 {object} map[string]interface{} "Single sign-on is not configured"
+// @Failure 502 {object} map[string]interface{} "Identity provider unavailable"
+// @Router /auth/oidc/login [get]
+func (h *AuthHandler) OIDCLogin(c *fiber.Ctx) error {
+	if h.oidcService == nil {
+		return c.Status(404).JSON(fiber.Map{
+
+			"error": "Single sign-on is not configured",
+		})
+	}
+
+	authURL, state, err := h.oidcService.Begin(c.UserContext())
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Failed to start single sign-on", "error", err)
+		return c.Status(502).JSON(fiber.Map{
+
+			"error": "Identity provider unavailable",
+		})
+	}
+
+	h.setOIDCStateCookie(c, state, int(h.oidcService.StateTTL().Seconds()))
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// OIDCCallback handles the identity provider redirecting back
+// @Summary Single sign-on callback
+// @Description Redirect target registered with the identity provider. Verifies the state and ID token, links or
+// @Description creates the user by verified email address and redirects to the web app's /sso/callback page with a
+// @Description one-time ticket, or to /login?error=... when single sign-on failed.
+// @Tags Authentication
+// @Param code query string false "Authorization code"
+// @Param state query string false "State of the login"
+// @Param error query string false "Error reported by the provider"
+// @Success 302 {string} string "Redirect to the web app"
+// @Failure 404 {object} map[string]interface{} "Single sign-on is not configured"
+// @Router /auth/oidc/callback [get]
+func (h *AuthHandler) OIDCCallback(c *fiber.Ctx) error {
+	if h.oidcService == nil {
+		return c.Status(404).JSON(fiber.Map{
+
+			"error": "Single sign-on is not configured",
+		})
+	}
+
+	cookie := c.Cookies(oidcStateCookie)
+	h.setOIDCStateCookie(c, "", -1)
+
+	if providerError := c.Query("error"); providerError != "" {
+		slog.WarnContext(c.UserContext(), "Identity provider refused single sign-on", "error", providerError, "description", c.Query("error_description"))
+		return c.Redirect(h.oidcService.ErrorURL("sso_cancelled"), fiber.StatusFound)
+	}
+
+	ticket, err := h.oidcService.Callback(c.UserContext(), cookie, c.Query("state"), c.Query("code"))
+	if err != nil {
+		reason := "sso_failed"
+		switch {
+		case errors.Is(err, services.ErrOIDCEmailNotVerified):
+			reason = "sso_email_not_verified"
+		case errors.Is(err, services.ErrOIDCAccountConflict):
+			reason = "sso_account_conflict"
+		}
+		slog.WarnContext(c.UserContext(), "Single sign-on failed", "reason", reason, "error", err)
+		return c.Redirect(h.oidcService.ErrorURL(reason), fiber.StatusFound)
+	}
+
+	return c.Redirect(h.oidcService.CompleteURL(ticket), fiber.StatusFound)
+}
+
+// OIDCExchange handles redeeming the ticket of a single sign-on
+// @Summary Complete single sign-on
+// @Description Exchange the one-time ticket the callback passed to the web app for access and refresh tokens.
+// @Description Tickets expire after one minute. Users with two-factor authentication get twoFactorRequired and a
+// @Description twoFactorToken instead, to be completed with POST /auth/2fa/verify.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.OIDCExchangeRequest true "Login ticket"
+// @Success 200 {object} models.AuthResponse "Login successful"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Invalid, expired or used ticket"
+// @Failure 404 {object} map[string]interface{} "Single sign-on is not configured"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/oidc/exchange [post]
+func (h *AuthHandler) OIDCExchange(c *fiber.Ctx) error {
+	if h.oidcService == nil {
+		return c.Status(404).JSON(fiber.Map{
+
+			"error": "Single sign-on is not configured",
+		})
+	}
+
+	var req models.OIDCExchangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	authResponse, err := h.oidcService.Exchange(c.UserContext(), req.Ticket, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidOIDCTicket) {
+			return c.Status(401).JSON(fiber.Map{
+
+				"error": err.Error(),
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to complete single sign-on",
+		})
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"data":    authResponse,
+	})
+}
+
+// setOIDCStateCookie sets or, with a negative maxAge, clears the state cookie. Lax
+// lets the cookie come back with the provider's top-level redirect.
+func (h *AuthHandler) setOIDCStateCookie(c *fiber.Ctx, value string, maxAge int) {
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/api/auth/oidc",
+		MaxAge:   maxAge,
+		Secure:   h.oidcService.SecureCookie(),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// loginFailed writes the response of a failed login or two-factor verification
+func loginFailed(c *fiber.Ctx, err error) error {
+	var throttled *services.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		return c.Status(429).JSON(fiber.Map{
+
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidCredentials),
+		errors.Is(err, services.ErrInvalidTwoFactorCode),
+		errors.Is(err, services.ErrInvalidTwoFactorToken):
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrEmailNotVerified):
+		return c.Status(403).JSON(fiber.Map{
+
+			"error": err.Error(),
+		})
+	}
+
+	slog.ErrorContext(c.UserContext(), "Failed to log in", "error", err)
+	return c.Status(500).JSON(fiber.Map{
+
+		"error": "Failed to log in",
+	})
+}
+
+// clientInfo returns the device making the request. The values are copied
+// because Fiber reuses the request buffers.
+func clientInfo(c *fiber.Ctx) *models.ClientInfo {
+	return &models.ClientInfo{
+		IP:        strings.Clone(c.IP()),
+		UserAgent: strings.Clone(c.Get("User-Agent")),
+	}
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"log/slog"
 	"strconv"
 
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/interfaces"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
 	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
 
 	validator "github.com/go-playground/validator/v10"
@@ -13,28 +16,56 @@ import (
 
 // FormHandler handles form-related HTTP requests
 type FormHandler struct {
-	formService interfaces.FormServiceInterface
-	validator   *validator.Validate
+	formService  interfaces.FormServiceInterface
+	abuseService interfaces.AbuseServiceInterface
+	validator    *validator.Validate
 }
 
 // NewFormHandler creates a new form handler
-func NewFormHandler(formService interfaces.FormServiceInterface, validator *validator.Validate) *FormHandler {
+func NewFormHandler(formService interfaces.FormServiceInterface, abuseService interfaces.AbuseServiceInterface, validator *validator.Validate) *FormHandler {
 	return &FormHandler{
-		formService: formService,
-		validator:   validator,
+		formService:  formService,
+		abuseService: abuseService,
+		validator:    validator,
 	}
 }
 
+// workspaceActor returns the actor WorkspaceMiddleware resolved for the request.
+// Without one, the request acts in the authenticated user's personal workspace.
+func workspaceActor(c *fiber.Ctx) *services.Actor {
+	if actor, ok := c.Locals("actor").(*services.Actor); ok {
+		return actor
+	}
+	userID, _ := c.Locals("userID").(string)
+	return &services.Actor{UserID: userID}
+}
+
+// formAccessStatus maps the authorizer's errors to a status and message. It
+// returns 0 for other errors.
+func formAccessStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		return 403, "Your role in this workspace does not allow this action"
+	case errors.Is(err, services.ErrFormNotFound):
+		return 404, "Form not found"
+	case errors.Is(err, services.ErrWorkspaceNotFound):
+		return 404, "Workspace not found"
+	}
+	return 0, ""
+}
+
 // CreateForm creates a new form
 // @Summary Create a new form
 // @Description Create a new form with the provided form data
 // @Tags Forms
 // @Accept json
 // @Produce json
+// @Param X-Workspace-ID header string false "Organization ID, or \"personal\" (default)"
 // @Param form body models.CreateFormRequest true "Form creation data"
 // @Success 201 {object} models.FormResponse "Form created successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Role does not allow changing forms"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
 // @Router /forms [post]
@@ -54,17 +85,16 @@ func (h *FormHandler) CreateForm(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
 	// Create form
-	form, err := h.formService.CreateForm(c.Context(), &req, ownerID)
+	form, err := h.formService.CreateForm(c.UserContext(), &req, actor)
 	if err != nil {
+		if status, message := formAccessStatus(err); status != 0 {
+			return c.Status(status).JSON(fiber.Map{
+				"error": message,
+			})
+		}
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to create form",
 		})
@@ -98,15 +128,9 @@ func (h *FormHandler) GetForm(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
-	form, err := h.formService.GetFormByID(c.Context(), formID, ownerID)
+	form, err := h.formService.GetFormByID(c.UserContext(), formID, actor)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "Form not found",
@@ -121,7 +145,8 @@ func (h *FormHandler) GetForm(c *fiber.Ctx) error {
 
 // GetPublicForm retrieves a public form by slug
 // @Summary Get public form by slug
-// @Description Retrieve a published form using its slug (public access)
+// @Description Retrieve a published form using its slug (public access). Forms with anti-abuse
+// @Description protection include a signed submission challenge that must be echoed back on submit.
 // @Tags Forms
 // @Accept json
 // @Produce json
@@ -139,13 +164,26 @@ func (h *FormHandler) GetPublicForm(c *fiber.Ctx) error {
 		})
 	}
 
-	form, err := h.formService.GetFormBySlug(c.Context(), slug)
+	form, err := h.formService.GetFormBySlug(c.UserContext(), slug)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "Form not found or not published",
 		})
 	}
 
+	// Issue a fresh submission challenge for forms with anti-abuse protection
+	challenge, err := h.abuseService.IssueChallenge(form.ID, form.Settings)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Failed to issue submission challenge", "form_id", form.ID, "error", err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load form",
+		})
+	}
+	if challenge != nil {
+		form.Challenge = challenge
+		c.Set(fiber.HeaderCacheControl, "no-store")
+	}
+
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data":    form,
@@ -163,6 +201,7 @@ func (h *FormHandler) GetPublicForm(c *fiber.Ctx) error {
 // @Success 200 {object} models.Form "Form updated successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Role does not allow changing forms"
 // @Failure 404 {object} map[string]interface{} "Form not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
@@ -190,16 +229,15 @@ func (h *FormHandler) UpdateForm(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
-	form, err := h.formService.UpdateForm(c.Context(), formID, &req, ownerID)
+	form, err := h.formService.UpdateForm(c.UserContext(), formID, &req, actor)
 	if err != nil {
+		if status, message := formAccessStatus(err); status != 0 {
+			return c.Status(status).JSON(fiber.Map{
+				"error": message,
+			})
+		}
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to update form",
 		})
@@ -221,6 +259,7 @@ func (h *FormHandler) UpdateForm(c *fiber.Ctx) error {
 // @Success 200 {object} map[string]interface{} "Form deleted successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Role does not allow changing forms"
 // @Failure 404 {object} map[string]interface{} "Form not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
@@ -233,16 +272,15 @@ func (h *FormHandler) DeleteForm(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
-	err := h.formService.DeleteForm(c.Context(), formID, ownerID)
+	err := h.formService.DeleteForm(c.UserContext(), formID, actor)
 	if err != nil {
+		if status, message := formAccessStatus(err); status != 0 {
+			return c.Status(status).JSON(fiber.Map{
+				"error": message,
+			})
+		}
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to delete form",
 		})
@@ -260,6 +298,7 @@ func (h *FormHandler) DeleteForm(c *fiber.Ctx) error {
 // @Tags Forms
 // @Accept json
 // @Produce json
+// @Param X-Workspace-ID header string false "Organization ID, or \"personal\" (default)"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
 // @Success 200 {object} map[string]interface{} "Forms retrieved successfully"
@@ -281,15 +320,9 @@ func (h *FormHandler) ListForms(c *fiber.Ctx) error {
 		limit = 10
 	}
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
-	forms, total, err := h.formService.ListForms(c.Context(), ownerID, page, limit)
+	forms, total, err := h.formService.ListForms(c.UserContext(), actor, page, limit)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to list forms",
@@ -325,6 +358,7 @@ func (h *FormHandler) ListForms(c *fiber.Ctx) error {
 // @Success 200 {object} models.Form "Form published successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Role does not allow changing forms"
 // @Failure 404 {object} map[string]interface{} "Form not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
@@ -337,16 +371,15 @@ func (h *FormHandler) PublishForm(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
-	form, err := h.formService.PublishForm(c.Context(), formID, ownerID)
+	form, err := h.formService.PublishForm(c.UserContext(), formID, actor)
 	if err != nil {
+		if status, message := formAccessStatus(err); status != 0 {
+			return c.Status(status).JSON(fiber.Map{
+				"error": message,
+			})
+		}
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to publish form",
 		})
@@ -369,6 +402,7 @@ func (h *FormHandler) PublishForm(c *fiber.Ctx) error {
 // @Success 200 {object} models.Form "Form unpublished successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Role does not allow changing forms"
 // @Failure 404 {object} map[string]interface{} "Form not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
@@ -381,16 +415,15 @@ func (h *FormHandler) UnpublishForm(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
-	form, err := h.formService.UnpublishForm(c.Context(), formID, ownerID)
+	form, err := h.formService.UnpublishForm(c.UserContext(), formID, actor)
 	if err != nil {
+		if status, message := formAccessStatus(err); status != 0 {
+			return c.Status(status).JSON(fiber.Map{
+				"error": message,
+			})
+		}
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to unpublish form",
 		})
@@ -402,3 +435,69 @@ func (h *FormHandler) UnpublishForm(c *fiber.Ctx) error {
 		"message": "Form unpublished successfully",
 	})
 }
+
+// TransferForm moves a personal form into an organization
+// @Summary Transfer form to an organization
+// @Description Move one of your personal forms into an organization where your role allows creating forms.
+// @Description The form, its responses and analytics are then shared with the organization's members. You
+// @Description remain its creator (ownerId). Forms cannot be moved out of an organization.
+// @Tags Forms
+// @Accept json
+// @Produce json
+// @Param id path string true "Form ID"
+// @Param request body models.TransferFormRequest true "Target organization"
+// @Success 200 {object} models.FormResponse "Form transferred successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Role does not allow creating forms in the organization"
+// @Failure 404 {object} map[string]interface{} "Form or organization not found"
+// @Failure 409 {object} map[string]interface{} "Form already belongs to an organization"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /forms/{id}/transfer [post]
+func (h *FormHandler) TransferForm(c *fiber.Ctx) error {
+	formID := c.Params("id")
+	if formID == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Form ID is required",
+		})
+	}
+
+	var req models.TransferFormRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": utils.FormatValidationErrors(err),
+		})
+	}
+
+	form, err := h.formService.TransferForm(c.UserContext(), formID, req.OrganizationID, workspaceActor(c))
+	if err != nil {
+		if errors.Is(err, services.ErrFormInOrganization) {
+			return c.Status(409).JSON(fiber.Map{
+				"error": "Form already belongs to an organization",
+			})
+		}
+		if status, message := formAccessStatus(err); status != 0 {
+			return c.Status(status).JSON(fiber.Map{
+				"error": message,
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to transfer form",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    form,
+		"message": "Form transferred successfully",
+	})
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+
+	fiber "github.com/gofiber/fiber/v2"
+)
+
+// JWKSHandler publishes the public keys that verify the API's tokens
+type JWKSHandler struct {
+	signingKeys *services.SigningKeyService // nil while tokens are signed with HS256
+}
+
+// NewJWKSHandler creates a new JWKS handler
+func NewJWKSHandler(signingKeys *services.SigningKeyService) *JWKSHandler {
+	return &JWKSHandler{
+		signingKeys: signingKeys,
+	}
+}
+
+// JWKS handles publishing the signing keys
+// @Summary JSON Web Key Set
+// @Description Public keys that verify access tokens signed with RS256 or EdDSA, selected by the "kid" token header.
+// @Description Lists the current key, the next key ahead of its activation and previous keys until their tokens have
+// @Description expired. Access tokens have the "typ" header "at+jwt" and the issuer "dune-form-analytics". Empty while
+// @Description tokens are signed with HS256. Served at the root, not under /api.
+// @Tags System
+// @Produce json
+// @Success 200 {object} models.JSONWebKeySet "Public keys"
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) JWKS(c *fiber.Ctx) error {
+	set := &models.JSONWebKeySet{Keys: []models.JSONWebKey{}}
+	if h.signingKeys != nil {
+		set = h.signingKeys.JWKS()
+	}
+
+	// Keys are published well ahead of use, so verifiers may cache them briefly
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(set)
+}
@@ -0,0 +1,578 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/interfaces"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+
+	validator "github.com/go-playground/validator/v10"
+	fiber "github.com/gofiber/fiber/v2"
+)
+
+// OrganizationHandler handles the organizations of the authenticated user and their members
+type OrganizationHandler struct {
+	organizationService interfaces.OrganizationServiceInterface
+	validator           *validator.Validate
+}
+
+// NewOrganizationHandler creates a new organization handler
+func NewOrganizationHandler(organizationService interfaces.OrganizationServiceInterface, validator *validator.Validate) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+		validator:           validator,
+	}
+}
+
+// CreateOrganization handles creating an organization
+// @Summary Create organization
+// @Description Create an organization whose forms are shared by its members. You become its owner.
+// @Description Select it with the X-Workspace-ID header to create and list its forms.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param request body models.CreateOrganizationRequest true "Organization name"
+// @Success 201 {object} models.OrganizationResponse "Organization created"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /organizations [post]
+func (h *OrganizationHandler) CreateOrganization(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	var req models.CreateOrganizationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	organization, err := h.organizationService.Create(c.UserContext(), userID, &req)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to create organization",
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    organization,
+	})
+}
+
+// ListOrganizations handles listing the user's organizations
+// @Summary List organizations
+// @Description List the organizations the authenticated user is a member of, with their role, by name.
+// @Description Together with the personal workspace these are the workspaces the user can switch between.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.OrganizationResponse "Organizations retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /organizations [get]
+func (h *OrganizationHandler) ListOrganizations(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	organizations, err := h.organizationService.List(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to list organizations",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    organizations,
+	})
+}
+
+// GetOrganization handles reading one of the user's organizations
+// @Summary Get organization
+// @Description Get an organization the authenticated user is a member of, with their role
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {object} models.OrganizationResponse "Organization retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Organization not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{id} [get]
+func (h *OrganizationHandler) GetOrganization(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	organization, err := h.organizationService.Get(c.UserContext(), userID, c.Params("id"))
+	if err != nil {
+		return organizationError(c, err, "Failed to get organization")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    organization,
+	})
+}
+
+// UpdateOrganization handles renaming an organization
+// @Summary Rename organization
+// @Description Rename an organization. Only owners may rename it.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param request body models.UpdateOrganizationRequest true "New name"
+// @Success 200 {object} models.OrganizationResponse "Organization renamed"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Organization not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{id} [patch]
+func (h *OrganizationHandler) UpdateOrganization(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	var req models.UpdateOrganizationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	organization, err := h.organizationService.Rename(c.UserContext(), userID, c.Params("id"), &req)
+	if err != nil {
+		return organizationError(c, err, "Failed to rename organization")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    organization,
+	})
+}
+
+// DeleteOrganization handles deleting an organization
+// @Summary Delete organization
+// @Description Delete an organization and its memberships. Only owners may delete it, and only once it owns no forms.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {object} map[string]interface{} "Organization deleted"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Organization not found"
+// @Failure 409 {object} map[string]interface{} "Organization still owns forms"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{id} [delete]
+func (h *OrganizationHandler) DeleteOrganization(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	if err := h.organizationService.Delete(c.UserContext(), userID, c.Params("id")); err != nil {
+		return organizationError(c, err, "Failed to delete organization")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Organization deleted",
+	})
+}
+
+// ListMembers handles listing the members of an organization
+// @Summary List organization members
+// @Description List the members of an organization the authenticated user is a member of, by email
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {array} models.MemberResponse "Members retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Organization not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{id}/members [get]
+func (h *OrganizationHandler) ListMembers(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	members, err := h.organizationService.Members(c.UserContext(), userID, c.Params("id"))
+	if err != nil {
+		return organizationError(c, err, "Failed to list members")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    members,
+	})
+}
+
+// InviteMember handles inviting someone to an organization
+// @Summary Invite organization member
+// @Description Email an invitation to join an organization. Owners and admins may invite, with a role no higher
+// @Description than their own. The response does not tell whether the address belongs to an account; the
+// @Description invitation only grants membership once the owner of the address accepts it. Inviting an address
+// @Description again replaces its pending invitation.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param request body models.InviteMemberRequest true "Email to invite and the offered role"
+// @Success 202 {object} map[string]interface{} "Invitation sent"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Organization not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{id}/invitations [post]
+func (h *OrganizationHandler) InviteMember(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	var req models.InviteMemberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	if err := h.organizationService.Invite(c.UserContext(), userID, c.Params("id"), &req); err != nil {
+		return organizationError(c, err, "Failed to send invitation")
+	}
+
+	return c.Status(202).JSON(fiber.Map{
+		"success": true,
+		"message": "Invitation sent",
+	})
+}
+
+// ListInvitations handles listing the pending invitations of an organization
+// @Summary List organization invitations
+// @Description List the pending invitations of an organization, newest first. Owners and admins may list them.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {array} models.Invitation "Invitations retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Organization not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{id}/invitations [get]
+func (h *OrganizationHandler) ListInvitations(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	invitations, err := h.organizationService.Invitations(c.UserContext(), userID, c.Params("id"))
+	if err != nil {
+		return organizationError(c, err, "Failed to list invitations")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    invitations,
+	})
+}
+
+// RevokeInvitation handles withdrawing a pending invitation
+// @Summary Revoke organization invitation
+// @Description Withdraw a pending invitation so its link no longer works. Owners and admins may revoke invitations.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param invitationId path string true "Invitation ID"
+// @Success 200 {object} map[string]interface{} "Invitation revoked"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Organization or invitation not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{id}/invitations/{invitationId} [delete]
+func (h *OrganizationHandler) RevokeInvitation(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	if err := h.organizationService.RevokeInvitation(c.UserContext(), userID, c.Params("id"), c.Params("invitationId")); err != nil {
+		return organizationError(c, err, "Failed to revoke invitation")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Invitation revoked",
+	})
+}
+
+// AcceptInvitation handles joining an organization through an invitation
+// @Summary Accept organization invitation
+// @Description Join an organization with the token from an invitation email. The invitation must have been sent
+// @Description to the email address of the authenticated user.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param request body models.AcceptInvitationRequest true "Invitation token"
+// @Success 200 {object} models.OrganizationResponse "Invitation accepted"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Invitation was sent to another email address"
+// @Failure 404 {object} map[string]interface{} "Invitation not found or expired"
+// @Failure 409 {object} map[string]interface{} "User is already a member"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /invitations/accept [post]
+func (h *OrganizationHandler) AcceptInvitation(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	var req models.AcceptInvitationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	organization, err := h.organizationService.AcceptInvitation(c.UserContext(), userID, req.Token)
+	if err != nil {
+		return organizationError(c, err, "Failed to accept invitation")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    organization,
+	})
+}
+
+// UpdateMember handles changing the role of a member
+// @Summary Change member role
+// @Description Change the role of a member. Owners and admins may change the roles of members up to their own role.
+// @Description The last owner cannot be demoted.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param userId path string true "User ID of the member"
+// @Param request body models.UpdateMemberRequest true "New role"
+// @Success 200 {object} map[string]interface{} "Role changed"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Organization or member not found"
+// @Failure 409 {object} map[string]interface{} "Organization needs at least one owner"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{id}/members/{userId} [patch]
+func (h *OrganizationHandler) UpdateMember(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	var req models.UpdateMemberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	}
+
+	if err := h.organizationService.UpdateMember(c.UserContext(), userID, c.Params("id"), c.Params("userId"), &req); err != nil {
+		return organizationError(c, err, "Failed to change member role")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Member role changed",
+	})
+}
+
+// RemoveMember handles removing a member from an organization
+// @Summary Remove organization member
+// @Description Remove a member from an organization. Members may leave by removing themselves; owners and admins
+// @Description may remove members up to their own role. The last owner cannot leave.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param userId path string true "User ID of the member"
+// @Success 200 {object} map[string]interface{} "Member removed"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Organization or member not found"
+// @Failure 409 {object} map[string]interface{} "Organization needs at least one owner"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{id}/members/{userId} [delete]
+func (h *OrganizationHandler) RemoveMember(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{
+
+			"error": "User not authenticated",
+		})
+	}
+
+	if err := h.organizationService.RemoveMember(c.UserContext(), userID, c.Params("id"), c.Params("userId")); err != nil {
+		return organizationError(c, err, "Failed to remove member")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Member removed",
+	})
+}
+
+// organizationError responds to an error of the organization service
+func organizationError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrWorkspaceNotFound):
+		return c.Status(404).JSON(fiber.Map{
+
+			"error": "Organization not found",
+		})
+	case errors.Is(err, services.ErrMemberNotFound):
+		return c.Status(404).JSON(fiber.Map{
+
+			"error": "Member not found",
+		})
+	case errors.Is(err, services.ErrInvitationNotFound):
+		return c.Status(404).JSON(fiber.Map{
+
+			"error": "Invitation not found or expired",
+		})
+	case errors.Is(err, services.ErrInvitationForOtherEmail):
+		return c.Status(403).JSON(fiber.Map{
+
+			"error": "This invitation was sent to another email address",
+		})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(403).JSON(fiber.Map{
+
+			"error": "Your role in this organization does not allow this action",
+		})
+	case errors.Is(err, services.ErrAlreadyMember),
+		errors.Is(err, services.ErrLastOwner),
+		errors.Is(err, services.ErrOrganizationHasForms):
+		return c.Status(409).JSON(fiber.Map{
+
+			"error": err.Error(),
+		})
+	}
+	return c.Status(500).JSON(fiber.Map{
+
+		"error": message,
+	})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/interfaces"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+
+	validator "github.com/go-playground/validator/v10"
+	fiber "github.com/gofiber/fiber/v2"
+)
+
+// PresenceHandler handles presence heartbeats from public form pages
+type PresenceHandler struct {
+	presenceService interfaces.PresenceServiceInterface
+	wsManager       interfaces.WebSocketManagerInterface
+	validator       *validator.Validate
+}
+
+// NewPresenceHandler creates a new presence handler
+func NewPresenceHandler(
+	presenceService interfaces.PresenceServiceInterface,
+	wsManager interfaces.WebSocketManagerInterface,
+	validator *validator.Validate,
+) *PresenceHandler {
+	return &PresenceHandler{
+		presenceService: presenceService,
+		wsManager:       wsManager,
+		validator:       validator,
+	}
+}
+
+// Heartbeat records that a respondent has a public form open
+// @Summary Presence heartbeat
+// @Description Sent by an open public form page every heartbeatInterval seconds. The state is "viewing" until
+// @Description the respondent starts answering ("filling") and "left" when the page is closed or submitted.
+// @Description Changes in the live audience are pushed to the form owner's dashboards as presence:update messages.
+// @Tags Responses
+// @Accept json
+// @Produce json
+// @Param id path string true "Form ID"
+// @Param heartbeat body models.PresenceHeartbeatRequest true "Presence heartbeat"
+// @Success 200 {object} map[string]interface{} "Heartbeat recorded"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Form not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /forms/{id}/presence [post]
+func (h *PresenceHandler) Heartbeat(c *fiber.Ctx) error {
+	formID := c.Params("id")
+	if formID == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Form ID is required",
+		})
+	}
+
+	var req models.PresenceHeartbeatRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": utils.FormatValidationErrors(err),
+		})
+	}
+
+	counts, changed, err := h.presenceService.Heartbeat(c.UserContext(), formID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrFormNotPublished) {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Form not found",
+			})
+		}
+		slog.ErrorContext(c.UserContext(), "Failed to record presence", "form_id", formID, "error", err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to record presence",
+		})
+	}
+
+	if changed {
+		h.wsManager.Broadcast(formID, "presence:update", counts)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"heartbeatInterval": int(services.PresenceHeartbeatInterval.Seconds()),
+		},
+	})
+}
@@ -3,19 +3,27 @@ package handlers
 import (
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/config"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/interfaces"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/logging"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/metrics"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/tracing"
 	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
 
 	validator "github.com/go-playground/validator/v10"
 	fiber "github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ResponseHandler handles response-related HTTP requests
@@ -23,8 +31,14 @@ type ResponseHandler struct {
 	responseService  interfaces.ResponseServiceInterface
 	analyticsService interfaces.AnalyticsServiceInterface
 	formService      interfaces.FormServiceInterface
+	abuseService     interfaces.AbuseServiceInterface
 	wsManager        interfaces.WebSocketManagerInterface
 	validator        *validator.Validate
+	submissionConfig config.SubmissionConfig
+	metrics          *metrics.Metrics
+
+	// pendingUpdates counts analytics updates that have not finished yet
+	pendingUpdates atomic.Int64
 }
 
 // NewResponseHandler creates a new response handler
@@ -32,15 +46,21 @@ func NewResponseHandler(
 	responseService interfaces.ResponseServiceInterface,
 	analyticsService interfaces.AnalyticsServiceInterface,
 	formService interfaces.FormServiceInterface,
+	abuseService interfaces.AbuseServiceInterface,
 	wsManager interfaces.WebSocketManagerInterface,
 	validator *validator.Validate,
+	submissionConfig config.SubmissionConfig,
+	metrics *metrics.Metrics,
 ) *ResponseHandler {
 	return &ResponseHandler{
 		responseService:  responseService,
 		analyticsService: analyticsService,
 		formService:      formService,
+		abuseService:     abuseService,
 		wsManager:        wsManager,
 		validator:        validator,
+		submissionConfig: submissionConfig,
+		metrics:          metrics,
 	}
 }
 
@@ -51,10 +71,15 @@ func NewResponseHandler(
 // @Accept json
 // @Produce json
 // @Param id path string true "Form ID"
+// @Param Idempotency-Key header string false "Unique key that makes client retries safe"
 // @Param response body models.SubmitResponseRequest true "Form response data"
 // @Success 201 {object} map[string]interface{} "Response submitted successfully"
-// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 400 {object} map[string]interface{} "Bad request or form not published"
+// @Failure 401 {object} map[string]interface{} "Sign-in required to respond"
+// @Failure 403 {object} map[string]interface{} "Submission rejected by anti-abuse checks"
 // @Failure 404 {object} map[string]interface{} "Form not found"
+// @Failure 409 {object} map[string]interface{} "Already responded or request in progress"
+// @Failure 422 {object} map[string]interface{} "Idempotency key reused with a different request"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /forms/{id}/submit [post]
 func (h *ResponseHandler) SubmitResponse(c *fiber.Ctx) error {
@@ -67,6 +92,7 @@ func (h *ResponseHandler) SubmitResponse(c *fiber.Ctx) error {
 
 	var req models.SubmitResponseRequest
 	if err := c.BodyParser(&req); err != nil {
+		h.metrics.RecordValidationFailure("invalid_body")
 		return c.Status(400).JSON(fiber.Map{
 			"error": "Invalid request body",
 		})
@@ -74,6 +100,7 @@ func (h *ResponseHandler) SubmitResponse(c *fiber.Ctx) error {
 
 	// Validate request
 	if err := h.validator.Struct(&req); err != nil {
+		h.metrics.RecordValidationFailure("invalid_request")
 		return c.Status(400).JSON(fiber.Map{
 			"error":   "Validation failed",
 			"details": utils.FormatValidationErrors(err),
@@ -94,9 +121,58 @@ func (h *ResponseHandler) SubmitResponse(c *fiber.Ctx) error {
 		req.Meta.Referrer = &referrer
 	}
 
+	// Run anti-abuse checks; rejected submissions are quarantined by the service
+	reason, err := h.abuseService.CheckSubmission(c.UserContext(), formID, &req)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Anti-abuse check failed", "form_id", formID, "error", err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to submit response",
+		})
+	}
+	if reason != "" {
+		return c.Status(403).JSON(fiber.Map{
+			"error":  "Submission rejected",
+			"reason": reason,
+		})
+	}
+
+	// Resolve respondent identity for one-response-per-respondent forms
+	token, newToken := h.respondentToken(c)
+	respondent := &models.Respondent{
+		Token:       token,
+		Fingerprint: utils.HashSHA256(c.IP() + "|" + userAgent),
+	}
+	if userID, ok := c.Locals("userID").(string); ok {
+		respondent.UserID = userID
+	}
+
 	// Submit response
-	response, validationErrors, err := h.responseService.SubmitResponse(c.Context(), formID, &req)
+	response, validationErrors, err := h.responseService.SubmitResponse(c.UserContext(), formID, &req, respondent)
+	if err == nil && len(validationErrors) > 0 {
+		// Let the respondent correct their answers and retry with the same challenge
+		if releaseErr := h.abuseService.ReleaseChallenge(c.UserContext(), req.Challenge); releaseErr != nil {
+			slog.WarnContext(c.UserContext(), "Failed to release submission challenge", "error", releaseErr)
+		}
+	}
 	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrFormNotFound):
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Form not found",
+			})
+		case errors.Is(err, services.ErrFormNotAcceptingResponses):
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Form is not accepting responses",
+			})
+		case errors.Is(err, services.ErrRespondentIdentityRequired):
+			return c.Status(401).JSON(fiber.Map{
+				"error": "Sign in to respond to this form",
+			})
+		case errors.Is(err, services.ErrDuplicateResponse):
+			return c.Status(409).JSON(fiber.Map{
+				"error": "You have already responded to this form",
+			})
+		}
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to submit response",
 		})
@@ -104,6 +180,9 @@ func (h *ResponseHandler) SubmitResponse(c *fiber.Ctx) error {
 
 	// If there are validation errors, return them
 	if len(validationErrors) > 0 {
+		for _, validationError := range validationErrors {
+			h.metrics.RecordValidationFailure(validationError.Reason)
+		}
 		return c.Status(400).JSON(models.SubmitResponseResponse{
 			Success: false,
 			Errors:  validationErrors,
@@ -111,8 +190,18 @@ func (h *ResponseHandler) SubmitResponse(c *fiber.Ctx) error {
 		})
 	}
 
-	// Update analytics and broadcast real-time update
-	go h.updateAnalyticsAndBroadcast(formID, response)
+	// Only forms limited to one response per respondent need the respondent cookie
+	if newToken && response.RespondentKey != nil {
+		h.setRespondentCookie(c, token)
+	}
+
+	h.metrics.RecordSubmission(strings.Clone(formID))
+
+	// Update analytics and broadcast real-time update. The update outlives the request,
+	// so it only keeps the request's trace and request ID.
+	updateCtx := logging.WithRequestID(tracing.Detach(c.UserContext()), logging.RequestID(c.UserContext()))
+	h.pendingUpdates.Add(1)
+	go h.updateAnalyticsAndBroadcast(updateCtx, strings.Clone(formID), response)
 
 	return c.Status(201).JSON(models.SubmitResponseResponse{
 		Success: true,
@@ -157,16 +246,15 @@ func (h *ResponseHandler) GetResponses(c *fiber.Ctx) error {
 		limit = 20
 	}
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
-	responses, total, err := h.responseService.GetResponses(c.Context(), formID, page, limit, ownerID)
+	responses, total, err := h.responseService.GetResponses(c.UserContext(), formID, page, limit, actor)
 	if err != nil {
+		if status, message := formAccessStatus(err); status != 0 {
+			return c.Status(status).JSON(fiber.Map{
+				"error": message,
+			})
+		}
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to get responses",
 		})
@@ -226,16 +314,10 @@ func (h *ResponseHandler) ExportCSV(c *fiber.Ctx) error {
 		}
 	}
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
 	// Get form to understand field structure
-	form, err := h.formService.GetFormByID(c.Context(), formID, ownerID)
+	form, err := h.formService.GetFormByID(c.UserContext(), formID, actor)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "Form not found",
@@ -243,7 +325,7 @@ func (h *ResponseHandler) ExportCSV(c *fiber.Ctx) error {
 	}
 
 	// Get responses for export
-	responses, err := h.responseService.GetResponsesForExport(c.Context(), formID, startDate, endDate, ownerID)
+	responses, err := h.responseService.GetResponsesForExport(c.UserContext(), formID, startDate, endDate, actor)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to export responses",
@@ -254,54 +336,12 @@ func (h *ResponseHandler) ExportCSV(c *fiber.Ctx) error {
 	c.Set("Content-Type", "text/csv")
 	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-responses.csv"`, form.Title))
 
-	// Create CSV writer
-	writer := csv.NewWriter(c.Response().BodyWriter())
-	defer writer.Flush()
-
-	// Write CSV header
-	headers := []string{"Response ID", "Submitted At"}
-	fieldMap := make(map[string]string)
-
-	for _, field := range form.Fields {
-		headers = append(headers, field.Label)
-		fieldMap[field.ID] = field.Label
-	}
-
-	if err := writer.Write(headers); err != nil {
+	if err := services.WriteResponsesCSV(c.Response().BodyWriter(), form.Fields, responses); err != nil {
 		return c.Status(500).JSON(fiber.Map{
-			"error": "Failed to write CSV headers",
+			"error": "Failed to write CSV",
 		})
 	}
 
-	// Write response data
-	for _, response := range responses {
-		row := []string{
-			response.ID,
-			response.SubmittedAt.Format("2006-01-02 15:04:05"),
-		}
-
-		// Create answer map for quick lookup
-		answerMap := make(map[string]interface{})
-		for _, answer := range response.Answers {
-			answerMap[answer.FieldID] = answer.Value
-		}
-
-		// Add field values in order
-		for _, field := range form.Fields {
-			value := ""
-			if answer, exists := answerMap[field.ID]; exists {
-				value = h.formatAnswerForCSV(answer)
-			}
-			row = append(row, value)
-		}
-
-		if err := writer.Write(row); err != nil {
-			return c.Status(500).JSON(fiber.Map{
-				"error": "Failed to write CSV row",
-			})
-		}
-	}
-
 	return nil
 }
 
@@ -327,16 +367,10 @@ func (h *ResponseHandler) ExportAnalyticsCSV(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get owner ID from context (if authenticated)
-	var ownerID *string
-	if userID := c.Locals("userID"); userID != nil {
-		if uid, ok := userID.(string); ok {
-			ownerID = &uid
-		}
-	}
+	actor := workspaceActor(c)
 
 	// Get form details
-	form, err := h.formService.GetFormByID(c.Context(), formID, ownerID)
+	form, err := h.formService.GetFormByID(c.UserContext(), formID, actor)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "Form not found",
@@ -344,7 +378,7 @@ func (h *ResponseHandler) ExportAnalyticsCSV(c *fiber.Ctx) error {
 	}
 
 	// Get analytics data
-	analytics, err := h.analyticsService.GetAnalytics(c.Context(), formID, ownerID)
+	analytics, err := h.analyticsService.GetAnalytics(c.UserContext(), formID, actor)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to get analytics",
@@ -457,21 +491,29 @@ func (h *ResponseHandler) ExportAnalyticsCSV(c *fiber.Ctx) error {
 }
 
 // updateAnalyticsAndBroadcast updates analytics and broadcasts to WebSocket clients
-func (h *ResponseHandler) updateAnalyticsAndBroadcast(formID string, response *models.ResponseData) {
+func (h *ResponseHandler) updateAnalyticsAndBroadcast(ctx context.Context, formID string, response *models.ResponseData) {
+	defer h.pendingUpdates.Add(-1)
+	start := time.Now()
+
+	ctx, span := tracing.Start(ctx, "ResponseHandler.updateAnalyticsAndBroadcast", trace.WithAttributes(tracing.FormID(formID)))
+	defer span.End()
+
 	// FormID should already be clean and valid - just validate it
 	if len(formID) != 24 {
-		log.Printf("ERROR: Invalid formID in updateAnalyticsAndBroadcast: '%s' (len:%d)", formID, len(formID))
+		slog.ErrorContext(ctx, "Invalid form ID for analytics update", "form_id", formID)
 		return
 	}
 	// Convert formID to ObjectID
 	objectID, err := primitive.ObjectIDFromHex(formID)
 	if err != nil {
+		tracing.RecordError(span, err)
 		return
 	}
 
 	// Get the form
-	form, err := h.formService.GetFormByID(context.Background(), formID, nil)
+	form, err := h.formService.GetFormByID(ctx, formID, nil)
 	if err != nil {
+		tracing.RecordError(span, err)
 		return
 	}
 
@@ -489,44 +531,54 @@ func (h *ResponseHandler) updateAnalyticsAndBroadcast(formID string, response *m
 
 	// Update analytics
 	analytics, err := h.analyticsService.UpdateAnalyticsIncremental(
-		context.Background(),
+		ctx,
 		objectID,
 		internalResponse,
 		internalForm,
 	)
 	if err != nil {
+		tracing.RecordError(span, err)
+		slog.WarnContext(ctx, "Failed to update analytics", "form_id", formID, "error", err)
 		return
 	}
 
-	// Broadcast analytics update via WebSocket
-	analyticsData := map[string]interface{}{
-		"byField":        analytics.ByField,
-		"totalResponses": analytics.TotalResponses,
-		"updatedAt":      analytics.UpdatedAt,
-	}
+	// Broadcast the changes via WebSocket; bursts of submissions are merged
+	h.wsManager.BroadcastAnalytics(ctx, formID, analytics)
+	h.metrics.ObserveAnalyticsUpdate(time.Since(start))
+}
 
-	// Broadcast analytics update via WebSocket
-	h.wsManager.Broadcast(formID, "analytics:update", analyticsData)
+// PendingAnalyticsUpdates returns the number of analytics updates of accepted
+// submissions that have not finished yet
+func (h *ResponseHandler) PendingAnalyticsUpdates() int64 {
+	return h.pendingUpdates.Load()
 }
 
-// formatAnswerForCSV formats an answer value for CSV export
-func (h *ResponseHandler) formatAnswerForCSV(value interface{}) string {
-	switch v := value.(type) {
-	case string:
-		return v
-	case float64:
-		return fmt.Sprintf("%.0f", v)
-	case []interface{}:
-		var strs []string
-		for _, item := range v {
-			if str, ok := item.(string); ok {
-				strs = append(strs, str)
-			}
+// respondentToken returns the respondent token from the signed cookie, or a new token
+// that is only sent to the client once setRespondentCookie is called
+func (h *ResponseHandler) respondentToken(c *fiber.Ctx) (string, bool) {
+	if cookie := c.Cookies(h.submissionConfig.CookieName); cookie != "" {
+		if token, ok := utils.VerifySignedValue(cookie, h.submissionConfig.TokenSecret); ok {
+			return token, false
 		}
-		return strings.Join(strs, "; ")
-	case []string:
-		return strings.Join(v, "; ")
-	default:
-		return fmt.Sprintf("%v", v)
 	}
+
+	token, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		slog.WarnContext(c.UserContext(), "Failed to issue respondent token", "error", err)
+		return "", false
+	}
+	return token, true
+}
+
+// setRespondentCookie issues the signed respondent cookie
+func (h *ResponseHandler) setRespondentCookie(c *fiber.Ctx, token string) {
+	c.Cookie(&fiber.Cookie{
+		Name:     h.submissionConfig.CookieName,
+		Value:    utils.SignValue(token, h.submissionConfig.TokenSecret),
+		Path:     "/",
+		Expires:  time.Now().AddDate(1, 0, 0),
+		Secure:   h.submissionConfig.CookieSecure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
 }
@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Pinger is a database that can be pinged
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// IndexVerifier reports indexes the database should have but does not
+type IndexVerifier interface {
+	MissingIndexes(ctx context.Context) ([]string, error)
+}
+
+// SchemaVersioner reports the schema version the database was migrated to
+type SchemaVersioner interface {
+	SchemaVersion(ctx context.Context) (int, error)
+}
+
+// RealtimeLoop reports the liveness and backlog of the realtime event loop
+type RealtimeLoop interface {
+	LastHeartbeat() time.Time
+	GetBroadcastBacklog() int
+}
+
+// AnalyticsQueue reports analytics updates that have not finished yet
+type AnalyticsQueue interface {
+	PendingAnalyticsUpdates() int64
+}
+
+// Database checks that the database answers pings, reporting it degraded when
+// a ping takes longer than slowAfter
+func Database(db Pinger, slowAfter time.Duration) Check {
+	return func(ctx context.Context) ComponentStatus {
+		start := time.Now()
+		err := db.Ping(ctx)
+		latency := time.Since(start)
+		details := map[string]interface{}{"latencyMs": latency.Milliseconds()}
+
+		switch {
+		case err != nil:
+			return ComponentStatus{Status: StatusDown, Message: err.Error(), Details: details}
+		case latency > slowAfter:
+			return ComponentStatus{Status: StatusDegraded, Message: "slow database responses", Details: details}
+		default:
+			return ComponentStatus{Status: StatusUp, Details: details}
+		}
+	}
+}
+
+// Indexes checks that the database has every index the queries rely on. Missing
+// indexes leave the service working but slow, so they degrade it.
+func Indexes(db IndexVerifier) Check {
+	return func(ctx context.Context) ComponentStatus {
+		missing, err := db.MissingIndexes(ctx)
+		if err != nil {
+			return ComponentStatus{Status: StatusDown, Message: err.Error()}
+		}
+		if len(missing) > 0 {
+			return ComponentStatus{
+				Status:  StatusDegraded,
+				Message: "indexes are missing",
+				Details: map[string]interface{}{"missing": missing},
+			}
+		}
+		return ComponentStatus{Status: StatusUp}
+	}
+}
+
+// Migrations checks that the database schema is at the version this build expects.
+// Pending migrations take the instance out of rotation; a newer schema, left by a
+// newer release, degrades it.
+func Migrations(db SchemaVersioner, expected int) Check {
+	return func(ctx context.Context) ComponentStatus {
+		applied, err := db.SchemaVersion(ctx)
+		if err != nil {
+			return ComponentStatus{Status: StatusDown, Message: err.Error()}
+		}
+
+		details := map[string]interface{}{"applied": applied, "expected": expected}
+		switch {
+		case applied < expected:
+			return ComponentStatus{Status: StatusDown, Message: "migrations are pending", Details: details}
+		case applied > expected:
+			return ComponentStatus{Status: StatusDegraded, Message: "database schema is newer than this build", Details: details}
+		default:
+			return ComponentStatus{Status: StatusUp, Details: details}
+		}
+	}
+}
+
+// Realtime checks that the realtime event loop is running and keeping up. A loop
+// that has not beaten for staleAfter is down; a broadcast backlog above
+// maxBacklog degrades it.
+func Realtime(loop RealtimeLoop, staleAfter time.Duration, maxBacklog int) Check {
+	return func(context.Context) ComponentStatus {
+		lastHeartbeat := loop.LastHeartbeat()
+		backlog := loop.GetBroadcastBacklog()
+		details := map[string]interface{}{"backlog": backlog}
+
+		if lastHeartbeat.IsZero() {
+			return ComponentStatus{Status: StatusDown, Message: "event loop is not running", Details: details}
+		}
+		details["lastHeartbeat"] = lastHeartbeat.UTC().Format(time.RFC3339)
+		if since := time.Since(lastHeartbeat); since > staleAfter {
+			return ComponentStatus{
+				Status:  StatusDown,
+				Message: fmt.Sprintf("event loop has not run for %s", since.Round(time.Second)),
+				Details: details,
+			}
+		}
+		if backlog > maxBacklog {
+			return ComponentStatus{Status: StatusDegraded, Message: "broadcast backlog is high", Details: details}
+		}
+		return ComponentStatus{Status: StatusUp, Details: details}
+	}
+}
+
+// AnalyticsWorker checks that analytics updates keep up with submissions,
+// reporting the service degraded when more than maxBacklog are in flight
+func AnalyticsWorker(queue AnalyticsQueue, maxBacklog int64) Check {
+	return func(context.Context) ComponentStatus {
+		pending := queue.PendingAnalyticsUpdates()
+		details := map[string]interface{}{"pending": pending}
+		if pending > maxBacklog {
+			return ComponentStatus{Status: StatusDegraded, Message: "analytics updates are backing up", Details: details}
+		}
+		return ComponentStatus{Status: StatusUp, Details: details}
+	}
+}
@@ -0,0 +1,135 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Status is the health of a component or of the whole service
+type Status string
+
+const (
+	// StatusUp means the component works normally
+	StatusUp Status = "up"
+	// StatusDegraded means the component works but slowly or with reduced capacity
+	StatusDegraded Status = "degraded"
+	// StatusDown means the component does not work and the instance should not receive traffic
+	StatusDown Status = "down"
+)
+
+// severity orders statuses from best to worst
+func (s Status) severity() int {
+	switch s {
+	case StatusUp:
+		return 0
+	case StatusDegraded:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// ComponentStatus is the result of checking one component
+type ComponentStatus struct {
+	Status  Status                 `json:"status"`
+	Message string                 `json:"message,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Report is the result of checking every registered component
+type Report struct {
+	Status     Status                     `json:"status"`
+	Version    string                     `json:"version"`
+	Timestamp  string                     `json:"timestamp"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// Check checks one component. It must return once ctx is done.
+type Check func(ctx context.Context) ComponentStatus
+
+// Checker runs the registered checks concurrently, each bounded by a timeout
+type Checker struct {
+	version string
+	timeout time.Duration
+
+	mutex  sync.RWMutex
+	checks map[string]Check
+}
+
+// NewChecker creates a checker reporting the given version
+func NewChecker(version string, timeout time.Duration) *Checker {
+	return &Checker{
+		version: version,
+		timeout: timeout,
+		checks:  make(map[string]Check),
+	}
+}
+
+// Register adds a component check under name, replacing an existing one
+func (c *Checker) Register(name string, check Check) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.checks[name] = check
+}
+
+// Check runs every registered check. The report's status is the worst component
+// status; a check that does not finish within the timeout reports its component down.
+func (c *Checker) Check(ctx context.Context) *Report {
+	c.mutex.RLock()
+	checks := make(map[string]Check, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mutex.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var (
+		mutex sync.Mutex
+		wg    sync.WaitGroup
+	)
+	components := make(map[string]ComponentStatus, len(checks))
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			result := run(ctx, check)
+
+			mutex.Lock()
+			components[name] = result
+			mutex.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	status := StatusUp
+	for _, component := range components {
+		if component.Status.severity() > status.severity() {
+			status = component.Status
+		}
+	}
+
+	return &Report{
+		Status:     status,
+		Version:    c.version,
+		Timestamp:  time.Now().UTC().Format(time.RFC3339),
+		Components: components,
+	}
+}
+
+// run runs a check, reporting the component down if it does not finish in time
+func run(ctx context.Context, check Check) ComponentStatus {
+	result := make(chan ComponentStatus, 1)
+	go func() {
+		result <- check(ctx)
+	}()
+
+	select {
+	case status := <-result:
+		return status
+	case <-ctx.Done():
+		return ComponentStatus{Status: StatusDown, Message: "check timed out"}
+	}
+}
@@ -0,0 +1,207 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func staticCheck(status Status) Check {
+	return func(context.Context) ComponentStatus {
+		return ComponentStatus{Status: status}
+	}
+}
+
+func TestChecker_Check(t *testing.T) {
+	tests := []struct {
+		name     string
+		checks   map[string]Status
+		expected Status
+	}{
+		{
+			name:     "no checks",
+			checks:   map[string]Status{},
+			expected: StatusUp,
+		},
+		{
+			name:     "all up",
+			checks:   map[string]Status{"a": StatusUp, "b": StatusUp},
+			expected: StatusUp,
+		},
+		{
+			name:     "one degraded",
+			checks:   map[string]Status{"a": StatusUp, "b": StatusDegraded},
+			expected: StatusDegraded,
+		},
+		{
+			name:     "down wins over degraded",
+			checks:   map[string]Status{"a": StatusDown, "b": StatusDegraded, "c": StatusUp},
+			expected: StatusDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker("v1.2.3", time.Second)
+			for name, status := range tt.checks {
+				checker.Register(name, staticCheck(status))
+			}
+
+			report := checker.Check(context.Background())
+
+			assert.Equal(t, tt.expected, report.Status)
+			assert.Equal(t, "v1.2.3", report.Version)
+			assert.NotEmpty(t, report.Timestamp)
+			require.Len(t, report.Components, len(tt.checks))
+			for name, status := range tt.checks {
+				assert.Equal(t, status, report.Components[name].Status)
+			}
+		})
+	}
+}
+
+func TestChecker_CheckTimeout(t *testing.T) {
+	checker := NewChecker("dev", 20*time.Millisecond)
+	checker.Register("fast", staticCheck(StatusUp))
+	checker.Register("stuck", func(context.Context) ComponentStatus {
+		time.Sleep(time.Second)
+		return ComponentStatus{Status: StatusUp}
+	})
+
+	start := time.Now()
+	report := checker.Check(context.Background())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, StatusUp, report.Components["fast"].Status)
+	assert.Equal(t, "check timed out", report.Components["stuck"].Message)
+}
+
+func TestChecker_RegisterReplaces(t *testing.T) {
+	checker := NewChecker("dev", time.Second)
+	checker.Register("database", staticCheck(StatusDown))
+	checker.Register("database", staticCheck(StatusUp))
+
+	report := checker.Check(context.Background())
+
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Len(t, report.Components, 1)
+}
+
+type fakeDatabase struct {
+	delay   time.Duration
+	err     error
+	missing []string
+	version int
+}
+
+func (f *fakeDatabase) Ping(ctx context.Context) error {
+	time.Sleep(f.delay)
+	return f.err
+}
+
+func (f *fakeDatabase) MissingIndexes(ctx context.Context) ([]string, error) {
+	return f.missing, f.err
+}
+
+func (f *fakeDatabase) SchemaVersion(ctx context.Context) (int, error) {
+	return f.version, f.err
+}
+
+func TestDatabase(t *testing.T) {
+	t.Run("up", func(t *testing.T) {
+		status := Database(&fakeDatabase{}, time.Second)(context.Background())
+		assert.Equal(t, StatusUp, status.Status)
+		assert.Contains(t, status.Details, "latencyMs")
+	})
+
+	t.Run("slow", func(t *testing.T) {
+		status := Database(&fakeDatabase{delay: 5 * time.Millisecond}, time.Millisecond)(context.Background())
+		assert.Equal(t, StatusDegraded, status.Status)
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		status := Database(&fakeDatabase{err: errors.New("connection refused")}, time.Second)(context.Background())
+		assert.Equal(t, StatusDown, status.Status)
+		assert.Equal(t, "connection refused", status.Message)
+	})
+}
+
+func TestIndexes(t *testing.T) {
+	assert.Equal(t, StatusUp, Indexes(&fakeDatabase{})(context.Background()).Status)
+
+	status := Indexes(&fakeDatabase{missing: []string{"forms.ownerId_1"}})(context.Background())
+	assert.Equal(t, StatusDegraded, status.Status)
+	assert.Equal(t, []string{"forms.ownerId_1"}, status.Details["missing"])
+
+	status = Indexes(&fakeDatabase{err: errors.New("timeout")})(context.Background())
+	assert.Equal(t, StatusDown, status.Status)
+}
+
+func TestMigrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		applied  int
+		err      error
+		expected Status
+	}{
+		{name: "current", applied: 3, expected: StatusUp},
+		{name: "pending", applied: 2, expected: StatusDown},
+		{name: "never migrated", applied: 0, expected: StatusDown},
+		{name: "newer schema", applied: 4, expected: StatusDegraded},
+		{name: "unreadable", err: errors.New("timeout"), expected: StatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := Migrations(&fakeDatabase{version: tt.applied, err: tt.err}, 3)(context.Background())
+			assert.Equal(t, tt.expected, status.Status)
+		})
+	}
+}
+
+type fakeRealtime struct {
+	heartbeat time.Time
+	backlog   int
+}
+
+func (f *fakeRealtime) LastHeartbeat() time.Time { return f.heartbeat }
+func (f *fakeRealtime) GetBroadcastBacklog() int { return f.backlog }
+
+func TestRealtime(t *testing.T) {
+	tests := []struct {
+		name     string
+		loop     *fakeRealtime
+		expected Status
+	}{
+		{name: "running", loop: &fakeRealtime{heartbeat: time.Now()}, expected: StatusUp},
+		{name: "not started", loop: &fakeRealtime{}, expected: StatusDown},
+		{name: "stuck", loop: &fakeRealtime{heartbeat: time.Now().Add(-time.Minute)}, expected: StatusDown},
+		{name: "backlog", loop: &fakeRealtime{heartbeat: time.Now(), backlog: 11}, expected: StatusDegraded},
+		{name: "backlog at limit", loop: &fakeRealtime{heartbeat: time.Now(), backlog: 10}, expected: StatusUp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := Realtime(tt.loop, 10*time.Second, 10)(context.Background())
+			assert.Equal(t, tt.expected, status.Status)
+			assert.Equal(t, tt.loop.backlog, status.Details["backlog"])
+		})
+	}
+}
+
+type fakeQueue int64
+
+func (f fakeQueue) PendingAnalyticsUpdates() int64 { return int64(f) }
+
+func TestAnalyticsWorker(t *testing.T) {
+	assert.Equal(t, StatusUp, AnalyticsWorker(fakeQueue(5), 5)(context.Background()).Status)
+
+	status := AnalyticsWorker(fakeQueue(6), 5)(context.Background())
+	assert.Equal(t, StatusDegraded, status.Status)
+	assert.Equal(t, int64(6), status.Details["pending"])
+}
@@ -14,60 +14,138 @@ import (
 
 // FormServiceInterface defines the contract for form-related operations
 type FormServiceInterface interface {
-	CreateForm(ctx context.Context, req *models.CreateFormRequest, ownerID *string) (*models.FormResponse, error)
-	GetFormByID(ctx context.Context, formID string, ownerID *string) (*models.FormResponse, error)
+	CreateForm(ctx context.Context, req *models.CreateFormRequest, actor *services.Actor) (*models.FormResponse, error)
+	GetFormByID(ctx context.Context, formID string, actor *services.Actor) (*models.FormResponse, error)
 	GetFormBySlug(ctx context.Context, slug string) (*models.PublicFormResponse, error)
-	UpdateForm(ctx context.Context, formID string, req *models.UpdateFormRequest, ownerID *string) (*models.FormResponse, error)
-	DeleteForm(ctx context.Context, formID string, ownerID *string) error
-	ListForms(ctx context.Context, ownerID *string, page, limit int) ([]*models.FormResponse, int64, error)
-	PublishForm(ctx context.Context, formID string, ownerID *string) (*models.FormResponse, error)
-	UnpublishForm(ctx context.Context, formID string, ownerID *string) (*models.FormResponse, error)
+	UpdateForm(ctx context.Context, formID string, req *models.UpdateFormRequest, actor *services.Actor) (*models.FormResponse, error)
+	DeleteForm(ctx context.Context, formID string, actor *services.Actor) error
+	ListForms(ctx context.Context, actor *services.Actor, page, limit int) ([]*models.FormResponse, int64, error)
+	PublishForm(ctx context.Context, formID string, actor *services.Actor) (*models.FormResponse, error)
+	UnpublishForm(ctx context.Context, formID string, actor *services.Actor) (*models.FormResponse, error)
+	TransferForm(ctx context.Context, formID, organizationID string, actor *services.Actor) (*models.FormResponse, error)
 }
 
 // ResponseServiceInterface defines the contract for response-related operations
 type ResponseServiceInterface interface {
-	SubmitResponse(ctx context.Context, formID string, req *models.SubmitResponseRequest) (*models.ResponseData, []models.ValidationError, error)
-	GetResponses(ctx context.Context, formID string, page, limit int, ownerID *string) ([]*models.ResponseData, int64, error)
-	GetResponsesForExport(ctx context.Context, formID string, startDate, endDate *time.Time, ownerID *string) ([]*models.ResponseData, error)
+	SubmitResponse(ctx context.Context, formID string, req *models.SubmitResponseRequest, respondent *models.Respondent) (*models.ResponseData, []models.ValidationError, error)
+	GetResponses(ctx context.Context, formID string, page, limit int, actor *services.Actor) ([]*models.ResponseData, int64, error)
+	GetResponsesForExport(ctx context.Context, formID string, startDate, endDate *time.Time, actor *services.Actor) ([]*models.ResponseData, error)
+}
+
+// AbuseServiceInterface defines the contract for anti-abuse checks on public submissions
+type AbuseServiceInterface interface {
+	IssueChallenge(formID string, settings *models.FormSettings) (*models.SubmissionChallenge, error)
+	CheckSubmission(ctx context.Context, formID string, req *models.SubmitResponseRequest) (models.RejectionReason, error)
+	ReleaseChallenge(ctx context.Context, solution *models.ChallengeSolution) error
+}
+
+// PresenceServiceInterface defines the contract for tracking respondents on public forms
+type PresenceServiceInterface interface {
+	Heartbeat(ctx context.Context, formID string, req *models.PresenceHeartbeatRequest) (*models.PresenceCounts, bool, error)
+	Counts(ctx context.Context, formID string) (*models.PresenceCounts, error)
+	Watch(ctx context.Context, interval time.Duration, notify func(*models.PresenceCounts))
 }
 
 // AnalyticsServiceInterface defines the contract for analytics-related operations
 type AnalyticsServiceInterface interface {
-	GetAnalytics(ctx context.Context, formID string, ownerID *string) (*models.AnalyticsResponse, error)
-	ComputeAnalytics(ctx context.Context, formID string, startDate, endDate *time.Time, fields []string, ownerID *string) (*models.AnalyticsResponse, error)
+	GetAnalytics(ctx context.Context, formID string, actor *services.Actor) (*models.AnalyticsResponse, error)
+	ComputeAnalytics(ctx context.Context, formID string, startDate, endDate *time.Time, fields []string, actor *services.Actor) (*models.AnalyticsResponse, error)
 	UpdateAnalyticsIncremental(ctx context.Context, formID primitive.ObjectID, response *models.Response, form *models.Form) (*models.Analytics, error)
-	GetRealTimeMetrics(ctx context.Context, formID string, ownerID *string) (*models.RealTimeMetrics, error)
-	GetAnalyticsSummary(ctx context.Context, ownerID *string) ([]*models.AnalyticsSummary, error)
-	GetTrendAnalytics(ctx context.Context, formID string, period string, ownerID *string) (*models.TrendAnalytics, error)
+	GetRealTimeMetrics(ctx context.Context, formID string, actor *services.Actor) (*models.RealTimeMetrics, error)
+	GetAnalyticsSummary(ctx context.Context, actor *services.Actor) ([]*models.AnalyticsSummary, error)
+	GetTrendAnalytics(ctx context.Context, formID string, period string, actor *services.Actor) (*models.TrendAnalytics, error)
 }
 
 // WebSocketManagerInterface defines the contract for WebSocket management
 type WebSocketManagerInterface interface {
 	HandleConnection(c *fiber.Ctx) error
+	HandleStream(c *fiber.Ctx) error
 	Broadcast(formID string, messageType string, data interface{})
+	BroadcastAnalytics(ctx context.Context, formID string, analytics *models.Analytics)
+	FormAccessChanged(formID string)
+	UserAccessChanged(userID string)
 	GetRoomCount(formID string) int
 	GetTotalConnections() int
-	Run()
+	GetActiveRooms() int
+	GetDroppedMessages() int64
+	GetCoalescedUpdates() int64
+	GetBroadcastBacklog() int
+	LastHeartbeat() time.Time
+	Run(ctx context.Context)
 }
 
 // AuthServiceInterface defines the contract for authentication operations
 type AuthServiceInterface interface {
 	CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error)
-	LoginUser(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error)
-	RefreshTokens(ctx context.Context, refreshToken string) (*models.AuthResponse, error)
+	LoginUser(ctx context.Context, req *models.LoginRequest, client *models.ClientInfo) (*models.AuthResponse, error)
+	CompleteTwoFactorLogin(ctx context.Context, req *models.TwoFactorLoginRequest, client *models.ClientInfo) (*models.AuthResponse, error)
+	CreateSession(ctx context.Context, user *models.User, client *models.ClientInfo) (*models.AuthResponse, error)
+	RefreshTokens(ctx context.Context, refreshToken string, client *models.ClientInfo) (*models.AuthResponse, error)
+	Logout(ctx context.Context, refreshToken string) error
+	LogoutAll(ctx context.Context, userID string) (int64, error)
+	ListSessions(ctx context.Context, userID string) ([]*models.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
 	ValidateAccessToken(tokenString string) (*services.Claims, error)
 	ValidateRefreshToken(tokenString string) (*services.Claims, error)
-	GenerateTokens(user *models.User) (string, string, error)
+	GenerateTokens(user *models.User, session *models.Session) (string, string, error)
 	GetUserByID(ctx context.Context, userID string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	SetPassword(ctx context.Context, userID primitive.ObjectID, password string) error
+	MarkEmailVerified(ctx context.Context, userID primitive.ObjectID) error
+	ResetTwoFactor(ctx context.Context, userID primitive.ObjectID) error
 	HashPassword(password string) (string, error)
 	VerifyPassword(hashedPassword, password string) error
 }
 
+// AccountServiceInterface defines the contract for password resets and email verification
+type AccountServiceInterface interface {
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	RequestEmailVerification(ctx context.Context, email string) error
+	SendVerificationEmail(ctx context.Context, user *models.User) error
+	VerifyEmail(ctx context.Context, token string) (*models.User, error)
+}
+
+// TwoFactorServiceInterface defines the contract for TOTP two-factor authentication
+type TwoFactorServiceInterface interface {
+	Setup(ctx context.Context, userID string) (*models.TwoFactorSetup, error)
+	Enable(ctx context.Context, userID, code string) (*models.TwoFactorRecoveryCodes, error)
+	Disable(ctx context.Context, userID, password, code string) error
+	VerifyCode(ctx context.Context, user *models.User, code string) error
+	IssueChallenge(user *models.User) (string, error)
+	ChallengeUser(ctx context.Context, token string) (*models.User, error)
+}
+
+// APIKeyServiceInterface defines the contract for personal API keys
+type APIKeyServiceInterface interface {
+	Create(ctx context.Context, userID string, req *models.CreateAPIKeyRequest) (*models.CreatedAPIKey, error)
+	List(ctx context.Context, userID string) ([]*models.APIKey, error)
+	Revoke(ctx context.Context, userID, keyID string) error
+	Authenticate(ctx context.Context, key string, client *models.ClientInfo) (*models.APIKey, error)
+}
+
+// OrganizationServiceInterface defines the contract for organizations and their members
+type OrganizationServiceInterface interface {
+	Create(ctx context.Context, userID string, req *models.CreateOrganizationRequest) (*models.OrganizationResponse, error)
+	List(ctx context.Context, userID string) ([]*models.OrganizationResponse, error)
+	Get(ctx context.Context, userID, organizationID string) (*models.OrganizationResponse, error)
+	Rename(ctx context.Context, userID, organizationID string, req *models.UpdateOrganizationRequest) (*models.OrganizationResponse, error)
+	Delete(ctx context.Context, userID, organizationID string) error
+	Members(ctx context.Context, userID, organizationID string) ([]*models.MemberResponse, error)
+	Invite(ctx context.Context, userID, organizationID string, req *models.InviteMemberRequest) error
+	Invitations(ctx context.Context, userID, organizationID string) ([]*models.Invitation, error)
+	RevokeInvitation(ctx context.Context, userID, organizationID, invitationID string) error
+	AcceptInvitation(ctx context.Context, userID, token string) (*models.OrganizationResponse, error)
+	UpdateMember(ctx context.Context, userID, organizationID, memberID string, req *models.UpdateMemberRequest) error
+	RemoveMember(ctx context.Context, userID, organizationID, memberID string) error
+}
+
 // DatabaseInterface defines the contract for database operations
 type DatabaseInterface interface {
 	GetCollections() *database.Collections
 	HealthCheck() error
+	Ping(ctx context.Context) error
+	MissingIndexes(ctx context.Context) ([]string, error)
 	EnsureIndexes() error
 	Close() error
-	RunMigrations() error
 }
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"log/slog"
+
+	"go.uber.org/fx/fxevent"
+)
+
+// FxLogger writes dependency injection events to the application logger.
+// Failures are logged as errors, lifecycle milestones at info and the rest at debug.
+type FxLogger struct {
+	Logger *slog.Logger
+}
+
+// LogEvent implements fxevent.Logger
+func (l *FxLogger) LogEvent(event fxevent.Event) {
+	switch e := event.(type) {
+	case *fxevent.OnStartExecuted:
+		l.result("OnStart hook executed", e.Err, "callee", e.FunctionName, "caller", e.CallerName, "runtime", e.Runtime)
+	case *fxevent.OnStopExecuted:
+		l.result("OnStop hook executed", e.Err, "callee", e.FunctionName, "caller", e.CallerName, "runtime", e.Runtime)
+	case *fxevent.Supplied:
+		l.result("Supplied", e.Err, "type", e.TypeName)
+	case *fxevent.Provided:
+		l.result("Provided", e.Err, "constructor", e.ConstructorName, "types", e.OutputTypeNames)
+	case *fxevent.Decorated:
+		l.result("Decorated", e.Err, "decorator", e.DecoratorName)
+	case *fxevent.Invoked:
+		l.result("Invoked", e.Err, "function", e.FunctionName)
+	case *fxevent.Stopping:
+		l.Logger.Info("Received signal, stopping", "signal", e.Signal.String())
+	case *fxevent.Stopped:
+		if e.Err != nil {
+			l.Logger.Error("Failed to stop cleanly", "error", e.Err)
+		}
+	case *fxevent.RollingBack:
+		l.Logger.Error("Start failed, rolling back", "error", e.StartErr)
+	case *fxevent.RolledBack:
+		if e.Err != nil {
+			l.Logger.Error("Rollback failed", "error", e.Err)
+		}
+	case *fxevent.Started:
+		if e.Err != nil {
+			l.Logger.Error("Failed to start", "error", e.Err)
+		} else {
+			l.Logger.Info("Application started")
+		}
+	case *fxevent.LoggerInitialized:
+		if e.Err != nil {
+			l.Logger.Error("Failed to initialize custom logger", "error", e.Err)
+		}
+	}
+}
+
+// result logs a successful step at debug level and a failed one as error
+func (l *FxLogger) result(msg string, err error, args ...any) {
+	if err != nil {
+		l.Logger.Error(msg, append(args, "error", err)...)
+		return
+	}
+	l.Logger.Debug(msg, args...)
+}
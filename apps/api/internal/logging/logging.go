@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/config"
+)
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// New creates the application logger writing to stdout
+func New(cfg *config.Config) (*slog.Logger, error) {
+	return NewWithWriter(os.Stdout, cfg.Log)
+}
+
+// NewWithWriter creates a logger writing records in the configured format and level.
+// Records carry the request ID and trace of their context and, unless disabled, have
+// IP addresses and email addresses redacted.
+func NewWithWriter(w io.Writer, cfg config.LogConfig) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", cfg.Level, err)
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	if cfg.Redact {
+		options.ReplaceAttr = redactAttr
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", cfg.Format)
+	}
+
+	return slog.New(&contextHandler{Handler: handler, redact: cfg.Redact}), nil
+}
+
+// SetDefault makes logger the default slog logger. Output of the standard log
+// package is routed through it as well.
+func SetDefault(logger *slog.Logger) {
+	slog.SetDefault(logger)
+}
+
+// contextHandler adds the request ID and trace of the record's context and redacts the message
+type contextHandler struct {
+	slog.Handler
+	redact bool
+}
+
+// Handle implements slog.Handler
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if h.redact {
+		redacted := slog.NewRecord(record.Time, record.Level, Redact(record.Message), record.PC)
+		record.Attrs(func(attr slog.Attr) bool {
+			redacted.AddAttrs(attr)
+			return true
+		})
+		record = redacted
+	}
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs), redact: h.redact}
+}
+
+// WithGroup implements slog.Handler
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name), redact: h.redact}
+}
@@ -0,0 +1,168 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/config"
+)
+
+// newTestLogger returns a JSON logger writing to a buffer
+func newTestLogger(t *testing.T, cfg config.LogConfig) (*slog.Logger, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	logger, err := NewWithWriter(&buf, cfg)
+	require.NoError(t, err)
+	return logger, &buf
+}
+
+// decodeRecords parses the JSON records written to buf
+func decodeRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestNewWithWriter(t *testing.T) {
+	t.Run("Writes JSON records with attributes", func(t *testing.T) {
+		logger, buf := newTestLogger(t, config.LogConfig{Level: "info", Format: "json"})
+
+		logger.Info("Form created", "form_id", "507f1f77bcf86cd799439011")
+
+		records := decodeRecords(t, buf)
+		require.Len(t, records, 1)
+		assert.Equal(t, "INFO", records[0]["level"])
+		assert.Equal(t, "Form created", records[0]["msg"])
+		assert.Equal(t, "507f1f77bcf86cd799439011", records[0]["form_id"])
+		assert.NotContains(t, records[0], "request_id")
+	})
+
+	t.Run("Filters records below the configured level", func(t *testing.T) {
+		logger, buf := newTestLogger(t, config.LogConfig{Level: "warn", Format: "json"})
+
+		logger.Info("Ignored")
+		logger.Warn("Kept")
+
+		records := decodeRecords(t, buf)
+		require.Len(t, records, 1)
+		assert.Equal(t, "Kept", records[0]["msg"])
+	})
+
+	t.Run("Writes text records", func(t *testing.T) {
+		logger, buf := newTestLogger(t, config.LogConfig{Level: "debug", Format: "text"})
+
+		logger.Debug("Room status", "clients", 2)
+
+		assert.Contains(t, buf.String(), `msg="Room status" clients=2`)
+	})
+
+	t.Run("Rejects invalid settings", func(t *testing.T) {
+		_, err := NewWithWriter(&bytes.Buffer{}, config.LogConfig{Level: "verbose", Format: "json"})
+		assert.Error(t, err)
+
+		_, err = NewWithWriter(&bytes.Buffer{}, config.LogConfig{Level: "info", Format: "xml"})
+		assert.Error(t, err)
+	})
+}
+
+func TestRequestID(t *testing.T) {
+	t.Run("Records include the request ID of their context", func(t *testing.T) {
+		logger, buf := newTestLogger(t, config.LogConfig{Level: "info", Format: "json"})
+
+		ctx := WithRequestID(context.Background(), "req-123")
+		logger.InfoContext(ctx, "Response submitted")
+		logger.With("client_id", "abc").InfoContext(ctx, "Client connected")
+
+		records := decodeRecords(t, buf)
+		require.Len(t, records, 2)
+		assert.Equal(t, "req-123", records[0]["request_id"])
+		assert.Equal(t, "req-123", records[1]["request_id"])
+		assert.Equal(t, "abc", records[1]["client_id"])
+	})
+
+	t.Run("Records include the trace of their context", func(t *testing.T) {
+		logger, buf := newTestLogger(t, config.LogConfig{Level: "info", Format: "json"})
+
+		spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+			SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		})
+		logger.InfoContext(trace.ContextWithSpanContext(context.Background(), spanContext), "Form created")
+
+		records := decodeRecords(t, buf)
+		require.Len(t, records, 1)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", records[0]["trace_id"])
+		assert.Equal(t, "00f067aa0ba902b7", records[0]["span_id"])
+	})
+
+	t.Run("Contexts without a request ID return an empty ID", func(t *testing.T) {
+		assert.Equal(t, "", RequestID(context.Background()))
+		assert.Equal(t, "req-1", RequestID(WithRequestID(context.Background(), "req-1")))
+	})
+}
+
+func TestRedaction(t *testing.T) {
+	t.Run("Messages, attributes and errors are redacted", func(t *testing.T) {
+		logger, buf := newTestLogger(t, config.LogConfig{Level: "info", Format: "json", Redact: true})
+
+		logger.Info("Login failed for jane.doe@example.com",
+			"ip", "203.0.113.42",
+			"error", errors.New("user jane.doe@example.com not found"),
+		)
+
+		records := decodeRecords(t, buf)
+		require.Len(t, records, 1)
+		assert.Equal(t, "Login failed for ***@example.com", records[0]["msg"])
+		assert.Equal(t, "203.0.113.x", records[0]["ip"])
+		assert.Equal(t, "user ***@example.com not found", records[0]["error"])
+	})
+
+	t.Run("Redaction can be disabled", func(t *testing.T) {
+		logger, buf := newTestLogger(t, config.LogConfig{Level: "info", Format: "json"})
+
+		logger.Info("Login failed", "ip", "203.0.113.42")
+
+		records := decodeRecords(t, buf)
+		require.Len(t, records, 1)
+		assert.Equal(t, "203.0.113.42", records[0]["ip"])
+	})
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Email", "contact a.b+c@mail.example.org now", "contact ***@mail.example.org now"},
+		{"IPv4", "client 192.168.1.20 connected", "client 192.168.1.x connected"},
+		{"IPv4 with port", "192.168.1.20:8080", "192.168.1.x:8080"},
+		{"IPv6", "client 2001:db8:85a3::8a2e:370:7334", "client 2001:db8:85a3:x"},
+		{"Loopback IPv6 is kept", "::1", "::1"},
+		{"Times are kept", "at 12:30:45", "at 12:30:45"},
+		{"Plain text is kept", "Room status", "Room status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Redact(tt.input))
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"log/slog"
+	"net"
+	"regexp"
+	"strings"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@((?:[A-Za-z0-9\-]+\.)+[A-Za-z]{2,})`)
+	ipv4Pattern  = regexp.MustCompile(`\b(\d{1,3}\.\d{1,3}\.\d{1,3})\.\d{1,3}\b`)
+	ipv6Pattern  = regexp.MustCompile(`[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`)
+)
+
+// Redact masks email addresses and IP addresses in s. Emails keep their domain,
+// IPv4 addresses their first three octets and IPv6 addresses their first three groups.
+func Redact(s string) string {
+	if !strings.ContainsAny(s, "@.:") {
+		return s
+	}
+
+	s = emailPattern.ReplaceAllString(s, "***@$1")
+	s = ipv4Pattern.ReplaceAllString(s, "$1.x")
+	return ipv6Pattern.ReplaceAllStringFunc(s, func(candidate string) string {
+		ip := net.ParseIP(candidate)
+		if ip == nil || ip.To4() != nil {
+			return candidate
+		}
+		groups := strings.SplitN(ip.String(), ":", 4)
+		if len(groups) < 4 {
+			return candidate
+		}
+		return strings.Join(groups[:3], ":") + ":x"
+	})
+}
+
+// redactAttr redacts string and error attribute values
+func redactAttr(_ []string, attr slog.Attr) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		attr.Value = slog.StringValue(Redact(attr.Value.String()))
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			attr.Value = slog.StringValue(Redact(err.Error()))
+		}
+	}
+	return attr
+}
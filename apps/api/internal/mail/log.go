@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+// LogMailer logs messages instead of delivering them, for local development. When
+// a directory is set, each message is also written there as an .eml file.
+type LogMailer struct {
+	from string
+	dir  string
+}
+
+// NewLogMailer creates a mailer that logs messages and writes them to dir, if set
+func NewLogMailer(from, dir string) *LogMailer {
+	return &LogMailer{from: from, dir: dir}
+}
+
+// Send logs a message and writes it to the directory
+func (m *LogMailer) Send(ctx context.Context, msg *Message) error {
+	now := time.Now()
+	body, err := compose(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	attrs := []any{"to", msg.To, "subject", msg.Subject, "text", msg.Text}
+	if m.dir != "" {
+		path, err := m.write(body, now)
+		if err != nil {
+			return err
+		}
+		attrs = append(attrs, "file", path)
+	}
+
+	slog.InfoContext(ctx, "Email not delivered, mail driver is log", attrs...)
+	return nil
+}
+
+// write stores a rendered message in the directory and returns its path
+func (m *LogMailer) write(body []byte, now time.Time) (string, error) {
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	suffix, err := utils.GenerateSecureToken(6)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(m.dir, now.UTC().Format("20060102T150405.000000000")+"-"+suffix+".eml")
+
+	// Messages contain account links, so only the developer may read them
+	if err := os.WriteFile(path, body, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write email: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,72 @@
+// Package mail sends transactional email such as password reset and email
+// verification links.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/config"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+// Mailer delivers email
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New creates the mailer selected by the configuration: "smtp" delivers through an
+// SMTP server, "log" logs messages and optionally writes them to a directory
+func New(cfg config.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case "log":
+		return NewLogMailer(cfg.From, cfg.Dir), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
+// compose renders a message in RFC 5322 format
+func compose(from string, msg *Message, now time.Time) ([]byte, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", from, err)
+	}
+	recipient, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+
+	id, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return nil, err
+	}
+	domain := sender.Address[strings.LastIndex(sender.Address, "@")+1:]
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", sender.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", recipient.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", id, domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Text, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,98 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/config"
+)
+
+func TestCompose(t *testing.T) {
+	now := time.Date(2024, 8, 20, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Headers and body", func(t *testing.T) {
+		body, err := compose("Dune Forms <no-reply@forms.example.com>", &Message{
+			To:      "ada@example.com",
+			Subject: "Réinitialiser",
+			Text:    "Hi Ada,\n\nOpen the link.\n",
+		}, now)
+		require.NoError(t, err)
+
+		headers, text, found := strings.Cut(string(body), "\r\n\r\n")
+		require.True(t, found)
+		assert.Contains(t, headers, "From: \"Dune Forms\" <no-reply@forms.example.com>\r\n")
+		assert.Contains(t, headers, "To: <ada@example.com>\r\n")
+		assert.Contains(t, headers, "Subject: =?utf-8?q?R=C3=A9initialiser?=\r\n")
+		assert.Contains(t, headers, "Date: Tue, 20 Aug 2024 10:00:00 +0000\r\n")
+		assert.Contains(t, headers, "@forms.example.com>\r\n")
+		assert.Equal(t, "Hi Ada,\r\n\r\nOpen the link.\r\n", text)
+	})
+
+	t.Run("Header injection is rejected", func(t *testing.T) {
+		_, err := compose("no-reply@forms.example.com", &Message{
+			To:      "ada@example.com\r\nBcc: eve@example.com",
+			Subject: "Hello",
+		}, now)
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid sender", func(t *testing.T) {
+		_, err := compose("not an address", &Message{To: "ada@example.com"}, now)
+		assert.Error(t, err)
+	})
+}
+
+func TestLogMailer(t *testing.T) {
+	msg := &Message{To: "ada@example.com", Subject: "Hello", Text: "Hi Ada"}
+
+	t.Run("Writes messages to the directory", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "mail")
+		mailer := NewLogMailer("no-reply@forms.example.com", dir)
+
+		require.NoError(t, mailer.Send(context.Background(), msg))
+		require.NoError(t, mailer.Send(context.Background(), msg))
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+
+		info, err := os.Stat(files[0])
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		body, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "Subject: Hello\r\n")
+	})
+
+	t.Run("Only logs without a directory", func(t *testing.T) {
+		mailer := NewLogMailer("no-reply@forms.example.com", "")
+		assert.NoError(t, mailer.Send(context.Background(), msg))
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Run("SMTP", func(t *testing.T) {
+		mailer, err := New(config.MailConfig{Driver: "smtp", SMTPHost: "smtp.example.com", SMTPPort: 587})
+		require.NoError(t, err)
+		assert.IsType(t, &SMTPMailer{}, mailer)
+	})
+
+	t.Run("Log", func(t *testing.T) {
+		mailer, err := New(config.MailConfig{Driver: "log"})
+		require.NoError(t, err)
+		assert.IsType(t, &LogMailer{}, mailer)
+	})
+
+	t.Run("Unknown driver", func(t *testing.T) {
+		_, err := New(config.MailConfig{Driver: "pigeon"})
+		assert.Error(t, err)
+	})
+}
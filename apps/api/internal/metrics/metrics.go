@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// namespace prefixes every metric name
+const namespace = "dune"
+
+// UnmatchedRoute labels requests that matched no route, keeping junk paths out of the labels
+const UnmatchedRoute = "unmatched"
+
+// RealtimeStats is the part of the WebSocket manager read when metrics are scraped
+type RealtimeStats interface {
+	GetTotalConnections() int
+	GetActiveRooms() int
+	GetDroppedMessages() int64
+	GetCoalescedUpdates() int64
+}
+
+// Metrics holds the Prometheus collectors of the API
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests       *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+	submissions        *prometheus.CounterVec
+	validationFailures *prometheus.CounterVec
+	analyticsLatency   prometheus.Histogram
+	mongoErrors        *prometheus.CounterVec
+}
+
+// New creates the API metrics, registered with Go runtime and process metrics
+// on a dedicated registry
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		submissions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "form_submissions_total",
+			Help:      "Accepted form submissions by form.",
+		}, []string{"form_id"}),
+		validationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "submission_validation_failures_total",
+			Help:      "Form submission validation failures by reason.",
+		}, []string{"reason"}),
+		analyticsLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "analytics_update_duration_seconds",
+			Help:      "Time from an accepted submission to the broadcast of the form's updated analytics.",
+			Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		}),
+		mongoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "mongo_operation_errors_total",
+			Help:      "Failed MongoDB commands by command name.",
+		}, []string{"command"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.submissions,
+		m.validationFailures,
+		m.analyticsLatency,
+		m.mongoErrors,
+	)
+
+	return m
+}
+
+// Registry returns the registry holding the API metrics
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// RegisterRealtime exposes WebSocket connection, room and queue statistics
+func (m *Metrics) RegisterRealtime(stats RealtimeStats) {
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "websocket_connections",
+			Help:      "Open WebSocket and Server-Sent Events connections.",
+		}, func() float64 { return float64(stats.GetTotalConnections()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "websocket_rooms",
+			Help:      "Form rooms and owner channels with at least one subscriber.",
+		}, func() float64 { return float64(stats.GetActiveRooms()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "websocket_dropped_messages_total",
+			Help:      "Realtime messages dropped because a queue was full.",
+		}, func() float64 { return float64(stats.GetDroppedMessages()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "websocket_coalesced_updates_total",
+			Help:      "Analytics updates merged into a later broadcast.",
+		}, func() float64 { return float64(stats.GetCoalescedUpdates()) }),
+	)
+}
+
+// ObserveRequest records a handled HTTP request
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// RecordSubmission counts an accepted submission to a form
+func (m *Metrics) RecordSubmission(formID string) {
+	m.submissions.WithLabelValues(formID).Inc()
+}
+
+// RecordValidationFailure counts a submission rejected as invalid
+func (m *Metrics) RecordValidationFailure(reason string) {
+	m.validationFailures.WithLabelValues(reason).Inc()
+}
+
+// ObserveAnalyticsUpdate records how long updating and broadcasting a form's analytics took
+func (m *Metrics) ObserveAnalyticsUpdate(duration time.Duration) {
+	m.analyticsLatency.Observe(duration.Seconds())
+}
+
+// CommandMonitor returns a MongoDB command monitor counting failed commands
+func (m *Metrics) CommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			m.mongoErrors.WithLabelValues(evt.CommandName).Inc()
+		},
+	}
+}
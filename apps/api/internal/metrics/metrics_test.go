@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// fakeStats reports fixed realtime statistics
+type fakeStats struct{}
+
+func (fakeStats) GetTotalConnections() int   { return 3 }
+func (fakeStats) GetActiveRooms() int        { return 2 }
+func (fakeStats) GetDroppedMessages() int64  { return 5 }
+func (fakeStats) GetCoalescedUpdates() int64 { return 7 }
+
+func TestMetrics_Counters(t *testing.T) {
+	m := New()
+
+	t.Run("Requests are counted by route and status", func(t *testing.T) {
+		m.ObserveRequest("GET", "/api/forms/:id", 200, 10*time.Millisecond)
+		m.ObserveRequest("GET", "/api/forms/:id", 200, 20*time.Millisecond)
+		m.ObserveRequest("GET", "/api/forms/:id", 404, time.Millisecond)
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/api/forms/:id", "200")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/api/forms/:id", "404")))
+		assert.Equal(t, 1, testutil.CollectAndCount(m.httpDuration))
+	})
+
+	t.Run("Submissions are counted per form", func(t *testing.T) {
+		m.RecordSubmission("507f1f77bcf86cd799439011")
+		m.RecordSubmission("507f1f77bcf86cd799439011")
+		m.RecordSubmission("507f1f77bcf86cd799439022")
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(m.submissions.WithLabelValues("507f1f77bcf86cd799439011")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.submissions.WithLabelValues("507f1f77bcf86cd799439022")))
+	})
+
+	t.Run("Validation failures are counted by reason", func(t *testing.T) {
+		m.RecordValidationFailure("required")
+		m.RecordValidationFailure("required")
+		m.RecordValidationFailure("invalid_body")
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(m.validationFailures.WithLabelValues("required")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.validationFailures.WithLabelValues("invalid_body")))
+	})
+
+	t.Run("Failed Mongo commands are counted", func(t *testing.T) {
+		monitor := m.CommandMonitor()
+		monitor.Failed(context.Background(), &event.CommandFailedEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "insert"},
+		})
+
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.mongoErrors.WithLabelValues("insert")))
+	})
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.RegisterRealtime(fakeStats{})
+	m.ObserveAnalyticsUpdate(30 * time.Millisecond)
+
+	app := fiber.New()
+	app.Get("/metrics", m.Handler())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain"))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	for _, line := range []string{
+		"dune_websocket_connections 3",
+		"dune_websocket_rooms 2",
+		"dune_websocket_dropped_messages_total 5",
+		"dune_websocket_coalesced_updates_total 7",
+		"dune_analytics_update_duration_seconds_count 1",
+		"go_goroutines",
+	} {
+		assert.Contains(t, string(body), line)
+	}
+}
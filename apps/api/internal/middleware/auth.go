@@ -1,15 +1,20 @@
 package middleware
 
 import (
+	"errors"
+	"log/slog"
 	"strings"
 
 	fiber "github.com/gofiber/fiber/v2"
 
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
 )
 
-// AuthMiddleware creates authentication middleware
-func AuthMiddleware(authService *services.AuthService) fiber.Handler {
+// AuthMiddleware creates authentication middleware. Access tokens from the login
+// flow are always accepted. API keys are only accepted on routes that list the
+// scopes they need, and only when the key was granted all of them.
+func AuthMiddleware(authService *services.AuthService, apiKeyService *services.APIKeyService, scopes ...models.APIKeyScope) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get Authorization header
 		authHeader := c.Get("Authorization")
@@ -38,6 +43,10 @@ func AuthMiddleware(authService *services.AuthService) fiber.Handler {
 			})
 		}
 
+		if services.IsAPIKey(token) {
+			return authenticateAPIKey(c, apiKeyService, token, scopes)
+		}
+
 		// Validate token
 		claims, err := authService.ValidateAccessToken(token)
 		if err != nil {
@@ -47,16 +56,77 @@ func AuthMiddleware(authService *services.AuthService) fiber.Handler {
 			})
 		}
 
+		// Access tokens end with their session
+		if err := authService.CheckSession(c.UserContext(), claims.SessionID); err != nil {
+			if errors.Is(err, services.ErrSessionRevoked) {
+				return c.Status(401).JSON(fiber.Map{
+
+					"error": "Session has been revoked",
+				})
+			}
+			slog.ErrorContext(c.UserContext(), "Failed to check session", "error", err)
+			return c.Status(500).JSON(fiber.Map{
+
+				"error": "Failed to authenticate request",
+			})
+		}
+
 		// Set user information in context
 		c.Locals("userID", claims.UserID)
 		c.Locals("userEmail", claims.Email)
 		c.Locals("userName", claims.Name)
+		c.Locals("sessionID", claims.SessionID)
+		if claims.ExpiresAt != nil {
+			c.Locals("tokenExpiresAt", claims.ExpiresAt.Time)
+		}
 
 		// Continue to next handler
 		return c.Next()
 	}
 }
 
+// authenticateAPIKey authenticates a request made with an API key
+func authenticateAPIKey(c *fiber.Ctx, apiKeyService *services.APIKeyService, key string, scopes []models.APIKeyScope) error {
+	if len(scopes) == 0 {
+		return c.Status(403).JSON(fiber.Map{
+
+			"error": "API keys cannot be used for this endpoint",
+		})
+	}
+
+	apiKey, err := apiKeyService.Authenticate(c.UserContext(), key, &models.ClientInfo{IP: strings.Clone(c.IP())})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			return c.Status(401).JSON(fiber.Map{
+
+				"error": "Invalid or expired API key",
+			})
+		}
+		slog.ErrorContext(c.UserContext(), "Failed to authenticate API key", "error", err)
+		return c.Status(500).JSON(fiber.Map{
+
+			"error": "Failed to authenticate API key",
+		})
+	}
+
+	if !apiKey.HasScopes(scopes...) {
+		return c.Status(403).JSON(fiber.Map{
+
+			"error":          "API key is missing a required scope",
+			"requiredScopes": scopes,
+		})
+	}
+
+	// Set user information in context
+	c.Locals("userID", apiKey.UserID.Hex())
+	c.Locals("apiKeyID", apiKey.ID.Hex())
+	if apiKey.ExpiresAt != nil {
+		c.Locals("tokenExpiresAt", *apiKey.ExpiresAt)
+	}
+
+	return c.Next()
+}
+
 // OptionalAuthMiddleware creates optional authentication middleware
 // This middleware sets user context if token is provided but doesn't fail if missing
 func OptionalAuthMiddleware(authService *services.AuthService) fiber.Handler {
@@ -84,6 +154,9 @@ func OptionalAuthMiddleware(authService *services.AuthService) fiber.Handler {
 		if err != nil {
 			return c.Next() // Continue without authentication
 		}
+		if err := authService.CheckSession(c.UserContext(), claims.SessionID); err != nil {
+			return c.Next() // Continue without authentication
+		}
 
 		// Set user information in context
 		c.Locals("userID", claims.UserID)
@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 
 	fiber "github.com/gofiber/fiber/v2"
 )
@@ -16,8 +16,12 @@ func ErrorHandler(c *fiber.Ctx, err error) error {
 		code = e.Code
 	}
 
-	// Log the error with structured format
-	log.Printf("ERROR: HTTP %d - %s %s - %s", code, c.Method(), c.Path(), err.Error())
+	// Client errors are expected; only server errors are logged at error level
+	level := slog.LevelWarn
+	if code >= fiber.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	slog.Log(c.UserContext(), level, "Request failed", "status", code, "method", c.Method(), "path", c.Path(), "error", err)
 
 	// Return appropriate error response
 	switch code {
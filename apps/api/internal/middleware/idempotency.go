@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	fiber "github.com/gofiber/fiber/v2"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+// maxIdempotencyKeyLength limits the size of client supplied idempotency keys
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyMiddleware creates middleware that honours the Idempotency-Key header.
+// A retried request with the same key and body receives the originally stored response
+// instead of being processed again.
+func IdempotencyMiddleware(idempotencyService *services.IdempotencyService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := strings.TrimSpace(c.Get("Idempotency-Key"))
+		if key == "" {
+			return c.Next() // Idempotency is opt-in per request
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Idempotency-Key header is too long",
+			})
+		}
+
+		// Keys are only unique per client, so the scope includes the caller
+		scope := c.Method() + " " + c.Path() + " " + idempotencyCaller(c)
+		record, err := idempotencyService.Begin(c.UserContext(), scope, key, utils.HashSHA256(string(c.Body())))
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrIdempotencyKeyInUse):
+				return c.Status(409).JSON(fiber.Map{
+					"error": "A request with this Idempotency-Key is still being processed",
+				})
+			case errors.Is(err, services.ErrIdempotencyKeyMismatch):
+				return c.Status(422).JSON(fiber.Map{
+					"error": "Idempotency-Key was already used with a different request",
+				})
+			default:
+				slog.ErrorContext(c.UserContext(), "Failed to check idempotency key", "error", err)
+				return c.Status(500).JSON(fiber.Map{
+					"error": "Failed to process request",
+				})
+			}
+		}
+
+		// Replay the stored response for a completed request
+		if record.IsCompleted() {
+			c.Set("Idempotent-Replayed", "true")
+			if record.ContentType != "" {
+				c.Set(fiber.HeaderContentType, record.ContentType)
+			}
+			return c.Status(record.StatusCode).Send(record.Body)
+		}
+
+		if err := c.Next(); err != nil {
+			if releaseErr := idempotencyService.Release(c.UserContext(), record.ID); releaseErr != nil {
+				slog.WarnContext(c.UserContext(), "Failed to release idempotency key", "error", releaseErr)
+			}
+			return err
+		}
+
+		// Server errors are not stored so the client can safely retry
+		statusCode := c.Response().StatusCode()
+		if statusCode >= 500 {
+			if err := idempotencyService.Release(c.UserContext(), record.ID); err != nil {
+				slog.WarnContext(c.UserContext(), "Failed to update idempotency key", "error", err)
+			}
+			return nil
+		}
+
+		contentType := string(c.Response().Header.ContentType())
+		if err := idempotencyService.Complete(c.UserContext(), record.ID, statusCode, contentType, c.Response().Body()); err != nil {
+			slog.WarnContext(c.UserContext(), "Failed to update idempotency key", "error", err)
+		}
+
+		return nil
+	}
+}
+
+// idempotencyCaller identifies the client of a request: the signed-in user, or
+// the client IP for anonymous requests
+func idempotencyCaller(c *fiber.Ctx) string {
+	if userID, ok := c.Locals("userID").(string); ok && userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + c.IP()
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	fiber "github.com/gofiber/fiber/v2"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/metrics"
+)
+
+// MetricsMiddleware records the count and latency of every request by route pattern.
+// It must be registered before the other middleware so that their time and the
+// errors they return are included.
+func MetricsMiddleware(m *metrics.Metrics) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status, fiberErr := responseStatus(c, err)
+
+		// Route strings are owned by the router and safe to keep as label values
+		route := c.Route()
+		path := route.Path
+		if fiberErr != nil && fiberErr.Code == fiber.StatusNotFound {
+			path = metrics.UnmatchedRoute
+		}
+
+		m.ObserveRequest(route.Method, path, status, time.Since(start))
+		return err
+	}
+}
+
+// responseStatus returns the status the response will have. Errors are turned into
+// responses by the error handler once the middleware chain returns.
+func responseStatus(c *fiber.Ctx, err error) (int, *fiber.Error) {
+	if err == nil {
+		return c.Response().StatusCode(), nil
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code, fiberErr
+	}
+	return fiber.StatusInternalServerError, nil
+}
+
+// MetricsTokenMiddleware protects the metrics endpoint with a static bearer token.
+// An empty token leaves the endpoint open, for deployments that restrict it on the network.
+func MetricsTokenMiddleware(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token == "" {
+			return c.Next()
+		}
+
+		provided := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			return c.Status(401).JSON(fiber.Map{
+				"error": "Invalid metrics token",
+			})
+		}
+		return c.Next()
+	}
+}
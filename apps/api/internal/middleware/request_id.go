@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"strings"
+
+	fiber "github.com/gofiber/fiber/v2"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/logging"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware assigns every request an ID, reusing a well-formed ID sent by
+// the client or a proxy. The ID is returned in the X-Request-ID header, stored in the
+// "requestID" local and carried by the user context so log records include it.
+func RequestIDMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(RequestIDHeader)
+		if validRequestID(requestID) {
+			// Header values alias the request buffer
+			requestID = strings.Clone(requestID)
+		} else {
+			requestID = newRequestID()
+		}
+
+		c.Set(RequestIDHeader, requestID)
+		c.Locals("requestID", requestID)
+		c.SetUserContext(logging.WithRequestID(c.UserContext(), requestID))
+		return c.Next()
+	}
+}
+
+// validRequestID reports whether id is short and only uses characters safe to log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random request ID
+func newRequestID() string {
+	id, err := utils.GenerateSecureToken(12)
+	if err != nil {
+		return utils.GenerateRandomString(16)
+	}
+	return id
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	fiber "github.com/gofiber/fiber/v2"
+)
+
+// RequestLogger writes an access log record for every request. Server errors are
+// logged at error level and everything else at info. It must run after
+// RequestIDMiddleware so records carry the request ID.
+func RequestLogger() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status, _ := responseStatus(c, err)
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		slog.Log(c.UserContext(), level, "HTTP request",
+			"method", c.Method(),
+			"path", c.Path(),
+			"route", c.Route().Path,
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"ip", c.IP(),
+		)
+		return err
+	}
+}
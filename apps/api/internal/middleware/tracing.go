@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"strings"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/metrics"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/tracing"
+)
+
+// headerCarrier reads trace context from the request headers
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+// Get returns the value of a request header
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+// Set is not used when extracting
+func (h headerCarrier) Set(string, string) {}
+
+// Keys is not used by the W3C propagators when extracting
+func (h headerCarrier) Keys() []string {
+	return nil
+}
+
+// TracingMiddleware starts a server span for every request, continuing the trace of
+// an incoming traceparent header. The span is carried by the user context, which
+// handlers pass on to services.
+func TracingMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c})
+
+		// Spans are exported after the request, so values backed by the request
+		// buffer must be copied
+		method := strings.Clone(c.Method())
+		ctx, span := tracing.Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", method),
+				attribute.String("url.path", strings.Clone(c.Path())),
+				attribute.String("client.address", strings.Clone(c.IP())),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		status, fiberErr := responseStatus(c, err)
+		route := c.Route().Path
+		if fiberErr != nil && fiberErr.Code == fiber.StatusNotFound {
+			route = metrics.UnmatchedRoute
+		}
+		span.SetName(method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= fiber.StatusInternalServerError {
+			if err != nil {
+				span.RecordError(err)
+			}
+			span.SetStatus(codes.Error, "")
+		}
+		return err
+	}
+}
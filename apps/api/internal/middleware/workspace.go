@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	fiber "github.com/gofiber/fiber/v2"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+)
+
+// WorkspaceHeader selects the workspace a request acts in: an organization ID,
+// or "personal" for the user's own forms, which is also the default
+const WorkspaceHeader = "X-Workspace-ID"
+
+// WorkspaceMiddleware resolves the workspace a request acts in and the user's
+// role there. It runs after AuthMiddleware; API keys act as their user.
+func WorkspaceMiddleware(authorizer *services.Authorizer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, _ := c.Locals("userID").(string)
+		if userID == "" {
+			return c.Status(401).JSON(fiber.Map{
+
+				"error": "Authentication required",
+			})
+		}
+
+		workspace := strings.TrimSpace(c.Get(WorkspaceHeader))
+		actor, err := authorizer.ResolveActor(c.UserContext(), userID, workspace)
+		if err != nil {
+			if errors.Is(err, services.ErrWorkspaceNotFound) {
+				return c.Status(404).JSON(fiber.Map{
+
+					"error": "Workspace not found",
+				})
+			}
+			slog.ErrorContext(c.UserContext(), "Failed to resolve workspace", "error", err)
+			return c.Status(500).JSON(fiber.Map{
+
+				"error": "Failed to resolve workspace",
+			})
+		}
+
+		c.Locals("actor", actor)
+		return c.Next()
+	}
+}
@@ -0,0 +1,117 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+)
+
+// namespaceNotFoundCode is the MongoDB error code for a missing collection
+const namespaceNotFoundCode = 26
+
+// All returns the migrations of this build in order. New migrations are appended
+// with the next version; applied migrations are never edited or renumbered.
+func All() []Migration {
+	return []Migration{
+		{
+			Version: 1,
+			Name:    "form_owner_ids_to_strings",
+			Up:      formOwnerIDsToStrings,
+			// The original types are not kept, and every query expects string owner IDs
+		},
+		{
+			Version: 2,
+			Name:    "forms_owner_id_validator",
+			Up:      addFormsOwnerIDValidator,
+			Down:    removeFormsOwnerIDValidator,
+		},
+		{
+			Version: 3,
+			Name:    "users_email_verified_backfill",
+			Up:      backfillUsersEmailVerified,
+			// Backfilled users cannot be told apart from users who verified later
+		},
+	}
+}
+
+// backfillUsersEmailVerified treats users created before email verification
+// existed as verified, so requiring verification does not lock them out
+func backfillUsersEmailVerified(ctx context.Context, collections *database.Collections) error {
+	_, err := collections.Users.UpdateMany(ctx,
+		bson.M{"email_verified": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"email_verified": true}},
+	)
+	return err
+}
+
+// formOwnerIDsToStrings converts owner IDs stored as ObjectIDs by early versions
+// to the hex strings that form queries match on
+func formOwnerIDsToStrings(ctx context.Context, collections *database.Collections) error {
+	_, err := collections.Forms.UpdateMany(ctx,
+		bson.M{"ownerId": bson.M{"$type": "objectId"}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"ownerId":   bson.M{"$toString": "$ownerId"},
+				"updatedAt": "$$NOW",
+			}}},
+		},
+	)
+	return err
+}
+
+// formsOwnerIDValidator rejects forms whose owner ID is not a string
+var formsOwnerIDValidator = bson.M{
+	"$jsonSchema": bson.M{
+		"properties": bson.M{
+			"ownerId": bson.M{"bsonType": "string"},
+		},
+	},
+}
+
+// addFormsOwnerIDValidator keeps owner IDs from being written as ObjectIDs again.
+// The moderate level leaves updates of existing invalid documents alone.
+func addFormsOwnerIDValidator(ctx context.Context, collections *database.Collections) error {
+	db := collections.Forms.Database()
+	name := collections.Forms.Name()
+
+	err := db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: name},
+		{Key: "validator", Value: formsOwnerIDValidator},
+		{Key: "validationLevel", Value: "moderate"},
+	}).Err()
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == namespaceNotFoundCode {
+		err = db.CreateCollection(ctx, name, options.CreateCollection().
+			SetValidator(formsOwnerIDValidator).
+			SetValidationLevel("moderate"))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set forms validator: %w", err)
+	}
+	return nil
+}
+
+// removeFormsOwnerIDValidator removes the forms validator
+func removeFormsOwnerIDValidator(ctx context.Context, collections *database.Collections) error {
+	err := collections.Forms.Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collections.Forms.Name()},
+		{Key: "validator", Value: bson.M{}},
+		{Key: "validationLevel", Value: "strict"},
+	}).Err()
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == namespaceNotFoundCode {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove forms validator: %w", err)
+	}
+	return nil
+}
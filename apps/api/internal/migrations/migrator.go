@@ -0,0 +1,330 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+const (
+	// DefaultLockTTL is how long a migration lock is held without being renewed.
+	// A replica that dies while migrating blocks others for at most this long.
+	DefaultLockTTL = time.Minute
+
+	// lockRetryInterval is how often a replica waiting for the lock tries again
+	lockRetryInterval = time.Second
+)
+
+var (
+	// ErrIrreversible is returned when rolling back a migration without a Down step
+	ErrIrreversible = errors.New("migration cannot be rolled back")
+
+	// ErrUnknownMigration is returned when rolling back a migration this build does not know
+	ErrUnknownMigration = errors.New("migration is not known to this build")
+)
+
+// Step changes the database schema or data in one direction. Steps must be
+// idempotent so that an interrupted run can be retried.
+type Step func(ctx context.Context, collections *database.Collections) error
+
+// Migration is a numbered change to the database
+type Migration struct {
+	Version int
+	Name    string
+	Up      Step
+	Down    Step // nil when the migration cannot be rolled back
+}
+
+// Record is an applied migration as stored in the schema_migrations collection
+type Record struct {
+	Version   int       `bson:"_id" json:"version"`
+	Name      string    `bson:"name" json:"name"`
+	AppliedAt time.Time `bson:"appliedAt" json:"appliedAt"`
+}
+
+// Store records applied migrations and holds the lock that serializes migration runs
+type Store interface {
+	// Applied returns the applied migrations ordered by version
+	Applied(ctx context.Context) ([]Record, error)
+	Insert(ctx context.Context, record Record) error
+	Delete(ctx context.Context, version int) error
+
+	// Lock acquires or renews the lock for owner until ttl has passed. It reports
+	// false when another owner holds an unexpired lock.
+	Lock(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, owner string) error
+}
+
+// Status is a migration and whether it has been applied
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+	Known     bool       `json:"known"` // false for migrations applied by a newer build
+}
+
+// Migrator applies and rolls back migrations, holding the store's lock while it
+// runs so that only one replica migrates at a time
+type Migrator struct {
+	store       Store
+	collections *database.Collections
+	migrations  []Migration
+	owner       string
+	lockTTL     time.Duration
+	retry       time.Duration
+}
+
+// NewMigrator creates a migrator for the given migrations, which must have unique
+// positive versions in ascending order
+func NewMigrator(store Store, collections *database.Collections, migrations []Migration) (*Migrator, error) {
+	for i, migration := range migrations {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("migration %q has invalid version %d", migration.Name, migration.Version)
+		}
+		if migration.Up == nil {
+			return nil, fmt.Errorf("migration %d has no Up step", migration.Version)
+		}
+		if i > 0 && migration.Version <= migrations[i-1].Version {
+			return nil, fmt.Errorf("migration %d is out of order after %d", migration.Version, migrations[i-1].Version)
+		}
+	}
+
+	return &Migrator{
+		store:       store,
+		collections: collections,
+		migrations:  migrations,
+		owner:       lockOwner(),
+		lockTTL:     DefaultLockTTL,
+		retry:       lockRetryInterval,
+	}, nil
+}
+
+// lockOwner identifies this process as the holder of the migration lock
+func lockOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	suffix, err := utils.GenerateSecureToken(6)
+	if err != nil {
+		suffix = utils.GenerateRandomString(8)
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), suffix)
+}
+
+// Latest returns the version of the newest migration this build knows
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// SchemaVersion returns the version of the newest applied migration, or 0 for a
+// database that was never migrated
+func (m *Migrator) SchemaVersion(ctx context.Context) (int, error) {
+	applied, err := m.store.Applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(applied) == 0 {
+		return 0, nil
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
+// Status lists every known or applied migration ordered by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.store.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Status, len(m.migrations)+len(applied))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = &Status{Version: migration.Version, Name: migration.Name, Known: true}
+	}
+	for _, record := range applied {
+		status, ok := byVersion[record.Version]
+		if !ok {
+			status = &Status{Version: record.Version, Name: record.Name}
+			byVersion[record.Version] = status
+		}
+		appliedAt := record.AppliedAt
+		status.Applied = true
+		status.AppliedAt = &appliedAt
+	}
+
+	statuses := make([]Status, 0, len(byVersion))
+	for _, status := range byVersion {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Up applies pending migrations in order, up to and including target, or all of
+// them when target is 0. It returns the migrations it applied; on error, the
+// migrations before the failing one stay applied.
+func (m *Migrator) Up(ctx context.Context, target int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		applied, err := m.appliedVersions(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if target > 0 && migration.Version > target {
+				break
+			}
+			if applied[migration.Version] {
+				continue
+			}
+
+			slog.InfoContext(ctx, "Applying migration", "version", migration.Version, "name", migration.Name)
+			if err := migration.Up(ctx, m.collections); err != nil {
+				return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+			}
+			record := Record{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+			if err := m.store.Insert(ctx, record); err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the newest steps applied migrations, newest first. It stops at
+// a migration without a Down step or one this build does not know.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		applied, err := m.store.Applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		known := make(map[int]Migration, len(m.migrations))
+		for _, migration := range m.migrations {
+			known[migration.Version] = migration
+		}
+
+		for i := len(applied) - 1; i >= 0 && len(done) < steps; i-- {
+			record := applied[i]
+			migration, ok := known[record.Version]
+			if !ok {
+				return fmt.Errorf("%w: %d (%s)", ErrUnknownMigration, record.Version, record.Name)
+			}
+			if migration.Down == nil {
+				return fmt.Errorf("%w: %d (%s)", ErrIrreversible, migration.Version, migration.Name)
+			}
+
+			slog.InfoContext(ctx, "Rolling back migration", "version", migration.Version, "name", migration.Name)
+			if err := migration.Down(ctx, m.collections); err != nil {
+				return fmt.Errorf("rollback of migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+			}
+			if err := m.store.Delete(ctx, migration.Version); err != nil {
+				return fmt.Errorf("failed to remove migration record %d: %w", migration.Version, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// appliedVersions returns the set of applied migration versions
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int]bool, error) {
+	applied, err := m.store.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[int]bool, len(applied))
+	for _, record := range applied {
+		versions[record.Version] = true
+	}
+	return versions, nil
+}
+
+// withLock runs fn while holding the migration lock. It waits for a lock held by
+// another replica and renews its own lock until fn returns.
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	if err := m.acquire(ctx); err != nil {
+		return err
+	}
+
+	renewCtx, stopRenewing := context.WithCancel(ctx)
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		m.renew(renewCtx)
+	}()
+
+	err := fn()
+
+	stopRenewing()
+	<-renewed
+
+	// Release the lock even when ctx was cancelled so that others need not wait for it to expire
+	unlockCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if unlockErr := m.store.Unlock(unlockCtx, m.owner); unlockErr != nil {
+		slog.WarnContext(ctx, "Failed to release migration lock", "error", unlockErr)
+	}
+	return err
+}
+
+// acquire waits until the migration lock is acquired or ctx is done
+func (m *Migrator) acquire(ctx context.Context) error {
+	waiting := false
+	for {
+		locked, err := m.store.Lock(ctx, m.owner, m.lockTTL)
+		if err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if locked {
+			return nil
+		}
+
+		if !waiting {
+			slog.InfoContext(ctx, "Waiting for another instance to finish migrating")
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for migration lock: %w", ctx.Err())
+		case <-time.After(m.retry):
+		}
+	}
+}
+
+// renew extends the lock well before it expires until ctx is done
+func (m *Migrator) renew(ctx context.Context) {
+	ticker := time.NewTicker(m.lockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			locked, err := m.store.Lock(ctx, m.owner, m.lockTTL)
+			if err != nil && ctx.Err() == nil {
+				slog.WarnContext(ctx, "Failed to renew migration lock", "error", err)
+			} else if err == nil && !locked {
+				slog.ErrorContext(ctx, "Migration lock was taken over by another instance")
+			}
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+)
+
+// fakeStore is an in-memory Store
+type fakeStore struct {
+	mutex     sync.Mutex
+	records   map[int]Record
+	lockOwner string
+	lockUntil time.Time
+	locks     int
+}
+
+func newFakeStore(applied ...Record) *fakeStore {
+	store := &fakeStore{records: make(map[int]Record)}
+	for _, record := range applied {
+		store.records[record.Version] = record
+	}
+	return store
+}
+
+func (s *fakeStore) Applied(ctx context.Context) ([]Record, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	records := make([]Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Version < records[j].Version })
+	return records, nil
+}
+
+func (s *fakeStore) Insert(ctx context.Context, record Record) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.records[record.Version] = record
+	return nil
+}
+
+func (s *fakeStore) Delete(ctx context.Context, version int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.records, version)
+	return nil
+}
+
+func (s *fakeStore) Lock(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.lockOwner != "" && s.lockOwner != owner && time.Now().Before(s.lockUntil) {
+		return false, nil
+	}
+	s.lockOwner, s.lockUntil = owner, time.Now().Add(ttl)
+	s.locks++
+	return true, nil
+}
+
+func (s *fakeStore) Unlock(ctx context.Context, owner string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.lockOwner == owner {
+		s.lockOwner = ""
+	}
+	return nil
+}
+
+func (s *fakeStore) versions() []int {
+	records, _ := s.Applied(context.Background())
+	versions := []int{}
+	for _, record := range records {
+		versions = append(versions, record.Version)
+	}
+	return versions
+}
+
+// journal records the steps run by test migrations
+type journal struct {
+	steps []string
+}
+
+func (j *journal) step(name string, err error) Step {
+	return func(context.Context, *database.Collections) error {
+		j.steps = append(j.steps, name)
+		return err
+	}
+}
+
+func testMigrations(j *journal) []Migration {
+	return []Migration{
+		{Version: 1, Name: "one", Up: j.step("up 1", nil), Down: j.step("down 1", nil)},
+		{Version: 2, Name: "two", Up: j.step("up 2", nil), Down: j.step("down 2", nil)},
+		{Version: 5, Name: "five", Up: j.step("up 5", nil), Down: j.step("down 5", nil)},
+	}
+}
+
+func newTestMigrator(t *testing.T, store Store, migrations []Migration) *Migrator {
+	migrator, err := NewMigrator(store, nil, migrations)
+	require.NoError(t, err)
+	migrator.retry = 5 * time.Millisecond
+	return migrator
+}
+
+func TestNewMigrator_Validation(t *testing.T) {
+	up := func(context.Context, *database.Collections) error { return nil }
+
+	tests := []struct {
+		name       string
+		migrations []Migration
+		valid      bool
+	}{
+		{name: "empty", valid: true},
+		{name: "ascending", migrations: []Migration{{Version: 1, Up: up}, {Version: 3, Up: up}}, valid: true},
+		{name: "duplicate", migrations: []Migration{{Version: 1, Up: up}, {Version: 1, Up: up}}},
+		{name: "descending", migrations: []Migration{{Version: 2, Up: up}, {Version: 1, Up: up}}},
+		{name: "zero version", migrations: []Migration{{Version: 0, Up: up}}},
+		{name: "missing up", migrations: []Migration{{Version: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMigrator(newFakeStore(), nil, tt.migrations)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestAll_IsValid(t *testing.T) {
+	migrator, err := NewMigrator(newFakeStore(), nil, All())
+	require.NoError(t, err)
+	assert.Positive(t, migrator.Latest())
+}
+
+func TestMigrator_Up(t *testing.T) {
+	j := &journal{}
+	store := newFakeStore()
+	migrator := newTestMigrator(t, store, testMigrations(j))
+
+	applied, err := migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Len(t, applied, 3)
+	assert.Equal(t, []string{"up 1", "up 2", "up 5"}, j.steps)
+	assert.Equal(t, []int{1, 2, 5}, store.versions())
+
+	version, err := migrator.SchemaVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, version)
+	assert.Equal(t, 5, migrator.Latest())
+
+	t.Run("is idempotent", func(t *testing.T) {
+		applied, err := migrator.Up(context.Background(), 0)
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.Len(t, j.steps, 3)
+	})
+
+	t.Run("releases the lock", func(t *testing.T) {
+		assert.Empty(t, store.lockOwner)
+	})
+}
+
+func TestMigrator_UpToTarget(t *testing.T) {
+	j := &journal{}
+	store := newFakeStore()
+	migrator := newTestMigrator(t, store, testMigrations(j))
+
+	_, err := migrator.Up(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, store.versions())
+
+	version, err := migrator.SchemaVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+}
+
+func TestMigrator_UpAppliesMissedMigrations(t *testing.T) {
+	j := &journal{}
+	store := newFakeStore(Record{Version: 1, Name: "one"}, Record{Version: 5, Name: "five"})
+	migrator := newTestMigrator(t, store, testMigrations(j))
+
+	_, err := migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"up 2"}, j.steps)
+}
+
+func TestMigrator_UpStopsAtFailure(t *testing.T) {
+	j := &journal{}
+	migrations := testMigrations(j)
+	migrations[1].Up = j.step("up 2", errors.New("boom"))
+	store := newFakeStore()
+	migrator := newTestMigrator(t, store, migrations)
+
+	applied, err := migrator.Up(context.Background(), 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 2 (two) failed")
+	assert.Len(t, applied, 1)
+	assert.Equal(t, []int{1}, store.versions(), "failed migrations are not recorded")
+	assert.Empty(t, store.lockOwner, "the lock is released after a failure")
+}
+
+func TestMigrator_Down(t *testing.T) {
+	j := &journal{}
+	store := newFakeStore()
+	migrator := newTestMigrator(t, store, testMigrations(j))
+	_, err := migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+	j.steps = nil
+
+	rolledBack, err := migrator.Down(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Len(t, rolledBack, 2)
+	assert.Equal(t, []string{"down 5", "down 2"}, j.steps)
+	assert.Equal(t, []int{1}, store.versions())
+
+	t.Run("stops when nothing is applied", func(t *testing.T) {
+		rolledBack, err := migrator.Down(context.Background(), 5)
+		require.NoError(t, err)
+		assert.Len(t, rolledBack, 1)
+		assert.Empty(t, store.versions())
+	})
+}
+
+func TestMigrator_DownIrreversible(t *testing.T) {
+	j := &journal{}
+	migrations := testMigrations(j)
+	migrations[1].Down = nil
+	store := newFakeStore()
+	migrator := newTestMigrator(t, store, migrations)
+	_, err := migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+
+	rolledBack, err := migrator.Down(context.Background(), 3)
+	assert.ErrorIs(t, err, ErrIrreversible)
+	assert.Len(t, rolledBack, 1)
+	assert.Equal(t, []int{1, 2}, store.versions())
+}
+
+func TestMigrator_DownUnknownMigration(t *testing.T) {
+	j := &journal{}
+	store := newFakeStore(Record{Version: 1, Name: "one"}, Record{Version: 9, Name: "newer"})
+	migrator := newTestMigrator(t, store, testMigrations(j))
+
+	_, err := migrator.Down(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrUnknownMigration)
+	assert.Empty(t, j.steps)
+	assert.Equal(t, []int{1, 9}, store.versions())
+}
+
+func TestMigrator_Status(t *testing.T) {
+	appliedAt := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	store := newFakeStore(Record{Version: 1, Name: "one", AppliedAt: appliedAt}, Record{Version: 9, Name: "newer", AppliedAt: appliedAt})
+	migrator := newTestMigrator(t, store, testMigrations(&journal{}))
+
+	statuses, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 4)
+
+	assert.Equal(t, Status{Version: 1, Name: "one", Applied: true, AppliedAt: &appliedAt, Known: true}, statuses[0])
+	assert.Equal(t, Status{Version: 2, Name: "two", Known: true}, statuses[1])
+	assert.Equal(t, Status{Version: 5, Name: "five", Known: true}, statuses[2])
+	assert.Equal(t, Status{Version: 9, Name: "newer", Applied: true, AppliedAt: &appliedAt}, statuses[3])
+}
+
+func TestMigrator_WaitsForLock(t *testing.T) {
+	j := &journal{}
+	store := newFakeStore()
+	store.lockOwner, store.lockUntil = "other-replica", time.Now().Add(time.Hour)
+	migrator := newTestMigrator(t, store, testMigrations(j))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := migrator.Up(context.Background(), 0)
+		done <- err
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	store.mutex.Lock()
+	assert.Empty(t, j.steps, "migrations wait for the lock")
+	store.mutex.Unlock()
+
+	require.NoError(t, store.Unlock(context.Background(), "other-replica"))
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("migrator did not acquire the released lock")
+	}
+	assert.Equal(t, []int{1, 2, 5}, store.versions())
+}
+
+func TestMigrator_LockWaitCancelled(t *testing.T) {
+	store := newFakeStore()
+	store.lockOwner, store.lockUntil = "other-replica", time.Now().Add(time.Hour)
+	migrator := newTestMigrator(t, store, testMigrations(&journal{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := migrator.Up(ctx, 0)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, store.versions())
+}
+
+func TestMigrator_TakesOverExpiredLock(t *testing.T) {
+	store := newFakeStore()
+	store.lockOwner, store.lockUntil = "crashed-replica", time.Now().Add(-time.Second)
+	migrator := newTestMigrator(t, store, testMigrations(&journal{}))
+
+	_, err := migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 5}, store.versions())
+}
+
+func TestMigrator_RenewsLock(t *testing.T) {
+	store := newFakeStore()
+	migrations := []Migration{{
+		Version: 1,
+		Name:    "slow",
+		Up: func(context.Context, *database.Collections) error {
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		},
+	}}
+	migrator := newTestMigrator(t, store, migrations)
+	migrator.lockTTL = 15 * time.Millisecond
+
+	_, err := migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	assert.Greater(t, store.locks, 1, "the lock is renewed while migrations run")
+}
@@ -0,0 +1,86 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+)
+
+// schemaLockID is the ID of the lock document serializing migration runs. The
+// document holds the owner and expiresAt; a lock whose expiresAt has passed is free.
+const schemaLockID = "schema"
+
+// MongoStore is a Store backed by the schema_migrations and migration_locks collections
+type MongoStore struct {
+	migrations *mongo.Collection
+	locks      *mongo.Collection
+}
+
+// NewMongoStore creates a store on the database's migration collections
+func NewMongoStore(collections *database.Collections) *MongoStore {
+	return &MongoStore{
+		migrations: collections.SchemaMigrations,
+		locks:      collections.MigrationLocks,
+	}
+}
+
+// Applied returns the applied migrations ordered by version
+func (s *MongoStore) Applied(ctx context.Context) ([]Record, error) {
+	cursor, err := s.migrations.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	var records []Record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode applied migrations: %w", err)
+	}
+	return records, nil
+}
+
+// Insert records an applied migration
+func (s *MongoStore) Insert(ctx context.Context, record Record) error {
+	_, err := s.migrations.InsertOne(ctx, record)
+	return err
+}
+
+// Delete removes the record of a rolled back migration
+func (s *MongoStore) Delete(ctx context.Context, version int) error {
+	_, err := s.migrations.DeleteOne(ctx, bson.M{"_id": version})
+	return err
+}
+
+// Lock takes the lock document when it is free or already held by owner. When
+// another owner holds it, the upsert collides with the existing document.
+func (s *MongoStore) Lock(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id": schemaLockID,
+		"$or": []bson.M{
+			{"owner": owner},
+			{"expiresAt": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": owner, "expiresAt": now.Add(ttl)}}
+
+	_, err := s.locks.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Unlock releases the lock if owner holds it
+func (s *MongoStore) Unlock(ctx context.Context, owner string) error {
+	_, err := s.locks.DeleteOne(ctx, bson.M{"_id": schemaLockID, "owner": owner})
+	return err
+}
@@ -0,0 +1,83 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+)
+
+// Development seed credentials. They are only created in the development environment.
+const (
+	SeedUserEmail    = "test@test.com"
+	SeedUserPassword = "Test@123"
+	SeedUserName     = "Test User"
+)
+
+// Seed creates development data: a user to log in with, who owns every form
+// without an owner. It is idempotent and must not run outside development.
+func Seed(ctx context.Context, collections *database.Collections) error {
+	userID, err := seedUser(ctx, collections)
+	if err != nil {
+		return err
+	}
+
+	result, err := collections.Forms.UpdateMany(ctx,
+		bson.M{"$or": []bson.M{
+			{"ownerId": bson.M{"$exists": false}},
+			{"ownerId": nil},
+			{"ownerId": ""},
+		}},
+		bson.M{"$set": bson.M{"ownerId": userID.Hex(), "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to assign ownerless forms to seed user: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Development data seeded", "user_id", userID.Hex(), "assigned_forms", result.ModifiedCount)
+	return nil
+}
+
+// seedUser returns the seed user, creating it if it does not exist
+func seedUser(ctx context.Context, collections *database.Collections) (primitive.ObjectID, error) {
+	var existing models.User
+	err := collections.Users.FindOne(ctx, bson.M{"email": SeedUserEmail}).Decode(&existing)
+	if err == nil {
+		return existing.ID, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return primitive.NilObjectID, fmt.Errorf("failed to check for seed user: %w", err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(SeedUserPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to hash seed user password: %w", err)
+	}
+
+	now := time.Now()
+	user := models.User{
+		ID:        primitive.NewObjectID(),
+		Email:     SeedUserEmail,
+		Password:  string(hashedPassword),
+		Name:      SeedUserName,
+		CreatedAt: now,
+		UpdatedAt: now,
+
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+	}
+	if _, err := collections.Users.InsertOne(ctx, user); err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to create seed user: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Created seed user", "user_id", user.ID.Hex())
+	return user.ID, nil
+}
@@ -77,6 +77,27 @@ func NewAnalyticsUpdate(formID string, analytics map[string]FieldAnalytics) *Ana
 	}
 }
 
+// AnalyticsDelta is the data of a realtime analytics:update message. Snapshots carry
+// every field; deltas carry only the fields and counters that changed since the
+// previous update from the same source. Seq increases by one per update and source,
+// so clients that see a gap wait for the next snapshot or refetch the analytics.
+type AnalyticsDelta struct {
+	Seq                   int64                     `json:"seq"`
+	Source                string                    `json:"source"`
+	Snapshot              bool                      `json:"snapshot"`
+	ByField               map[string]FieldAnalytics `json:"byField,omitempty"`
+	TotalResponses        *int                      `json:"totalResponses,omitempty"`
+	CompletionRate        *float64                  `json:"completionRate,omitempty"`
+	AverageTimeToComplete *float64                  `json:"averageTimeToComplete,omitempty"`
+	UpdatedAt             time.Time                 `json:"updatedAt"`
+}
+
+// IsEmpty reports whether a delta carries no changes
+func (d *AnalyticsDelta) IsEmpty() bool {
+	return !d.Snapshot && len(d.ByField) == 0 && d.TotalResponses == nil &&
+		d.CompletionRate == nil && d.AverageTimeToComplete == nil
+}
+
 // AnalyticsSummary represents a summary of analytics for dashboard overview
 type AnalyticsSummary struct {
 	FormID         string     `json:"formId"`
@@ -89,6 +110,7 @@ type AnalyticsSummary struct {
 // RealTimeMetrics represents real-time metrics for live updates
 type RealTimeMetrics struct {
 	ActiveUsers       int       `json:"activeUsers"`
+	ActiveFilling     int       `json:"activeFilling"`
 	ResponsesToday    int       `json:"responsesToday"`
 	ResponsesThisHour int       `json:"responsesThisHour"`
 	LastUpdate        time.Time `json:"lastUpdate"`
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKeyPrefix starts every API key, so that keys are told apart from JWTs and
+// are easy to find in leaked files
+const APIKeyPrefix = "dune_"
+
+// APIKeyScope is a permission granted to an API key
+type APIKeyScope string
+
+const (
+	ScopeFormsRead       APIKeyScope = "forms:read"       // List and read forms
+	ScopeResponsesRead   APIKeyScope = "responses:read"   // Read responses
+	ScopeResponsesExport APIKeyScope = "responses:export" // Download responses as CSV
+	ScopeAnalyticsRead   APIKeyScope = "analytics:read"   // Read analytics, metrics and trends
+)
+
+// APIKey is a long-lived credential a user creates for scripts and integrations.
+// Only a digest of the key is stored; the key itself is shown once.
+type APIKey struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id"`
+	UserID     primitive.ObjectID `json:"-" bson:"userId"`
+	Name       string             `json:"name" bson:"name"`
+	Prefix     string             `json:"prefix" bson:"prefix"` // Start of the key, to recognise it in lists
+	KeyHash    string             `json:"-" bson:"keyHash"`
+	Scopes     []APIKeyScope      `json:"scopes" bson:"scopes"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+	ExpiresAt  *time.Time         `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+	LastUsedAt *time.Time         `json:"lastUsedAt,omitempty" bson:"lastUsedAt,omitempty"`
+	LastUsedIP string             `json:"lastUsedIp,omitempty" bson:"lastUsedIp,omitempty"`
+	RevokedAt  *time.Time         `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+}
+
+// CreateAPIKeyRequest represents the request payload for creating an API key
+type CreateAPIKeyRequest struct {
+	Name      string        `json:"name" validate:"required,min=1,max=100"`
+	Scopes    []APIKeyScope `json:"scopes" validate:"required,min=1,dive,oneof=forms:read responses:read responses:export analytics:read"`
+	ExpiresAt *time.Time    `json:"expiresAt,omitempty"` // Never expires when omitted
+}
+
+// CreatedAPIKey is returned once, when an API key is created
+type CreatedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+// IsActive reports whether the key is accepted at the given time
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// HasScopes reports whether the key was granted every one of scopes
+func (k *APIKey) HasScopes(scopes ...APIKeyScope) bool {
+	for _, scope := range scopes {
+		granted := false
+		for _, s := range k.Scopes {
+			if s == scope {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKey_IsActive(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Minute)
+
+	tests := []struct {
+		name string
+		key  APIKey
+		want bool
+	}{
+		{
+			name: "Key without expiry",
+			key:  APIKey{},
+			want: true,
+		},
+		{
+			name: "Unexpired key",
+			key:  APIKey{ExpiresAt: &later},
+			want: true,
+		},
+		{
+			name: "Expired key",
+			key:  APIKey{ExpiresAt: &earlier},
+			want: false,
+		},
+		{
+			name: "Key expiring now",
+			key:  APIKey{ExpiresAt: &now},
+			want: false,
+		},
+		{
+			name: "Revoked key",
+			key:  APIKey{ExpiresAt: &later, RevokedAt: &earlier},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.key.IsActive(now))
+		})
+	}
+}
+
+func TestAPIKey_HasScopes(t *testing.T) {
+	key := APIKey{Scopes: []APIKeyScope{ScopeFormsRead, ScopeResponsesRead}}
+
+	assert.True(t, key.HasScopes(ScopeFormsRead))
+	assert.True(t, key.HasScopes(ScopeFormsRead, ScopeResponsesRead))
+	assert.False(t, key.HasScopes(ScopeResponsesExport))
+	assert.False(t, key.HasScopes(ScopeFormsRead, ScopeAnalyticsRead))
+	assert.True(t, key.HasScopes(), "no scopes are required")
+}
+
+func TestCreateAPIKeyRequest_Validation(t *testing.T) {
+	validate := validator.New()
+
+	tests := []struct {
+		name    string
+		req     CreateAPIKeyRequest
+		wantErr bool
+	}{
+		{
+			name: "Known scopes",
+			req:  CreateAPIKeyRequest{Name: "ETL", Scopes: []APIKeyScope{ScopeFormsRead, ScopeResponsesRead, ScopeResponsesExport, ScopeAnalyticsRead}},
+		},
+		{
+			name:    "Unknown scope",
+			req:     CreateAPIKeyRequest{Name: "ETL", Scopes: []APIKeyScope{"forms:write"}},
+			wantErr: true,
+		},
+		{
+			name:    "No scopes",
+			req:     CreateAPIKeyRequest{Name: "ETL", Scopes: []APIKeyScope{}},
+			wantErr: true,
+		},
+		{
+			name:    "No name",
+			req:     CreateAPIKeyRequest{Scopes: []APIKeyScope{ScopeFormsRead}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(&tt.req)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -24,6 +24,43 @@ const (
 	FieldTypeRating   FieldType = "rating"
 )
 
+// RespondentIdentity represents how respondents are identified when limiting responses
+type RespondentIdentity string
+
+const (
+	RespondentIdentityUser        RespondentIdentity = "user"
+	RespondentIdentityCookie      RespondentIdentity = "cookie"
+	RespondentIdentityFingerprint RespondentIdentity = "fingerprint"
+)
+
+// AntiAbuseSettings represents bot protection applied to public submissions
+type AntiAbuseSettings struct {
+	Enabled        bool `json:"enabled" bson:"enabled"`
+	MinFillSeconds int  `json:"minFillSeconds,omitempty" bson:"minFillSeconds,omitempty" validate:"omitempty,min=0,max=3600"`
+	ProofOfWork    bool `json:"proofOfWork" bson:"proofOfWork"`
+	PowDifficulty  int  `json:"powDifficulty,omitempty" bson:"powDifficulty,omitempty" validate:"omitempty,min=1,max=32"`
+}
+
+// FormSettings represents per-form submission settings
+type FormSettings struct {
+	LimitOneResponse   bool               `json:"limitOneResponse" bson:"limitOneResponse"`
+	RespondentIdentity RespondentIdentity `json:"respondentIdentity,omitempty" bson:"respondentIdentity,omitempty" validate:"omitempty,oneof=user cookie fingerprint"`
+	AntiAbuse          *AntiAbuseSettings `json:"antiAbuse,omitempty" bson:"antiAbuse,omitempty"`
+}
+
+// AntiAbuseEnabled reports whether submissions to the form must pass anti-abuse checks
+func (s *FormSettings) AntiAbuseEnabled() bool {
+	return s != nil && s.AntiAbuse != nil && s.AntiAbuse.Enabled
+}
+
+// EffectiveRespondentIdentity returns the identity key used to limit responses (cookie by default)
+func (s *FormSettings) EffectiveRespondentIdentity() RespondentIdentity {
+	if s == nil || s.RespondentIdentity == "" {
+		return RespondentIdentityCookie
+	}
+	return s.RespondentIdentity
+}
+
 // Option represents an option for MCQ or Checkbox fields
 type Option struct {
 	ID    string `json:"id" bson:"id" validate:"required,min=1,max=50"`
@@ -59,65 +96,81 @@ type Field struct {
 
 // Form represents a form document in MongoDB
 type Form struct {
-	ID          primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
-	OwnerID     *string            `json:"ownerId,omitempty" bson:"ownerId,omitempty"`
-	Title       string             `json:"title" bson:"title" validate:"required,min=1,max=200"`
-	Description *string            `json:"description,omitempty" bson:"description,omitempty" validate:"omitempty,max=1000"`
-	Status      FormStatus         `json:"status" bson:"status" validate:"required,oneof=draft published"`
-	ShareSlug   string             `json:"shareSlug" bson:"shareSlug" validate:"required,min=3,max=50,alphanum"`
-	Fields      []Field            `json:"fields" bson:"fields" validate:"required,min=1,max=50,dive"`
-	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+	ID             primitive.ObjectID  `json:"_id,omitempty" bson:"_id,omitempty"`
+	OwnerID        *string             `json:"ownerId,omitempty" bson:"ownerId,omitempty"`               // Creator; owns the form unless it belongs to an organization
+	OrganizationID *primitive.ObjectID `json:"organizationId,omitempty" bson:"organizationId,omitempty"` // Organization whose members share the form
+	Title          string              `json:"title" bson:"title" validate:"required,min=1,max=200"`
+	Description    *string             `json:"description,omitempty" bson:"description,omitempty" validate:"omitempty,max=1000"`
+	Status         FormStatus          `json:"status" bson:"status" validate:"required,oneof=draft published"`
+	ShareSlug      string              `json:"shareSlug" bson:"shareSlug" validate:"required,min=3,max=50,alphanum"`
+	Fields         []Field             `json:"fields" bson:"fields" validate:"required,min=1,max=50,dive"`
+	Settings       *FormSettings       `json:"settings,omitempty" bson:"settings,omitempty"`
+	CreatedAt      time.Time           `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time           `json:"updatedAt" bson:"updatedAt"`
 }
 
 // CreateFormRequest represents the request to create a new form
 type CreateFormRequest struct {
-	Title       string  `json:"title" validate:"required,min=1,max=200"`
-	Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
-	Fields      []Field `json:"fields" validate:"required,min=1,max=50,dive"`
+	Title       string        `json:"title" validate:"required,min=1,max=200"`
+	Description *string       `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Fields      []Field       `json:"fields" validate:"required,min=1,max=50,dive"`
+	Settings    *FormSettings `json:"settings,omitempty"`
 }
 
 // UpdateFormRequest represents the request to update a form
 type UpdateFormRequest struct {
-	Title       *string     `json:"title,omitempty" validate:"omitempty,min=1,max=200"`
-	Description *string     `json:"description,omitempty" validate:"omitempty,max=1000"`
-	Status      *FormStatus `json:"status,omitempty" validate:"omitempty,oneof=draft published"`
-	Fields      []Field     `json:"fields,omitempty" validate:"omitempty,min=1,max=50,dive"`
+	Title       *string       `json:"title,omitempty" validate:"omitempty,min=1,max=200"`
+	Description *string       `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Status      *FormStatus   `json:"status,omitempty" validate:"omitempty,oneof=draft published"`
+	Fields      []Field       `json:"fields,omitempty" validate:"omitempty,min=1,max=50,dive"`
+	Settings    *FormSettings `json:"settings,omitempty"`
 }
 
 // FormResponse represents the response when returning form data
 type FormResponse struct {
-	ID          string    `json:"id"`
-	OwnerID     *string   `json:"ownerId,omitempty"`
-	Title       string    `json:"title"`
-	Description *string   `json:"description,omitempty"`
-	Status      string    `json:"status"`
-	ShareSlug   string    `json:"shareSlug"`
-	Fields      []Field   `json:"fields"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID             string        `json:"id"`
+	OwnerID        *string       `json:"ownerId,omitempty"`
+	OrganizationID *string       `json:"organizationId,omitempty"`
+	Title          string        `json:"title"`
+	Description    *string       `json:"description,omitempty"`
+	Status         string        `json:"status"`
+	ShareSlug      string        `json:"shareSlug"`
+	Fields         []Field       `json:"fields"`
+	Settings       *FormSettings `json:"settings,omitempty"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	UpdatedAt      time.Time     `json:"updatedAt"`
 }
 
 // PublicFormResponse represents the public form data (without sensitive info)
 type PublicFormResponse struct {
-	ID          string  `json:"id"`
-	Title       string  `json:"title"`
-	Description *string `json:"description,omitempty"`
-	Fields      []Field `json:"fields"`
+	ID          string               `json:"id"`
+	Title       string               `json:"title"`
+	Description *string              `json:"description,omitempty"`
+	Fields      []Field              `json:"fields"`
+	Settings    *FormSettings        `json:"settings,omitempty"`
+	Challenge   *SubmissionChallenge `json:"challenge,omitempty"`
 }
 
 // ToResponse converts a Form model to FormResponse
 func (f *Form) ToResponse() *FormResponse {
+	var organizationID *string
+	if f.OrganizationID != nil {
+		id := f.OrganizationID.Hex()
+		organizationID = &id
+	}
+
 	return &FormResponse{
-		ID:          f.ID.Hex(),
-		OwnerID:     f.OwnerID,
-		Title:       f.Title,
-		Description: f.Description,
-		Status:      string(f.Status),
-		ShareSlug:   f.ShareSlug,
-		Fields:      f.Fields,
-		CreatedAt:   f.CreatedAt,
-		UpdatedAt:   f.UpdatedAt,
+		ID:             f.ID.Hex(),
+		OwnerID:        f.OwnerID,
+		OrganizationID: organizationID,
+		Title:          f.Title,
+		Description:    f.Description,
+		Status:         string(f.Status),
+		ShareSlug:      f.ShareSlug,
+		Fields:         f.Fields,
+		Settings:       f.Settings,
+		CreatedAt:      f.CreatedAt,
+		UpdatedAt:      f.UpdatedAt,
 	}
 }
 
@@ -128,5 +181,6 @@ func (f *Form) ToPublicResponse() *PublicFormResponse {
 		Title:       f.Title,
 		Description: f.Description,
 		Fields:      f.Fields,
+		Settings:    f.Settings,
 	}
 }
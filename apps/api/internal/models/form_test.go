@@ -436,11 +436,32 @@ func TestForm_ToResponse(t *testing.T) {
 		assert.NotNil(t, response)
 		assert.Equal(t, formID.Hex(), response.ID)
 		assert.Nil(t, response.OwnerID)
+		assert.Nil(t, response.OrganizationID)
 		assert.Equal(t, "Public Form", response.Title)
 		assert.Nil(t, response.Description)
 		assert.Equal(t, "draft", response.Status)
 		assert.Empty(t, response.Fields)
 	})
+
+	t.Run("Convert organization form", func(t *testing.T) {
+		ownerID := "user123"
+		organizationID := primitive.NewObjectID()
+
+		form := &Form{
+			ID:             primitive.NewObjectID(),
+			OwnerID:        &ownerID,
+			OrganizationID: &organizationID,
+			Title:          "Team Form",
+			Status:         FormStatusDraft,
+		}
+
+		response := form.ToResponse()
+
+		assert.Equal(t, "user123", *response.OwnerID)
+		if assert.NotNil(t, response.OrganizationID) {
+			assert.Equal(t, organizationID.Hex(), *response.OrganizationID)
+		}
+	})
 }
 
 func TestForm_ToPublicResponse(t *testing.T) {
@@ -519,3 +540,28 @@ func TestForm_ToPublicResponse(t *testing.T) {
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestFormSettings_EffectiveRespondentIdentity(t *testing.T) {
+	t.Run("Nil settings default to cookie", func(t *testing.T) {
+		var settings *FormSettings
+		assert.Equal(t, RespondentIdentityCookie, settings.EffectiveRespondentIdentity())
+	})
+
+	t.Run("Unset identity defaults to cookie", func(t *testing.T) {
+		settings := &FormSettings{LimitOneResponse: true}
+		assert.Equal(t, RespondentIdentityCookie, settings.EffectiveRespondentIdentity())
+	})
+
+	t.Run("Configured identity is used", func(t *testing.T) {
+		settings := &FormSettings{LimitOneResponse: true, RespondentIdentity: RespondentIdentityUser}
+		assert.Equal(t, RespondentIdentityUser, settings.EffectiveRespondentIdentity())
+	})
+
+	t.Run("Settings are included in form responses", func(t *testing.T) {
+		settings := &FormSettings{LimitOneResponse: true, RespondentIdentity: RespondentIdentityFingerprint}
+		form := &Form{ID: primitive.NewObjectID(), Title: "Poll", Settings: settings}
+
+		assert.Equal(t, settings, form.ToResponse().Settings)
+		assert.Equal(t, settings, form.ToPublicResponse().Settings)
+	})
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyStatus represents the processing state of an idempotent request
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusProcessing IdempotencyStatus = "processing"
+	IdempotencyStatusCompleted  IdempotencyStatus = "completed"
+)
+
+// IdempotencyRecord represents a stored idempotent request and its response in MongoDB
+type IdempotencyRecord struct {
+	ID          string            `json:"id" bson:"_id"`
+	Scope       string            `json:"scope" bson:"scope"`
+	RequestHash string            `json:"requestHash" bson:"requestHash"`
+	Status      IdempotencyStatus `json:"status" bson:"status"`
+	StatusCode  int               `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	ContentType string            `json:"contentType,omitempty" bson:"contentType,omitempty"`
+	Body        []byte            `json:"-" bson:"body,omitempty"`
+	CreatedAt   time.Time         `json:"createdAt" bson:"createdAt"`
+	ExpiresAt   time.Time         `json:"expiresAt" bson:"expiresAt"`
+}
+
+// IsCompleted reports whether the stored response can be replayed
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.Status == IdempotencyStatusCompleted
+}
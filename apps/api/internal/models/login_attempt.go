@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FailedLoginReason records why a login attempt failed
+type FailedLoginReason string
+
+const (
+	FailedLoginUnknownEmail  FailedLoginReason = "unknown_email"
+	FailedLoginWrongPassword FailedLoginReason = "wrong_password"
+	FailedLoginWrongCode     FailedLoginReason = "wrong_two_factor_code"
+	FailedLoginLocked        FailedLoginReason = "locked"    // Refused because the account or IP is locked
+	FailedLoginThrottled     FailedLoginReason = "throttled" // Refused because it came before the delay after the last failure
+)
+
+// LoginAttempts counts the recent failed logins of an account or client IP
+type LoginAttempts struct {
+	Key           string     `bson:"_id"` // "email:<address>" or "ip:<address>"
+	Failures      int        `bson:"failures"`
+	LastFailureAt time.Time  `bson:"lastFailureAt"`
+	LockedUntil   *time.Time `bson:"lockedUntil,omitempty"`
+	ExpiresAt     time.Time  `bson:"expiresAt"`
+}
+
+// FailedLogin is the audit record of a failed login attempt
+type FailedLogin struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id"`
+	Email     string              `json:"email" bson:"email"`
+	UserID    *primitive.ObjectID `json:"userId,omitempty" bson:"userId,omitempty"` // Set when the email belongs to a user
+	IP        string              `json:"ip" bson:"ip,omitempty"`
+	UserAgent string              `json:"userAgent" bson:"userAgent,omitempty"`
+	Reason    FailedLoginReason   `json:"reason" bson:"reason"`
+	CreatedAt time.Time           `json:"createdAt" bson:"createdAt"`
+	ExpiresAt time.Time           `json:"-" bson:"expiresAt"`
+}
+
+// SetClient records the device the attempt came from
+func (f *FailedLogin) SetClient(client *ClientInfo) {
+	if client == nil {
+		return
+	}
+	f.IP = client.IP
+	f.UserAgent = client.userAgent()
+}
+
+// IsLocked reports whether logins are refused at the given time
+func (a *LoginAttempts) IsLocked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAttempts_IsLocked(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	lockedUntil := now.Add(time.Minute)
+	expired := now
+
+	assert.False(t, (&LoginAttempts{Failures: 3}).IsLocked(now))
+	assert.True(t, (&LoginAttempts{Failures: 5, LockedUntil: &lockedUntil}).IsLocked(now))
+	assert.False(t, (&LoginAttempts{Failures: 5, LockedUntil: &expired}).IsLocked(now))
+}
+
+func TestFailedLogin_SetClient(t *testing.T) {
+	t.Run("Long user agents are cut", func(t *testing.T) {
+		var record FailedLogin
+		record.SetClient(&ClientInfo{IP: "203.0.113.7", UserAgent: strings.Repeat("a", 1000)})
+		assert.Equal(t, "203.0.113.7", record.IP)
+		assert.Len(t, record.UserAgent, maxUserAgentLength)
+	})
+
+	t.Run("Unknown client", func(t *testing.T) {
+		var record FailedLogin
+		record.SetClient(nil)
+		assert.Empty(t, record.IP)
+		assert.Empty(t, record.UserAgent)
+	})
+}
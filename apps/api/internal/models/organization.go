@@ -0,0 +1,121 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PersonalWorkspace selects the forms a user owns outside any organization
+const PersonalWorkspace = "personal"
+
+// OrganizationRole is what a member may do in an organization
+type OrganizationRole string
+
+const (
+	RoleOwner  OrganizationRole = "owner"  // Everything, including renaming and deleting the organization
+	RoleAdmin  OrganizationRole = "admin"  // Manage forms and members
+	RoleEditor OrganizationRole = "editor" // Create, edit, publish and delete forms
+	RoleViewer OrganizationRole = "viewer" // Read forms, responses and analytics
+)
+
+// Organization is a workspace whose forms are shared by its members
+type Organization struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Name      string             `json:"name" bson:"name"`
+	CreatedBy primitive.ObjectID `json:"createdBy" bson:"createdBy"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+
+	// OwnerCount counts the owner memberships. It is never above the real count,
+	// so updates conditional on it cannot remove the last owner.
+	OwnerCount int `json:"-" bson:"ownerCount"`
+	// FormCount counts the forms of the organization. It is never below the real
+	// count, so deleting only while it is zero cannot leave forms behind.
+	FormCount int `json:"-" bson:"formCount"`
+}
+
+// Membership grants a user a role in an organization
+type Membership struct {
+	ID             primitive.ObjectID `json:"-" bson:"_id"`
+	OrganizationID primitive.ObjectID `json:"organizationId" bson:"organizationId"`
+	UserID         primitive.ObjectID `json:"userId" bson:"userId"`
+	Role           OrganizationRole   `json:"role" bson:"role"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// Invitation offers a role in an organization to an email address. The member is
+// only added once the invited user accepts with the token emailed to them.
+type Invitation struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	OrganizationID primitive.ObjectID `json:"organizationId" bson:"organizationId"`
+	Email          string             `json:"email" bson:"email"` // Lower case
+	Role           OrganizationRole   `json:"role" bson:"role"`
+	TokenHash      string             `json:"-" bson:"tokenHash"` // SHA-256 of the emailed token
+	InvitedBy      primitive.ObjectID `json:"invitedBy" bson:"invitedBy"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+	ExpiresAt      time.Time          `json:"expiresAt" bson:"expiresAt"` // Removed by a TTL index
+}
+
+// CreateOrganizationRequest represents the request payload for creating an organization
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+// UpdateOrganizationRequest represents the request payload for renaming an organization
+type UpdateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+// InviteMemberRequest represents the request payload for inviting someone to an organization
+type InviteMemberRequest struct {
+	Email string           `json:"email" validate:"required,email"`
+	Role  OrganizationRole `json:"role" validate:"required,oneof=owner admin editor viewer"`
+}
+
+// AcceptInvitationRequest represents the request payload for accepting an invitation
+type AcceptInvitationRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// UpdateMemberRequest represents the request payload for changing a member's role
+type UpdateMemberRequest struct {
+	Role OrganizationRole `json:"role" validate:"required,oneof=owner admin editor viewer"`
+}
+
+// TransferFormRequest represents the request payload for moving a personal form into an organization
+type TransferFormRequest struct {
+	OrganizationID string `json:"organizationId" validate:"required,len=24,hexadecimal"`
+}
+
+// OrganizationResponse is an organization as seen by one of its members
+type OrganizationResponse struct {
+	*Organization
+	Role OrganizationRole `json:"role"` // Role of the requesting user
+}
+
+// MemberResponse is a member of an organization
+type MemberResponse struct {
+	UserID   string           `json:"userId"`
+	Email    string           `json:"email"`
+	Name     string           `json:"name"`
+	Role     OrganizationRole `json:"role"`
+	JoinedAt time.Time        `json:"joinedAt"`
+}
+
+// Rank orders roles by how much they allow, so that members cannot grant or
+// manage roles above their own. Unknown roles rank lowest.
+func (r OrganizationRole) Rank() int {
+	switch r {
+	case RoleOwner:
+		return 4
+	case RoleAdmin:
+		return 3
+	case RoleEditor:
+		return 2
+	case RoleViewer:
+		return 1
+	}
+	return 0
+}
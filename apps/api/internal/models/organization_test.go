@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationRole_Rank(t *testing.T) {
+	assert.Greater(t, RoleOwner.Rank(), RoleAdmin.Rank())
+	assert.Greater(t, RoleAdmin.Rank(), RoleEditor.Rank())
+	assert.Greater(t, RoleEditor.Rank(), RoleViewer.Rank())
+	assert.Greater(t, RoleViewer.Rank(), OrganizationRole("guest").Rank())
+}
+
+func TestInviteMemberRequest_Validation(t *testing.T) {
+	validate := validator.New()
+
+	tests := []struct {
+		name  string
+		req   InviteMemberRequest
+		valid bool
+	}{
+		{name: "Editor", req: InviteMemberRequest{Email: "ana@example.com", Role: RoleEditor}, valid: true},
+		{name: "Owner", req: InviteMemberRequest{Email: "ana@example.com", Role: RoleOwner}, valid: true},
+		{name: "Unknown role", req: InviteMemberRequest{Email: "ana@example.com", Role: "guest"}},
+		{name: "Missing role", req: InviteMemberRequest{Email: "ana@example.com"}},
+		{name: "Invalid email", req: InviteMemberRequest{Email: "ana", Role: RoleViewer}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(&tt.req)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestTransferFormRequest_Validation(t *testing.T) {
+	validate := validator.New()
+
+	assert.NoError(t, validate.Struct(&TransferFormRequest{OrganizationID: "507f1f77bcf86cd799439011"}))
+	assert.Error(t, validate.Struct(&TransferFormRequest{OrganizationID: "personal"}))
+	assert.Error(t, validate.Struct(&TransferFormRequest{}))
+}
+
+func TestAcceptInvitationRequest_Validation(t *testing.T) {
+	validate := validator.New()
+
+	assert.NoError(t, validate.Struct(&AcceptInvitationRequest{Token: "token"}))
+	assert.Error(t, validate.Struct(&AcceptInvitationRequest{}))
+}
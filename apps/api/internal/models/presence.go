@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PresenceState represents what a respondent is doing on a public form
+type PresenceState string
+
+const (
+	PresenceViewing PresenceState = "viewing"
+	PresenceFilling PresenceState = "filling"
+	PresenceLeft    PresenceState = "left"
+)
+
+// PresenceHeartbeatRequest is sent periodically by an open public form page
+type PresenceHeartbeatRequest struct {
+	SessionID string        `json:"sessionId" validate:"required,min=16,max=64,alphanum"`
+	State     PresenceState `json:"state" validate:"required,oneof=viewing filling left"`
+}
+
+// PresenceSession is a respondent session that currently has a form open
+type PresenceSession struct {
+	ID        string             `json:"-" bson:"_id"`
+	FormID    primitive.ObjectID `json:"formId" bson:"formId"`
+	State     PresenceState      `json:"state" bson:"state"`
+	LastSeen  time.Time          `json:"lastSeen" bson:"lastSeen"`
+	ExpiresAt time.Time          `json:"-" bson:"expiresAt"`
+}
+
+// PresenceCounts is the live audience of a form, pushed to dashboards as presence:update
+type PresenceCounts struct {
+	FormID    string    `json:"formId"`
+	Active    int       `json:"active"`  // Respondents with the form open
+	Filling   int       `json:"filling"` // Active respondents that started answering
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SameAs reports whether two counts describe the same audience
+func (p *PresenceCounts) SameAs(other *PresenceCounts) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return p.Active == other.Active && p.Filling == other.Filling
+}
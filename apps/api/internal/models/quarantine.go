@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RejectionReason represents why a submission failed anti-abuse checks
+type RejectionReason string
+
+const (
+	RejectionMissingToken RejectionReason = "missing_token"
+	RejectionInvalidToken RejectionReason = "invalid_token"
+	RejectionTokenExpired RejectionReason = "token_expired"
+	RejectionTokenReused  RejectionReason = "token_reused"
+	RejectionHoneypot     RejectionReason = "honeypot_filled"
+	RejectionTooFast      RejectionReason = "too_fast"
+	RejectionPowMissing   RejectionReason = "pow_missing"
+	RejectionPowInvalid   RejectionReason = "pow_invalid"
+)
+
+// SubmissionChallenge is issued with a public form and must be echoed back on submit.
+// Clients render a hidden input named HoneypotField, submit it as an answer when it is not
+// empty and, when PowDifficulty is set, find a nonce such that SHA-256(token + ":" + nonce)
+// has PowDifficulty leading zero bits.
+type SubmissionChallenge struct {
+	Token         string `json:"token"`
+	HoneypotField string `json:"honeypotField"`
+	PowDifficulty int    `json:"powDifficulty,omitempty"`
+}
+
+// ChallengeSolution represents the client's answer to a submission challenge
+type ChallengeSolution struct {
+	Token string `json:"token"`
+	Nonce string `json:"nonce,omitempty"`
+}
+
+// QuarantinedResponse represents a rejected submission kept for review in MongoDB
+type QuarantinedResponse struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	FormID     primitive.ObjectID `json:"formId" bson:"formId"`
+	Reason     RejectionReason    `json:"reason" bson:"reason"`
+	Answers    []Answer           `json:"answers" bson:"answers"`
+	Meta       *ResponseMeta      `json:"meta,omitempty" bson:"meta,omitempty"`
+	ReceivedAt time.Time          `json:"receivedAt" bson:"receivedAt"`
+}
@@ -21,17 +21,50 @@ type ResponseMeta struct {
 
 // Response represents a form response document in MongoDB
 type Response struct {
-	ID          primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
-	FormID      primitive.ObjectID `json:"formId" bson:"formId" validate:"required"`
-	Answers     []Answer           `json:"answers" bson:"answers" validate:"required,min=1,dive"`
-	SubmittedAt time.Time          `json:"submittedAt" bson:"submittedAt"`
-	Meta        *ResponseMeta      `json:"meta,omitempty" bson:"meta,omitempty"`
+	ID            primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	FormID        primitive.ObjectID `json:"formId" bson:"formId" validate:"required"`
+	Answers       []Answer           `json:"answers" bson:"answers" validate:"required,min=1,dive"`
+	SubmittedAt   time.Time          `json:"submittedAt" bson:"submittedAt"`
+	Meta          *ResponseMeta      `json:"meta,omitempty" bson:"meta,omitempty"`
+	RespondentKey *string            `json:"-" bson:"respondentKey,omitempty"`
+}
+
+// Respondent holds the identity candidates of the person submitting a response
+type Respondent struct {
+	UserID      string
+	Token       string
+	Fingerprint string
+}
+
+// Key returns the respondent key for the given identity kind, or false if it cannot be resolved
+func (r *Respondent) Key(identity RespondentIdentity) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+
+	switch identity {
+	case RespondentIdentityUser:
+		if r.UserID != "" {
+			return "user:" + r.UserID, true
+		}
+	case RespondentIdentityCookie:
+		if r.Token != "" {
+			return "cookie:" + r.Token, true
+		}
+	case RespondentIdentityFingerprint:
+		if r.Fingerprint != "" {
+			return "fingerprint:" + r.Fingerprint, true
+		}
+	}
+
+	return "", false
 }
 
 // SubmitResponseRequest represents the request to submit a form response
 type SubmitResponseRequest struct {
-	Answers []Answer      `json:"answers" validate:"required,min=1,dive"`
-	Meta    *ResponseMeta `json:"meta,omitempty"`
+	Answers   []Answer           `json:"answers" validate:"required,min=1,dive"`
+	Meta      *ResponseMeta      `json:"meta,omitempty"`
+	Challenge *ChallengeSolution `json:"challenge,omitempty"`
 }
 
 // ResponseData represents the response when returning response data
@@ -41,23 +74,41 @@ type ResponseData struct {
 	Answers     []Answer      `json:"answers"`
 	SubmittedAt time.Time     `json:"submittedAt"`
 	Meta        *ResponseMeta `json:"meta,omitempty"`
+
+	// RespondentKey is set when the form is limited to one response per respondent
+	RespondentKey *string `json:"-"`
 }
 
 // ToResponseData converts a Response model to ResponseData
 func (r *Response) ToResponseData() *ResponseData {
 	return &ResponseData{
-		ID:          r.ID.Hex(),
-		FormID:      r.FormID.Hex(),
-		Answers:     r.Answers,
-		SubmittedAt: r.SubmittedAt,
-		Meta:        r.Meta,
+		ID:            r.ID.Hex(),
+		FormID:        r.FormID.Hex(),
+		Answers:       r.Answers,
+		SubmittedAt:   r.SubmittedAt,
+		Meta:          r.Meta,
+		RespondentKey: r.RespondentKey,
 	}
 }
 
+// Validation failure reasons, used to count failures without the field specific message
+const (
+	ValidationReasonRequired      = "required"
+	ValidationReasonEmpty         = "empty"
+	ValidationReasonUnknownField  = "unknown_field"
+	ValidationReasonMinLength     = "min_length"
+	ValidationReasonMaxLength     = "max_length"
+	ValidationReasonMin           = "min"
+	ValidationReasonMax           = "max"
+	ValidationReasonInvalidValue  = "invalid_value"
+	ValidationReasonInvalidOption = "invalid_option"
+)
+
 // ValidationError represents a field validation error
 type ValidationError struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
+	Reason  string `json:"-"`
 }
 
 // SubmitResponseResponse represents the response after submitting a form
@@ -43,6 +43,16 @@ func TestResponse_ToResponseData(t *testing.T) {
 		assert.Equal(t, testAnswers, data.Answers)
 		assert.Equal(t, submittedAt, data.SubmittedAt)
 		assert.Equal(t, testMeta, data.Meta)
+		assert.Nil(t, data.RespondentKey)
+	})
+
+	t.Run("Convert limited response keeps the respondent key", func(t *testing.T) {
+		key := "cookie:token"
+		limited := &Response{ID: responseID, FormID: formID, Answers: testAnswers, RespondentKey: &key}
+
+		data := limited.ToResponseData()
+
+		assert.Equal(t, &key, data.RespondentKey)
 	})
 
 	t.Run("Convert response with nil meta", func(t *testing.T) {
@@ -241,3 +251,35 @@ func TestSubmitResponseResponse_Structure(t *testing.T) {
 func responseStringPtr(s string) *string {
 	return &s
 }
+
+func TestRespondent_Key(t *testing.T) {
+	respondent := &Respondent{
+		UserID:      "user123",
+		Token:       "token456",
+		Fingerprint: "abc789",
+	}
+
+	tests := []struct {
+		name       string
+		respondent *Respondent
+		identity   RespondentIdentity
+		expected   string
+		ok         bool
+	}{
+		{"User identity", respondent, RespondentIdentityUser, "user:user123", true},
+		{"Cookie identity", respondent, RespondentIdentityCookie, "cookie:token456", true},
+		{"Fingerprint identity", respondent, RespondentIdentityFingerprint, "fingerprint:abc789", true},
+		{"Anonymous user", &Respondent{Token: "token456"}, RespondentIdentityUser, "", false},
+		{"Missing cookie token", &Respondent{UserID: "user123"}, RespondentIdentityCookie, "", false},
+		{"Unknown identity", respondent, RespondentIdentity("email"), "", false},
+		{"Nil respondent", nil, RespondentIdentityCookie, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := tt.respondent.Key(tt.identity)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, key)
+		})
+	}
+}
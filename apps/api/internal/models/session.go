@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SessionRevokeReason records why a session was revoked
+type SessionRevokeReason string
+
+const (
+	SessionRevokedLogout    SessionRevokeReason = "logout"
+	SessionRevokedLogoutAll SessionRevokeReason = "logout_all"
+	SessionRevokedReuse     SessionRevokeReason = "token_reuse"
+	SessionRevokedByUser    SessionRevokeReason = "revoked"
+)
+
+// maxUserAgentLength bounds the user agent stored with a session
+const maxUserAgentLength = 512
+
+// ClientInfo describes the device a session is used from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// userAgent returns the user agent cut to the length stored in the database
+func (c *ClientInfo) userAgent() string {
+	if len(c.UserAgent) > maxUserAgentLength {
+		return c.UserAgent[:maxUserAgentLength]
+	}
+	return c.UserAgent
+}
+
+// Session is a signed-in device. Its refresh tokens form one family: every
+// refresh replaces TokenID, the jti of the only refresh token still accepted.
+type Session struct {
+	ID            primitive.ObjectID  `json:"id" bson:"_id"`
+	UserID        primitive.ObjectID  `json:"-" bson:"userId"`
+	TokenID       string              `json:"-" bson:"tokenId"`
+	UserAgent     string              `json:"userAgent" bson:"userAgent,omitempty"`
+	IP            string              `json:"ip" bson:"ip,omitempty"`
+	CreatedAt     time.Time           `json:"createdAt" bson:"createdAt"`
+	LastUsedAt    time.Time           `json:"lastUsedAt" bson:"lastUsedAt"`
+	ExpiresAt     time.Time           `json:"expiresAt" bson:"expiresAt"`
+	RevokedAt     *time.Time          `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+	RevokedReason SessionRevokeReason `json:"revokedReason,omitempty" bson:"revokedReason,omitempty"`
+}
+
+// SessionResponse is a session as shown to its user
+type SessionResponse struct {
+	ID         primitive.ObjectID `json:"id"`
+	UserAgent  string             `json:"userAgent"`
+	IP         string             `json:"ip"`
+	CreatedAt  time.Time          `json:"createdAt"`
+	LastUsedAt time.Time          `json:"lastUsedAt"`
+	ExpiresAt  time.Time          `json:"expiresAt"`
+	Current    bool               `json:"current"` // The session of the access token making the request
+}
+
+// SetClient records the device the session was last used from
+func (s *Session) SetClient(client *ClientInfo) {
+	if client == nil {
+		return
+	}
+	s.IP = client.IP
+	s.UserAgent = client.userAgent()
+}
+
+// ToSessionResponse converts a Session to a SessionResponse. currentID is the
+// session of the requesting access token.
+func (s *Session) ToSessionResponse(currentID string) *SessionResponse {
+	return &SessionResponse{
+		ID:         s.ID,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    currentID != "" && s.ID.Hex() == currentID,
+	}
+}
+
+// IsActive reports whether the session can still be refreshed at the given time
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSession_IsActive(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	revokedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		session Session
+		want    bool
+	}{
+		{
+			name:    "Unexpired session",
+			session: Session{ExpiresAt: now.Add(time.Hour)},
+			want:    true,
+		},
+		{
+			name:    "Expired session",
+			session: Session{ExpiresAt: now.Add(-time.Second)},
+			want:    false,
+		},
+		{
+			name:    "Session expiring now",
+			session: Session{ExpiresAt: now},
+			want:    false,
+		},
+		{
+			name:    "Revoked session",
+			session: Session{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt, RevokedReason: SessionRevokedLogout},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.session.IsActive(now))
+		})
+	}
+}
+
+func TestSession_SetClient(t *testing.T) {
+	t.Run("Records the client", func(t *testing.T) {
+		session := &Session{}
+		session.SetClient(&ClientInfo{IP: "203.0.113.7", UserAgent: "Mozilla/5.0"})
+
+		assert.Equal(t, "203.0.113.7", session.IP)
+		assert.Equal(t, "Mozilla/5.0", session.UserAgent)
+	})
+
+	t.Run("Truncates long user agents", func(t *testing.T) {
+		session := &Session{}
+		session.SetClient(&ClientInfo{UserAgent: strings.Repeat("a", maxUserAgentLength+100)})
+
+		assert.Len(t, session.UserAgent, maxUserAgentLength)
+	})
+
+	t.Run("Keeps the previous client without one", func(t *testing.T) {
+		session := &Session{IP: "203.0.113.7", UserAgent: "Mozilla/5.0"}
+		session.SetClient(nil)
+
+		assert.Equal(t, "203.0.113.7", session.IP)
+		assert.Equal(t, "Mozilla/5.0", session.UserAgent)
+	})
+}
+
+func TestSession_ToSessionResponse(t *testing.T) {
+	session := &Session{
+		ID:         primitive.NewObjectID(),
+		UserID:     primitive.NewObjectID(),
+		TokenID:    "secret-token-id",
+		UserAgent:  "Mozilla/5.0",
+		IP:         "203.0.113.7",
+		CreatedAt:  time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		LastUsedAt: time.Date(2024, 1, 16, 8, 0, 0, 0, time.UTC),
+		ExpiresAt:  time.Date(2024, 1, 23, 8, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("Current session", func(t *testing.T) {
+		response := session.ToSessionResponse(session.ID.Hex())
+
+		assert.Equal(t, session.ID, response.ID)
+		assert.Equal(t, session.UserAgent, response.UserAgent)
+		assert.Equal(t, session.IP, response.IP)
+		assert.Equal(t, session.CreatedAt, response.CreatedAt)
+		assert.Equal(t, session.LastUsedAt, response.LastUsedAt)
+		assert.Equal(t, session.ExpiresAt, response.ExpiresAt)
+		assert.True(t, response.Current)
+	})
+
+	t.Run("Other session", func(t *testing.T) {
+		assert.False(t, session.ToSessionResponse(primitive.NewObjectID().Hex()).Current)
+	})
+
+	t.Run("Access token without session", func(t *testing.T) {
+		assert.False(t, session.ToSessionResponse("").Current)
+	})
+}
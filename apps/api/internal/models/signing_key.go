@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// SigningKey is a key pair that signs access and refresh tokens. Keys are published
+// before they start signing and kept after the next key takes over, until the
+// last token they signed has expired.
+type SigningKey struct {
+	ID          string     `bson:"_id"`        // Key ID (kid) in token headers and the JWKS
+	Algorithm   string     `bson:"algorithm"`  // RS256 or EdDSA
+	PrivateKey  string     `bson:"privateKey"` // AES-GCM encrypted PKCS #8 private key
+	CreatedAt   time.Time  `bson:"createdAt"`
+	ActivatesAt time.Time  `bson:"activatesAt"`         // Signs tokens from then until the next key activates
+	ExpiresAt   *time.Time `bson:"expiresAt,omitempty"` // Set once a successor is created; removed by a TTL index
+}
+
+// JSONWebKey is a public key in a JWK Set (RFC 7517)
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet lists the keys that verify tokens issued by the API
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
@@ -14,6 +14,31 @@ type User struct {
 	Name      string             `bson:"name" json:"name"`
 	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
 	UpdatedAt time.Time          `bson:"updated_at" json:"updatedAt"`
+
+	EmailVerified   bool       `bson:"email_verified" json:"emailVerified"`
+	EmailVerifiedAt *time.Time `bson:"email_verified_at,omitempty" json:"emailVerifiedAt,omitempty"`
+
+	TwoFactor *TwoFactor `bson:"two_factor,omitempty" json:"-"`
+
+	// Identity at the single sign-on provider, set once the user signed in with it
+	OIDCIssuer  string `bson:"oidc_issuer,omitempty" json:"-"`
+	OIDCSubject string `bson:"oidc_subject,omitempty" json:"-"`
+}
+
+// TwoFactor holds a user's TOTP two-factor authentication state. Secrets are
+// stored encrypted and recovery codes as SHA-256 digests.
+type TwoFactor struct {
+	Enabled       bool       `bson:"enabled"`
+	Secret        string     `bson:"secret,omitempty"`         // Confirmed secret
+	PendingSecret string     `bson:"pending_secret,omitempty"` // Secret of an enrollment awaiting its first code
+	RecoveryCodes []string   `bson:"recovery_codes,omitempty"` // Digests of unused recovery codes
+	LastUsedStep  int64      `bson:"last_used_step"`           // Time step of the last accepted code, which cannot be used again
+	EnabledAt     *time.Time `bson:"enabled_at,omitempty"`
+}
+
+// TwoFactorEnabled reports whether logins require a second factor
+func (u *User) TwoFactorEnabled() bool {
+	return u.TwoFactor != nil && u.TwoFactor.Enabled
 }
 
 // CreateUserRequest represents the request payload for user registration
@@ -29,11 +54,15 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
-// AuthResponse represents the response after successful authentication
+// AuthResponse represents the response after successful authentication. When the
+// user has two-factor authentication enabled, login only returns TwoFactorToken,
+// which is exchanged for the other fields together with a code.
 type AuthResponse struct {
-	User         *UserResponse `json:"user"`
-	AccessToken  string        `json:"accessToken"`
-	RefreshToken string        `json:"refreshToken"`
+	User              *UserResponse `json:"user,omitempty"`
+	AccessToken       string        `json:"accessToken,omitempty"`
+	RefreshToken      string        `json:"refreshToken,omitempty"`
+	TwoFactorRequired bool          `json:"twoFactorRequired,omitempty"`
+	TwoFactorToken    string        `json:"twoFactorToken,omitempty"`
 }
 
 // RefreshTokenRequest represents the request payload for token refresh
@@ -41,22 +70,84 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refreshToken" validate:"required"`
 }
 
+// ForgotPasswordRequest represents the request payload for a password reset email
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest represents the request payload for setting a new password with a reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+// ResendVerificationRequest represents the request payload for a new verification email
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// VerifyEmailRequest represents the request payload for confirming an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// OIDCExchangeRequest represents the request payload for redeeming the ticket of a
+// single sign-on login
+type OIDCExchangeRequest struct {
+	Ticket string `json:"ticket" validate:"required"`
+}
+
+// TwoFactorLoginRequest represents the second step of a login with two-factor
+// authentication. Code is a current TOTP code or an unused recovery code.
+type TwoFactorLoginRequest struct {
+	TwoFactorToken string `json:"twoFactorToken" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+// TwoFactorCodeRequest represents the request payload for confirming a two-factor enrollment
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// DisableTwoFactorRequest represents the request payload for turning two-factor
+// authentication off, which requires the password and a code
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// TwoFactorSetup is returned when two-factor enrollment starts. The secret is
+// shown for manual entry; the URI is usually rendered as a QR code.
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+// TwoFactorRecoveryCodes is returned once, when two-factor authentication is enabled
+type TwoFactorRecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
 // UserResponse represents a safe user response (without password)
 type UserResponse struct {
-	ID        primitive.ObjectID `json:"id"`
-	Email     string             `json:"email"`
-	Name      string             `json:"name"`
-	CreatedAt time.Time          `json:"createdAt"`
-	UpdatedAt time.Time          `json:"updatedAt"`
+	ID               primitive.ObjectID `json:"id"`
+	Email            string             `json:"email"`
+	Name             string             `json:"name"`
+	EmailVerified    bool               `json:"emailVerified"`
+	TwoFactorEnabled bool               `json:"twoFactorEnabled"`
+	CreatedAt        time.Time          `json:"createdAt"`
+	UpdatedAt        time.Time          `json:"updatedAt"`
 }
 
 // ToUserResponse converts a User to UserResponse (safe for API responses)
 func (u *User) ToUserResponse() *UserResponse {
 	return &UserResponse{
-		ID:        u.ID,
-		Email:     u.Email,
-		Name:      u.Name,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:               u.ID,
+		Email:            u.Email,
+		Name:             u.Name,
+		EmailVerified:    u.EmailVerified,
+		TwoFactorEnabled: u.TwoFactorEnabled(),
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
 	}
 }
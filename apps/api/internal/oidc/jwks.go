@@ -0,0 +1,82 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// jsonWebKeySet is a JWK Set (RFC 7517) as published at the provider's jwks_uri
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// jsonWebKey holds the members of RSA and EC public keys
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKeys returns the signing keys of the set by key ID. Encryption keys and
+// keys of unsupported types are skipped.
+func (s *jsonWebKeySet) publicKeys() map[string]interface{} {
+	keys := make(map[string]interface{}, len(s.Keys))
+	for _, jwk := range s.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key := jwk.publicKey(); key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys
+}
+
+// publicKey decodes the key, or returns nil if it is malformed or unsupported
+func (k *jsonWebKey) publicKey() interface{} {
+	switch k.Kty {
+	case "RSA":
+		n, okN := decodeInt(k.N)
+		e, okE := decodeInt(k.E)
+		if !okN || !okE || !e.IsInt64() || e.Int64() < 3 {
+			return nil
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil
+		}
+		x, okX := decodeInt(k.X)
+		y, okY := decodeInt(k.Y)
+		if !okX || !okY || !curve.IsOnCurve(x, y) {
+			return nil
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	}
+	return nil
+}
+
+// decodeInt decodes a base64url big-endian integer
+func decodeInt(value string) (*big.Int, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, false
+	}
+	return new(big.Int).SetBytes(b), true
+}
@@ -0,0 +1,365 @@
+// Package oidc implements the parts of OpenID Connect the API needs to sign users
+// in with an identity provider: discovery, the authorization code flow with PKCE
+// (RFC 7636) and ID token verification against the provider's published keys.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+var (
+	// ErrInvalidIDToken is returned for ID tokens with a bad signature, issuer,
+	// audience, expiry or nonce
+	ErrInvalidIDToken = errors.New("invalid ID token")
+	// ErrExchangeFailed is returned when the provider refuses an authorization code
+	ErrExchangeFailed = errors.New("authorization code exchange failed")
+)
+
+const (
+	// verifierBytes gives PKCE code verifiers 43 characters, the minimum length
+	verifierBytes = 32
+	// keyRefreshInterval bounds how often unknown key IDs make the keys be fetched again
+	keyRefreshInterval = time.Minute
+	// clockSkew is the leeway for the time claims of ID tokens
+	clockSkew = time.Minute
+	// maxResponseSize bounds documents read from the provider
+	maxResponseSize = 1 << 20
+)
+
+// signingMethods are the ID token algorithms accepted. Symmetric algorithms and
+// "none" are refused.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// Config configures a provider
+type Config struct {
+	Issuer       string       // Issuer URL, where /.well-known/openid-configuration is found
+	ClientID     string       // Client registered with the provider
+	ClientSecret string       // Empty for public clients, which rely on PKCE alone
+	RedirectURL  string       // Callback registered with the provider
+	Scopes       []string     // Requested scopes, "openid" is always included
+	HTTPClient   *http.Client // Defaults to a client with a 10 second timeout
+}
+
+// Metadata is the subset of the provider's discovery document the flow uses
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims are the verified claims of an ID token
+type Claims struct {
+	Email         string `json:"email"`
+	EmailVerified Bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Nonce         string `json:"nonce"`
+	AuthorizedBy  string `json:"azp,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Bool decodes claims that providers send as either a boolean or a string
+type Bool bool
+
+// UnmarshalJSON accepts true, false, "true" and "false"
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = Bool(v)
+	case string:
+		*b = Bool(strings.EqualFold(v, "true"))
+	default:
+		*b = false
+	}
+	return nil
+}
+
+// Provider is an OpenID Connect identity provider. Its discovery document and keys
+// are fetched on first use and cached.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+
+	mu            sync.Mutex
+	metadata      *Metadata
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// New creates a provider
+func New(cfg Config) *Provider {
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{
+		cfg:    cfg,
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// AuthCodeURL returns the URL to send the user to. state and nonce are opaque
+// values checked on return; verifier is the PKCE code verifier kept by the client.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.cfg.ClientID)
+	params.Set("redirect_uri", p.cfg.RedirectURL)
+	params.Set("scope", p.scope())
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", Challenge(verifier))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the verified claims of the
+// ID token, which must carry nonce
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.do(req, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || tokens.IDToken == "" {
+		if tokens.Error != "" {
+			return nil, fmt.Errorf("%w: %s %s", ErrExchangeFailed, tokens.Error, tokens.ErrorDescription)
+		}
+		return nil, fmt.Errorf("%w: status %d", ErrExchangeFailed, status)
+	}
+
+	return p.VerifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+// VerifyIDToken checks an ID token's signature, issuer, audience, expiry and nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, rawToken, nonce string) (*Claims, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	_, err = jwt.ParseWithClaims(rawToken, &claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return p.key(ctx, kid)
+		},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+		jwt.WithTimeFunc(p.now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	// Tokens for several audiences must name this client as the authorized party
+	if len(claims.Audience) > 1 && claims.AuthorizedBy != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: authorized party %q", ErrInvalidIDToken, claims.AuthorizedBy)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	return &claims, nil
+}
+
+// Issuer returns the issuer the provider was configured with
+func (p *Provider) Issuer() string {
+	return p.cfg.Issuer
+}
+
+// GenerateVerifier returns a new PKCE code verifier
+func GenerateVerifier() (string, error) {
+	return utils.GenerateSecureToken(verifierBytes)
+}
+
+// Challenge returns the S256 code challenge of a verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// scope returns the requested scopes, always including openid
+func (p *Provider) scope() string {
+	scopes := []string{"openid"}
+	for _, scope := range p.cfg.Scopes {
+		if scope != "" && scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return strings.Join(scopes, " ")
+}
+
+// discover returns the provider's metadata, fetching it on first use
+func (p *Provider) discover(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery request: %w", err)
+	}
+
+	var metadata Metadata
+	status, err := p.do(req, &metadata)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to discover OIDC provider: status %d", status)
+	}
+	if strings.TrimRight(metadata.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("OIDC provider issuer %q does not match %q", metadata.Issuer, p.cfg.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery document is missing endpoints")
+	}
+
+	p.metadata = &metadata
+	return p.metadata, nil
+}
+
+// key returns the public key with an ID, fetching the keys again when the ID is
+// unknown, e.g. after the provider rotated its keys
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if !p.keysFetchedAt.IsZero() && p.now().Sub(p.keysFetchedAt) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+
+	keys, err := p.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = p.now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key ID %q", kid)
+}
+
+// lookupKey returns a cached key. Tokens without a key ID are accepted when the
+// provider publishes a single key.
+func (p *Provider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// fetchKeys downloads the provider's signing keys
+func (p *Provider) fetchKeys(ctx context.Context) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.metadata.JWKSURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+
+	var set jsonWebKeySet
+	status, err := p.do(req, &set)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch OIDC keys: status %d", status)
+	}
+	return set.publicKeys(), nil
+}
+
+// do sends a request and decodes its JSON response
+func (p *Provider) do(req *http.Request, v interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("OIDC request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read OIDC response: %w", err)
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+			return 0, fmt.Errorf("failed to decode OIDC response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,380 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockProvider is a minimal OpenID provider that signs in a fixed user
+type mockProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	mu      sync.Mutex
+	pending map[string]url.Values // Authorization requests by code
+	claims  func(jwt.MapClaims)   // Adjusts the claims of issued ID tokens
+}
+
+// newMockProvider starts a mock provider that is stopped when the test ends
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockProvider{t: t, key: key, kid: "key-1", pending: make(map[string]url.Values)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": m.kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", m.token)
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// authorize plays the user signing in at the authorization URL and returns the
+// code and state the provider redirects back with
+func (m *mockProvider) authorize(authURL string) (string, string) {
+	u, err := url.Parse(authURL)
+	require.NoError(m.t, err)
+	params := u.Query()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	code := "code-" + params.Get("state")
+	m.pending[code] = params
+	return code, params.Get("state")
+}
+
+// token redeems a code, checking the client and the PKCE verifier
+func (m *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	require.NoError(m.t, r.ParseForm())
+
+	m.mu.Lock()
+	params, ok := m.pending[r.Form.Get("code")]
+	delete(m.pending, r.Form.Get("code"))
+	m.mu.Unlock()
+
+	clientID, secret, _ := r.BasicAuth()
+	switch {
+	case !ok:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	case clientID != "dune" || secret != "client-secret":
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	case Challenge(r.Form.Get("code_verifier")) != params.Get("code_challenge"):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	case r.Form.Get("redirect_uri") != params.Get("redirect_uri"):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":            m.server.URL,
+		"sub":            "user-123",
+		"aud":            "dune",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          params.Get("nonce"),
+		"email":          "ada@example.com",
+		"email_verified": true,
+		"name":           "Ada Lovelace",
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": "provider-access-token",
+		"token_type":   "Bearer",
+		"id_token":     m.sign(claims),
+	})
+}
+
+// sign returns an ID token with claims, after applying the test's adjustments
+func (m *mockProvider) sign(claims jwt.MapClaims) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.claims != nil {
+		m.claims(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = m.kid
+	signed, err := token.SignedString(m.key)
+	require.NoError(m.t, err)
+	return signed
+}
+
+// rotate replaces the signing key
+func (m *mockProvider) rotate(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(m.t, err)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.key = key
+	m.kid = kid
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// newTestProvider creates a provider for the mock
+func newTestProvider(m *mockProvider) *Provider {
+	return New(Config{
+		Issuer:       m.server.URL,
+		ClientID:     "dune",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:8080/api/auth/oidc/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	})
+}
+
+// signIn runs the authorization code flow against the mock
+func signIn(t *testing.T, m *mockProvider, p *Provider) (*Claims, error) {
+	verifier, err := GenerateVerifier()
+	require.NoError(t, err)
+
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+	code, _ := m.authorize(authURL)
+
+	return p.Exchange(context.Background(), code, verifier, "nonce-1")
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	m := newMockProvider(t)
+	p := newTestProvider(m)
+
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", "nonce-1", "verifier")
+	require.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, m.server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+
+	params := u.Query()
+	assert.Equal(t, "code", params.Get("response_type"))
+	assert.Equal(t, "dune", params.Get("client_id"))
+	assert.Equal(t, "http://localhost:8080/api/auth/oidc/callback", params.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", params.Get("scope"))
+	assert.Equal(t, "state-1", params.Get("state"))
+	assert.Equal(t, "nonce-1", params.Get("nonce"))
+	assert.Equal(t, Challenge("verifier"), params.Get("code_challenge"))
+	assert.Equal(t, "S256", params.Get("code_challenge_method"))
+}
+
+func TestChallenge(t *testing.T) {
+	// RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func TestExchange(t *testing.T) {
+	m := newMockProvider(t)
+	p := newTestProvider(m)
+
+	claims, err := signIn(t, m, p)
+	require.NoError(t, err)
+	assert.Equal(t, "user-123", claims.Subject)
+	assert.Equal(t, "ada@example.com", claims.Email)
+	assert.True(t, bool(claims.EmailVerified))
+	assert.Equal(t, "Ada Lovelace", claims.Name)
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	m := newMockProvider(t)
+	p := newTestProvider(m)
+
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", "nonce-1", "verifier-one")
+	require.NoError(t, err)
+	code, _ := m.authorize(authURL)
+
+	_, err = p.Exchange(context.Background(), code, "verifier-two", "nonce-1")
+	assert.ErrorIs(t, err, ErrExchangeFailed)
+	assert.Contains(t, err.Error(), "PKCE verification failed")
+}
+
+func TestExchangeRejectsUsedCode(t *testing.T) {
+	m := newMockProvider(t)
+	p := newTestProvider(m)
+
+	verifier, err := GenerateVerifier()
+	require.NoError(t, err)
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+	code, _ := m.authorize(authURL)
+
+	_, err = p.Exchange(context.Background(), code, verifier, "nonce-1")
+	require.NoError(t, err)
+	_, err = p.Exchange(context.Background(), code, verifier, "nonce-1")
+	assert.ErrorIs(t, err, ErrExchangeFailed)
+}
+
+func TestExchangeVerifiesIDToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		adjust func(jwt.MapClaims)
+	}{
+		{"wrong nonce", func(c jwt.MapClaims) { c["nonce"] = "other" }},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "another-client" }},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"no expiry", func(c jwt.MapClaims) { delete(c, "exp") }},
+		{"no subject", func(c jwt.MapClaims) { delete(c, "sub") }},
+		{"other authorized party", func(c jwt.MapClaims) {
+			c["aud"] = []string{"dune", "another-client"}
+			c["azp"] = "another-client"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockProvider(t)
+			m.claims = tt.adjust
+
+			_, err := signIn(t, m, newTestProvider(m))
+			assert.ErrorIs(t, err, ErrInvalidIDToken)
+		})
+	}
+}
+
+func TestVerifyIDTokenRejectsUnsafeAlgorithms(t *testing.T) {
+	m := newMockProvider(t)
+	p := newTestProvider(m)
+
+	claims := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"sub":   "user-123",
+		"aud":   "dune",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": "nonce-1",
+	}
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = p.VerifyIDToken(context.Background(), unsigned, "nonce-1")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+	// An HMAC token keyed with the client secret must not pass for a provider token
+	hmac, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("client-secret"))
+	require.NoError(t, err)
+	_, err = p.VerifyIDToken(context.Background(), hmac, "nonce-1")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestVerifyIDTokenFetchesRotatedKeys(t *testing.T) {
+	m := newMockProvider(t)
+	p := newTestProvider(m)
+	now := time.Now()
+	p.now = func() time.Time { return now }
+
+	_, err := signIn(t, m, p)
+	require.NoError(t, err)
+
+	// Keys are not fetched again right away, so unknown key IDs cannot flood the provider
+	m.rotate("key-2")
+	_, err = signIn(t, m, p)
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+	now = now.Add(keyRefreshInterval)
+	_, err = signIn(t, m, p)
+	assert.NoError(t, err)
+}
+
+func TestDiscoveryRequiresMatchingIssuer(t *testing.T) {
+	m := newMockProvider(t)
+	p := New(Config{Issuer: m.server.URL + "/tenant", ClientID: "dune"})
+
+	_, err := p.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	assert.Error(t, err)
+}
+
+func TestBool(t *testing.T) {
+	tests := []struct {
+		json string
+		want bool
+	}{
+		{`true`, true},
+		{`false`, false},
+		{`"true"`, true},
+		{`"false"`, false},
+		{`1`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var b Bool
+			require.NoError(t, json.Unmarshal([]byte(tt.json), &b))
+			assert.Equal(t, tt.want, bool(b))
+		})
+	}
+}
+
+func TestJSONWebKeySet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	set := jsonWebKeySet{Keys: []jsonWebKey{
+		{
+			Kty: "RSA",
+			Kid: "rsa",
+			N:   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		},
+		{
+			Kty: "EC",
+			Kid: "ec",
+			Use: "sig",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(ecKey.X.Bytes()),
+			Y:   base64.RawURLEncoding.EncodeToString(ecKey.Y.Bytes()),
+		},
+		{Kty: "RSA", Kid: "encryption", Use: "enc", N: "AQAB", E: "AQAB"},
+		{Kty: "EC", Kid: "off-curve", Crv: "P-256", X: "AQ", Y: "AQ"},
+		{Kty: "oct", Kid: "symmetric"},
+	}}
+
+	keys := set.publicKeys()
+	assert.Len(t, keys, 2)
+	assert.True(t, rsaKey.PublicKey.Equal(keys["rsa"]))
+	assert.True(t, ecKey.PublicKey.Equal(keys["ec"]))
+}
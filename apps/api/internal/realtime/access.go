@@ -0,0 +1,123 @@
+package realtime
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// Control messages telling other instances that form access changed. They are
+// published through the backplane only and never delivered to clients.
+const (
+	formAccessType = "access:form"
+	userAccessType = "access:user"
+)
+
+// revokedText is reported to clients whose access to a subscribed form was revoked
+const revokedText = "Access to the form was revoked"
+
+// FormAccessChanged forgets what this and the other instances know about who may
+// read a form, after it moved to another workspace or was deleted. The owner
+// cache and the replay owner are reset so events stop reaching the former owner's
+// all-forms channel, and subscribers who lost access are dropped.
+func (w *WebSocketManager) FormAccessChanged(formID string) {
+	formID = normalizeFormID(formID)
+	w.formAccessChanged(formID)
+	w.publishControl(&Message{FormID: formID, Type: formAccessType})
+}
+
+// UserAccessChanged rechecks the form subscriptions of a user on this and the other
+// instances, after they were removed from an organization or their role changed.
+// Subscriptions to forms the user may no longer read are dropped.
+func (w *WebSocketManager) UserAccessChanged(userID string) {
+	w.userAccessChanged(userID)
+	w.publishControl(&Message{Type: userAccessType, UserID: userID})
+}
+
+// formAccessChanged applies a form access change to this instance
+func (w *WebSocketManager) formAccessChanged(formID string) {
+	w.ownersMutex.Lock()
+	delete(w.owners, formID)
+	w.ownersMutex.Unlock()
+
+	w.mutex.Lock()
+	if buffer, exists := w.replay[formID]; exists {
+		buffer.ownerID = ""
+	}
+	subscribers := make([]*Client, 0, len(w.rooms[formID]))
+	for client := range w.rooms[formID] {
+		subscribers = append(subscribers, client)
+	}
+	w.mutex.Unlock()
+
+	for _, client := range subscribers {
+		w.recheck(client, formID)
+	}
+}
+
+// userAccessChanged applies a user access change to this instance. Owner channels
+// only carry personal forms, which organization changes do not affect.
+func (w *WebSocketManager) userAccessChanged(userID string) {
+	type subscription struct {
+		client *Client
+		formID string
+	}
+
+	w.mutex.RLock()
+	var subscriptions []subscription
+	for client := range w.clients {
+		if client.UserID != userID {
+			continue
+		}
+		for room := range client.rooms {
+			if !strings.HasPrefix(room, ownerRoomPrefix) {
+				subscriptions = append(subscriptions, subscription{client: client, formID: room})
+			}
+		}
+	}
+	w.mutex.RUnlock()
+
+	for _, s := range subscriptions {
+		w.recheck(s.client, s.formID)
+	}
+}
+
+// recheck drops the client's subscription to a form it may no longer read. Clients
+// connected for that form alone are disconnected.
+func (w *WebSocketManager) recheck(client *Client, formID string) {
+	if w.canAccess(formID, client.UserID) {
+		return
+	}
+
+	if !w.leave(client, formID) {
+		return
+	}
+	client.sendError(formID, revokedText)
+	if client.FormID == formID {
+		client.closeWith(CloseForbidden, revokedText)
+	}
+}
+
+// publishControl forwards an access change to the other instances
+func (w *WebSocketManager) publishControl(message *Message) {
+	select {
+	case w.outbound <- message:
+	default:
+		w.dropped.Add(1)
+		slog.Warn("Backplane queue full, other instances miss access change", "type", message.Type, "form_id", message.FormID)
+	}
+}
+
+// receiveControl applies an access change published by another instance and reports
+// whether the message was a control message. Rechecks query the database, so they
+// run outside the backplane handler, which must not block.
+func (w *WebSocketManager) receiveControl(message *Message) bool {
+	switch message.Type {
+	case formAccessType:
+		go w.formAccessChanged(message.FormID)
+	case userAccessType:
+		go w.userAccessChanged(message.UserID)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+)
+
+const (
+	testOrganizationID = "507f1f77bcf86cd799439bbb"
+	testMemberID       = "507f1f77bcf86cd799439ccc"
+)
+
+// movableForms serves testFormID as a personal form of testOwnerID until it is
+// moved into an organization, where the readers may read it
+type movableForms struct {
+	mutex        sync.Mutex
+	organization bool
+	readers      map[string]bool
+}
+
+func newMovableForms() *movableForms {
+	return &movableForms{readers: map[string]bool{testOwnerID: true}}
+}
+
+func (f *movableForms) GetFormByID(_ context.Context, formID string, actor *services.Actor) (*models.FormResponse, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if formID != testFormID || (actor != nil && !f.readers[actor.UserID]) {
+		return nil, errors.New("form not found")
+	}
+	owner := testOwnerID
+	form := &models.FormResponse{ID: formID, OwnerID: &owner}
+	if f.organization {
+		organizationID := testOrganizationID
+		form.OrganizationID = &organizationID
+	}
+	return form, nil
+}
+
+// move puts the form into the organization with the given readers
+func (f *movableForms) move(readers ...string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.organization = true
+	f.readers = make(map[string]bool)
+	for _, reader := range readers {
+		f.readers[reader] = true
+	}
+}
+
+func TestWebSocketManager_FormAccessChanged(t *testing.T) {
+	forms := newMovableForms()
+	manager := NewWebSocketManager(DefaultConfig(), NewMemoryBackplane(), fakeTokens{}, forms)
+	baseURL := serveManager(t, manager)
+
+	owner := dial(t, subscribeURL(baseURL)+"?token="+testOwnerID)
+	require.NoError(t, owner.WriteJSON(clientMessage{Type: "subscribe", AllForms: true}))
+	readType(t, owner, "subscribed")
+	first := broadcastAndWait(t, manager, testFormID, 1)
+	assert.Equal(t, []string{testFormID + ":1"}, readUpdates(t, owner, 1))
+
+	connected := dial(t, baseURL+testFormID+"?token="+testOwnerID)
+	readType(t, connected, "connected")
+
+	forms.move(testMemberID)
+	manager.FormAccessChanged(testFormID)
+
+	t.Run("Clients connected for the form are disconnected", func(t *testing.T) {
+		assert.Equal(t, CloseForbidden, readUntilClose(t, connected))
+	})
+
+	t.Run("Events stop reaching the former owner channel", func(t *testing.T) {
+		broadcastAndWait(t, manager, testFormID, 2)
+
+		owner.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		var msg map[string]interface{}
+		assert.Error(t, owner.ReadJSON(&msg))
+	})
+
+	t.Run("Buffered events are not replayed to the former owner", func(t *testing.T) {
+		conn := dial(t, subscribeURL(baseURL)+"?token="+testOwnerID)
+		require.NoError(t, conn.WriteJSON(clientMessage{Type: "subscribe", AllForms: true, LastEventID: first}))
+		readType(t, conn, "subscribed")
+
+		conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		var msg map[string]interface{}
+		assert.Error(t, conn.ReadJSON(&msg))
+	})
+}
+
+func TestWebSocketManager_UserAccessChanged(t *testing.T) {
+	forms := newMovableForms()
+	forms.move(testOwnerID, testMemberID)
+
+	backplane := NewMemoryBackplane()
+	first := NewWebSocketManager(DefaultConfig(), backplane, fakeTokens{}, forms)
+	second := NewWebSocketManager(DefaultConfig(), backplane, fakeTokens{}, forms)
+	serveManager(t, first)
+	secondURL := serveManager(t, second)
+
+	member := dial(t, subscribeURL(secondURL)+"?token="+testMemberID)
+	require.NoError(t, member.WriteJSON(clientMessage{Type: "subscribe", FormIDs: []string{testFormID}}))
+	readType(t, member, "subscribed")
+
+	t.Run("Members keeping access stay subscribed", func(t *testing.T) {
+		first.UserAccessChanged(testMemberID)
+
+		second.Broadcast(testFormID, "analytics:update", map[string]int{"total": 1})
+		assert.Equal(t, []string{testFormID + ":1"}, readUpdates(t, member, 1))
+	})
+
+	t.Run("Removed members are unsubscribed on every instance", func(t *testing.T) {
+		forms.move(testOwnerID)
+		first.UserAccessChanged(testMemberID)
+
+		msg := readType(t, member, "error")
+		assert.Equal(t, testFormID, msg["formId"])
+		assert.Equal(t, revokedText, msg["error"])
+		assert.Equal(t, 0, second.GetRoomCount(testFormID))
+
+		second.Broadcast(testFormID, "analytics:update", map[string]int{"total": 2})
+		member.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		var update map[string]interface{}
+		assert.Error(t, member.ReadJSON(&update))
+	})
+}
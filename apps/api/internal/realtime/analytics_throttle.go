@@ -0,0 +1,254 @@
+package realtime
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/tracing"
+)
+
+// analyticsThrottle coalesces analytics updates per form. At most one update per
+// interval is broadcast for a form; updates arriving in between replace the pending
+// one. Broadcasts are deltas against the previous broadcast, with a full snapshot
+// at least every snapshotInterval.
+type analyticsThrottle struct {
+	manager          *WebSocketManager
+	interval         time.Duration
+	snapshotInterval time.Duration
+
+	mutex sync.Mutex
+	forms map[string]*analyticsState
+}
+
+// analyticsState tracks what was last broadcast for a form
+type analyticsState struct {
+	sent         *models.Analytics
+	pending      *models.Analytics
+	links        []trace.Link // traces of the updates merged into pending
+	seq          int64
+	lastSent     time.Time
+	lastSnapshot time.Time
+	timer        *time.Timer
+}
+
+// newAnalyticsThrottle creates a throttle allowing updatesPerSecond broadcasts per form
+// (0 broadcasts every update)
+func newAnalyticsThrottle(manager *WebSocketManager, updatesPerSecond int, snapshotInterval time.Duration) *analyticsThrottle {
+	var interval time.Duration
+	if updatesPerSecond > 0 {
+		interval = time.Second / time.Duration(updatesPerSecond)
+	}
+
+	return &analyticsThrottle{
+		manager:          manager,
+		interval:         interval,
+		snapshotInterval: snapshotInterval,
+		forms:            make(map[string]*analyticsState),
+	}
+}
+
+// publish queues the form's latest analytics for broadcasting. Analytics broadcast
+// right away stay in the trace of ctx; a later flush links the traces it merges.
+func (t *analyticsThrottle) publish(ctx context.Context, formID string, analytics *models.Analytics) {
+	t.mutex.Lock()
+
+	state, exists := t.forms[formID]
+	if !exists {
+		state = &analyticsState{}
+		t.forms[formID] = state
+	}
+
+	if state.pending != nil {
+		t.manager.coalesced.Add(1)
+	}
+	state.pending = analytics
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		state.links = append(state.links, trace.Link{SpanContext: spanContext})
+	}
+
+	// A flush is already scheduled and will pick up the new analytics
+	if state.timer != nil {
+		t.mutex.Unlock()
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("dune.realtime.deferred", true))
+		return
+	}
+
+	wait := time.Until(state.lastSent.Add(t.interval))
+	if wait > 0 {
+		state.timer = time.AfterFunc(wait, func() { t.flush(formID) })
+		t.mutex.Unlock()
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("dune.realtime.deferred", true))
+		return
+	}
+
+	// Broadcast under the lock so that messages leave in sequence order
+	delta, _ := t.takeLocked(state)
+	t.broadcast(ctx, formID, delta)
+	t.mutex.Unlock()
+}
+
+// flush broadcasts the pending analytics of a form once its interval has passed
+func (t *analyticsThrottle) flush(formID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	state, exists := t.forms[formID]
+	if !exists {
+		return
+	}
+	state.timer = nil
+
+	delta, links := t.takeLocked(state)
+	if delta == nil {
+		return
+	}
+
+	// The flush has no caller of its own; it links the traces of the merged updates
+	ctx, span := tracing.Start(context.Background(), "WebSocketManager.flushAnalytics",
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			tracing.FormID(formID),
+			attribute.Int("dune.realtime.merged_updates", len(links)),
+		),
+	)
+	defer span.End()
+	t.broadcast(ctx, formID, delta)
+}
+
+// broadcast sends a delta unless it carries no changes
+func (t *analyticsThrottle) broadcast(ctx context.Context, formID string, delta *models.AnalyticsDelta) {
+	if delta == nil || delta.IsEmpty() {
+		return
+	}
+	t.manager.broadcastContext(ctx, formID, "analytics:update", delta)
+}
+
+// takeLocked turns the pending analytics into the next delta or snapshot and
+// returns it with the traces of the updates it carries. The caller holds the
+// throttle mutex.
+func (t *analyticsThrottle) takeLocked(state *analyticsState) (*models.AnalyticsDelta, []trace.Link) {
+	current := state.pending
+	if current == nil {
+		return nil, nil
+	}
+	links := state.links
+	state.pending = nil
+	state.links = nil
+
+	now := time.Now()
+	snapshot := state.sent == nil || now.Sub(state.lastSnapshot) >= t.snapshotInterval
+	delta := diffAnalytics(state.sent, current, snapshot)
+	if delta.IsEmpty() {
+		return nil, nil
+	}
+
+	state.seq++
+	delta.Seq = state.seq
+	delta.Source = t.manager.id
+	state.sent = current
+	state.lastSent = now
+	if snapshot {
+		state.lastSnapshot = now
+	}
+	return delta, links
+}
+
+// stop cancels scheduled broadcasts
+func (t *analyticsThrottle) stop() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, state := range t.forms {
+		if state.timer != nil {
+			state.timer.Stop()
+			state.timer = nil
+		}
+	}
+}
+
+// expire forgets forms without updates for longer than the snapshot interval.
+// Their next update is broadcast as a snapshot.
+func (t *analyticsThrottle) expire() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	cutoff := time.Now().Add(-t.snapshotInterval)
+	for formID, state := range t.forms {
+		if state.timer == nil && state.pending == nil && state.lastSent.Before(cutoff) {
+			delete(t.forms, formID)
+		}
+	}
+}
+
+// diffAnalytics returns the fields and counters of current that differ from previous,
+// or all of them for a snapshot
+func diffAnalytics(previous, current *models.Analytics, snapshot bool) *models.AnalyticsDelta {
+	delta := &models.AnalyticsDelta{
+		Snapshot:  snapshot,
+		UpdatedAt: current.UpdatedAt,
+	}
+
+	if snapshot || previous == nil {
+		total := current.TotalResponses
+		delta.ByField = current.ByField
+		delta.TotalResponses = &total
+		delta.CompletionRate = current.CompletionRate
+		delta.AverageTimeToComplete = current.AverageTimeToComplete
+		return delta
+	}
+
+	for fieldID, field := range current.ByField {
+		if sent, exists := previous.ByField[fieldID]; !exists || !reflect.DeepEqual(sent, field) {
+			if delta.ByField == nil {
+				delta.ByField = make(map[string]models.FieldAnalytics)
+			}
+			delta.ByField[fieldID] = field
+		}
+	}
+	if current.TotalResponses != previous.TotalResponses {
+		total := current.TotalResponses
+		delta.TotalResponses = &total
+	}
+	if !reflect.DeepEqual(current.CompletionRate, previous.CompletionRate) {
+		delta.CompletionRate = current.CompletionRate
+	}
+	if !reflect.DeepEqual(current.AverageTimeToComplete, previous.AverageTimeToComplete) {
+		delta.AverageTimeToComplete = current.AverageTimeToComplete
+	}
+	return delta
+}
+
+// BroadcastAnalytics broadcasts a form's recomputed analytics, throttled to the
+// configured rate. Clients receive analytics:update messages carrying a delta
+// (or periodically a snapshot); see models.AnalyticsDelta.
+func (w *WebSocketManager) BroadcastAnalytics(ctx context.Context, formID string, analytics *models.Analytics) {
+	if analytics == nil {
+		return
+	}
+
+	normalizedFormID := normalizeFormID(formID)
+	if len(normalizedFormID) != 24 {
+		slog.Error("Invalid form ID for analytics broadcast", "form_id", normalizedFormID)
+		return
+	}
+	ctx, span := tracing.Start(ctx, "WebSocketManager.BroadcastAnalytics", trace.WithAttributes(tracing.FormID(normalizedFormID)))
+	defer span.End()
+	w.analytics.publish(ctx, normalizedFormID, analytics)
+}
+
+// GetDroppedMessages returns the number of messages dropped because a queue was full
+func (w *WebSocketManager) GetDroppedMessages() int64 {
+	return w.dropped.Load()
+}
+
+// GetCoalescedUpdates returns the number of analytics updates merged into a later one
+func (w *WebSocketManager) GetCoalescedUpdates() int64 {
+	return w.coalesced.Load()
+}
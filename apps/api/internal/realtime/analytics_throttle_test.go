@@ -0,0 +1,189 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/tracing"
+)
+
+// testAnalytics builds analytics with the given response total and per-field counts
+func testAnalytics(total int, counts map[string]int) *models.Analytics {
+	analytics := &models.Analytics{
+		TotalResponses: total,
+		ByField:        make(map[string]models.FieldAnalytics),
+		UpdatedAt:      time.Now(),
+	}
+	for fieldID, count := range counts {
+		analytics.ByField[fieldID] = models.FieldAnalytics{Count: count}
+	}
+	return analytics
+}
+
+func TestBroadcastAnalytics(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AnalyticsRate = 10
+	cfg.SnapshotInterval = time.Hour
+	manager, baseURL := startTestServer(t, cfg)
+	conn := dial(t, baseURL+testFormID+"?token="+testOwnerID)
+	readType(t, conn, "connected")
+
+	readDelta := func(t *testing.T) map[string]interface{} {
+		return readType(t, conn, "analytics:update")["data"].(map[string]interface{})
+	}
+
+	t.Run("First update is a snapshot", func(t *testing.T) {
+		manager.BroadcastAnalytics(context.Background(), testFormID, testAnalytics(1, map[string]int{"f1": 1, "f2": 1}))
+
+		data := readDelta(t)
+		assert.Equal(t, float64(1), data["seq"])
+		assert.Equal(t, true, data["snapshot"])
+		assert.Equal(t, float64(1), data["totalResponses"])
+		assert.Len(t, data["byField"], 2)
+	})
+
+	t.Run("Bursts are merged into one delta of the changed fields", func(t *testing.T) {
+		before := manager.GetCoalescedUpdates()
+		manager.BroadcastAnalytics(context.Background(), testFormID, testAnalytics(2, map[string]int{"f1": 2, "f2": 1}))
+		manager.BroadcastAnalytics(context.Background(), testFormID, testAnalytics(3, map[string]int{"f1": 3, "f2": 1}))
+
+		data := readDelta(t)
+		assert.Equal(t, float64(2), data["seq"])
+		assert.Equal(t, false, data["snapshot"])
+		assert.Equal(t, float64(3), data["totalResponses"])
+		assert.Equal(t, map[string]interface{}{"f1": map[string]interface{}{"count": float64(3)}}, data["byField"])
+		assert.Equal(t, before+1, manager.GetCoalescedUpdates())
+	})
+
+	t.Run("Unchanged analytics are not broadcast", func(t *testing.T) {
+		manager.BroadcastAnalytics(context.Background(), testFormID, testAnalytics(3, map[string]int{"f1": 3, "f2": 1}))
+		time.Sleep(150 * time.Millisecond)
+		manager.BroadcastAnalytics(context.Background(), testFormID, testAnalytics(3, map[string]int{"f1": 3, "f2": 2}))
+
+		data := readDelta(t)
+		assert.Equal(t, float64(3), data["seq"])
+		assert.NotContains(t, data, "totalResponses")
+		assert.Equal(t, map[string]interface{}{"f2": map[string]interface{}{"count": float64(2)}}, data["byField"])
+	})
+
+	t.Run("Snapshots are sent periodically", func(t *testing.T) {
+		manager.analytics.mutex.Lock()
+		manager.analytics.snapshotInterval = 100 * time.Millisecond
+		manager.analytics.mutex.Unlock()
+		time.Sleep(150 * time.Millisecond)
+		manager.BroadcastAnalytics(context.Background(), testFormID, testAnalytics(4, map[string]int{"f1": 4, "f2": 2}))
+
+		data := readDelta(t)
+		assert.Equal(t, float64(4), data["seq"])
+		assert.Equal(t, true, data["snapshot"])
+		assert.Len(t, data["byField"], 2)
+	})
+}
+
+func TestBroadcastAnalytics_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	cfg := DefaultConfig()
+	cfg.AnalyticsRate = 10
+	manager, baseURL := startTestServer(t, cfg)
+	conn := dial(t, baseURL+testFormID+"?token="+testOwnerID)
+	readType(t, conn, "connected")
+
+	// endedSpan waits for a span with the given name to end
+	endedSpan := func(t *testing.T, name string, skip int) sdktrace.ReadOnlySpan {
+		var found sdktrace.ReadOnlySpan
+		require.Eventually(t, func() bool {
+			seen := 0
+			for _, span := range recorder.Ended() {
+				if span.Name() == name {
+					if seen == skip {
+						found = span
+						return true
+					}
+					seen++
+				}
+			}
+			return false
+		}, time.Second, 10*time.Millisecond)
+		return found
+	}
+
+	t.Run("Immediate broadcasts are delivered within the caller's trace", func(t *testing.T) {
+		ctx, parent := tracing.Start(context.Background(), "ResponseHandler.updateAnalyticsAndBroadcast")
+		manager.BroadcastAnalytics(ctx, testFormID, testAnalytics(1, map[string]int{"f1": 1}))
+		parent.End()
+		readType(t, conn, "analytics:update")
+
+		broadcast := endedSpan(t, "WebSocketManager.BroadcastAnalytics", 0)
+		deliver := endedSpan(t, "WebSocketManager.deliver", 0)
+		assert.Equal(t, parent.SpanContext().SpanID(), broadcast.Parent().SpanID())
+		assert.Equal(t, broadcast.SpanContext().SpanID(), deliver.Parent().SpanID())
+		assert.Equal(t, parent.SpanContext().TraceID(), deliver.SpanContext().TraceID())
+	})
+
+	t.Run("Merged updates are linked from the flush", func(t *testing.T) {
+		var parents []trace.SpanContext
+		for total := 2; total <= 3; total++ {
+			ctx, parent := tracing.Start(context.Background(), "ResponseHandler.updateAnalyticsAndBroadcast")
+			manager.BroadcastAnalytics(ctx, testFormID, testAnalytics(total, map[string]int{"f1": total}))
+			parent.End()
+			parents = append(parents, parent.SpanContext())
+		}
+		readType(t, conn, "analytics:update")
+
+		flush := endedSpan(t, "WebSocketManager.flushAnalytics", 0)
+		require.Len(t, flush.Links(), 2)
+		assert.Equal(t, parents[0].TraceID(), flush.Links()[0].SpanContext.TraceID())
+		assert.Equal(t, parents[1].TraceID(), flush.Links()[1].SpanContext.TraceID())
+
+		deliver := endedSpan(t, "WebSocketManager.deliver", 1)
+		assert.Equal(t, flush.SpanContext().SpanID(), deliver.Parent().SpanID())
+	})
+}
+
+func TestWebSocketManager_DroppedMessages(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.BroadcastQueueSize = 1
+	manager := NewWebSocketManager(cfg, NewMemoryBackplane(), fakeTokens{}, fakeForms{})
+
+	// Without Run nothing drains the queues, so the second broadcast is dropped
+	// locally and for the backplane
+	manager.Broadcast(testFormID, "analytics:update", nil)
+	manager.Broadcast(testFormID, "analytics:update", nil)
+	require.Equal(t, int64(2), manager.GetDroppedMessages())
+}
+
+func TestDiffAnalytics(t *testing.T) {
+	previous := testAnalytics(1, map[string]int{"f1": 1, "f2": 1})
+
+	t.Run("Delta contains changed and new fields", func(t *testing.T) {
+		delta := diffAnalytics(previous, testAnalytics(1, map[string]int{"f1": 1, "f2": 2, "f3": 1}), false)
+		assert.False(t, delta.Snapshot)
+		assert.Nil(t, delta.TotalResponses)
+		assert.Equal(t, map[string]models.FieldAnalytics{"f2": {Count: 2}, "f3": {Count: 1}}, delta.ByField)
+	})
+
+	t.Run("Identical analytics produce an empty delta", func(t *testing.T) {
+		assert.True(t, diffAnalytics(previous, testAnalytics(1, map[string]int{"f1": 1, "f2": 1}), false).IsEmpty())
+	})
+
+	t.Run("Snapshot contains everything", func(t *testing.T) {
+		delta := diffAnalytics(previous, previous, true)
+		assert.False(t, delta.IsEmpty())
+		require.NotNil(t, delta.TotalResponses)
+		assert.Equal(t, 1, *delta.TotalResponses)
+		assert.Len(t, delta.ByField, 2)
+	})
+}
@@ -0,0 +1,70 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+)
+
+// Envelope is a broadcast message travelling between API instances
+type Envelope struct {
+	// Origin identifies the WebSocketManager that published the message
+	Origin  string
+	Message *Message
+}
+
+// Backplane fans broadcast messages out to every API instance so that clients
+// connected to any replica receive updates published by another one
+type Backplane interface {
+	// Publish sends a message to all subscribers, including the publishing instance
+	Publish(ctx context.Context, envelope *Envelope) error
+
+	// Subscribe registers a handler for published messages and returns a function
+	// that cancels the subscription. Handlers must not block.
+	Subscribe(handler func(*Envelope)) (func(), error)
+}
+
+// MemoryBackplane is a Backplane for managers running in the same process.
+// It is used for single-instance deployments and tests.
+type MemoryBackplane struct {
+	mutex       sync.RWMutex
+	subscribers map[int]func(*Envelope)
+	nextID      int
+}
+
+// NewMemoryBackplane creates a new in-memory backplane
+func NewMemoryBackplane() *MemoryBackplane {
+	return &MemoryBackplane{
+		subscribers: make(map[int]func(*Envelope)),
+	}
+}
+
+// Publish delivers the message to every subscriber
+func (b *MemoryBackplane) Publish(_ context.Context, envelope *Envelope) error {
+	b.mutex.RLock()
+	handlers := make([]func(*Envelope), 0, len(b.subscribers))
+	for _, handler := range b.subscribers {
+		handlers = append(handlers, handler)
+	}
+	b.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(envelope)
+	}
+	return nil
+}
+
+// Subscribe registers a handler for published messages
+func (b *MemoryBackplane) Subscribe(handler func(*Envelope)) (func(), error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = handler
+
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		delete(b.subscribers, id)
+	}, nil
+}
@@ -0,0 +1,81 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBackplane(t *testing.T) {
+	backplane := NewMemoryBackplane()
+
+	var mutex sync.Mutex
+	var received []string
+	unsubscribe, err := backplane.Subscribe(func(envelope *Envelope) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		received = append(received, envelope.Origin)
+	})
+	require.NoError(t, err)
+
+	message := &Message{FormID: testFormID, Type: "analytics:update"}
+	require.NoError(t, backplane.Publish(context.Background(), &Envelope{Origin: "a", Message: message}))
+	require.NoError(t, backplane.Publish(context.Background(), &Envelope{Origin: "b", Message: message}))
+
+	unsubscribe()
+	require.NoError(t, backplane.Publish(context.Background(), &Envelope{Origin: "c", Message: message}))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, []string{"a", "b"}, received)
+}
+
+func TestWebSocketManager_BackplaneFanOut(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	first := NewWebSocketManager(DefaultConfig(), backplane, fakeTokens{}, fakeForms{})
+	second := NewWebSocketManager(DefaultConfig(), backplane, fakeTokens{}, fakeForms{})
+
+	firstURL := serveManager(t, first)
+	secondURL := serveManager(t, second)
+
+	// readUpdate returns the next analytics update received on conn
+	readUpdate := func(t *testing.T, conn interface{ ReadJSON(v interface{}) error }) map[string]interface{} {
+		for {
+			var msg map[string]interface{}
+			require.NoError(t, conn.ReadJSON(&msg))
+			if msg["type"] == "analytics:update" {
+				return msg
+			}
+		}
+	}
+
+	firstConn := dial(t, firstURL+testFormID+"?token="+testOwnerID)
+	secondConn := dial(t, secondURL+testFormID+"?token="+testOwnerID)
+	require.Eventually(t, func() bool {
+		return first.GetRoomCount(testFormID) == 1 && second.GetRoomCount(testFormID) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	firstConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	secondConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	t.Run("Broadcast reaches clients on both instances", func(t *testing.T) {
+		second.Broadcast(testFormID, "analytics:update", map[string]int{"totalResponses": 1})
+
+		assert.Equal(t, float64(1), readUpdate(t, firstConn)["data"].(map[string]interface{})["totalResponses"])
+		assert.Equal(t, float64(1), readUpdate(t, secondConn)["data"].(map[string]interface{})["totalResponses"])
+	})
+
+	t.Run("Publishing instance delivers its own broadcast once", func(t *testing.T) {
+		first.Broadcast(testFormID, "analytics:update", map[string]int{"totalResponses": 2})
+		first.Broadcast(testFormID, "analytics:update", map[string]int{"totalResponses": 3})
+
+		assert.Equal(t, float64(2), readUpdate(t, firstConn)["data"].(map[string]interface{})["totalResponses"])
+		assert.Equal(t, float64(3), readUpdate(t, firstConn)["data"].(map[string]interface{})["totalResponses"])
+		assert.Equal(t, float64(2), readUpdate(t, secondConn)["data"].(map[string]interface{})["totalResponses"])
+		assert.Equal(t, float64(3), readUpdate(t, secondConn)["data"].(map[string]interface{})["totalResponses"])
+	})
+}
@@ -0,0 +1,79 @@
+package realtime
+
+import "time"
+
+// Config holds the WebSocket manager settings
+type Config struct {
+	// Origins allowed to open connections ("*" allows any origin)
+	Origins []string
+
+	// Connection buffer sizes in bytes
+	ReadBufferSize  int
+	WriteBufferSize int
+
+	// BroadcastQueueSize is the number of pending broadcasts before new ones are dropped
+	BroadcastQueueSize int
+
+	// SendQueueSize is the number of pending messages per client before it is disconnected
+	SendQueueSize int
+
+	// MaxMessageSize limits the size of messages read from clients
+	MaxMessageSize int64
+
+	// Connection limits (0 disables a limit)
+	MaxConnectionsPerRoom int
+	MaxConnectionsPerIP   int
+
+	// MaxSubscriptions limits the rooms a single connection may subscribe to (0 disables the limit)
+	MaxSubscriptions int
+
+	// ReplayBufferSize is the number of recent events kept per form for resuming
+	// clients (0 disables replay); ReplayRetention drops older buffered events
+	ReplayBufferSize int
+	ReplayRetention  time.Duration
+
+	// AuthTimeout bounds how long a client may take to send its auth message
+	AuthTimeout time.Duration
+
+	// ReadTimeout drops clients that send nothing (not even a pong) for this long
+	ReadTimeout time.Duration
+
+	// WriteTimeout bounds a single write to a client
+	WriteTimeout time.Duration
+
+	// PingInterval is how often clients are pinged; it must be shorter than ReadTimeout
+	PingInterval time.Duration
+
+	// AnalyticsRate limits analytics broadcasts per form and second; updates in
+	// between are merged (0 broadcasts every update). SnapshotInterval is
+	// the longest time between full snapshots of a form's analytics.
+	AnalyticsRate    int
+	SnapshotInterval time.Duration
+
+	// StreamHeartbeat is how often Server-Sent Events streams receive a heartbeat comment
+	StreamHeartbeat time.Duration
+}
+
+// DefaultConfig returns the default WebSocket manager settings
+func DefaultConfig() Config {
+	return Config{
+		Origins:               []string{"http://localhost:3000"},
+		ReadBufferSize:        1024,
+		WriteBufferSize:       1024,
+		BroadcastQueueSize:    256,
+		SendQueueSize:         1024,
+		MaxMessageSize:        4096,
+		MaxConnectionsPerRoom: 100,
+		MaxConnectionsPerIP:   20,
+		MaxSubscriptions:      100,
+		ReplayBufferSize:      100,
+		ReplayRetention:       15 * time.Minute,
+		AuthTimeout:           10 * time.Second,
+		ReadTimeout:           60 * time.Second,
+		WriteTimeout:          10 * time.Second,
+		PingInterval:          50 * time.Second,
+		AnalyticsRate:         2,
+		SnapshotInterval:      30 * time.Second,
+		StreamHeartbeat:       15 * time.Second,
+	}
+}
@@ -0,0 +1,207 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// mongoBackplaneRetryDelay is how long the tailing loop waits before reopening a dead cursor
+	mongoBackplaneRetryDelay = time.Second
+
+	// mongoBackplaneAwaitTime bounds how long the server waits for new events per batch
+	mongoBackplaneAwaitTime = time.Second
+
+	// namespaceExistsCode is the MongoDB error code for an already existing collection
+	namespaceExistsCode = 48
+)
+
+// backplaneEvent is the document stored in the capped events collection
+type backplaneEvent struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Origin    string             `bson:"origin"`
+	EventID   string             `bson:"eventId"`
+	FormID    string             `bson:"formId"`
+	OwnerID   string             `bson:"ownerId"`
+	UserID    string             `bson:"userId,omitempty"` // User of access control messages
+	Type      string             `bson:"type"`
+	Data      []byte             `bson:"data"`            // JSON encoded message data
+	Trace     map[string]string  `bson:"trace,omitempty"` // W3C trace context of the broadcast
+	CreatedAt time.Time          `bson:"createdAt"`
+}
+
+// MongoBackplane is a Backplane backed by a capped MongoDB collection.
+// Every instance inserts published messages and tails the collection with a
+// tailable cursor, which also works on standalone servers without change streams.
+type MongoBackplane struct {
+	collection *mongo.Collection
+	sizeBytes  int64
+}
+
+// NewMongoBackplane creates a backplane on the given collection, which is created
+// as a capped collection of sizeBytes if it does not exist yet
+func NewMongoBackplane(collection *mongo.Collection, sizeBytes int64) *MongoBackplane {
+	return &MongoBackplane{
+		collection: collection,
+		sizeBytes:  sizeBytes,
+	}
+}
+
+// Publish stores the message in the events collection
+func (b *MongoBackplane) Publish(ctx context.Context, envelope *Envelope) error {
+	data, err := json.Marshal(envelope.Message.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode backplane message: %w", err)
+	}
+
+	// Other instances deliver the message as part of the same trace
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	_, err = b.collection.InsertOne(ctx, &backplaneEvent{
+		ID:        primitive.NewObjectID(),
+		Origin:    envelope.Origin,
+		EventID:   envelope.Message.ID,
+		FormID:    envelope.Message.FormID,
+		OwnerID:   envelope.Message.OwnerID,
+		UserID:    envelope.Message.UserID,
+		Type:      envelope.Message.Type,
+		Data:      data,
+		Trace:     carrier,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish backplane message: %w", err)
+	}
+	return nil
+}
+
+// Subscribe tails the events collection and hands every new message to handler.
+// Only messages published after the subscription starts are delivered.
+func (b *MongoBackplane) Subscribe(handler func(*Envelope)) (func(), error) {
+	setupCtx, setupCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer setupCancel()
+
+	if err := b.ensureCollection(setupCtx); err != nil {
+		return nil, err
+	}
+
+	lastID, err := b.latestID(setupCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.tail(ctx, lastID, handler)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
+
+// ensureCollection creates the capped events collection if needed
+func (b *MongoBackplane) ensureCollection(ctx context.Context) error {
+	opts := options.CreateCollection().SetCapped(true).SetSizeInBytes(b.sizeBytes)
+	err := b.collection.Database().CreateCollection(ctx, b.collection.Name(), opts)
+	if err != nil {
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Code == namespaceExistsCode {
+			return nil
+		}
+		return fmt.Errorf("failed to create backplane collection: %w", err)
+	}
+	return nil
+}
+
+// latestID returns the ID of the newest event, or a zero ID for an empty collection
+func (b *MongoBackplane) latestID(ctx context.Context) (primitive.ObjectID, error) {
+	var event backplaneEvent
+	opts := options.FindOne().SetSort(bson.M{"$natural": -1}).SetProjection(bson.M{"_id": 1})
+	err := b.collection.FindOne(ctx, bson.M{}, opts).Decode(&event)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return primitive.NilObjectID, nil
+		}
+		return primitive.NilObjectID, fmt.Errorf("failed to read backplane position: %w", err)
+	}
+	return event.ID, nil
+}
+
+// tail follows the events collection until ctx is cancelled. Cursors die when the
+// collection is empty or the cursor falls behind the capped size; they are then
+// reopened after the last seen event.
+func (b *MongoBackplane) tail(ctx context.Context, lastID primitive.ObjectID, handler func(*Envelope)) {
+	opts := options.Find().
+		SetCursorType(options.TailableAwait).
+		SetMaxAwaitTime(mongoBackplaneAwaitTime)
+
+	for ctx.Err() == nil {
+		cursor, err := b.collection.Find(ctx, bson.M{"_id": bson.M{"$gt": lastID}}, opts)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Warn("Failed to open backplane cursor", "error", err)
+			}
+			sleepContext(ctx, mongoBackplaneRetryDelay)
+			continue
+		}
+
+		for cursor.Next(ctx) {
+			var event backplaneEvent
+			if err := cursor.Decode(&event); err != nil {
+				slog.Warn("Failed to decode backplane event", "error", err)
+				continue
+			}
+			lastID = event.ID
+
+			traceCtx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(event.Trace))
+			handler(&Envelope{
+				Origin: event.Origin,
+				Message: &Message{
+					ID:      event.EventID,
+					FormID:  event.FormID,
+					Type:    event.Type,
+					Data:    json.RawMessage(event.Data),
+					OwnerID: event.OwnerID,
+					UserID:  event.UserID,
+
+					spanContext: trace.SpanContextFromContext(traceCtx),
+				},
+			})
+		}
+
+		if err := cursor.Err(); err != nil && ctx.Err() == nil {
+			slog.Warn("Backplane cursor failed", "error", err)
+		}
+		cursor.Close(context.Background())
+
+		sleepContext(ctx, mongoBackplaneRetryDelay)
+	}
+}
+
+// sleepContext waits for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,79 @@
+package realtime
+
+import (
+	"strconv"
+	"time"
+)
+
+// replayedTypes are the message types kept for clients resuming with a lastEventId.
+// Other messages describe transient state that is refetched on reconnect anyway.
+var replayedTypes = map[string]bool{
+	"analytics:update": true,
+}
+
+// replayEvent is a delivered message kept for replay
+type replayEvent struct {
+	seq  int64
+	data []byte
+	at   time.Time
+}
+
+// replayBuffer holds the most recent events of a form
+type replayBuffer struct {
+	ownerID string
+	events  []replayEvent
+
+	// evicted is the ID of the newest event dropped from the buffer. Clients
+	// resuming from an older event have missed events that cannot be replayed.
+	evicted int64
+}
+
+// add appends an event, dropping the oldest one once the buffer holds size events
+func (b *replayBuffer) add(event replayEvent, size int) {
+	if len(b.events) >= size {
+		drop := len(b.events) - size + 1
+		b.evicted = b.events[drop-1].seq
+		b.events = append(b.events[:0], b.events[drop:]...)
+	}
+	b.events = append(b.events, event)
+}
+
+// expire drops events received before cutoff
+func (b *replayBuffer) expire(cutoff time.Time) {
+	drop := 0
+	for drop < len(b.events) && b.events[drop].at.Before(cutoff) {
+		drop++
+	}
+	if drop == 0 {
+		return
+	}
+	b.evicted = b.events[drop-1].seq
+	b.events = append(b.events[:0], b.events[drop:]...)
+}
+
+// since returns the events newer than seq. complete is false when events
+// newer than seq have already been dropped.
+func (b *replayBuffer) since(seq int64) (events []replayEvent, complete bool) {
+	for i, event := range b.events {
+		if event.seq > seq {
+			events = b.events[i:]
+			break
+		}
+	}
+	return events, seq >= b.evicted
+}
+
+// parseEventID parses an event ID sent by a client. ok is false for malformed IDs.
+func parseEventID(id string) (seq int64, ok bool) {
+	seq, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || seq <= 0 {
+		return 0, false
+	}
+	return seq, true
+}
+
+// formatEventID formats an event sequence number as sent to clients. IDs are
+// strings because they exceed the integer precision of JavaScript numbers.
+func formatEventID(seq int64) string {
+	return strconv.FormatInt(seq, 10)
+}
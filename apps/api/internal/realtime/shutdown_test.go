@@ -0,0 +1,190 @@
+package realtime
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+// lifecycleServer is a manager served with both the WebSocket and stream routes
+// whose lifecycle is controlled by the test
+type lifecycleServer struct {
+	manager *WebSocketManager
+	app     *fiber.App
+	addr    string
+	stop    context.CancelFunc
+	stopped chan struct{}
+}
+
+// startLifecycleServer runs a manager until the returned server is shut down
+func startLifecycleServer(t *testing.T, cfg Config) *lifecycleServer {
+	manager := NewWebSocketManager(cfg, NewMemoryBackplane(), fakeTokens{}, fakeForms{})
+	ctx, cancel := context.WithCancel(context.Background())
+	server := &lifecycleServer{manager: manager, stop: cancel, stopped: make(chan struct{})}
+	go func() {
+		defer close(server.stopped)
+		manager.Run(ctx)
+	}()
+
+	auth := func(c *fiber.Ctx) error {
+		c.Locals("userID", c.Query("token"))
+		return c.Next()
+	}
+
+	server.app = fiber.New(fiber.Config{DisableStartupMessage: true})
+	server.app.Get("/ws/forms/:id", manager.HandleConnection)
+	server.app.Get("/ws", manager.HandleConnection)
+	server.app.Get("/api/forms/:id/analytics/stream", auth, manager.HandleStream)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.app.Listener(listener)
+	server.addr = listener.Addr().String()
+
+	t.Cleanup(func() { server.shutdown(t) })
+	return server
+}
+
+// shutdown stops the manager, then the HTTP server, in the order used by the container
+func (s *lifecycleServer) shutdown(t *testing.T) {
+	s.stop()
+	select {
+	case <-s.stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("WebSocket manager did not stop")
+	}
+	s.app.Shutdown()
+}
+
+func TestWebSocketManager_Shutdown(t *testing.T) {
+	server := startLifecycleServer(t, DefaultConfig())
+
+	conn := dial(t, "ws://"+server.addr+"/ws/forms/"+testFormID+"?token="+testOwnerID)
+	readType(t, conn, "connected")
+
+	resp, err := http.Get("http://" + server.addr + "/api/forms/" + testFormID + "/analytics/stream?token=" + testOwnerID)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	readStreamType(t, reader, "connected")
+
+	server.shutdown(t)
+
+	t.Run("WebSocket clients receive a close frame", func(t *testing.T) {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			_, _, err := conn.ReadMessage()
+			if err == nil {
+				continue
+			}
+			var closeErr *websocket.CloseError
+			require.ErrorAs(t, err, &closeErr)
+			assert.Equal(t, websocket.CloseServiceRestart, closeErr.Code)
+			assert.Equal(t, shutdownCloseReason, closeErr.Text)
+			return
+		}
+	})
+
+	t.Run("Streams end", func(t *testing.T) {
+		_, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+	})
+
+	t.Run("All clients are unregistered", func(t *testing.T) {
+		assert.Equal(t, 0, server.manager.GetTotalConnections())
+		assert.Equal(t, 0, server.manager.GetRoomCount(testFormID))
+	})
+}
+
+func TestWebSocketManager_RejectsClientsAfterShutdown(t *testing.T) {
+	server := startLifecycleServer(t, DefaultConfig())
+	server.stop()
+	<-server.stopped
+
+	_, status, err := dialOrigin("ws://"+server.addr+"/ws/forms/"+testFormID+"?token="+testOwnerID, "http://localhost:3000")
+	require.Error(t, err)
+	assert.Equal(t, 503, status)
+
+	resp, err := http.Get("http://" + server.addr + "/api/forms/" + testFormID + "/analytics/stream?token=" + testOwnerID)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 503, resp.StatusCode)
+}
+
+func TestWebSocketManager_Heartbeat(t *testing.T) {
+	manager := NewWebSocketManager(DefaultConfig(), NewMemoryBackplane(), fakeTokens{}, fakeForms{})
+	assert.True(t, manager.LastHeartbeat().IsZero(), "no heartbeat before Run")
+	assert.Equal(t, 0, manager.GetBroadcastBacklog())
+
+	// Broadcasts queue up for local delivery and publishing until the loop runs
+	manager.Broadcast(testFormID, "analytics:update", map[string]int{"count": 1})
+	assert.Equal(t, 2, manager.GetBroadcastBacklog())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		manager.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return !manager.LastHeartbeat().IsZero() && manager.GetBroadcastBacklog() == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.WithinDuration(t, time.Now(), manager.LastHeartbeat(), HeartbeatInterval+time.Second)
+
+	cancel()
+	<-stopped
+	assert.True(t, manager.LastHeartbeat().IsZero(), "no heartbeat after the loop stopped")
+}
+
+func TestWebSocketManager_NoGoroutineLeaks(t *testing.T) {
+	defer goleak.VerifyNone(t,
+		goleak.IgnoreCurrent(),
+		// fasthttp's idle worker cleaner only notices the server stopped after its sleep
+		goleak.IgnoreAnyFunction("github.com/valyala/fasthttp.(*workerPool).Start.func2"),
+	)
+
+	transport := &http.Transport{}
+	client := &http.Client{Transport: transport}
+	defer transport.CloseIdleConnections()
+
+	// Closed streams are noticed on the next heartbeat
+	cfg := DefaultConfig()
+	cfg.StreamHeartbeat = 50 * time.Millisecond
+
+	server := startLifecycleServer(t, cfg)
+	for i := 0; i < 5; i++ {
+		conn, _, err := dialOrigin("ws://"+server.addr+"/ws/forms/"+testFormID+"?token="+testOwnerID, "http://localhost:3000")
+		require.NoError(t, err)
+		readType(t, conn, "connected")
+		conn.Close()
+
+		resp, err := client.Get("http://" + server.addr + "/api/forms/" + testFormID + "/analytics/stream?token=" + testOwnerID)
+		require.NoError(t, err)
+		readStreamType(t, bufio.NewReader(resp.Body), "connected")
+		resp.Body.Close()
+	}
+
+	require.Eventually(t, func() bool {
+		return server.manager.GetTotalConnections() == 0
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// A client left open is disconnected by the shutdown
+	conn, _, err := dialOrigin("ws://"+server.addr+"/ws?token="+testOwnerID, "http://localhost:3000")
+	require.NoError(t, err)
+	defer conn.Close()
+	readType(t, conn, "connected")
+
+	server.shutdown(t)
+	transport.CloseIdleConnections()
+}
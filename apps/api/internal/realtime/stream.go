@@ -0,0 +1,188 @@
+package realtime
+
+import (
+	"bufio"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	fiber "github.com/gofiber/fiber/v2"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+// streamRetry is the reconnection delay suggested to Server-Sent Events clients
+const streamRetry = 3 * time.Second
+
+// HandleStream streams a form's realtime messages as Server-Sent Events for clients
+// behind proxies that block WebSockets. Stream clients join the same rooms as
+// WebSocket clients and receive the same payloads. The route runs behind
+// AuthMiddleware; the stream ends when the access token expires, and clients
+// resume with the Last-Event-ID header (or lastEventId query parameter).
+func (w *WebSocketManager) HandleStream(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	formID := normalizeFormID(c.Params("id"))
+	if !utils.IsValidObjectID(formID) {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid form ID format",
+		})
+	}
+
+	var since int64
+	lastEventID := c.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("lastEventId")
+	}
+	if lastEventID != "" {
+		if since, ok = parseEventID(lastEventID); !ok {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Invalid lastEventId",
+			})
+		}
+	}
+
+	if w.isStopped() {
+		return c.Status(503).JSON(fiber.Map{
+			"error": "Server is shutting down",
+		})
+	}
+
+	if !w.canAccess(formID, userID) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Form not found",
+		})
+	}
+
+	if w.cfg.MaxConnectionsPerRoom > 0 && w.GetRoomCount(formID) >= w.cfg.MaxConnectionsPerRoom {
+		return c.Status(503).JSON(fiber.Map{
+			"error": "Too many subscribers for this form",
+		})
+	}
+
+	ip := c.IP()
+	if !w.acquireIP(ip) {
+		return c.Status(429).JSON(fiber.Map{
+			"error": "Too many realtime connections",
+		})
+	}
+
+	// The stream writer runs after the handler returns, so it must not use values
+	// backed by the request buffer
+	requestID, _ := c.Locals("requestID").(string)
+	client := newStreamClient(w, strings.Clone(formID), strings.Clone(userID), requestID)
+	client.resumeFrom = since
+	if expiresAt, ok := c.Locals("tokenExpiresAt").(time.Time); ok {
+		client.expiresAt.Store(expiresAt.UnixNano())
+	}
+
+	w.RegisterClient(client)
+	<-client.registered
+
+	client.logger.Info("Stream client connected", "form_id", client.FormID)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+
+	c.Context().SetBodyStreamWriter(func(buf *bufio.Writer) {
+		defer w.releaseIP(ip)
+		client.streamPump(buf)
+	})
+	return nil
+}
+
+// newStreamClient creates a client for a Server-Sent Events stream
+func newStreamClient(manager *WebSocketManager, formID, userID, requestID string) *Client {
+	id := utils.GenerateRandomString(16)
+	return &Client{
+		ID:         id,
+		FormID:     formID,
+		UserID:     userID,
+		Send:       make(chan []byte, manager.cfg.SendQueueSize),
+		Manager:    manager,
+		rooms:      make(map[string]bool),
+		registered: make(chan struct{}),
+		closing:    make(chan []byte, 1),
+		done:       make(chan struct{}),
+		logger:     clientLogger(id, userID, requestID),
+	}
+}
+
+// streamPump writes the client's messages as Server-Sent Events until the client
+// disconnects, its token expires or it is unregistered. Heartbeat comments keep
+// proxies from closing idle streams and detect disconnected clients.
+func (c *Client) streamPump(buf *bufio.Writer) {
+	defer func() {
+		c.Manager.UnregisterClient(c)
+		close(c.done)
+	}()
+
+	heartbeat := time.NewTicker(c.Manager.cfg.StreamHeartbeat)
+	expiry := time.NewTimer(time.Hour)
+	if remaining, ok := c.tokenExpiry(); ok {
+		expiry.Reset(remaining)
+	} else {
+		expiry.Stop()
+	}
+	defer func() {
+		heartbeat.Stop()
+		expiry.Stop()
+	}()
+
+	buf.WriteString("retry: " + strconv.FormatInt(streamRetry.Milliseconds(), 10) + "\n\n")
+	if err := buf.Flush(); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-heartbeat.C:
+			buf.WriteString(": heartbeat\n\n")
+			if err := buf.Flush(); err != nil {
+				return
+			}
+
+		case <-expiry.C:
+			c.logger.Info("Access token expired, closing stream")
+			writeStreamEvent(buf, []byte(`{"type":"error","error":"Token expired"}`))
+			return
+
+		case <-c.closing:
+			return
+
+		case message, ok := <-c.Send:
+			if !ok {
+				return
+			}
+			if err := writeStreamEvent(buf, message); err != nil {
+				c.logger.Info("Stream client disconnected", "error", err)
+				return
+			}
+		}
+	}
+}
+
+// writeStreamEvent writes a JSON message as a Server-Sent Event, using the
+// message ID as event ID so clients can resume with Last-Event-ID
+func writeStreamEvent(buf *bufio.Writer, message []byte) error {
+	var event struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(message, &event); err == nil && event.ID != "" {
+		buf.WriteString("id: " + event.ID + "\n")
+	}
+
+	// Marshalled JSON never contains raw newlines, so one data line suffices
+	buf.WriteString("data: ")
+	buf.Write(message)
+	buf.WriteString("\n\n")
+	return buf.Flush()
+}
@@ -0,0 +1,168 @@
+package realtime
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamEvent is a Server-Sent Event read from a stream
+type streamEvent struct {
+	ID      string
+	Data    map[string]interface{}
+	Comment string
+}
+
+// startStreamServer serves the stream route behind a fake auth middleware that
+// treats the bearer token as the user ID, returning the stream URL of testFormID
+func startStreamServer(t *testing.T, cfg Config) (*WebSocketManager, string) {
+	manager := NewWebSocketManager(cfg, NewMemoryBackplane(), fakeTokens{}, fakeForms{})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go manager.Run(ctx)
+
+	auth := func(c *fiber.Ctx) error {
+		claims, err := fakeTokens{}.ValidateAccessToken(strings.TrimPrefix(c.Get("Authorization"), "Bearer "))
+		if err != nil {
+			return c.SendStatus(401)
+		}
+		c.Locals("userID", claims.UserID)
+		c.Locals("tokenExpiresAt", claims.ExpiresAt.Time)
+		return c.Next()
+	}
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/api/forms/:id/analytics/stream", auth, manager.HandleStream)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(listener)
+	t.Cleanup(func() { app.Shutdown() })
+
+	return manager, "http://" + listener.Addr().String() + "/api/forms/" + testFormID + "/analytics/stream"
+}
+
+// openStream requests a stream with the given token and Last-Event-ID
+func openStream(t *testing.T, url, token, lastEventID string) *http.Response {
+	req, err := http.NewRequest("GET", url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// readEvent reads the next event or comment from a stream, skipping retry fields
+func readEvent(t *testing.T, reader *bufio.Reader) streamEvent {
+	t.Helper()
+	var event streamEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if event.Data != nil || event.Comment != "" {
+				return event
+			}
+		case strings.HasPrefix(line, ":"):
+			event.Comment = strings.TrimSpace(strings.TrimPrefix(line, ":"))
+		case strings.HasPrefix(line, "id: "):
+			event.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.Data))
+		}
+	}
+}
+
+// readStreamType returns the next event of the given message type
+func readStreamType(t *testing.T, reader *bufio.Reader, messageType string) streamEvent {
+	t.Helper()
+	for {
+		if event := readEvent(t, reader); event.Data["type"] == messageType {
+			return event
+		}
+	}
+}
+
+func TestHandleStream(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamHeartbeat = 100 * time.Millisecond
+	manager, url := startStreamServer(t, cfg)
+
+	t.Run("Rejects users who do not own the form", func(t *testing.T) {
+		resp := openStream(t, url, "someone-else", "")
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+
+	t.Run("Rejects malformed Last-Event-ID", func(t *testing.T) {
+		resp := openStream(t, url, testOwnerID, "abc")
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	var lastID string
+	t.Run("Streams the same messages as the WebSocket", func(t *testing.T) {
+		resp := openStream(t, url, testOwnerID, "")
+		require.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		reader := bufio.NewReader(resp.Body)
+		readStreamType(t, reader, "connected")
+		assert.Equal(t, 1, manager.GetRoomCount(testFormID))
+
+		manager.Broadcast(testFormID, "analytics:update", map[string]int{"total": 1})
+		event := readStreamType(t, reader, "analytics:update")
+		assert.Equal(t, testFormID, event.Data["formId"])
+		assert.Equal(t, event.Data["id"], event.ID)
+		lastID = event.ID
+
+		manager.Broadcast(testFormID, "analytics:update", map[string]int{"total": 2})
+		manager.Broadcast(testFormID, "analytics:update", map[string]int{"total": 3})
+		readStreamType(t, reader, "analytics:update")
+		readStreamType(t, reader, "analytics:update")
+	})
+
+	t.Run("Sends heartbeat comments", func(t *testing.T) {
+		resp := openStream(t, url, testOwnerID, "")
+		reader := bufio.NewReader(resp.Body)
+		for {
+			if event := readEvent(t, reader); event.Comment != "" {
+				assert.Equal(t, "heartbeat", event.Comment)
+				return
+			}
+		}
+	})
+
+	t.Run("Resumes from Last-Event-ID", func(t *testing.T) {
+		resp := openStream(t, url, testOwnerID, lastID)
+		reader := bufio.NewReader(resp.Body)
+
+		totals := []float64{}
+		for len(totals) < 2 {
+			event := readStreamType(t, reader, "analytics:update")
+			totals = append(totals, event.Data["data"].(map[string]interface{})["total"].(float64))
+		}
+		assert.Equal(t, []float64{2, 3}, totals)
+	})
+
+	t.Run("Disconnected clients leave the room", func(t *testing.T) {
+		assert.Eventually(t, func() bool {
+			return manager.GetRoomCount(testFormID) == 0
+		}, 2*time.Second, 20*time.Millisecond)
+	})
+}
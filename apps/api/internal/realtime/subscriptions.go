@@ -0,0 +1,369 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+// ownerRoomPrefix prefixes the room keys of owner-wide "all my forms" channels
+const ownerRoomPrefix = "owner:"
+
+// maxCachedOwners bounds the form owner cache used to route broadcasts
+const maxCachedOwners = 10000
+
+// Subscription errors reported to clients
+var (
+	ErrRoomFull             = errors.New("room is full")
+	ErrTooManySubscriptions = errors.New("too many subscriptions")
+	errClientGone           = errors.New("client disconnected")
+)
+
+// clientMessage is a message sent by a connected client.
+//
+//	{"type":"subscribe","formIds":["..."],"allForms":true,"lastEventId":"..."}
+//	{"type":"unsubscribe","formIds":["..."],"allForms":true}
+//	{"type":"auth","token":"..."}
+//	{"type":"ping","timestamp":...}
+type clientMessage struct {
+	Type        string      `json:"type"`
+	Token       string      `json:"token"`
+	FormIDs     []string    `json:"formIds"`
+	AllForms    bool        `json:"allForms"`
+	LastEventID string      `json:"lastEventId"`
+	Timestamp   interface{} `json:"timestamp"`
+}
+
+// ownerRoom returns the room key of a user's all-forms channel
+func ownerRoom(userID string) string {
+	return ownerRoomPrefix + userID
+}
+
+// normalizeFormID removes spaces and slashes from a form ID
+func normalizeFormID(formID string) string {
+	normalized := strings.TrimSpace(formID)
+	normalized = strings.Trim(normalized, "/")
+	normalized = strings.ReplaceAll(normalized, "/", "")
+	return strings.ReplaceAll(normalized, " ", "")
+}
+
+// subscribe adds the client to the requested form rooms and, for allForms, to the
+// owner channel, replaying buffered events newer than lastEventId
+func (c *Client) subscribe(msg *clientMessage) {
+	var since int64
+	if msg.LastEventID != "" {
+		var ok bool
+		if since, ok = parseEventID(msg.LastEventID); !ok {
+			c.sendJSON(map[string]interface{}{"type": "error", "error": "Invalid lastEventId"})
+			return
+		}
+	}
+
+	accepted := make([]string, 0, len(msg.FormIDs))
+	for _, raw := range msg.FormIDs {
+		formID := normalizeFormID(raw)
+		if !utils.IsValidObjectID(formID) {
+			c.sendError(raw, "Invalid form ID format")
+			continue
+		}
+		if !c.Manager.canAccess(formID, c.UserID) {
+			c.sendError(formID, "Form not found")
+			continue
+		}
+		if err := c.Manager.join(c, formID, formID, since); err != nil {
+			c.sendError(formID, err.Error())
+			continue
+		}
+		accepted = append(accepted, formID)
+	}
+
+	allForms := false
+	if msg.AllForms {
+		if err := c.Manager.join(c, ownerRoom(c.UserID), "", since); err != nil {
+			c.sendJSON(map[string]interface{}{"type": "error", "allForms": true, "error": err.Error()})
+		} else {
+			allForms = true
+		}
+	}
+
+	c.sendJSON(map[string]interface{}{
+		"type":     "subscribed",
+		"formIds":  accepted,
+		"allForms": allForms,
+	})
+}
+
+// unsubscribe removes the client from the given form rooms and owner channel
+func (c *Client) unsubscribe(msg *clientMessage) {
+	removed := make([]string, 0, len(msg.FormIDs))
+	for _, raw := range msg.FormIDs {
+		formID := normalizeFormID(raw)
+		if c.Manager.leave(c, formID) {
+			removed = append(removed, formID)
+		}
+	}
+
+	allForms := msg.AllForms && c.Manager.leave(c, ownerRoom(c.UserID))
+
+	c.sendJSON(map[string]interface{}{
+		"type":     "unsubscribed",
+		"formIds":  removed,
+		"allForms": allForms,
+	})
+}
+
+// sendError reports a failed subscription to a form
+func (c *Client) sendError(formID, text string) {
+	c.sendJSON(map[string]interface{}{
+		"type":   "error",
+		"formId": formID,
+		"error":  text,
+	})
+}
+
+// sendJSON queues a message for a registered client without blocking.
+// It reports false when the client is gone or its queue is full.
+func (c *Client) sendJSON(v interface{}) bool {
+	data, err := json.Marshal(v)
+	if err != nil {
+		c.logger.Error("Failed to marshal message", "error", err)
+		return false
+	}
+
+	// Send is closed under the write lock when the client is unregistered
+	c.Manager.mutex.RLock()
+	defer c.Manager.mutex.RUnlock()
+
+	if !c.Manager.clients[c] {
+		return false
+	}
+	return deliver(c, data)
+}
+
+// deliver queues data for the client without blocking. The caller holds the manager mutex.
+func deliver(c *Client, data []byte) bool {
+	select {
+	case c.Send <- data:
+		return true
+	default:
+		return false
+	}
+}
+
+// join adds the client to a room. formID is empty for owner channels.
+func (w *WebSocketManager) join(c *Client, room, formID string, since int64) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.joinLocked(c, room, formID, since)
+}
+
+// joinLocked adds the client to a room and replays events newer than since.
+// The caller holds the manager mutex.
+func (w *WebSocketManager) joinLocked(c *Client, room, formID string, since int64) error {
+	if !w.clients[c] {
+		return errClientGone
+	}
+	if c.rooms[room] {
+		return nil
+	}
+	if w.cfg.MaxSubscriptions > 0 && len(c.rooms) >= w.cfg.MaxSubscriptions {
+		return ErrTooManySubscriptions
+	}
+	if formID != "" && w.cfg.MaxConnectionsPerRoom > 0 && len(w.rooms[room]) >= w.cfg.MaxConnectionsPerRoom {
+		return ErrRoomFull
+	}
+
+	if w.rooms[room] == nil {
+		w.rooms[room] = make(map[*Client]bool)
+	}
+	w.rooms[room][c] = true
+	c.rooms[room] = true
+
+	c.logger.Info("Client joined room", "room", room, "room_size", len(w.rooms[room]))
+
+	if since > 0 {
+		w.replayLocked(c, formID, since)
+	}
+	return nil
+}
+
+// leave removes the client from a room and reports whether it was subscribed
+func (w *WebSocketManager) leave(c *Client, room string) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !c.rooms[room] {
+		return false
+	}
+	delete(c.rooms, room)
+	w.removeFromRoom(c, room)
+
+	c.logger.Info("Client left room", "room", room)
+	return true
+}
+
+// removeFromRoom deletes the client from a room, dropping empty rooms. The caller holds the manager mutex.
+func (w *WebSocketManager) removeFromRoom(c *Client, room string) {
+	clients, exists := w.rooms[room]
+	if !exists {
+		return
+	}
+	delete(clients, c)
+	if len(clients) == 0 {
+		delete(w.rooms, room)
+	}
+}
+
+// replayLocked sends the buffered events newer than since for one form, or for every
+// form of the client's user when formID is empty. Forms whose missed events are no
+// longer buffered get a replay:gap message instead so the client refetches them.
+// The caller holds the manager mutex.
+func (w *WebSocketManager) replayLocked(c *Client, formID string, since int64) {
+	gap := map[string]interface{}{"type": "replay:gap"}
+	if formID != "" {
+		gap["formId"] = formID
+	} else {
+		gap["allForms"] = true
+	}
+
+	// Events from before this instance started are unknown
+	if since < w.replayFloor {
+		w.sendLocked(c, gap)
+		return
+	}
+
+	var events []replayEvent
+	collect := func(id string, buffer *replayBuffer) {
+		missed, complete := buffer.since(since)
+		if !complete {
+			w.sendLocked(c, map[string]interface{}{"type": "replay:gap", "formId": id})
+			return
+		}
+		events = append(events, missed...)
+	}
+
+	if formID != "" {
+		if buffer, exists := w.replay[formID]; exists {
+			collect(formID, buffer)
+		}
+	} else {
+		for id, buffer := range w.replay {
+			if buffer.ownerID == c.UserID {
+				collect(id, buffer)
+			}
+		}
+		sort.Slice(events, func(i, j int) bool { return events[i].seq < events[j].seq })
+	}
+
+	for _, event := range events {
+		if !deliver(c, event.data) {
+			w.dropped.Add(1)
+			c.logger.Warn("Send channel full during replay, disconnecting")
+			go w.UnregisterClient(c)
+			return
+		}
+	}
+}
+
+// sendLocked queues a control message for the client. The caller holds the manager mutex.
+func (w *WebSocketManager) sendLocked(c *Client, v interface{}) {
+	if data, err := json.Marshal(v); err == nil {
+		deliver(c, data)
+	}
+}
+
+// remember buffers a delivered event for replay. The caller holds the manager mutex.
+func (w *WebSocketManager) remember(message *Message, seq int64, data []byte) {
+	if w.cfg.ReplayBufferSize <= 0 || seq == 0 || !replayedTypes[message.Type] {
+		return
+	}
+
+	buffer, exists := w.replay[message.FormID]
+	if !exists {
+		buffer = &replayBuffer{}
+		w.replay[message.FormID] = buffer
+	}
+	if message.OwnerID != "" {
+		buffer.ownerID = message.OwnerID
+	}
+	buffer.add(replayEvent{seq: seq, data: data, at: time.Now()}, w.cfg.ReplayBufferSize)
+}
+
+// expireReplay drops buffered events older than the replay retention
+func (w *WebSocketManager) expireReplay() {
+	if w.cfg.ReplayRetention <= 0 {
+		return
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	cutoff := time.Now().Add(-w.cfg.ReplayRetention)
+	for _, buffer := range w.replay {
+		buffer.expire(cutoff)
+	}
+}
+
+// nextEventID returns a new event ID. IDs are based on the clock so that they stay
+// comparable across instances and restarts, and strictly increase per instance.
+func (w *WebSocketManager) nextEventID() int64 {
+	for {
+		last := w.lastEventID.Load()
+		next := time.Now().UnixNano()
+		if next <= last {
+			next = last + 1
+		}
+		if w.lastEventID.CompareAndSwap(last, next) {
+			return next
+		}
+	}
+}
+
+// observeEventID keeps local event IDs ahead of IDs received from other instances
+func (w *WebSocketManager) observeEventID(seq int64) {
+	for {
+		last := w.lastEventID.Load()
+		if seq <= last || w.lastEventID.CompareAndSwap(last, seq) {
+			return
+		}
+	}
+}
+
+// ownerOf returns the owner of a personal form so its events reach the owner's
+// all-forms channel. Organization forms have no owner channel: members subscribe
+// to them one by one.
+func (w *WebSocketManager) ownerOf(formID string) string {
+	w.ownersMutex.Lock()
+	ownerID, cached := w.owners[formID]
+	w.ownersMutex.Unlock()
+	if cached {
+		return ownerID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), accessCheckTimeout)
+	defer cancel()
+
+	form, err := w.forms.GetFormByID(ctx, formID, nil)
+	if err != nil {
+		slog.Warn("Failed to resolve form owner for broadcast", "form_id", formID, "error", err)
+		return ""
+	}
+	if form.OwnerID != nil && form.OrganizationID == nil {
+		ownerID = *form.OwnerID
+	}
+
+	w.ownersMutex.Lock()
+	if len(w.owners) >= maxCachedOwners {
+		w.owners = make(map[string]string)
+	}
+	w.owners[formID] = ownerID
+	w.ownersMutex.Unlock()
+
+	return ownerID
+}
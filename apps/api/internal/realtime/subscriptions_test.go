@@ -0,0 +1,218 @@
+package realtime
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readType returns the next message of the given type, skipping other messages
+func readType(t *testing.T, conn *websocket.Conn, messageType string) map[string]interface{} {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var msg map[string]interface{}
+		require.NoError(t, conn.ReadJSON(&msg))
+		if msg["type"] == messageType {
+			return msg
+		}
+	}
+}
+
+// readUpdates returns the form IDs and totals of the next n analytics updates
+func readUpdates(t *testing.T, conn *websocket.Conn, n int) []string {
+	t.Helper()
+	updates := make([]string, 0, n)
+	for len(updates) < n {
+		msg := readType(t, conn, "analytics:update")
+		total := msg["data"].(map[string]interface{})["total"]
+		updates = append(updates, msg["formId"].(string)+":"+strconv.Itoa(int(total.(float64))))
+	}
+	return updates
+}
+
+// subscribeURL returns the URL of the form-less subscription endpoint
+func subscribeURL(baseURL string) string {
+	return strings.TrimSuffix(baseURL, "/forms/")
+}
+
+// broadcastAndWait broadcasts an update and waits until it is buffered for replay
+func broadcastAndWait(t *testing.T, manager *WebSocketManager, formID string, total int) string {
+	t.Helper()
+	before := manager.lastEventID.Load()
+	manager.Broadcast(formID, "analytics:update", map[string]int{"total": total})
+
+	var id string
+	require.Eventually(t, func() bool {
+		manager.mutex.RLock()
+		defer manager.mutex.RUnlock()
+		buffer := manager.replay[formID]
+		if buffer == nil || len(buffer.events) == 0 || buffer.events[len(buffer.events)-1].seq <= before {
+			return false
+		}
+		id = formatEventID(buffer.events[len(buffer.events)-1].seq)
+		return true
+	}, time.Second, 5*time.Millisecond)
+	return id
+}
+
+func TestSubscriptions_MultipleForms(t *testing.T) {
+	manager, baseURL := startTestServer(t, DefaultConfig())
+	conn := dial(t, subscribeURL(baseURL)+"?token="+testOwnerID)
+	readType(t, conn, "connected")
+
+	require.NoError(t, conn.WriteJSON(clientMessage{
+		Type:    "subscribe",
+		FormIDs: []string{testFormID, testOtherFormID, "not-a-form", "507f1f77bcf86cd799439033"},
+	}))
+
+	t.Run("Inaccessible forms are reported", func(t *testing.T) {
+		assert.Equal(t, "Invalid form ID format", readType(t, conn, "error")["error"])
+		assert.Equal(t, "Form not found", readType(t, conn, "error")["error"])
+	})
+
+	t.Run("Accessible forms are subscribed on one connection", func(t *testing.T) {
+		ack := readType(t, conn, "subscribed")
+		assert.ElementsMatch(t, []interface{}{testFormID, testOtherFormID}, ack["formIds"])
+		assert.Equal(t, 1, manager.GetRoomCount(testFormID))
+		assert.Equal(t, 1, manager.GetRoomCount(testOtherFormID))
+		assert.Equal(t, 1, manager.GetTotalConnections())
+
+		manager.Broadcast(testFormID, "analytics:update", map[string]int{"total": 1})
+		manager.Broadcast(testOtherFormID, "analytics:update", map[string]int{"total": 2})
+		assert.Equal(t, []string{testFormID + ":1", testOtherFormID + ":2"}, readUpdates(t, conn, 2))
+	})
+
+	t.Run("Unsubscribed forms stop receiving updates", func(t *testing.T) {
+		require.NoError(t, conn.WriteJSON(clientMessage{Type: "unsubscribe", FormIDs: []string{testOtherFormID}}))
+		ack := readType(t, conn, "unsubscribed")
+		assert.Equal(t, []interface{}{testOtherFormID}, ack["formIds"])
+		assert.Equal(t, 0, manager.GetRoomCount(testOtherFormID))
+
+		manager.Broadcast(testOtherFormID, "analytics:update", map[string]int{"total": 3})
+		manager.Broadcast(testFormID, "analytics:update", map[string]int{"total": 4})
+		assert.Equal(t, []string{testFormID + ":4"}, readUpdates(t, conn, 1))
+	})
+
+	t.Run("Subscription limit", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.MaxSubscriptions = 1
+		_, limitedURL := startTestServer(t, cfg)
+		limited := dial(t, limitedURL+testFormID+"?token="+testOwnerID)
+		readType(t, limited, "connected")
+
+		require.NoError(t, limited.WriteJSON(clientMessage{Type: "subscribe", FormIDs: []string{testOtherFormID}}))
+		assert.Equal(t, ErrTooManySubscriptions.Error(), readType(t, limited, "error")["error"])
+	})
+}
+
+func TestSubscriptions_OwnerChannel(t *testing.T) {
+	manager, baseURL := startTestServer(t, DefaultConfig())
+	conn := dial(t, subscribeURL(baseURL)+"?token="+testOwnerID)
+
+	require.NoError(t, conn.WriteJSON(clientMessage{Type: "subscribe", FormIDs: []string{testFormID}, AllForms: true}))
+	ack := readType(t, conn, "subscribed")
+	assert.Equal(t, true, ack["allForms"])
+
+	t.Run("Updates of every owned form arrive once", func(t *testing.T) {
+		manager.Broadcast(testFormID, "analytics:update", map[string]int{"total": 1})
+		manager.Broadcast(testOtherFormID, "analytics:update", map[string]int{"total": 2})
+		manager.Broadcast(testFormID, "analytics:update", map[string]int{"total": 3})
+		assert.Equal(t, []string{testFormID + ":1", testOtherFormID + ":2", testFormID + ":3"}, readUpdates(t, conn, 3))
+	})
+
+	t.Run("Other users do not receive the owner's updates", func(t *testing.T) {
+		other := dial(t, subscribeURL(baseURL)+"?token=someone-else")
+		require.NoError(t, other.WriteJSON(clientMessage{Type: "subscribe", AllForms: true}))
+		readType(t, other, "subscribed")
+
+		manager.Broadcast(testFormID, "analytics:update", map[string]int{"total": 4})
+		readUpdates(t, conn, 1)
+
+		other.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		var msg map[string]interface{}
+		assert.Error(t, other.ReadJSON(&msg))
+	})
+}
+
+func TestSubscriptions_Replay(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ReplayBufferSize = 3
+	manager, baseURL := startTestServer(t, cfg)
+
+	first := broadcastAndWait(t, manager, testFormID, 1)
+	broadcastAndWait(t, manager, testOtherFormID, 2)
+	broadcastAndWait(t, manager, testFormID, 3)
+
+	t.Run("Form connection replays missed events", func(t *testing.T) {
+		conn := dial(t, baseURL+testFormID+"?token="+testOwnerID+"&lastEventId="+first)
+		assert.Equal(t, []string{testFormID + ":3"}, readUpdates(t, conn, 1))
+	})
+
+	t.Run("Owner channel replays missed events of every form in order", func(t *testing.T) {
+		conn := dial(t, subscribeURL(baseURL)+"?token="+testOwnerID)
+		require.NoError(t, conn.WriteJSON(clientMessage{Type: "subscribe", AllForms: true, LastEventID: first}))
+		assert.Equal(t, []string{testOtherFormID + ":2", testFormID + ":3"}, readUpdates(t, conn, 2))
+	})
+
+	t.Run("Evicted events are reported as a gap", func(t *testing.T) {
+		for total := 4; total <= 6; total++ {
+			broadcastAndWait(t, manager, testFormID, total)
+		}
+
+		conn := dial(t, baseURL+testFormID+"?token="+testOwnerID+"&lastEventId="+first)
+		gap := readType(t, conn, "replay:gap")
+		assert.Equal(t, testFormID, gap["formId"])
+	})
+
+	t.Run("Events from before the instance started are reported as a gap", func(t *testing.T) {
+		conn := dial(t, subscribeURL(baseURL)+"?token="+testOwnerID)
+		require.NoError(t, conn.WriteJSON(clientMessage{Type: "subscribe", FormIDs: []string{testOtherFormID}, LastEventID: "1"}))
+		assert.Equal(t, testOtherFormID, readType(t, conn, "replay:gap")["formId"])
+	})
+
+	t.Run("Malformed lastEventId is rejected", func(t *testing.T) {
+		_, status, err := dialOrigin(baseURL+testFormID+"?token="+testOwnerID+"&lastEventId=abc", "http://localhost:3000")
+		require.Error(t, err)
+		assert.Equal(t, 400, status)
+	})
+}
+
+func TestReplayBuffer(t *testing.T) {
+	buffer := &replayBuffer{}
+	start := time.Now()
+	for seq := int64(1); seq <= 5; seq++ {
+		buffer.add(replayEvent{seq: seq, at: start.Add(time.Duration(seq) * time.Second)}, 3)
+	}
+
+	t.Run("Keeps the newest events", func(t *testing.T) {
+		events, complete := buffer.since(2)
+		assert.True(t, complete)
+		assert.Len(t, events, 3)
+
+		events, complete = buffer.since(4)
+		assert.True(t, complete)
+		require.Len(t, events, 1)
+		assert.Equal(t, int64(5), events[0].seq)
+	})
+
+	t.Run("Reports evicted events", func(t *testing.T) {
+		_, complete := buffer.since(1)
+		assert.False(t, complete)
+	})
+
+	t.Run("Expires old events", func(t *testing.T) {
+		buffer.expire(start.Add(4500 * time.Millisecond))
+		events, complete := buffer.since(4)
+		assert.True(t, complete)
+		assert.Len(t, events, 1)
+
+		_, complete = buffer.since(3)
+		assert.False(t, complete)
+	})
+}
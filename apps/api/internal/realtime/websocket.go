@@ -1,37 +1,186 @@
 package realtime
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	fiber "github.com/gofiber/fiber/v2"
 	websocket "github.com/gofiber/websocket/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/tracing"
 	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
 )
 
-// Client represents a WebSocket client for form analytics
+// Close codes sent to clients that fail authentication or authorization
+const (
+	CloseUnauthorized = 4401
+	CloseForbidden    = 4403
+)
+
+// accessCheckTimeout bounds the form ownership lookup
+const accessCheckTimeout = 5 * time.Second
+
+// shutdownCloseReason is sent to clients disconnected because the server stops
+const shutdownCloseReason = "server restarting"
+
+// HeartbeatInterval is how often the event loop records that it is running
+const HeartbeatInterval = time.Second
+
+// TokenValidator validates access tokens presented by WebSocket clients and checks
+// that their session was not revoked
+type TokenValidator interface {
+	ValidateAccessToken(tokenString string) (*services.Claims, error)
+	CheckSession(ctx context.Context, sessionID string) error
+}
+
+// errInvalidToken is returned for access tokens that fail validation
+var errInvalidToken = errors.New("invalid or expired token")
+
+// FormAccessChecker looks up a form on behalf of a user
+type FormAccessChecker interface {
+	GetFormByID(ctx context.Context, formID string, actor *services.Actor) (*models.FormResponse, error)
+}
+
+// authMessage is the first message sent by clients that did not pass a token in the URL,
+// and the message used to refresh the token of an open connection
+type authMessage struct {
+	Type  string `json:"type"`
+	Token string `json:"token"`
+}
+
+// Client represents a WebSocket client for form analytics.
+// FormID is the form given in the connection URL, if any; clients may subscribe to
+// further forms and to their owner channel with subscribe messages.
 type Client struct {
 	ID      string
 	FormID  string
+	UserID  string
 	Conn    *websocket.Conn
 	Send    chan []byte
 	Manager *WebSocketManager
+
+	// rooms the client is subscribed to, guarded by the manager mutex
+	rooms map[string]bool
+
+	// resumeFrom is the lastEventId the client connected with
+	resumeFrom int64
+
+	// registered is closed once the manager has registered the client
+	registered chan struct{}
+
+	// expiresAt is the access token expiry in Unix nanoseconds
+	expiresAt atomic.Int64
+
+	// closing carries a close frame the write pump sends before disconnecting
+	closing chan []byte
+
+	// done is closed when the write pump has stopped using the connection
+	done chan struct{}
+
+	// logger tags the client's log records with its ID, user and originating request
+	logger *slog.Logger
+}
+
+// newClient creates a client for an authenticated user
+func newClient(manager *WebSocketManager, conn *websocket.Conn, formID string, claims *services.Claims, requestID string) *Client {
+	id := utils.GenerateRandomString(16)
+	client := &Client{
+		ID:         id,
+		FormID:     formID,
+		UserID:     claims.UserID,
+		Conn:       conn,
+		Send:       make(chan []byte, manager.cfg.SendQueueSize),
+		Manager:    manager,
+		rooms:      make(map[string]bool),
+		registered: make(chan struct{}),
+		closing:    make(chan []byte, 1),
+		done:       make(chan struct{}),
+		logger:     clientLogger(id, claims.UserID, requestID),
+	}
+	client.setExpiry(claims)
+	return client
+}
+
+// clientLogger returns a logger tagging records with the client's identity
+func clientLogger(clientID, userID, requestID string) *slog.Logger {
+	return slog.With("client_id", clientID, "user_id", userID, "request_id", requestID)
+}
+
+// setExpiry records when the client's access token expires
+func (c *Client) setExpiry(claims *services.Claims) {
+	if claims.ExpiresAt == nil {
+		c.expiresAt.Store(0)
+		return
+	}
+	c.expiresAt.Store(claims.ExpiresAt.UnixNano())
+}
+
+// tokenExpiry returns the remaining lifetime of the client's access token.
+// ok is false when the token does not expire.
+func (c *Client) tokenExpiry() (remaining time.Duration, ok bool) {
+	expiresAt := c.expiresAt.Load()
+	if expiresAt == 0 {
+		return 0, false
+	}
+	return time.Until(time.Unix(0, expiresAt)), true
+}
+
+// closeWith asks the write pump to send a close frame and disconnect the client
+func (c *Client) closeWith(code int, text string) {
+	select {
+	case c.closing <- websocket.FormatCloseMessage(code, text):
+	default:
+		// A close is already pending
+	}
 }
 
 // IsValid checks if the client has valid data
 func (c *Client) IsValid() bool {
-	return len(c.FormID) == 24 && !strings.Contains(c.FormID, "/") && c.ID != ""
+	if c.FormID != "" && (len(c.FormID) != 24 || strings.Contains(c.FormID, "/")) {
+		return false
+	}
+	return c.ID != ""
 }
 
 // WebSocketManager manages real-time WebSocket connections for form analytics
 type WebSocketManager struct {
-	// Registered clients grouped by form ID
+	// Registered clients grouped by room: form IDs and owner channels
 	rooms map[string]map[*Client]bool
 
+	// All registered clients
+	clients map[*Client]bool
+
+	// Recent events per form for clients resuming with a lastEventId.
+	// Events up to replayFloor predate this instance and cannot be replayed.
+	replay      map[string]*replayBuffer
+	replayFloor int64
+	lastEventID atomic.Int64
+
+	// Form owners, used to route events to owner channels
+	owners      map[string]string
+	ownersMutex sync.Mutex
+
+	// Throttles analytics broadcasts per form
+	analytics *analyticsThrottle
+
+	// Messages dropped on full queues and analytics updates merged by the throttle
+	dropped   atomic.Int64
+	coalesced atomic.Int64
+
+	// heartbeat is when the event loop last ran, in Unix nanoseconds
+	heartbeat atomic.Int64
+
 	// Register requests from clients
 	register chan *Client
 
@@ -41,36 +190,120 @@ type WebSocketManager struct {
 	// Broadcast messages to all clients in a room
 	broadcast chan *Message
 
+	// Messages waiting to be published to other instances
+	outbound chan *Message
+
+	// Closed once Run has stopped; clients are then registered and unregistered directly
+	stopped chan struct{}
+
+	// Backplane shared with other instances; id tells this instance's messages apart
+	backplane Backplane
+	id        string
+
 	// Mutex for thread-safe operations
 	mutex sync.RWMutex
+
+	// Authentication and authorization of subscribers
+	tokens TokenValidator
+	forms  FormAccessChecker
+
+	// Connection settings and limits
+	cfg Config
+
+	// Open connections per remote IP
+	ipConnections map[string]int
+	ipMutex       sync.Mutex
 }
 
 // Message represents an analytics message to be broadcast
 type Message struct {
+	ID     string      `json:"id,omitempty"`
 	FormID string      `json:"formId"`
 	Type   string      `json:"type"`
 	Data   interface{} `json:"data"`
+
+	// OwnerID routes the message to the owner's all-forms channel
+	OwnerID string `json:"-"`
+
+	// UserID names the user whose access changed in access control messages
+	UserID string `json:"-"`
+
+	// spanContext is the trace the message was broadcast in
+	spanContext trace.SpanContext
 }
 
-// NewWebSocketManager creates a new WebSocket manager
-func NewWebSocketManager() *WebSocketManager {
-	return &WebSocketManager{
-		rooms:      make(map[string]map[*Client]bool),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		broadcast:  make(chan *Message, 256),
+// NewWebSocketManager creates a new WebSocket manager.
+// Broadcasts are fanned out to other instances through the backplane, and
+// subscribers must present a valid access token and own the form they subscribe to.
+func NewWebSocketManager(cfg Config, backplane Backplane, tokens TokenValidator, forms FormAccessChecker) *WebSocketManager {
+	manager := &WebSocketManager{
+		rooms:         make(map[string]map[*Client]bool),
+		clients:       make(map[*Client]bool),
+		replay:        make(map[string]*replayBuffer),
+		owners:        make(map[string]string),
+		register:      make(chan *Client),
+		unregister:    make(chan *Client),
+		stopped:       make(chan struct{}),
+		broadcast:     make(chan *Message, cfg.BroadcastQueueSize),
+		outbound:      make(chan *Message, cfg.BroadcastQueueSize),
+		backplane:     backplane,
+		id:            utils.GenerateRandomString(16),
+		tokens:        tokens,
+		forms:         forms,
+		cfg:           cfg,
+		ipConnections: make(map[string]int),
 	}
+	manager.replayFloor = manager.nextEventID()
+	manager.analytics = newAnalyticsThrottle(manager, cfg.AnalyticsRate, cfg.SnapshotInterval)
+	return manager
 }
 
-// Run starts the WebSocket manager
-func (w *WebSocketManager) Run() {
-	log.Println("INFO: WebSocket manager started for real-time analytics")
+// Run starts the WebSocket manager and blocks until ctx is cancelled. On shutdown it
+// stops its background goroutines, sends every client a close frame and waits
+// (bounded by the write timeout) for the clients to disconnect.
+func (w *WebSocketManager) Run(ctx context.Context) {
+	slog.Info("WebSocket manager started")
+
+	// Receive broadcasts published by other instances
+	unsubscribe, err := w.backplane.Subscribe(w.receive)
+	if err != nil {
+		slog.Error("Failed to subscribe to realtime backplane, broadcasts stay local", "error", err)
+	}
+
+	var workers sync.WaitGroup
+	workers.Add(2)
+	go func() {
+		defer workers.Done()
+		w.publishLoop(ctx)
+	}()
 
 	// Start periodic cleanup routine
-	go w.periodicCleanup()
+	go func() {
+		defer workers.Done()
+		w.periodicCleanup(ctx)
+	}()
+
+	// The heartbeat shows health checks that the loop is not stuck
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+	w.heartbeat.Store(time.Now().UnixNano())
 
 	for {
 		select {
+		case <-ctx.Done():
+			w.heartbeat.Store(0)
+			if unsubscribe != nil {
+				unsubscribe()
+			}
+			w.analytics.stop()
+			workers.Wait()
+			w.closeAll()
+			slog.Info("WebSocket manager stopped")
+			return
+
+		case <-heartbeat.C:
+			w.heartbeat.Store(time.Now().UnixNano())
+
 		case client := <-w.register:
 			w.registerClient(client)
 
@@ -83,19 +316,63 @@ func (w *WebSocketManager) Run() {
 	}
 }
 
-// periodicCleanup runs every minute to log room status
-func (w *WebSocketManager) periodicCleanup() {
+// closeAll stops accepting clients, asks every client to disconnect and waits
+// until they are unregistered or the write timeout passes
+func (w *WebSocketManager) closeAll() {
+	w.mutex.Lock()
+	close(w.stopped)
+	for client := range w.clients {
+		client.closeWith(websocket.CloseServiceRestart, shutdownCloseReason)
+	}
+	remaining := len(w.clients)
+	w.mutex.Unlock()
+
+	if remaining > 0 {
+		slog.Info("Closing realtime clients for shutdown", "clients", remaining)
+	}
+
+	deadline := time.Now().Add(w.cfg.WriteTimeout)
+	for remaining > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		remaining = w.GetTotalConnections()
+	}
+	if remaining > 0 {
+		slog.Warn("Realtime clients did not disconnect before shutdown", "clients", remaining)
+	}
+}
+
+// isStopped reports whether Run has stopped
+func (w *WebSocketManager) isStopped() bool {
+	select {
+	case <-w.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// periodicCleanup runs every minute to expire replay buffers and log room status
+func (w *WebSocketManager) periodicCleanup(ctx context.Context) {
 	ticker := time.NewTicker(60 * time.Second)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		w.expireReplay()
+		w.analytics.expire()
+
 		w.mutex.RLock()
 
-		log.Printf("INFO: Periodic room status check - %d rooms active", len(w.rooms))
+		slog.Info("Periodic room status check", "rooms", len(w.rooms))
 		for roomKey, clients := range w.rooms {
 			clientCount := len(clients)
 			if clientCount > 0 {
-				log.Printf("INFO: Room '%s' (len:%d) has %d active clients", roomKey, len(roomKey), clientCount)
+				slog.Debug("Room status", "room", roomKey, "clients", clientCount)
 			}
 		}
 
@@ -103,7 +380,10 @@ func (w *WebSocketManager) periodicCleanup() {
 	}
 }
 
-// HandleConnection handles WebSocket connections for real-time analytics
+// HandleConnection handles WebSocket connections for real-time analytics.
+// Connections to /ws/forms/:id start subscribed to that form; connections without a
+// form ID subscribe with subscribe messages. A lastEventId query parameter replays
+// events missed since a previous connection.
 func (w *WebSocketManager) HandleConnection(c *fiber.Ctx) error {
 	// Check if it's a WebSocket upgrade request
 	if !websocket.IsWebSocketUpgrade(c) {
@@ -112,136 +392,393 @@ func (w *WebSocketManager) HandleConnection(c *fiber.Ctx) error {
 		})
 	}
 
-	// Extract form ID from URL parameter
-	formIDParam := c.Params("id")
+	// Extract form ID from URL parameter. Fiber reuses the request buffer once the
+	// connection is hijacked, so the handler below needs its own copy.
+	formIDParam := strings.Clone(c.Params("id"))
 
 	// Clean and normalize form ID - remove any spaces, slashes, or special characters
-	formID := strings.TrimSpace(formIDParam)
-	formID = strings.Trim(formID, "/")
-	formID = strings.ReplaceAll(formID, "/", "")
-	formID = strings.ReplaceAll(formID, " ", "")
+	formID := normalizeFormID(formIDParam)
 
-	if formID == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Form ID is required",
-		})
-	}
+	// The request ID is set by the request ID middleware and owned by the context locals
+	requestID, _ := c.Locals("requestID").(string)
 
 	// Validate form ID format
-	if !utils.IsValidObjectID(formID) {
-		log.Printf("WARN: Invalid ObjectID format for WebSocket connection: '%s'", formID)
+	if formID != "" && !utils.IsValidObjectID(formID) {
+		slog.WarnContext(c.UserContext(), "Invalid form ID for WebSocket connection", "form_id", formID)
 		return c.Status(400).JSON(fiber.Map{
 			"error": "Invalid form ID format",
 		})
 	}
 
+	var resumeFrom int64
+	if lastEventID := c.Query("lastEventId"); lastEventID != "" {
+		var ok bool
+		if resumeFrom, ok = parseEventID(lastEventID); !ok {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Invalid lastEventId",
+			})
+		}
+	}
+
+	if w.isStopped() {
+		return c.Status(503).JSON(fiber.Map{
+			"error": "Server is shutting down",
+		})
+	}
+
+	// Reject clients over the per-IP limit before upgrading
+	ip := c.IP()
+	if w.ipLimitReached(ip) {
+		slog.WarnContext(c.UserContext(), "Rejecting WebSocket connection, too many connections", "ip", ip)
+		return c.Status(429).JSON(fiber.Map{
+			"error": "Too many WebSocket connections",
+		})
+	}
+
+	// A token passed in the URL is checked before upgrading so the client gets a plain HTTP error
+	var claims *services.Claims
+	if token := requestToken(c); token != "" {
+		var err error
+		claims, err = w.authenticate(token)
+		if err != nil {
+			status, _, text := authFailure(err)
+			return c.Status(status).JSON(fiber.Map{
+				"error": text,
+			})
+		}
+
+		if formID != "" && !w.canAccess(formID, claims.UserID) {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Form not found",
+			})
+		}
+	}
+
 	// Handle WebSocket connection with proper configuration
 	return websocket.New(func(conn *websocket.Conn) {
 		defer func() {
 			if r := recover(); r != nil {
-				log.Printf("ERROR: WebSocket handler panic: %v", r)
+				slog.Error("WebSocket handler panic", "panic", r, "request_id", requestID)
 			}
 		}()
 
-		// Generate unique client ID
-		clientID := utils.GenerateRandomString(16)
+		// Reserve a connection slot for the client's IP
+		if !w.acquireIP(ip) {
+			closeConnection(conn, websocket.ClosePolicyViolation, "Too many connections")
+			return
+		}
+		defer w.releaseIP(ip)
 
 		// Validate FormID one more time before creating client
-		if len(formID) != 24 || strings.Contains(formID, "/") {
-			log.Printf("ERROR: Rejecting WebSocket connection - invalid FormID: '%s' (length: %d)", formID, len(formID))
+		if formID != "" && (len(formID) != 24 || strings.Contains(formID, "/")) {
+			slog.Error("Rejecting WebSocket connection with invalid form ID", "form_id", formID, "request_id", requestID)
 			conn.WriteMessage(websocket.CloseMessage, []byte("Invalid form ID"))
 			return
 		}
 
+		// Without a URL token the first message must authenticate the connection
+		if claims == nil {
+			var code int
+			var reason string
+			claims, code, reason = w.authenticateConnection(conn, formID)
+			if claims == nil {
+				slog.Warn("Rejecting WebSocket connection", "form_id", formID, "reason", reason, "request_id", requestID)
+				closeConnection(conn, code, reason)
+				return
+			}
+		}
+
+		if formID != "" && w.cfg.MaxConnectionsPerRoom > 0 && w.GetRoomCount(formID) >= w.cfg.MaxConnectionsPerRoom {
+			slog.Warn("Rejecting WebSocket connection, room is full", "form_id", formID, "request_id", requestID)
+			closeConnection(conn, websocket.CloseTryAgainLater, "Room is full")
+			return
+		}
+
 		// Create a deep copy of formID to ensure it's immutable
 		// Use a fresh string allocation to prevent any possible mutation
 		formIDBytes := []byte(formID)
 		immutableFormID := string(append([]byte{}, formIDBytes...))
 
 		// Create client with immutable FormID
-		client := &Client{
-			ID:      clientID,
-			FormID:  immutableFormID,
-			Conn:    conn,
-			Send:    make(chan []byte, 1024),
-			Manager: w,
-		}
+		client := newClient(w, conn, immutableFormID, claims, requestID)
+		client.resumeFrom = resumeFrom
 
 		// Final validation before registration
 		if !client.IsValid() {
-			log.Printf("ERROR: Client failed final validation before registration - FormID: '%s'", client.FormID)
+			client.logger.Error("Client failed validation before registration", "form_id", client.FormID)
 			conn.WriteMessage(websocket.CloseMessage, []byte("Client validation failed"))
 			return
 		}
 
-		// Register client with manager (this starts readPump and writePump)
+		// Register client with manager (this starts writePump)
 		w.RegisterClient(client)
+		<-client.registered
 
-		log.Printf("INFO: WebSocket client %s connected to form analytics %s", clientID, formID)
+		client.logger.Info("WebSocket client connected", "form_id", formID)
 
-		// Keep the handler alive - this is crucial!
-		// The connection will be managed by readPump and writePump goroutines
-		select {}
+		// Read until the client disconnects, then wait for writePump to let go of the
+		// connection - it is recycled as soon as this handler returns
+		client.readPump()
+		<-client.done
 	}, websocket.Config{
-		Origins:         []string{"http://127.0.0.1:3000", "http://localhost:3000"},
-		WriteBufferSize: 1024,
-		ReadBufferSize:  1024,
+		Origins:         w.cfg.Origins,
+		WriteBufferSize: w.cfg.WriteBufferSize,
+		ReadBufferSize:  w.cfg.ReadBufferSize,
 	})(c)
 }
 
-// RegisterClient registers a new client
+// authenticateConnection reads the auth message of a freshly upgraded connection and verifies
+// that its user owns the form. On failure it returns nil claims with a close code and reason.
+func (w *WebSocketManager) authenticateConnection(conn *websocket.Conn, formID string) (*services.Claims, int, string) {
+	conn.SetReadDeadline(time.Now().Add(w.cfg.AuthTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	var msg authMessage
+	if err := conn.ReadJSON(&msg); err != nil || msg.Type != "auth" || msg.Token == "" {
+		return nil, CloseUnauthorized, "Authentication required"
+	}
+
+	claims, err := w.authenticate(msg.Token)
+	if err != nil {
+		_, code, text := authFailure(err)
+		return nil, code, text
+	}
+
+	if formID != "" && !w.canAccess(formID, claims.UserID) {
+		return nil, CloseForbidden, "Form not found"
+	}
+
+	return claims, 0, ""
+}
+
+// authenticate validates an access token and checks that its session is still
+// active, so that logging out also ends the session's sockets
+func (w *WebSocketManager) authenticate(token string) (*services.Claims, error) {
+	claims, err := w.tokens.ValidateAccessToken(token)
+	if err != nil {
+		return nil, errInvalidToken
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), accessCheckTimeout)
+	defer cancel()
+
+	if err := w.tokens.CheckSession(ctx, claims.SessionID); err != nil {
+		if errors.Is(err, services.ErrSessionRevoked) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	return claims, nil
+}
+
+// authFailure returns the HTTP status, close code and text reporting an authentication error
+func authFailure(err error) (status int, code int, text string) {
+	switch {
+	case errors.Is(err, errInvalidToken):
+		return 401, CloseUnauthorized, "Invalid or expired token"
+	case errors.Is(err, services.ErrSessionRevoked):
+		return 401, CloseUnauthorized, "Session has been revoked"
+	}
+	slog.Error("Failed to authenticate WebSocket client", "error", err)
+	return 500, websocket.CloseInternalServerErr, "Failed to authenticate"
+}
+
+// canAccess reports whether the user may read the form: they own it, or they
+// are a member of its organization
+func (w *WebSocketManager) canAccess(formID, userID string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), accessCheckTimeout)
+	defer cancel()
+
+	if _, err := w.forms.GetFormByID(ctx, formID, &services.Actor{UserID: userID}); err != nil {
+		slog.Warn("Analytics subscription denied", "user_id", userID, "form_id", formID, "error", err)
+		return false
+	}
+	return true
+}
+
+// ipLimitReached reports whether the IP already holds the maximum number of connections
+func (w *WebSocketManager) ipLimitReached(ip string) bool {
+	if w.cfg.MaxConnectionsPerIP <= 0 {
+		return false
+	}
+
+	w.ipMutex.Lock()
+	defer w.ipMutex.Unlock()
+	return w.ipConnections[ip] >= w.cfg.MaxConnectionsPerIP
+}
+
+// acquireIP reserves a connection slot for the IP, failing when the limit is reached
+func (w *WebSocketManager) acquireIP(ip string) bool {
+	w.ipMutex.Lock()
+	defer w.ipMutex.Unlock()
+
+	if w.cfg.MaxConnectionsPerIP > 0 && w.ipConnections[ip] >= w.cfg.MaxConnectionsPerIP {
+		return false
+	}
+	w.ipConnections[ip]++
+	return true
+}
+
+// releaseIP frees a connection slot reserved by acquireIP
+func (w *WebSocketManager) releaseIP(ip string) {
+	w.ipMutex.Lock()
+	defer w.ipMutex.Unlock()
+
+	if w.ipConnections[ip] <= 1 {
+		delete(w.ipConnections, ip)
+		return
+	}
+	w.ipConnections[ip]--
+}
+
+// requestToken extracts an access token from the token query parameter or Authorization header
+func requestToken(c *fiber.Ctx) string {
+	if token := c.Query("token"); token != "" {
+		return token
+	}
+
+	if token, found := strings.CutPrefix(c.Get("Authorization"), "Bearer "); found {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// closeConnection sends a close frame with the given code before the connection is dropped
+func closeConnection(conn *websocket.Conn, code int, text string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(time.Second))
+}
+
+// RegisterClient registers a new client. Clients registering after the manager
+// stopped are closed right away.
 func (w *WebSocketManager) RegisterClient(client *Client) {
-	w.register <- client
+	select {
+	case w.register <- client:
+	case <-w.stopped:
+		w.registerClient(client)
+		client.closeWith(websocket.CloseServiceRestart, shutdownCloseReason)
+	}
 }
 
 // UnregisterClient unregisters a client
 func (w *WebSocketManager) UnregisterClient(client *Client) {
-	w.unregister <- client
+	select {
+	case w.unregister <- client:
+	case <-w.stopped:
+		w.unregisterClient(client)
+	}
 }
 
 // Broadcast sends analytics updates to all connected clients for a form
+// and to the all-forms channel of its owner
 func (w *WebSocketManager) Broadcast(formID string, messageType string, data interface{}) {
+	w.broadcastContext(context.Background(), formID, messageType, data)
+}
+
+// broadcastContext queues a message for delivery as part of the trace in ctx
+func (w *WebSocketManager) broadcastContext(ctx context.Context, formID string, messageType string, data interface{}) {
 	// Normalize form ID to ensure consistency with room keys
-	// Remove any spaces, slashes, or special characters
-	normalizedFormID := strings.TrimSpace(formID)
-	normalizedFormID = strings.Trim(normalizedFormID, "/")
-	normalizedFormID = strings.ReplaceAll(normalizedFormID, "/", "")
-	normalizedFormID = strings.ReplaceAll(normalizedFormID, " ", "")
+	normalizedFormID := normalizeFormID(formID)
 
 	// Validate the normalized form ID
 	if len(normalizedFormID) != 24 {
-		log.Printf("ERROR: Invalid form ID length after normalization: '%s' (len:%d)", normalizedFormID, len(normalizedFormID))
+		slog.Error("Invalid form ID for broadcast", "form_id", normalizedFormID)
 		return
 	}
 
 	message := &Message{
-		FormID: normalizedFormID,
-		Type:   messageType,
-		Data:   data,
+		ID:      formatEventID(w.nextEventID()),
+		FormID:  normalizedFormID,
+		Type:    messageType,
+		Data:    data,
+		OwnerID: w.ownerOf(normalizedFormID),
+
+		spanContext: trace.SpanContextFromContext(ctx),
 	}
 
 	select {
 	case w.broadcast <- message:
 		// Message successfully queued
 	default:
-		log.Printf("WARN: Broadcast channel full, dropping analytics message for form %s", normalizedFormID)
+		w.dropped.Add(1)
+		slog.Warn("Broadcast channel full, dropping message", "form_id", normalizedFormID)
+	}
+
+	// Forward to other instances
+	select {
+	case w.outbound <- message:
+	default:
+		w.dropped.Add(1)
+		slog.Warn("Backplane queue full, other instances miss message", "form_id", normalizedFormID)
 	}
 }
 
+// publishLoop publishes local broadcasts to the backplane until ctx is cancelled
+func (w *WebSocketManager) publishLoop(ctx context.Context) {
+	for {
+		var message *Message
+		select {
+		case <-ctx.Done():
+			return
+		case message = <-w.outbound:
+		}
+
+		// Backplane writes belong to the trace of the broadcast
+		publishCtx, cancel := context.WithTimeout(trace.ContextWithSpanContext(ctx, message.spanContext), 5*time.Second)
+		if err := w.backplane.Publish(publishCtx, &Envelope{Origin: w.id, Message: message}); err != nil {
+			slog.Warn("Failed to publish message to backplane", "form_id", message.FormID, "error", err)
+		}
+		cancel()
+	}
+}
+
+// receive queues a message published by another instance for local delivery.
+// Messages published by this instance were already delivered by Broadcast.
+func (w *WebSocketManager) receive(envelope *Envelope) {
+	if envelope.Origin == w.id || envelope.Message == nil {
+		return
+	}
+	if w.receiveControl(envelope.Message) {
+		return
+	}
+
+	if seq, ok := parseEventID(envelope.Message.ID); ok {
+		w.observeEventID(seq)
+	}
+
+	select {
+	case w.broadcast <- envelope.Message:
+	default:
+		w.dropped.Add(1)
+		slog.Warn("Broadcast channel full, dropping message from backplane", "form_id", envelope.Message.FormID)
+	}
+}
+
+// LastHeartbeat returns when the event loop last ran, or the zero time when it is not running
+func (w *WebSocketManager) LastHeartbeat() time.Time {
+	heartbeat := w.heartbeat.Load()
+	if heartbeat == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, heartbeat)
+}
+
+// GetBroadcastBacklog returns the number of messages waiting for local delivery or
+// for publishing to other instances
+func (w *WebSocketManager) GetBroadcastBacklog() int {
+	return len(w.broadcast) + len(w.outbound)
+}
+
 // GetRoomCount returns the number of clients in a form analytics room
 func (w *WebSocketManager) GetRoomCount(formID string) int {
 	w.mutex.RLock()
 	defer w.mutex.RUnlock()
 
 	// Normalize form ID to match room keys
-	normalizedFormID := strings.TrimSpace(formID)
-	normalizedFormID = strings.Trim(normalizedFormID, "/")
-	normalizedFormID = strings.ReplaceAll(normalizedFormID, "/", "")
-	normalizedFormID = strings.ReplaceAll(normalizedFormID, " ", "")
+	normalizedFormID := normalizeFormID(formID)
 
 	// Validate FormID format
 	if len(normalizedFormID) != 24 {
-		log.Printf("WARN: GetRoomCount called with invalid FormID: '%s' (length: %d)", normalizedFormID, len(normalizedFormID))
+		slog.Warn("Room count requested for invalid form ID", "form_id", normalizedFormID)
 		return 0
 	}
 
@@ -256,58 +793,62 @@ func (w *WebSocketManager) GetTotalConnections() int {
 	w.mutex.RLock()
 	defer w.mutex.RUnlock()
 
-	total := 0
-	for _, room := range w.rooms {
-		total += len(room)
-	}
-	return total
+	return len(w.clients)
+}
+
+// GetActiveRooms returns the number of form rooms and owner channels with subscribers
+func (w *WebSocketManager) GetActiveRooms() int {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	return len(w.rooms)
 }
 
-// registerClient handles client registration
+// registerClient handles client registration and subscribes the client to the form
+// given in its connection URL
 func (w *WebSocketManager) registerClient(client *Client) {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
+	defer close(client.registered)
 
 	// Validate FormID format before creating room
-	if len(client.FormID) != 24 || strings.Contains(client.FormID, "/") {
-		log.Printf("ERROR: Cannot register client with invalid FormID: '%s' (length: %d)", client.FormID, len(client.FormID))
+	if !client.IsValid() {
+		client.logger.Error("Cannot register client with invalid form ID", "form_id", client.FormID)
 		return
 	}
 
-	// Use the client's FormID directly as the room key
-	// The FormID is already immutable from the HandleConnection function
-	roomKey := client.FormID
+	w.clients[client] = true
 
-	// Create room if it doesn't exist
-	if w.rooms[roomKey] == nil {
-		w.rooms[roomKey] = make(map[*Client]bool)
+	// Start the client's write goroutine; the connection handler runs readPump.
+	// Stream clients have no connection and are written by their stream handler.
+	if client.Conn != nil {
+		go client.writePump()
 	}
 
-	// Add client to room
-	w.rooms[roomKey][client] = true
-
-	log.Printf("INFO: Client %s joined form analytics %s (room size: %d)",
-		client.ID, roomKey, len(w.rooms[roomKey]))
-
-	// Start client goroutines
-	go client.writePump()
-	go client.readPump()
-
 	// Send welcome message
 	welcomeMsg := map[string]interface{}{
 		"type":    "connected",
 		"message": "Connected to real-time analytics",
-		"formId":  client.FormID,
+	}
+	if client.FormID != "" {
+		welcomeMsg["formId"] = client.FormID
 	}
 
-	if data, err := json.Marshal(welcomeMsg); err == nil {
-		select {
-		case client.Send <- data:
-		default:
-			// Welcome message failed to send, unregister client safely
-			log.Printf("WARN: Welcome message failed to send to client %s, unregistering", client.ID)
-			go w.UnregisterClient(client)
-		}
+	if data, err := json.Marshal(welcomeMsg); err == nil && !deliver(client, data) {
+		// Welcome message failed to send, unregister client safely
+		client.logger.Warn("Welcome message failed to send, unregistering")
+		go w.UnregisterClient(client)
+		return
+	}
+
+	if client.FormID == "" {
+		return
+	}
+
+	// The FormID is already immutable from the HandleConnection function
+	if err := w.joinLocked(client, client.FormID, client.FormID, client.resumeFrom); err != nil {
+		client.logger.Warn("Client could not join form room", "form_id", client.FormID, "error", err)
+		client.closeWith(websocket.CloseTryAgainLater, "Room is full")
 	}
 }
 
@@ -316,117 +857,140 @@ func (w *WebSocketManager) unregisterClient(client *Client) {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
-	// Validate client has valid FormID
-	if len(client.FormID) != 24 {
-		log.Printf("ERROR: Unregistering client with invalid FormID: '%s' (length: %d) for client %s",
-			client.FormID, len(client.FormID), client.ID)
-		close(client.Send)
+	// The client may already have been dropped after a failed send
+	if !w.clients[client] {
 		return
 	}
+	delete(w.clients, client)
 
-	// Use the client's FormID directly as the room key
-	roomKey := client.FormID
-
-	// Find and remove client from room
-	if room, exists := w.rooms[roomKey]; exists {
-		if _, exists := room[client]; exists {
-			delete(room, client)
-
-			// Close the channel (client goroutines should handle cleanup)
-			close(client.Send)
-
-			log.Printf("INFO: Client %s left form analytics %s (room size: %d)",
-				client.ID, roomKey, len(room))
-
-			// Clean up empty rooms to prevent memory leaks
-			if len(room) == 0 {
-				delete(w.rooms, roomKey)
-			}
-			return
-		}
+	for room := range client.rooms {
+		w.removeFromRoom(client, room)
 	}
 
-	// Client not found in expected room
-	log.Printf("WARN: Client %s with FormID %s not found in expected room during unregistration",
-		client.ID, client.FormID)
-	// Close the channel anyway
+	// Close the channel (client goroutines should handle cleanup)
 	close(client.Send)
+
+	client.logger.Info("Client disconnected", "subscriptions", len(client.rooms))
 }
 
-// broadcastToRoom broadcasts analytics message to all clients in a specific room
+// broadcastToRoom broadcasts an analytics message to the clients of its form room and
+// owner channel and buffers it for replay. Both happen under the manager mutex so
+// that clients joining concurrently receive every event exactly once.
 func (w *WebSocketManager) broadcastToRoom(message *Message) {
 	// Validate message FormID first
 	if len(message.FormID) != 24 {
-		log.Printf("ERROR: Invalid FormID length: '%s' (length: %d)", message.FormID, len(message.FormID))
-		return
-	}
-
-	w.mutex.Lock() // Use write lock for complete safety
-
-	// Look for the room with exact match only
-	targetRoom, roomExists := w.rooms[message.FormID]
-
-	if !roomExists {
-		// Room doesn't exist, no clients to broadcast to
-		w.mutex.Unlock()
-		return
-	}
-
-	if len(targetRoom) == 0 {
-		w.mutex.Unlock()
+		slog.Error("Invalid form ID in broadcast", "form_id", message.FormID)
 		return
 	}
 
 	// Prepare message data
 	data, err := json.Marshal(message)
 	if err != nil {
-		log.Printf("ERROR: Error marshaling analytics message: %v", err)
-		w.mutex.Unlock()
+		slog.Error("Failed to marshal broadcast message", "error", err)
 		return
 	}
+	seq, _ := parseEventID(message.ID)
 
-	// Safely copy clients list for broadcast (we already have the write lock)
-	clients := make([]*Client, 0, len(targetRoom))
-	for client := range targetRoom {
-		clients = append(clients, client)
-	}
+	span := startDeliverySpan(message)
+	defer span.End()
 
-	// Release the lock before sending messages to prevent deadlocks
-	w.mutex.Unlock()
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
 
-	// Store clients that need to be unregistered
-	var failedClients []*Client
+	w.remember(message, seq, data)
 
-	for _, client := range clients {
-		select {
-		case client.Send <- data:
-			// Success
-		default:
-			// Client's send channel is full, mark for cleanup
-			log.Printf("WARN: Client %s send channel full, marking for cleanup from form %s", client.ID, client.FormID)
-			failedClients = append(failedClients, client)
+	// Clients subscribed to both the form and the owner channel receive the message once
+	targets := make(map[*Client]bool, len(w.rooms[message.FormID]))
+	for client := range w.rooms[message.FormID] {
+		targets[client] = true
+	}
+	if message.OwnerID != "" {
+		for client := range w.rooms[ownerRoom(message.OwnerID)] {
+			targets[client] = true
 		}
 	}
 
-	// Clean up failed clients without the main lock held
-	for _, client := range failedClients {
-		go func(c *Client) {
-			w.UnregisterClient(c)
-		}(client)
+	dropped := 0
+	for client := range targets {
+		if !deliver(client, data) {
+			// Client's send channel is full, clean it up once the lock is released
+			dropped++
+			w.dropped.Add(1)
+			client.logger.Warn("Send channel full, disconnecting client", "form_id", message.FormID)
+			go w.UnregisterClient(client)
+		}
+	}
+	span.SetAttributes(
+		attribute.Int("dune.realtime.recipients", len(targets)),
+		attribute.Int("dune.realtime.dropped", dropped),
+	)
+}
+
+// startDeliverySpan starts a span for delivering a message to local clients. Only
+// messages broadcast within a trace are traced.
+func startDeliverySpan(message *Message) trace.Span {
+	if !message.spanContext.IsValid() {
+		return trace.SpanFromContext(context.Background())
 	}
 
-	// Successfully broadcasted to all clients
+	ctx := trace.ContextWithSpanContext(context.Background(), message.spanContext)
+	_, span := tracing.Start(ctx, "WebSocketManager.deliver", trace.WithAttributes(
+		tracing.FormID(message.FormID),
+		attribute.String("dune.realtime.message_type", message.Type),
+	))
+	return span
 }
 
-// writePump pumps messages from the manager to the websocket connection
+// writePump pumps messages from the manager to the websocket connection.
+// It also pings the client and disconnects it once its access token expires.
 func (c *Client) writePump() {
+	cfg := c.Manager.cfg
+
+	ping := time.NewTicker(cfg.PingInterval)
+	expiry := time.NewTimer(time.Hour)
+	if remaining, ok := c.tokenExpiry(); ok {
+		expiry.Reset(remaining)
+	} else {
+		expiry.Stop()
+	}
+
 	defer func() {
+		ping.Stop()
+		expiry.Stop()
+		// Closing a hijacked connection is deferred to the server, so also
+		// expire the read deadline to stop readPump without waiting for the client
+		c.Conn.SetReadDeadline(time.Now())
 		c.Conn.Close()
+		close(c.done)
 	}()
 
 	for {
 		select {
+		case <-ping.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.logger.Warn("Failed to ping client", "error", err)
+				return
+			}
+
+		case <-expiry.C:
+			// The token may have been refreshed since the timer was set
+			if remaining, ok := c.tokenExpiry(); ok && remaining > 0 {
+				expiry.Reset(remaining)
+				continue
+			}
+			c.logger.Info("Access token expired, disconnecting")
+			c.Conn.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
+			c.Conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(CloseUnauthorized, "Token expired"))
+			return
+
+		case frame := <-c.closing:
+			c.Conn.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
+			c.Conn.WriteMessage(websocket.CloseMessage, frame)
+			return
+
 		case message, ok := <-c.Send:
+			c.Conn.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
 			if !ok {
 				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
@@ -434,58 +998,95 @@ func (c *Client) writePump() {
 
 			// Check client integrity before writing
 			if !c.IsValid() {
-				log.Printf("ERROR: Client %s corrupted during write (FormID: '%s') - closing connection", c.ID, c.FormID)
+				c.logger.Error("Client corrupted during write, closing connection", "form_id", c.FormID)
 				return
 			}
 
 			if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
-				log.Printf("ERROR: Error writing message to analytics client %s: %v", c.ID, err)
+				c.logger.Warn("Failed to write message to client", "error", err)
 				return
 			}
 		}
 	}
 }
 
-// readPump pumps messages from the websocket connection to the manager
+// readPump pumps messages from the websocket connection to the manager.
+// Clients that stay silent (including pongs) longer than the read timeout are dropped.
 func (c *Client) readPump() {
 	defer func() {
 		c.Manager.UnregisterClient(c)
 		c.Conn.Close()
 	}()
 
+	readTimeout := c.Manager.cfg.ReadTimeout
+	c.Conn.SetReadLimit(c.Manager.cfg.MaxMessageSize)
+	c.Conn.SetReadDeadline(time.Now().Add(readTimeout))
+	c.Conn.SetPongHandler(func(string) error {
+		return c.Conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+
 	for {
 		// Check client integrity before processing messages
 		if !c.IsValid() {
-			log.Printf("ERROR: Client %s has been corrupted (FormID: '%s') - disconnecting", c.ID, c.FormID)
+			c.logger.Error("Client corrupted, disconnecting", "form_id", c.FormID)
 			break
 		}
 
-		var msg map[string]interface{}
+		var msg clientMessage
 		err := c.Conn.ReadJSON(&msg)
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WARN: WebSocket error for analytics client %s: %v", c.ID, err)
+				c.logger.Warn("WebSocket error", "error", err)
 			}
 			break
 		}
-
-		// Handle client messages (ping, heartbeat, etc.)
-		if msgType, exists := msg["type"]; exists {
-			switch msgType {
-			case "ping":
-				// Send pong response
-				pong := map[string]interface{}{
-					"type":      "pong",
-					"timestamp": msg["timestamp"],
-				}
-				if data, err := json.Marshal(pong); err == nil {
-					select {
-					case c.Send <- data:
-					default:
-						return
-					}
-				}
+		c.Conn.SetReadDeadline(time.Now().Add(readTimeout))
+
+		// Handle client messages (subscriptions, ping, token refresh, etc.)
+		switch msg.Type {
+		case "subscribe":
+			c.subscribe(&msg)
+		case "unsubscribe":
+			c.unsubscribe(&msg)
+		case "auth":
+			// The write pump disconnects the client if the refresh is rejected
+			c.refreshToken(msg.Token)
+		case "ping":
+			// Send pong response
+			pong := map[string]interface{}{
+				"type":      "pong",
+				"timestamp": msg.Timestamp,
+			}
+			if !c.sendJSON(pong) {
+				return
 			}
 		}
 	}
 }
+
+// refreshToken replaces the client's access token so the connection outlives the original token.
+// The new token must belong to the same user; otherwise the client is disconnected.
+func (c *Client) refreshToken(token string) {
+	claims, err := c.Manager.authenticate(token)
+	if err != nil {
+		c.logger.Warn("Invalid token refresh, disconnecting", "error", err)
+		_, code, text := authFailure(err)
+		c.closeWith(code, text)
+		return
+	}
+	if claims.UserID != c.UserID {
+		c.logger.Warn("Token refresh for another user, disconnecting")
+		c.closeWith(CloseUnauthorized, "Invalid or expired token")
+		return
+	}
+
+	c.setExpiry(claims)
+
+	ack := map[string]interface{}{
+		"type": "authenticated",
+	}
+	if claims.ExpiresAt != nil {
+		ack["expiresAt"] = claims.ExpiresAt.Time
+	}
+	c.sendJSON(ack)
+}
@@ -0,0 +1,338 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	fiber "github.com/gofiber/fiber/v2"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/services"
+)
+
+const (
+	testFormID      = "507f1f77bcf86cd799439011"
+	testOtherFormID = "507f1f77bcf86cd799439022"
+	testOwnerID     = "507f1f77bcf86cd799439aaa"
+)
+
+// fakeTokens treats the token as the user ID; a ":short" suffix issues a one-second
+// token and a ":revoked" suffix a token of a revoked session
+type fakeTokens struct{}
+
+func (fakeTokens) ValidateAccessToken(token string) (*services.Claims, error) {
+	ttl := time.Hour
+	userID, revoked := strings.CutSuffix(token, ":revoked")
+	userID, short := strings.CutSuffix(userID, ":short")
+	if short {
+		ttl = time.Second
+	}
+	if userID == "invalid" {
+		return nil, errors.New("invalid token")
+	}
+	sessionID := "session"
+	if revoked {
+		sessionID = "revoked"
+	}
+	return &services.Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	}, nil
+}
+
+func (fakeTokens) CheckSession(_ context.Context, sessionID string) error {
+	if sessionID == "revoked" {
+		return services.ErrSessionRevoked
+	}
+	return nil
+}
+
+// fakeForms grants access to testFormID and testOtherFormID for testOwnerID only
+type fakeForms struct{}
+
+func (fakeForms) GetFormByID(_ context.Context, formID string, actor *services.Actor) (*models.FormResponse, error) {
+	if formID != testFormID && formID != testOtherFormID {
+		return nil, errors.New("form not found")
+	}
+	if actor != nil && actor.UserID != testOwnerID {
+		return nil, errors.New("form not found")
+	}
+	owner := testOwnerID
+	return &models.FormResponse{ID: formID, OwnerID: &owner}, nil
+}
+
+// startTestServer serves the WebSocket route on a random local port
+func startTestServer(t *testing.T, cfg Config) (*WebSocketManager, string) {
+	manager := NewWebSocketManager(cfg, NewMemoryBackplane(), fakeTokens{}, fakeForms{})
+	return manager, serveManager(t, manager)
+}
+
+// serveManager runs the manager and serves its WebSocket route, returning the base URL
+func serveManager(t *testing.T, manager *WebSocketManager) string {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go manager.Run(ctx)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/ws/forms/:id", manager.HandleConnection)
+	app.Get("/ws", manager.HandleConnection)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(listener)
+	t.Cleanup(func() { app.Shutdown() })
+
+	return "ws://" + listener.Addr().String() + "/ws/forms/"
+}
+
+// dial opens a WebSocket connection with an allowed origin
+func dial(t *testing.T, url string) *websocket.Conn {
+	conn, resp, err := dialOrigin(url, "http://localhost:3000")
+	require.NoError(t, err, "handshake status: %v", resp)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// dialOrigin opens a WebSocket connection from the given origin
+func dialOrigin(url, origin string) (*websocket.Conn, int, error) {
+	header := map[string][]string{"Origin": {origin}}
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if resp == nil {
+		return conn, 0, err
+	}
+	return conn, resp.StatusCode, err
+}
+
+// readUntilClose reads messages until the server closes the connection and returns the close code
+func readUntilClose(t *testing.T, conn *websocket.Conn) int {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		return closeErr.Code
+	}
+}
+
+func TestHandleConnection_RejectsBeforeUpgrade(t *testing.T) {
+	manager := NewWebSocketManager(DefaultConfig(), NewMemoryBackplane(), fakeTokens{}, fakeForms{})
+	app := fiber.New()
+	app.Get("/ws/forms/:id", manager.HandleConnection)
+
+	tests := []struct {
+		name     string
+		query    string
+		upgrade  bool
+		expected int
+	}{
+		{name: "Plain HTTP request", query: "", upgrade: false, expected: 426},
+		{name: "Invalid token", query: "?token=invalid", upgrade: true, expected: 401},
+		{name: "Form owned by another user", query: "?token=someone-else", upgrade: true, expected: 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/ws/forms/"+testFormID+tt.query, nil)
+			if tt.upgrade {
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("Upgrade", "websocket")
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, resp.StatusCode)
+		})
+	}
+}
+
+func TestHandleConnection_Authentication(t *testing.T) {
+	manager, baseURL := startTestServer(t, DefaultConfig())
+
+	t.Run("Token in query parameter", func(t *testing.T) {
+		conn := dial(t, baseURL+testFormID+"?token="+testOwnerID)
+
+		var welcome map[string]interface{}
+		require.NoError(t, conn.ReadJSON(&welcome))
+		assert.Equal(t, "connected", welcome["type"])
+	})
+
+	t.Run("Token in first message", func(t *testing.T) {
+		conn := dial(t, baseURL+testFormID)
+		require.NoError(t, conn.WriteJSON(authMessage{Type: "auth", Token: testOwnerID}))
+
+		var welcome map[string]interface{}
+		require.NoError(t, conn.ReadJSON(&welcome))
+		assert.Equal(t, "connected", welcome["type"])
+
+		assert.Eventually(t, func() bool { return manager.GetRoomCount(testFormID) > 0 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("First message without auth", func(t *testing.T) {
+		conn := dial(t, baseURL+testFormID)
+		require.NoError(t, conn.WriteJSON(map[string]string{"type": "ping"}))
+		assert.Equal(t, CloseUnauthorized, readUntilClose(t, conn))
+	})
+
+	t.Run("Invalid token in first message", func(t *testing.T) {
+		conn := dial(t, baseURL+testFormID)
+		require.NoError(t, conn.WriteJSON(authMessage{Type: "auth", Token: "invalid"}))
+		assert.Equal(t, CloseUnauthorized, readUntilClose(t, conn))
+	})
+
+	t.Run("Form owned by another user", func(t *testing.T) {
+		conn := dial(t, baseURL+testFormID)
+		require.NoError(t, conn.WriteJSON(authMessage{Type: "auth", Token: "someone-else"}))
+		assert.Equal(t, CloseForbidden, readUntilClose(t, conn))
+	})
+
+	t.Run("Refresh with another user's token", func(t *testing.T) {
+		conn := dial(t, baseURL+testFormID+"?token="+testOwnerID)
+		require.NoError(t, conn.WriteJSON(authMessage{Type: "auth", Token: "someone-else"}))
+		assert.Equal(t, CloseUnauthorized, readUntilClose(t, conn))
+	})
+
+	t.Run("Revoked session in query parameter", func(t *testing.T) {
+		_, status, err := dialOrigin(baseURL+testFormID+"?token="+testOwnerID+":revoked", "http://localhost:3000")
+		require.Error(t, err)
+		assert.Equal(t, 401, status)
+	})
+
+	t.Run("Revoked session in first message", func(t *testing.T) {
+		conn := dial(t, baseURL+testFormID)
+		require.NoError(t, conn.WriteJSON(authMessage{Type: "auth", Token: testOwnerID + ":revoked"}))
+		assert.Equal(t, CloseUnauthorized, readUntilClose(t, conn))
+	})
+
+	t.Run("Refresh with a token of a revoked session", func(t *testing.T) {
+		conn := dial(t, baseURL+testFormID+"?token="+testOwnerID)
+		require.NoError(t, conn.WriteJSON(authMessage{Type: "auth", Token: testOwnerID + ":revoked"}))
+		assert.Equal(t, CloseUnauthorized, readUntilClose(t, conn))
+	})
+}
+
+func TestHandleConnection_TokenExpiry(t *testing.T) {
+	_, baseURL := startTestServer(t, DefaultConfig())
+
+	t.Run("Expired token drops the subscriber", func(t *testing.T) {
+		conn := dial(t, baseURL+testFormID+"?token="+testOwnerID+":short")
+		assert.Equal(t, CloseUnauthorized, readUntilClose(t, conn))
+	})
+
+	t.Run("Refreshed token keeps the subscriber connected", func(t *testing.T) {
+		conn := dial(t, baseURL+testFormID+"?token="+testOwnerID+":short")
+		require.NoError(t, conn.WriteJSON(authMessage{Type: "auth", Token: testOwnerID}))
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			var msg map[string]interface{}
+			err := conn.ReadJSON(&msg)
+			if err != nil {
+				var netErr net.Error
+				require.ErrorAs(t, err, &netErr, "connection should stay open past the original expiry")
+				assert.True(t, netErr.Timeout())
+				return
+			}
+		}
+	})
+}
+
+func TestHandleConnection_Origins(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Origins = []string{"https://forms.example.com"}
+	_, baseURL := startTestServer(t, cfg)
+
+	conn, status, err := dialOrigin(baseURL+testFormID+"?token="+testOwnerID, "https://forms.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusSwitchingProtocols, status)
+	conn.Close()
+
+	// The upgrade is refused for origins that are not allowed
+	_, status, err = dialOrigin(baseURL+testFormID+"?token="+testOwnerID, "http://localhost:3000")
+	assert.Error(t, err)
+	assert.Equal(t, fiber.StatusUpgradeRequired, status)
+}
+
+func TestHandleConnection_Limits(t *testing.T) {
+	t.Run("Connections per IP", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.MaxConnectionsPerIP = 2
+		_, baseURL := startTestServer(t, cfg)
+
+		first := dial(t, baseURL+testFormID+"?token="+testOwnerID)
+		dial(t, baseURL+testFormID+"?token="+testOwnerID)
+
+		_, status, err := dialOrigin(baseURL+testFormID+"?token="+testOwnerID, "http://localhost:3000")
+		assert.Error(t, err)
+		assert.Equal(t, 429, status)
+
+		// Closing a connection frees its slot
+		first.Close()
+		assert.Eventually(t, func() bool {
+			conn, _, err := dialOrigin(baseURL+testFormID+"?token="+testOwnerID, "http://localhost:3000")
+			if err != nil {
+				return false
+			}
+			conn.Close()
+			return true
+		}, 2*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("Connections per room", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.MaxConnectionsPerRoom = 1
+		manager, baseURL := startTestServer(t, cfg)
+
+		dial(t, baseURL+testFormID+"?token="+testOwnerID)
+		assert.Eventually(t, func() bool { return manager.GetRoomCount(testFormID) == 1 }, time.Second, 10*time.Millisecond)
+
+		conn := dial(t, baseURL+testFormID+"?token="+testOwnerID)
+		assert.Equal(t, websocket.CloseTryAgainLater, readUntilClose(t, conn))
+	})
+}
+
+func TestHandleConnection_Heartbeat(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ReadTimeout = 300 * time.Millisecond
+	cfg.PingInterval = 100 * time.Millisecond
+	manager, baseURL := startTestServer(t, cfg)
+
+	t.Run("Responsive client stays connected", func(t *testing.T) {
+		// Reading lets the client answer pings with pongs automatically
+		conn := dial(t, baseURL+testFormID+"?token="+testOwnerID)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		for {
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				var netErr net.Error
+				require.ErrorAs(t, err, &netErr, "connection should stay open")
+				assert.True(t, netErr.Timeout())
+				break
+			}
+		}
+		conn.Close()
+	})
+
+	t.Run("Silent client is dropped", func(t *testing.T) {
+		assert.Eventually(t, func() bool { return manager.GetTotalConnections() == 0 }, 2*time.Second, 10*time.Millisecond)
+
+		// Not reading means pings are never answered
+		dial(t, baseURL+testFormID+"?token="+testOwnerID)
+		assert.Eventually(t, func() bool { return manager.GetTotalConnections() == 1 }, time.Second, 10*time.Millisecond)
+		assert.Eventually(t, func() bool { return manager.GetTotalConnections() == 0 }, 2*time.Second, 20*time.Millisecond)
+	})
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/bits"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+// defaultPowDifficulty is used when proof-of-work is enabled without an explicit difficulty
+const defaultPowDifficulty = 18
+
+// challengeClaims is the signed payload of a submission challenge token
+type challengeClaims struct {
+	FormID   string `json:"f"`
+	IssuedAt int64  `json:"iat"`
+	Nonce    string `json:"n"`
+}
+
+// AbuseService protects public submissions with honeypot, fill-time and proof-of-work checks
+type AbuseService struct {
+	collections *database.Collections
+	secret      string
+	ttl         time.Duration
+	now         func() time.Time
+}
+
+// NewAbuseService creates a new anti-abuse service
+func NewAbuseService(collections *database.Collections, secret string, ttl time.Duration) *AbuseService {
+	return &AbuseService{
+		collections: collections,
+		secret:      secret,
+		ttl:         ttl,
+		now:         time.Now,
+	}
+}
+
+// IssueChallenge creates a signed submission challenge for a public form.
+// It returns nil when anti-abuse checks are disabled for the form.
+func (s *AbuseService) IssueChallenge(formID string, settings *models.FormSettings) (*models.SubmissionChallenge, error) {
+	if !settings.AntiAbuseEnabled() {
+		return nil, nil
+	}
+
+	nonce, err := utils.GenerateSecureToken(12)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(challengeClaims{
+		FormID:   formID,
+		IssuedAt: s.now().Unix(),
+		Nonce:    nonce,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode challenge: %w", err)
+	}
+
+	challenge := &models.SubmissionChallenge{
+		Token:         utils.SignValue(base64.RawURLEncoding.EncodeToString(payload), s.secret),
+		HoneypotField: honeypotFieldName(nonce),
+	}
+	if settings.AntiAbuse.ProofOfWork {
+		challenge.PowDifficulty = powDifficulty(settings.AntiAbuse)
+	}
+
+	return challenge, nil
+}
+
+// CheckSubmission verifies the challenge solution of a submission. Rejected submissions are
+// stored in the quarantine collection and the rejection reason is returned; an empty reason
+// means the submission may proceed.
+func (s *AbuseService) CheckSubmission(ctx context.Context, formID string, req *models.SubmitResponseRequest) (models.RejectionReason, error) {
+	objectID, err := primitive.ObjectIDFromHex(formID)
+	if err != nil {
+		return "", nil // Let the response service report the missing form
+	}
+
+	var form models.Form
+	err = s.collections.Forms.FindOne(ctx, bson.M{
+		"_id":    objectID,
+		"status": models.FormStatusPublished,
+	}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return "", nil // Let the response service report the missing form
+		}
+		return "", fmt.Errorf("failed to get form: %w", err)
+	}
+
+	if !form.Settings.AntiAbuseEnabled() {
+		return "", nil
+	}
+
+	reason, claims := s.verifySolution(formID, form.Settings.AntiAbuse, req.Challenge, req.Answers)
+	if reason == "" {
+		reason, err = s.consumeChallenge(ctx, objectID, claims)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if reason != "" {
+		s.quarantine(ctx, objectID, reason, req)
+		return reason, nil
+	}
+
+	// The honeypot is not a field of the form, so it is not part of the response
+	req.Answers = withoutAnswer(req.Answers, honeypotFieldName(claims.Nonce))
+	return "", nil
+}
+
+// ReleaseChallenge makes a consumed challenge usable again, e.g. after the submission
+// failed validation and the respondent needs to correct their answers
+func (s *AbuseService) ReleaseChallenge(ctx context.Context, solution *models.ChallengeSolution) error {
+	if solution == nil {
+		return nil
+	}
+
+	claims, ok := s.decodeToken(solution.Token)
+	if !ok {
+		return nil
+	}
+
+	if _, err := s.collections.SubmissionChallenges.DeleteOne(ctx, bson.M{"_id": claims.Nonce}); err != nil {
+		return fmt.Errorf("failed to release submission challenge: %w", err)
+	}
+	return nil
+}
+
+// verifySolution runs the stateless challenge checks and returns the decoded token claims.
+// The honeypot is read from the submitted answers, where clients send every input of the form.
+func (s *AbuseService) verifySolution(formID string, settings *models.AntiAbuseSettings, solution *models.ChallengeSolution, answers []models.Answer) (models.RejectionReason, *challengeClaims) {
+	if solution == nil || solution.Token == "" {
+		return models.RejectionMissingToken, nil
+	}
+
+	claims, ok := s.decodeToken(solution.Token)
+	if !ok || claims.FormID != formID {
+		return models.RejectionInvalidToken, nil
+	}
+
+	issuedAt := time.Unix(claims.IssuedAt, 0)
+	elapsed := s.now().Sub(issuedAt)
+	if elapsed > s.ttl {
+		return models.RejectionTokenExpired, claims
+	}
+
+	if honeypotFilled(answers, honeypotFieldName(claims.Nonce)) {
+		return models.RejectionHoneypot, claims
+	}
+
+	if elapsed < time.Duration(settings.MinFillSeconds)*time.Second {
+		return models.RejectionTooFast, claims
+	}
+
+	if settings.ProofOfWork {
+		if solution.Nonce == "" {
+			return models.RejectionPowMissing, claims
+		}
+		if !VerifyProofOfWork(solution.Token, solution.Nonce, powDifficulty(settings)) {
+			return models.RejectionPowInvalid, claims
+		}
+	}
+
+	return "", claims
+}
+
+// consumeChallenge marks a challenge as used so a solved token cannot be replayed
+func (s *AbuseService) consumeChallenge(ctx context.Context, formID primitive.ObjectID, claims *challengeClaims) (models.RejectionReason, error) {
+	_, err := s.collections.SubmissionChallenges.InsertOne(ctx, bson.M{
+		"_id":       claims.Nonce,
+		"formId":    formID,
+		"expiresAt": time.Unix(claims.IssuedAt, 0).Add(s.ttl),
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return models.RejectionTokenReused, nil
+		}
+		return "", fmt.Errorf("failed to record submission challenge: %w", err)
+	}
+	return "", nil
+}
+
+// quarantine stores a rejected submission for later review
+func (s *AbuseService) quarantine(ctx context.Context, formID primitive.ObjectID, reason models.RejectionReason, req *models.SubmitResponseRequest) {
+	_, err := s.collections.QuarantinedResponses.InsertOne(ctx, &models.QuarantinedResponse{
+		ID:         primitive.NewObjectID(),
+		FormID:     formID,
+		Reason:     reason,
+		Answers:    req.Answers,
+		Meta:       req.Meta,
+		ReceivedAt: s.now(),
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to quarantine submission", "form_id", formID.Hex(), "error", err)
+	}
+}
+
+// decodeToken verifies a challenge token's signature and decodes its claims
+func (s *AbuseService) decodeToken(token string) (*challengeClaims, bool) {
+	encoded, ok := utils.VerifySignedValue(token, s.secret)
+	if !ok {
+		return nil, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+
+	var claims challengeClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Nonce == "" {
+		return nil, false
+	}
+
+	return &claims, true
+}
+
+// VerifyProofOfWork checks that SHA-256(token + ":" + nonce) has at least difficulty leading zero bits
+func VerifyProofOfWork(token, nonce string, difficulty int) bool {
+	sum := sha256.Sum256([]byte(token + ":" + nonce))
+
+	zeros := 0
+	for _, b := range sum {
+		if b == 0 {
+			zeros += 8
+			continue
+		}
+		zeros += bits.LeadingZeros8(b)
+		break
+	}
+
+	return zeros >= difficulty
+}
+
+// powDifficulty returns the configured proof-of-work difficulty
+func powDifficulty(settings *models.AntiAbuseSettings) int {
+	if settings.PowDifficulty > 0 {
+		return settings.PowDifficulty
+	}
+	return defaultPowDifficulty
+}
+
+// honeypotFilled reports whether the answers contain a non-empty value for the honeypot field
+func honeypotFilled(answers []models.Answer, field string) bool {
+	for _, answer := range answers {
+		if answer.FieldID != field {
+			continue
+		}
+		if value, ok := answer.Value.(string); !ok || strings.TrimSpace(value) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutAnswer returns the answers without the answer to a field
+func withoutAnswer(answers []models.Answer, field string) []models.Answer {
+	kept := answers[:0:0]
+	for _, answer := range answers {
+		if answer.FieldID != field {
+			kept = append(kept, answer)
+		}
+	}
+	return kept
+}
+
+// honeypotFieldName derives an innocuous looking field name from the challenge nonce
+func honeypotFieldName(nonce string) string {
+	return "website_" + nonce[:6]
+}
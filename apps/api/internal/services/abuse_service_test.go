@@ -0,0 +1,222 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+)
+
+const testFormID = "507f1f77bcf86cd799439011"
+
+// newTestAbuseService creates an abuse service with a controllable clock
+func newTestAbuseService(now *time.Time) *AbuseService {
+	service := NewAbuseService(nil, "test_submission_secret_32_characters", time.Hour)
+	service.now = func() time.Time { return *now }
+	return service
+}
+
+// solveProofOfWork brute-forces a nonce for the given token and difficulty
+func solveProofOfWork(t *testing.T, token string, difficulty int) string {
+	for i := 0; i < 1<<22; i++ {
+		nonce := strconv.Itoa(i)
+		if VerifyProofOfWork(token, nonce, difficulty) {
+			return nonce
+		}
+	}
+	t.Fatalf("no proof-of-work solution found for difficulty %d", difficulty)
+	return ""
+}
+
+func TestAbuseService_IssueChallenge(t *testing.T) {
+	now := time.Date(2024, 8, 20, 10, 0, 0, 0, time.UTC)
+	service := newTestAbuseService(&now)
+
+	t.Run("No challenge when anti-abuse is disabled", func(t *testing.T) {
+		challenge, err := service.IssueChallenge(testFormID, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, challenge)
+
+		challenge, err = service.IssueChallenge(testFormID, &models.FormSettings{
+			AntiAbuse: &models.AntiAbuseSettings{Enabled: false},
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, challenge)
+	})
+
+	t.Run("Challenge encodes form and issue time", func(t *testing.T) {
+		challenge, err := service.IssueChallenge(testFormID, &models.FormSettings{
+			AntiAbuse: &models.AntiAbuseSettings{Enabled: true},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, challenge)
+
+		assert.NotEmpty(t, challenge.Token)
+		assert.Regexp(t, "^website_[A-Za-z0-9_-]{6}$", challenge.HoneypotField)
+		assert.Zero(t, challenge.PowDifficulty)
+
+		claims, ok := service.decodeToken(challenge.Token)
+		require.True(t, ok)
+		assert.Equal(t, testFormID, claims.FormID)
+		assert.Equal(t, now.Unix(), claims.IssuedAt)
+	})
+
+	t.Run("Proof-of-work difficulty is included when enabled", func(t *testing.T) {
+		challenge, err := service.IssueChallenge(testFormID, &models.FormSettings{
+			AntiAbuse: &models.AntiAbuseSettings{Enabled: true, ProofOfWork: true},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, defaultPowDifficulty, challenge.PowDifficulty)
+
+		challenge, err = service.IssueChallenge(testFormID, &models.FormSettings{
+			AntiAbuse: &models.AntiAbuseSettings{Enabled: true, ProofOfWork: true, PowDifficulty: 4},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 4, challenge.PowDifficulty)
+	})
+
+	t.Run("Tokens signed with another secret are rejected", func(t *testing.T) {
+		challenge, err := service.IssueChallenge(testFormID, &models.FormSettings{
+			AntiAbuse: &models.AntiAbuseSettings{Enabled: true},
+		})
+		require.NoError(t, err)
+
+		other := NewAbuseService(nil, "another_submission_secret_32_chars", time.Hour)
+		_, ok := other.decodeToken(challenge.Token)
+		assert.False(t, ok)
+	})
+}
+
+func TestAbuseService_VerifySolution(t *testing.T) {
+	issuedAt := time.Date(2024, 8, 20, 10, 0, 0, 0, time.UTC)
+	now := issuedAt
+	service := newTestAbuseService(&now)
+
+	settings := &models.AntiAbuseSettings{Enabled: true, MinFillSeconds: 5, ProofOfWork: true, PowDifficulty: 8}
+	challenge, err := service.IssueChallenge(testFormID, &models.FormSettings{AntiAbuse: settings})
+	require.NoError(t, err)
+	nonce := solveProofOfWork(t, challenge.Token, settings.PowDifficulty)
+	invalidNonce := "invalid"
+	for VerifyProofOfWork(challenge.Token, invalidNonce, settings.PowDifficulty) {
+		invalidNonce += "x"
+	}
+
+	tests := []struct {
+		name     string
+		formID   string
+		elapsed  time.Duration
+		solution *models.ChallengeSolution
+		answers  []models.Answer
+		expected models.RejectionReason
+	}{
+		{
+			name:     "Valid solution",
+			formID:   testFormID,
+			elapsed:  30 * time.Second,
+			solution: &models.ChallengeSolution{Token: challenge.Token, Nonce: nonce},
+			expected: "",
+		},
+		{
+			name:     "Missing solution",
+			formID:   testFormID,
+			elapsed:  30 * time.Second,
+			solution: nil,
+			expected: models.RejectionMissingToken,
+		},
+		{
+			name:     "Tampered token",
+			formID:   testFormID,
+			elapsed:  30 * time.Second,
+			solution: &models.ChallengeSolution{Token: challenge.Token + "x", Nonce: nonce},
+			expected: models.RejectionInvalidToken,
+		},
+		{
+			name:     "Token issued for another form",
+			formID:   "507f1f77bcf86cd799439012",
+			elapsed:  30 * time.Second,
+			solution: &models.ChallengeSolution{Token: challenge.Token, Nonce: nonce},
+			expected: models.RejectionInvalidToken,
+		},
+		{
+			name:     "Expired token",
+			formID:   testFormID,
+			elapsed:  2 * time.Hour,
+			solution: &models.ChallengeSolution{Token: challenge.Token, Nonce: nonce},
+			expected: models.RejectionTokenExpired,
+		},
+		{
+			name:     "Honeypot filled",
+			formID:   testFormID,
+			elapsed:  30 * time.Second,
+			solution: &models.ChallengeSolution{Token: challenge.Token, Nonce: nonce},
+			answers:  []models.Answer{{FieldID: "name", Value: "Ada"}, {FieldID: challenge.HoneypotField, Value: "http://spam.example"}},
+			expected: models.RejectionHoneypot,
+		},
+		{
+			name:     "Empty honeypot",
+			formID:   testFormID,
+			elapsed:  30 * time.Second,
+			solution: &models.ChallengeSolution{Token: challenge.Token, Nonce: nonce},
+			answers:  []models.Answer{{FieldID: "name", Value: "Ada"}, {FieldID: challenge.HoneypotField, Value: "  "}},
+			expected: "",
+		},
+		{
+			name:     "Submitted too fast",
+			formID:   testFormID,
+			elapsed:  2 * time.Second,
+			solution: &models.ChallengeSolution{Token: challenge.Token, Nonce: nonce},
+			expected: models.RejectionTooFast,
+		},
+		{
+			name:     "Missing proof-of-work",
+			formID:   testFormID,
+			elapsed:  30 * time.Second,
+			solution: &models.ChallengeSolution{Token: challenge.Token},
+			expected: models.RejectionPowMissing,
+		},
+		{
+			name:     "Invalid proof-of-work",
+			formID:   testFormID,
+			elapsed:  30 * time.Second,
+			solution: &models.ChallengeSolution{Token: challenge.Token, Nonce: invalidNonce},
+			expected: models.RejectionPowInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = issuedAt.Add(tt.elapsed)
+			reason, _ := service.verifySolution(tt.formID, settings, tt.solution, tt.answers)
+			assert.Equal(t, tt.expected, reason)
+		})
+	}
+}
+
+func TestWithoutAnswer(t *testing.T) {
+	answers := []models.Answer{{FieldID: "name", Value: "Ada"}, {FieldID: "website_abc123", Value: ""}}
+
+	kept := withoutAnswer(answers, "website_abc123")
+
+	assert.Equal(t, []models.Answer{{FieldID: "name", Value: "Ada"}}, kept)
+	assert.Len(t, answers, 2, "the submitted answers are not modified")
+}
+
+func TestVerifyProofOfWork(t *testing.T) {
+	t.Run("Zero difficulty accepts any nonce", func(t *testing.T) {
+		assert.True(t, VerifyProofOfWork("token", "anything", 0))
+	})
+
+	t.Run("Solved nonce satisfies its difficulty", func(t *testing.T) {
+		nonce := solveProofOfWork(t, "token", 12)
+		assert.True(t, VerifyProofOfWork("token", nonce, 12))
+		assert.True(t, VerifyProofOfWork("token", nonce, 8))
+	})
+
+	t.Run("Difficulty is capped by the hash size", func(t *testing.T) {
+		assert.False(t, VerifyProofOfWork("token", "nonce", 257))
+	})
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/mail"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+// ErrInvalidAccountToken is returned for reset and verification tokens that are
+// malformed, expired, already used or no longer match the account
+var ErrInvalidAccountToken = errors.New("invalid or expired token")
+
+// Purposes of account tokens; a token is only accepted for its own purpose
+const (
+	accountTokenPasswordReset     = "password_reset"
+	accountTokenEmailVerification = "email_verification"
+)
+
+// mailTimeout bounds the delivery of one email
+const mailTimeout = 30 * time.Second
+
+// accountTokenClaims is the signed payload of a password reset or verification token.
+// Binding ties the token to the account state it was issued for: the password hash
+// for resets and the email address for verification. Changing either invalidates it.
+type accountTokenClaims struct {
+	Purpose  string `json:"p"`
+	UserID   string `json:"u"`
+	Binding  string `json:"b"`
+	IssuedAt int64  `json:"iat"`
+	Nonce    string `json:"n"`
+}
+
+// AccountConfig configures account recovery and verification
+type AccountConfig struct {
+	Secret               string        // Signs account tokens
+	PasswordResetTTL     time.Duration // Lifetime of password reset links
+	EmailVerificationTTL time.Duration // Lifetime of verification links
+	AppURL               string        // Web app the links point to
+}
+
+// AccountService handles password resets and email verification. Tokens are
+// signed, expire, and can be used once.
+type AccountService struct {
+	collections *database.Collections
+	auth        *AuthService
+	mailer      mail.Mailer
+	cfg         AccountConfig
+	now         func() time.Time
+	deliveries  sync.WaitGroup
+}
+
+// NewAccountService creates a new account service
+func NewAccountService(collections *database.Collections, auth *AuthService, mailer mail.Mailer, cfg AccountConfig) *AccountService {
+	cfg.AppURL = strings.TrimRight(cfg.AppURL, "/")
+	return &AccountService{
+		collections: collections,
+		auth:        auth,
+		mailer:      mailer,
+		cfg:         cfg,
+		now:         time.Now,
+	}
+}
+
+// RequestPasswordReset emails a password reset link to the user with the given
+// email address. Unknown addresses are ignored so callers cannot probe for accounts.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.findUserByEmail(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+
+	token, err := s.issueToken(accountTokenPasswordReset, user)
+	if err != nil {
+		return err
+	}
+
+	s.deliver(ctx, &mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Text: fmt.Sprintf("Hi %s,\n\n"+
+			"We received a request to reset the password of your Dune Forms account. "+
+			"Open this link within %s to choose a new password:\n\n%s\n\n"+
+			"If you did not ask for this, ignore this email and your password stays the same.\n",
+			user.Name, formatTTL(s.cfg.PasswordResetTTL), s.link("/reset-password", token)),
+	})
+	return nil
+}
+
+// ResetPassword sets a new password with a password reset token and signs the
+// user out of every session
+func (s *AccountService) ResetPassword(ctx context.Context, token, password string) error {
+	claims, user, err := s.redeemToken(ctx, accountTokenPasswordReset, token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.auth.SetPassword(ctx, user.ID, password); err != nil {
+		return err
+	}
+	if _, err := s.auth.LogoutAll(ctx, claims.UserID); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Password reset", "user_id", claims.UserID)
+	return nil
+}
+
+// RequestEmailVerification emails a new verification link to the user with the
+// given email address. Unknown and already verified addresses are ignored.
+func (s *AccountService) RequestEmailVerification(ctx context.Context, email string) error {
+	user, err := s.findUserByEmail(ctx, email)
+	if err != nil || user == nil || user.EmailVerified {
+		return err
+	}
+	return s.SendVerificationEmail(ctx, user)
+}
+
+// SendVerificationEmail emails a verification link to a user
+func (s *AccountService) SendVerificationEmail(ctx context.Context, user *models.User) error {
+	token, err := s.issueToken(accountTokenEmailVerification, user)
+	if err != nil {
+		return err
+	}
+
+	s.deliver(ctx, &mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Text: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm that %s is your email address by opening this link within %s:\n\n%s\n\n"+
+			"If you did not create a Dune Forms account, you can ignore this email.\n",
+			user.Name, user.Email, formatTTL(s.cfg.EmailVerificationTTL), s.link("/verify-email", token)),
+	})
+	return nil
+}
+
+// VerifyEmail marks the email address of a verification token as verified.
+// Verifying an address that is already verified succeeds.
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	claims, err := s.parseToken(accountTokenEmailVerification, token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.boundUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if user.EmailVerified {
+		return user, nil
+	}
+	if err := s.consumeToken(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.MarkEmailVerified(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Email verified", "user_id", claims.UserID)
+	return s.auth.GetUserByID(ctx, claims.UserID)
+}
+
+// Wait blocks until emails being delivered have been handed to the mailer
+func (s *AccountService) Wait() {
+	s.deliveries.Wait()
+}
+
+// deliver sends an email in the background, so that responses take the same time
+// whether or not an email was sent. Failures are logged.
+func (s *AccountService) deliver(ctx context.Context, msg *mail.Message) {
+	s.deliveries.Add(1)
+	go func() {
+		defer s.deliveries.Done()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailTimeout)
+		defer cancel()
+
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			slog.ErrorContext(ctx, "Failed to send email", "subject", msg.Subject, "to", msg.To, "error", err)
+		}
+	}()
+}
+
+// link returns a web app URL carrying a token
+func (s *AccountService) link(path, token string) string {
+	return s.cfg.AppURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// findUserByEmail returns the user with an email address, or nil if there is none
+func (s *AccountService) findUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := s.collections.Users.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return &user, nil
+}
+
+// redeemToken verifies a token, checks that it still matches its account and marks it as used
+func (s *AccountService) redeemToken(ctx context.Context, purpose, token string) (*accountTokenClaims, *models.User, error) {
+	claims, err := s.parseToken(purpose, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := s.boundUser(ctx, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.consumeToken(ctx, claims); err != nil {
+		return nil, nil, err
+	}
+	return claims, user, nil
+}
+
+// boundUser returns the user of a token if the account still matches the token's binding
+func (s *AccountService) boundUser(ctx context.Context, claims *accountTokenClaims) (*models.User, error) {
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidAccountToken
+	}
+
+	var user models.User
+	err = s.collections.Users.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrInvalidAccountToken
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if tokenBinding(claims.Purpose, &user) != claims.Binding {
+		return nil, ErrInvalidAccountToken
+	}
+	return &user, nil
+}
+
+// consumeToken records a token as used until it expires, so it cannot be redeemed twice
+func (s *AccountService) consumeToken(ctx context.Context, claims *accountTokenClaims) error {
+	_, err := s.collections.UsedAccountTokens.InsertOne(ctx, bson.M{
+		"_id":       claims.Nonce,
+		"purpose":   claims.Purpose,
+		"userId":    claims.UserID,
+		"expiresAt": time.Unix(claims.IssuedAt, 0).Add(s.ttl(claims.Purpose)),
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrInvalidAccountToken
+		}
+		return fmt.Errorf("failed to record used token: %w", err)
+	}
+	return nil
+}
+
+// issueToken creates a signed token of a purpose for a user
+func (s *AccountService) issueToken(purpose string, user *models.User) (string, error) {
+	nonce, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(accountTokenClaims{
+		Purpose:  purpose,
+		UserID:   user.ID.Hex(),
+		Binding:  tokenBinding(purpose, user),
+		IssuedAt: s.now().Unix(),
+		Nonce:    nonce,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token: %w", err)
+	}
+
+	return utils.SignValue(base64.RawURLEncoding.EncodeToString(payload), s.cfg.Secret), nil
+}
+
+// parseToken verifies a token's signature, purpose and age and decodes its claims
+func (s *AccountService) parseToken(purpose, token string) (*accountTokenClaims, error) {
+	encoded, ok := utils.VerifySignedValue(token, s.cfg.Secret)
+	if !ok {
+		return nil, ErrInvalidAccountToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidAccountToken
+	}
+
+	var claims accountTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Nonce == "" || claims.Purpose != purpose {
+		return nil, ErrInvalidAccountToken
+	}
+	if !s.now().Before(time.Unix(claims.IssuedAt, 0).Add(s.ttl(purpose))) {
+		return nil, ErrInvalidAccountToken
+	}
+
+	return &claims, nil
+}
+
+// ttl returns the lifetime of tokens of a purpose
+func (s *AccountService) ttl(purpose string) time.Duration {
+	if purpose == accountTokenPasswordReset {
+		return s.cfg.PasswordResetTTL
+	}
+	return s.cfg.EmailVerificationTTL
+}
+
+// tokenBinding returns the digest of the account state a token of a purpose depends on
+func tokenBinding(purpose string, user *models.User) string {
+	state := strings.ToLower(user.Email)
+	if purpose == accountTokenPasswordReset {
+		state = user.Password
+	}
+	return utils.HashSHA256(purpose + "|" + state)[:16]
+}
+
+// formatTTL describes a token lifetime for email text, e.g. "1 hour" or "30 minutes"
+func formatTTL(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		if hours := int(ttl / time.Hour); hours != 1 {
+			return fmt.Sprintf("%d hours", hours)
+		}
+		return "1 hour"
+	}
+	if minutes := int(ttl / time.Minute); minutes != 1 {
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+	return "1 minute"
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/mail"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+)
+
+// recordingMailer keeps the messages it is asked to send
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []*mail.Message
+	err      error
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg *mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return m.err
+}
+
+// newTestAccountService creates an account service with a controllable clock
+func newTestAccountService(now *time.Time, mailer mail.Mailer) *AccountService {
+	service := NewAccountService(nil, nil, mailer, AccountConfig{
+		Secret:               "test_account_secret_32_characters_min",
+		PasswordResetTTL:     time.Hour,
+		EmailVerificationTTL: 72 * time.Hour,
+		AppURL:               "https://forms.example.com/",
+	})
+	service.now = func() time.Time { return *now }
+	return service
+}
+
+func TestAccountService_Tokens(t *testing.T) {
+	now := time.Date(2024, 8, 20, 10, 0, 0, 0, time.UTC)
+	service := newTestAccountService(&now, nil)
+	user := &models.User{ID: primitive.NewObjectID(), Email: "ada@example.com", Password: "$2a$10$hash"}
+
+	t.Run("Valid token", func(t *testing.T) {
+		token, err := service.issueToken(accountTokenPasswordReset, user)
+		require.NoError(t, err)
+
+		claims, err := service.parseToken(accountTokenPasswordReset, token)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID.Hex(), claims.UserID)
+		assert.Equal(t, tokenBinding(accountTokenPasswordReset, user), claims.Binding)
+		assert.NotEmpty(t, claims.Nonce)
+	})
+
+	t.Run("Tokens are unique", func(t *testing.T) {
+		first, err := service.issueToken(accountTokenPasswordReset, user)
+		require.NoError(t, err)
+		second, err := service.issueToken(accountTokenPasswordReset, user)
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("Token of another purpose", func(t *testing.T) {
+		token, err := service.issueToken(accountTokenEmailVerification, user)
+		require.NoError(t, err)
+
+		_, err = service.parseToken(accountTokenPasswordReset, token)
+		assert.ErrorIs(t, err, ErrInvalidAccountToken)
+	})
+
+	t.Run("Tampered token", func(t *testing.T) {
+		token, err := service.issueToken(accountTokenPasswordReset, user)
+		require.NoError(t, err)
+
+		_, err = service.parseToken(accountTokenPasswordReset, "x"+token)
+		assert.ErrorIs(t, err, ErrInvalidAccountToken)
+	})
+
+	t.Run("Token signed with another secret", func(t *testing.T) {
+		other := newTestAccountService(&now, nil)
+		other.cfg.Secret = "another_account_secret_32_characters"
+		token, err := other.issueToken(accountTokenPasswordReset, user)
+		require.NoError(t, err)
+
+		_, err = service.parseToken(accountTokenPasswordReset, token)
+		assert.ErrorIs(t, err, ErrInvalidAccountToken)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		issued := now
+		issuer := newTestAccountService(&issued, nil)
+		token, err := issuer.issueToken(accountTokenPasswordReset, user)
+		require.NoError(t, err)
+
+		later := now.Add(59 * time.Minute)
+		_, err = newTestAccountService(&later, nil).parseToken(accountTokenPasswordReset, token)
+		assert.NoError(t, err)
+
+		later = now.Add(time.Hour)
+		_, err = newTestAccountService(&later, nil).parseToken(accountTokenPasswordReset, token)
+		assert.ErrorIs(t, err, ErrInvalidAccountToken)
+	})
+
+	t.Run("Verification tokens live longer", func(t *testing.T) {
+		issued := now
+		token, err := newTestAccountService(&issued, nil).issueToken(accountTokenEmailVerification, user)
+		require.NoError(t, err)
+
+		later := now.Add(48 * time.Hour)
+		_, err = newTestAccountService(&later, nil).parseToken(accountTokenEmailVerification, token)
+		assert.NoError(t, err)
+	})
+}
+
+func TestTokenBinding(t *testing.T) {
+	user := &models.User{ID: primitive.NewObjectID(), Email: "ada@example.com", Password: "$2a$10$old"}
+	reset := tokenBinding(accountTokenPasswordReset, user)
+	verification := tokenBinding(accountTokenEmailVerification, user)
+
+	t.Run("Password change invalidates reset tokens", func(t *testing.T) {
+		changed := *user
+		changed.Password = "$2a$10$new"
+		assert.NotEqual(t, reset, tokenBinding(accountTokenPasswordReset, &changed))
+		assert.Equal(t, verification, tokenBinding(accountTokenEmailVerification, &changed))
+	})
+
+	t.Run("Email change invalidates verification tokens", func(t *testing.T) {
+		changed := *user
+		changed.Email = "ada@example.org"
+		assert.NotEqual(t, verification, tokenBinding(accountTokenEmailVerification, &changed))
+		assert.Equal(t, reset, tokenBinding(accountTokenPasswordReset, &changed))
+	})
+
+	t.Run("Email case does not matter", func(t *testing.T) {
+		changed := *user
+		changed.Email = "Ada@Example.com"
+		assert.Equal(t, verification, tokenBinding(accountTokenEmailVerification, &changed))
+	})
+}
+
+func TestAccountService_SendVerificationEmail(t *testing.T) {
+	now := time.Date(2024, 8, 20, 10, 0, 0, 0, time.UTC)
+	mailer := &recordingMailer{}
+	service := newTestAccountService(&now, mailer)
+	user := &models.User{ID: primitive.NewObjectID(), Email: "ada@example.com", Name: "Ada"}
+
+	require.NoError(t, service.SendVerificationEmail(context.Background(), user))
+	service.Wait()
+
+	require.Len(t, mailer.messages, 1)
+	msg := mailer.messages[0]
+	assert.Equal(t, "ada@example.com", msg.To)
+	assert.Equal(t, "Verify your email address", msg.Subject)
+	assert.Contains(t, msg.Text, "Hi Ada,")
+	assert.Contains(t, msg.Text, "within 72 hours")
+
+	// The link carries a token the service accepts
+	start := strings.Index(msg.Text, "https://forms.example.com/verify-email?token=")
+	require.GreaterOrEqual(t, start, 0)
+	link, err := url.Parse(strings.Fields(msg.Text[start:])[0])
+	require.NoError(t, err)
+	claims, err := service.parseToken(accountTokenEmailVerification, link.Query().Get("token"))
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.Hex(), claims.UserID)
+}
+
+func TestAccountService_DeliveryFailure(t *testing.T) {
+	now := time.Date(2024, 8, 20, 10, 0, 0, 0, time.UTC)
+	mailer := &recordingMailer{err: errors.New("connection refused")}
+	service := newTestAccountService(&now, mailer)
+	user := &models.User{ID: primitive.NewObjectID(), Email: "ada@example.com", Name: "Ada"}
+
+	// Delivery happens in the background, so failures are logged rather than returned
+	assert.NoError(t, service.SendVerificationEmail(context.Background(), user))
+	service.Wait()
+	assert.Len(t, mailer.messages, 1)
+}
+
+func TestFormatTTL(t *testing.T) {
+	tests := []struct {
+		ttl      time.Duration
+		expected string
+	}{
+		{time.Hour, "1 hour"},
+		{72 * time.Hour, "72 hours"},
+		{30 * time.Minute, "30 minutes"},
+		{90 * time.Minute, "90 minutes"},
+		{time.Minute, "1 minute"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatTTL(tt.ttl))
+		})
+	}
+}
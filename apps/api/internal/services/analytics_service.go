@@ -8,45 +8,41 @@ import (
 
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/tracing"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // AnalyticsService handles analytics-related business logic
 type AnalyticsService struct {
 	collections *database.Collections
+	authz       *Authorizer
 }
 
 // NewAnalyticsService creates a new analytics service
-func NewAnalyticsService(collections *database.Collections) *AnalyticsService {
+func NewAnalyticsService(collections *database.Collections, authz *Authorizer) *AnalyticsService {
 	return &AnalyticsService{
 		collections: collections,
+		authz:       authz,
 	}
 }
 
 // GetAnalytics retrieves analytics for a form
-func (s *AnalyticsService) GetAnalytics(ctx context.Context, formID string, ownerID *string) (*models.AnalyticsResponse, error) {
+func (s *AnalyticsService) GetAnalytics(ctx context.Context, formID string, actor *Actor) (_ *models.AnalyticsResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.GetAnalytics", trace.WithAttributes(tracing.FormID(formID)))
+	defer tracing.End(span, &err)
+
 	objectID, err := primitive.ObjectIDFromHex(formID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid form ID: %w", err)
 	}
 
-	// Verify form ownership if ownerID is provided
-	if ownerID != nil {
-		var form models.Form
-		err = s.collections.Forms.FindOne(ctx, bson.M{
-			"_id":     objectID,
-			"ownerId": *ownerID,
-		}).Decode(&form)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				return nil, fmt.Errorf("form not found or access denied")
-			}
-			return nil, fmt.Errorf("failed to verify form ownership: %w", err)
-		}
+	if err := s.authz.AuthorizeForm(ctx, actor, objectID, PermissionAnalyticsRead); err != nil {
+		return nil, err
 	}
 
 	// Get analytics from database
@@ -55,7 +51,7 @@ func (s *AnalyticsService) GetAnalytics(ctx context.Context, formID string, owne
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			// If analytics don't exist, compute them
-			return s.ComputeAnalytics(ctx, formID, nil, nil, nil, ownerID)
+			return s.ComputeAnalytics(ctx, formID, nil, nil, nil, actor)
 		}
 		return nil, fmt.Errorf("failed to get analytics: %w", err)
 	}
@@ -64,25 +60,19 @@ func (s *AnalyticsService) GetAnalytics(ctx context.Context, formID string, owne
 }
 
 // ComputeAnalytics computes analytics for a form from responses
-func (s *AnalyticsService) ComputeAnalytics(ctx context.Context, formID string, startDate, endDate *time.Time, fields []string, ownerID *string) (*models.AnalyticsResponse, error) {
+func (s *AnalyticsService) ComputeAnalytics(ctx context.Context, formID string, startDate, endDate *time.Time, fields []string, actor *Actor) (_ *models.AnalyticsResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.ComputeAnalytics", trace.WithAttributes(tracing.FormID(formID)))
+	defer tracing.End(span, &err)
+
 	objectID, err := primitive.ObjectIDFromHex(formID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid form ID: %w", err)
 	}
 
 	// Get the form
-	var form models.Form
-	filter := bson.M{"_id": objectID}
-	if ownerID != nil {
-		filter["ownerId"] = *ownerID
-	}
-
-	err = s.collections.Forms.FindOne(ctx, filter).Decode(&form)
+	form, err := s.authz.Form(ctx, actor, objectID, PermissionAnalyticsRead)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("form not found or access denied")
-		}
-		return nil, fmt.Errorf("failed to get form: %w", err)
+		return nil, err
 	}
 
 	// Build response filter
@@ -131,10 +121,13 @@ func (s *AnalyticsService) ComputeAnalytics(ctx context.Context, formID string,
 }
 
 // UpdateAnalyticsIncremental updates analytics incrementally when a new response is submitted
-func (s *AnalyticsService) UpdateAnalyticsIncremental(ctx context.Context, formID primitive.ObjectID, response *models.Response, form *models.Form) (*models.Analytics, error) {
+func (s *AnalyticsService) UpdateAnalyticsIncremental(ctx context.Context, formID primitive.ObjectID, response *models.Response, form *models.Form) (_ *models.Analytics, err error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.UpdateAnalyticsIncremental", trace.WithAttributes(tracing.FormID(formID.Hex())))
+	defer tracing.End(span, &err)
+
 	// Get current analytics
 	var analytics models.Analytics
-	err := s.collections.Analytics.FindOne(ctx, bson.M{"_id": formID}).Decode(&analytics)
+	err = s.collections.Analytics.FindOne(ctx, bson.M{"_id": formID}).Decode(&analytics)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			// Initialize analytics if they don't exist
@@ -357,25 +350,17 @@ func (s *AnalyticsService) updateDistributionAnalytics(fieldAnalytics *models.Fi
 }
 
 // GetRealTimeMetrics gets real-time metrics for a form
-func (s *AnalyticsService) GetRealTimeMetrics(ctx context.Context, formID string, ownerID *string) (*models.RealTimeMetrics, error) {
+func (s *AnalyticsService) GetRealTimeMetrics(ctx context.Context, formID string, actor *Actor) (_ *models.RealTimeMetrics, err error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.GetRealTimeMetrics", trace.WithAttributes(tracing.FormID(formID)))
+	defer tracing.End(span, &err)
+
 	objectID, err := primitive.ObjectIDFromHex(formID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid form ID: %w", err)
 	}
 
-	// Verify form ownership if ownerID is provided
-	if ownerID != nil {
-		var form models.Form
-		err = s.collections.Forms.FindOne(ctx, bson.M{
-			"_id":     objectID,
-			"ownerId": *ownerID,
-		}).Decode(&form)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				return nil, fmt.Errorf("form not found or access denied")
-			}
-			return nil, fmt.Errorf("failed to verify form ownership: %w", err)
-		}
+	if err := s.authz.AuthorizeForm(ctx, actor, objectID, PermissionAnalyticsRead); err != nil {
+		return nil, err
 	}
 
 	now := time.Now()
@@ -404,22 +389,33 @@ func (s *AnalyticsService) GetRealTimeMetrics(ctx context.Context, formID string
 		return nil, fmt.Errorf("failed to count this hour's responses: %w", err)
 	}
 
+	// Count respondents that currently have the form open
+	activeUsers, activeFilling, err := countPresence(ctx, s.collections, objectID, now)
+	if err != nil {
+		return nil, err
+	}
+
 	return &models.RealTimeMetrics{
-		ActiveUsers:       0, // This would require WebSocket tracking
+		ActiveUsers:       activeUsers,
+		ActiveFilling:     activeFilling,
 		ResponsesToday:    int(responsesToday),
 		ResponsesThisHour: int(responsesThisHour),
 		LastUpdate:        now,
 	}, nil
 }
 
-// GetAnalyticsSummary gets a summary of analytics for multiple forms
-func (s *AnalyticsService) GetAnalyticsSummary(ctx context.Context, ownerID *string) ([]*models.AnalyticsSummary, error) {
-	// Build filter for forms
-	filter := bson.M{}
-	if ownerID != nil {
-		filter["ownerId"] = *ownerID
+// GetAnalyticsSummary gets a summary of analytics for the forms in the actor's workspace
+func (s *AnalyticsService) GetAnalyticsSummary(ctx context.Context, actor *Actor) (_ []*models.AnalyticsSummary, err error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.GetAnalyticsSummary")
+	defer tracing.End(span, &err)
+
+	if err := s.authz.Require(actor, PermissionAnalyticsRead); err != nil {
+		return nil, err
 	}
 
+	// Build filter for forms in the workspace
+	filter := s.authz.WorkspaceFilter(actor)
+
 	// Get forms
 	cursor, err := s.collections.Forms.Find(ctx, filter)
 	if err != nil {
@@ -465,25 +461,17 @@ func (s *AnalyticsService) GetAnalyticsSummary(ctx context.Context, ownerID *str
 }
 
 // GetTrendAnalytics gets trend analytics for a form over a specific period
-func (s *AnalyticsService) GetTrendAnalytics(ctx context.Context, formID string, period string, ownerID *string) (*models.TrendAnalytics, error) {
+func (s *AnalyticsService) GetTrendAnalytics(ctx context.Context, formID string, period string, actor *Actor) (_ *models.TrendAnalytics, err error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.GetTrendAnalytics", trace.WithAttributes(tracing.FormID(formID)))
+	defer tracing.End(span, &err)
+
 	objectID, err := primitive.ObjectIDFromHex(formID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid form ID: %w", err)
 	}
 
-	// Verify form ownership if ownerID is provided
-	if ownerID != nil {
-		var form models.Form
-		err = s.collections.Forms.FindOne(ctx, bson.M{
-			"_id":     objectID,
-			"ownerId": *ownerID,
-		}).Decode(&form)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				return nil, fmt.Errorf("form not found or access denied")
-			}
-			return nil, fmt.Errorf("failed to verify form ownership: %w", err)
-		}
+	if err := s.authz.AuthorizeForm(ctx, actor, objectID, PermissionAnalyticsRead); err != nil {
+		return nil, err
 	}
 
 	// Calculate date range based on period
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+var (
+	// ErrInvalidAPIKey is returned for unknown, revoked and expired API keys
+	ErrInvalidAPIKey = errors.New("invalid or expired API key")
+	// ErrAPIKeyNotFound is returned when a user has no active API key with the given ID
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrAPIKeyExpiryInPast is returned when a new API key would already be expired
+	ErrAPIKeyExpiryInPast = errors.New("expiresAt must be in the future")
+	// ErrTooManyAPIKeys is returned when a user already has maxAPIKeysPerUser keys
+	ErrTooManyAPIKeys = errors.New("too many API keys, revoke one first")
+)
+
+const (
+	// apiKeyBytes is the random part of a key, 256 bits
+	apiKeyBytes = 32
+	// apiKeyPrefixLength is how much of the random part is kept to recognise a key
+	apiKeyPrefixLength = 6
+	// maxAPIKeysPerUser bounds the unrevoked keys of a user
+	maxAPIKeysPerUser = 25
+	// apiKeyLastUsedInterval bounds how often the last use of a key is written
+	apiKeyLastUsedInterval = time.Minute
+)
+
+// APIKeyService manages the personal API keys users create for programmatic access
+type APIKeyService struct {
+	collections *database.Collections
+	now         func() time.Time
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(collections *database.Collections) *APIKeyService {
+	return &APIKeyService{
+		collections: collections,
+		now:         time.Now,
+	}
+}
+
+// Create creates an API key for a user. The returned key is not stored and cannot
+// be shown again.
+func (s *APIKeyService) Create(ctx context.Context, userID string, req *models.CreateAPIKeyRequest) (*models.CreatedAPIKey, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	now := s.now()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, ErrAPIKeyExpiryInPast
+	}
+
+	count, err := s.collections.APIKeys.CountDocuments(ctx, bson.M{
+		"userId":    userObjectID,
+		"revokedAt": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count API keys: %w", err)
+	}
+	if count >= maxAPIKeysPerUser {
+		return nil, ErrTooManyAPIKeys
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := &models.APIKey{
+		ID:        primitive.NewObjectID(),
+		UserID:    userObjectID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    key[:len(models.APIKeyPrefix)+apiKeyPrefixLength],
+		KeyHash:   utils.HashSHA256(key),
+		Scopes:    uniqueScopes(req.Scopes),
+		CreatedAt: now,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if _, err := s.collections.APIKeys.InsertOne(ctx, apiKey); err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	slog.InfoContext(ctx, "API key created", "user_id", userID, "api_key_id", apiKey.ID.Hex(), "scopes", apiKey.Scopes)
+	return &models.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// List returns the unrevoked API keys of a user, newest first. Expired keys are
+// included so that users see why a job stopped working.
+func (s *APIKeyService) List(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	filter := bson.M{
+		"userId":    userObjectID,
+		"revokedAt": bson.M{"$exists": false},
+	}
+	opts := options.Find().SetSort(bson.D{bson.E{Key: "createdAt", Value: -1}})
+	cursor, err := s.collections.APIKeys.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find API keys: %w", err)
+	}
+
+	keys := []*models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke revokes one API key of a user. Revoked keys are kept for audit.
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID string) error {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID: %w", err)
+	}
+	keyObjectID, err := primitive.ObjectIDFromHex(keyID)
+	if err != nil {
+		return ErrAPIKeyNotFound
+	}
+
+	result, err := s.collections.APIKeys.UpdateOne(ctx,
+		bson.M{"_id": keyObjectID, "userId": userObjectID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": s.now()}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if result.ModifiedCount == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	slog.InfoContext(ctx, "API key revoked", "user_id", userID, "api_key_id", keyID)
+	return nil
+}
+
+// Authenticate returns the active API key matching key and records its use
+func (s *APIKeyService) Authenticate(ctx context.Context, key string, client *models.ClientInfo) (*models.APIKey, error) {
+	if !IsAPIKey(key) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var apiKey models.APIKey
+	err := s.collections.APIKeys.FindOne(ctx, bson.M{"keyHash": utils.HashSHA256(key)}).Decode(&apiKey)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to find API key: %w", err)
+	}
+
+	now := s.now()
+	if !apiKey.IsActive(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	s.touch(ctx, &apiKey, client, now)
+	return &apiKey, nil
+}
+
+// touch records the last use of a key, at most once per apiKeyLastUsedInterval so
+// that busy jobs do not write on every request. Failures are logged, since they
+// must not fail the request.
+func (s *APIKeyService) touch(ctx context.Context, apiKey *models.APIKey, client *models.ClientInfo, now time.Time) {
+	if apiKey.LastUsedAt != nil && now.Sub(*apiKey.LastUsedAt) < apiKeyLastUsedInterval {
+		return
+	}
+
+	set := bson.M{"lastUsedAt": now}
+	if client != nil && client.IP != "" {
+		set["lastUsedIp"] = client.IP
+	}
+	if _, err := s.collections.APIKeys.UpdateOne(ctx, bson.M{"_id": apiKey.ID}, bson.M{"$set": set}); err != nil {
+		slog.ErrorContext(ctx, "Failed to record API key use", "api_key_id", apiKey.ID.Hex(), "error", err)
+	}
+}
+
+// IsAPIKey reports whether a bearer credential is an API key rather than a JWT
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, models.APIKeyPrefix)
+}
+
+// generateAPIKey returns a new random API key
+func generateAPIKey() (string, error) {
+	token, err := utils.GenerateSecureToken(apiKeyBytes)
+	if err != nil {
+		return "", err
+	}
+	return models.APIKeyPrefix + token, nil
+}
+
+// uniqueScopes returns scopes without duplicates, in their original order
+func uniqueScopes(scopes []models.APIKeyScope) []models.APIKeyScope {
+	unique := make([]models.APIKeyScope, 0, len(scopes))
+	seen := make(map[models.APIKeyScope]bool, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique
+}
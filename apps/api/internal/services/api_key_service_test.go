@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, err := generateAPIKey()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(key, models.APIKeyPrefix))
+	assert.True(t, IsAPIKey(key))
+	// 32 random bytes in unpadded base64url
+	assert.Len(t, key, len(models.APIKeyPrefix)+43)
+
+	other, err := generateAPIKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestIsAPIKey(t *testing.T) {
+	assert.True(t, IsAPIKey("dune_abc"))
+	assert.False(t, IsAPIKey("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.sig"))
+	assert.False(t, IsAPIKey(""))
+}
+
+func TestUniqueScopes(t *testing.T) {
+	scopes := uniqueScopes([]models.APIKeyScope{
+		models.ScopeResponsesRead,
+		models.ScopeFormsRead,
+		models.ScopeResponsesRead,
+	})
+
+	assert.Equal(t, []models.APIKeyScope{models.ScopeResponsesRead, models.ScopeFormsRead}, scopes)
+}
+
+func TestAPIKeyService_CreateRejectsPastExpiry(t *testing.T) {
+	s := NewAPIKeyService(nil)
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	_, err := s.Create(context.Background(), primitive.NewObjectID().Hex(), &models.CreateAPIKeyRequest{
+		Name:      "ETL",
+		Scopes:    []models.APIKeyScope{models.ScopeFormsRead},
+		ExpiresAt: &now,
+	})
+	assert.ErrorIs(t, err, ErrAPIKeyExpiryInPast)
+}
+
+func TestAPIKeyService_AuthenticateRejectsOtherTokens(t *testing.T) {
+	s := NewAPIKeyService(nil)
+
+	_, err := s.Authenticate(context.Background(), "eyJhbGciOiJIUzI1NiJ9.e30.sig", nil)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
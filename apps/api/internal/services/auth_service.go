@@ -2,24 +2,76 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
 	jwt "github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
 )
 
-// JWT Claims structure
+var (
+	// ErrSessionRevoked is returned when a refresh token belongs to a session that was logged out
+	ErrSessionRevoked = errors.New("session has been revoked")
+	// ErrRefreshTokenReused is returned when a refresh token that was already rotated is presented
+	// again. The session is revoked, since the token may have been stolen.
+	ErrRefreshTokenReused = errors.New("refresh token has already been used, session revoked")
+	// ErrSessionNotFound is returned when a user has no active session with the given ID
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrEmailNotVerified is returned on login when verified email addresses are required
+	ErrEmailNotVerified = errors.New("email address is not verified")
+	// ErrUserNotFound is returned when no user has the given ID or email address
+	ErrUserNotFound = errors.New("user not found")
+	// ErrInvalidCredentials is returned on login for unknown email addresses and wrong
+	// passwords alike, so that callers cannot tell which accounts exist
+	ErrInvalidCredentials = errors.New("invalid email or password")
+)
+
+// dummyPasswordHash is checked against on logins to unknown email addresses, so that
+// they take as long as logins with a wrong password
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dune-forms-unknown-account"), bcrypt.DefaultCost)
+	return hash
+})
+
+// RefreshTokenTTL is the lifetime of sessions and their refresh tokens, the
+// longest-lived tokens the service issues
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
+// tokenIDBytes is the entropy of refresh token IDs (jti)
+const tokenIDBytes = 16
+
+// sessionCheckInterval is how long the state of a session is cached for access
+// token checks. Revocations on this instance apply at once; other instances
+// reject the session's access tokens within the interval.
+const sessionCheckInterval = 30 * time.Second
+
+// maxCachedSessions bounds the session state cache
+const maxCachedSessions = 10000
+
+// sessionCheck is the cached state of a session
+type sessionCheck struct {
+	active    bool
+	checkedAt time.Time
+}
+
+// JWT Claims structure. Both tokens carry the session ID; refresh tokens also carry
+// a unique token ID (jti) that is rotated on every refresh.
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Name   string `json:"name"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -30,6 +82,16 @@ type AuthService struct {
 	refreshSecret string
 	accessTTL     time.Duration
 	refreshTTL    time.Duration
+
+	requireVerifiedEmail bool
+	lockout              *LockoutService
+	twoFactor            *TwoFactorService
+	signingKeys          *SigningKeyService
+	hs256Switchover      time.Time
+
+	sessionsMutex sync.Mutex
+	sessions      map[string]sessionCheck
+	now           func() time.Time
 }
 
 // NewAuthService creates a new authentication service
@@ -38,11 +100,48 @@ func NewAuthService(collections *database.Collections, accessSecret, refreshSecr
 		collections:   collections,
 		accessSecret:  accessSecret,
 		refreshSecret: refreshSecret,
-		accessTTL:     60 * time.Minute,   // 60 minutes for access token
-		refreshTTL:    7 * 24 * time.Hour, // 7 days for refresh token
+		accessTTL:     60 * time.Minute, // 60 minutes for access token
+		refreshTTL:    RefreshTokenTTL,
+		sessions:      make(map[string]sessionCheck),
+		now:           time.Now,
 	}
 }
 
+// SetRequireVerifiedEmail sets whether users must verify their email address before logging in
+func (s *AuthService) SetRequireVerifiedEmail(required bool) {
+	s.requireVerifiedEmail = required
+}
+
+// SetLockout enables brute-force protection of logins. Without it, failed logins
+// are neither throttled nor recorded.
+func (s *AuthService) SetLockout(lockout *LockoutService) {
+	s.lockout = lockout
+}
+
+// SetTwoFactor enables two-factor authentication. Users who turned it on must
+// complete their logins with CompleteTwoFactorLogin.
+func (s *AuthService) SetTwoFactor(twoFactor *TwoFactorService) {
+	s.twoFactor = twoFactor
+}
+
+// SetSigningKeys signs tokens with rotating asymmetric keys instead of the HS256
+// secrets. Tokens signed with the secrets stay valid until they expire.
+func (s *AuthService) SetSigningKeys(signingKeys *SigningKeyService) {
+	s.signingKeys = signingKeys
+}
+
+// SetHS256Switchover accepts tokens signed with the HS256 secrets next to the signing
+// keys, if they were issued before switchover, until the longest-lived of them has
+// expired. A zero time rejects them, as do services without signing keys.
+func (s *AuthService) SetHS256Switchover(switchover time.Time) {
+	s.hs256Switchover = switchover
+}
+
+// RequiresVerifiedEmail reports whether users must verify their email address before logging in
+func (s *AuthService) RequiresVerifiedEmail() bool {
+	return s.requireVerifiedEmail
+}
+
 // HashPassword hashes a password using bcrypt
 func (s *AuthService) HashPassword(password string) (string, error) {
 	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -57,44 +156,48 @@ func (s *AuthService) VerifyPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
 
-// GenerateTokens generates both access and refresh tokens for a user
-func (s *AuthService) GenerateTokens(user *models.User) (string, string, error) {
+// GenerateTokens generates an access token and a refresh token for a user's session.
+// The refresh token expires with the session and is identified by its TokenID.
+func (s *AuthService) GenerateTokens(user *models.User, session *models.Session) (string, string, error) {
+	now := time.Now()
+
 	// Generate access token
 	accessClaims := &Claims{
-		UserID: user.ID.Hex(),
-		Email:  user.Email,
-		Name:   user.Name,
+		UserID:    user.ID.Hex(),
+		Email:     user.Email,
+		Name:      user.Name,
+		SessionID: session.ID.Hex(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.accessTTL)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "dune-form-analytics",
 			Subject:   user.ID.Hex(),
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString([]byte(s.accessSecret))
+	accessTokenString, err := s.signToken(accessClaims, s.accessSecret, accessTokenType)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate access token: %w", err)
 	}
 
 	// Generate refresh token
 	refreshClaims := &Claims{
-		UserID: user.ID.Hex(),
-		Email:  user.Email,
-		Name:   user.Name,
+		UserID:    user.ID.Hex(),
+		Email:     user.Email,
+		Name:      user.Name,
+		SessionID: session.ID.Hex(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.refreshTTL)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        session.TokenID,
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "dune-form-analytics",
 			Subject:   user.ID.Hex(),
 		},
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString([]byte(s.refreshSecret))
+	refreshTokenString, err := s.signToken(refreshClaims, s.refreshSecret, refreshTokenType)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
 	}
@@ -104,12 +207,7 @@ func (s *AuthService) GenerateTokens(user *models.User) (string, string, error)
 
 // ValidateAccessToken validates an access token and returns the claims
 func (s *AuthService) ValidateAccessToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.accessSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.tokenKey(s.accessSecret, accessTokenType))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -124,24 +222,68 @@ func (s *AuthService) ValidateAccessToken(tokenString string) (*Claims, error) {
 
 // ValidateRefreshToken validates a refresh token and returns the claims
 func (s *AuthService) ValidateRefreshToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.refreshSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.tokenKey(s.refreshSecret, refreshTokenType))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse refresh token: %w", err)
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		// Refresh tokens issued before sessions existed cannot be rotated
+		if claims.SessionID == "" || claims.ID == "" {
+			return nil, fmt.Errorf("refresh token has no session")
+		}
 		return claims, nil
 	}
 
 	return nil, fmt.Errorf("invalid refresh token")
 }
 
+// signToken signs claims with the current signing key, or with the HS256 secret
+// when no signing keys are configured
+func (s *AuthService) signToken(claims *Claims, secret, typ string) (string, error) {
+	if s.signingKeys != nil {
+		return s.signingKeys.sign(claims, typ)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// tokenKey returns the function looking up the key that verifies a token of the
+// given type: the HS256 secret, or the signing key named by the token. With signing
+// keys, the secrets only verify tokens of the HS256 switchover window, so a leaked
+// secret cannot mint tokens once the algorithm was changed.
+func (s *AuthService) tokenKey(secret, typ string) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			if s.signingKeys != nil && !s.acceptsHS256(token) {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return []byte(secret), nil
+		}
+		if s.signingKeys == nil {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.signingKeys.publicKey(token, typ)
+	}
+}
+
+// acceptsHS256 reports whether an HS256 token falls in the switchover window: it was
+// issued before the switchover, and no token issued then can have expired yet
+func (s *AuthService) acceptsHS256(token *jwt.Token) bool {
+	if s.hs256Switchover.IsZero() || token.Method != jwt.SigningMethodHS256 {
+		return false
+	}
+	if !s.now().Before(s.hs256Switchover.Add(RefreshTokenTTL)) {
+		return false
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || claims.IssuedAt == nil || !claims.IssuedAt.Before(s.hs256Switchover) {
+		return false
+	}
+	return claims.ExpiresAt != nil && !claims.ExpiresAt.After(s.hs256Switchover.Add(RefreshTokenTTL))
+}
+
 // CreateUser creates a new user account
 func (s *AuthService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
 	// Check if user already exists
@@ -179,26 +321,130 @@ func (s *AuthService) CreateUser(ctx context.Context, req *models.CreateUserRequ
 	return user, nil
 }
 
-// LoginUser authenticates a user and returns tokens
-func (s *AuthService) LoginUser(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error) {
+// LoginUser authenticates a user and returns tokens of a new session on the client
+func (s *AuthService) LoginUser(ctx context.Context, req *models.LoginRequest, client *models.ClientInfo) (*models.AuthResponse, error) {
+	if s.lockout != nil {
+		if err := s.lockout.Check(ctx, req.Email, client); err != nil {
+			return nil, err
+		}
+	}
+
 	// Find user by email
 	var user models.User
 	err := s.collections.Users.FindOne(ctx, bson.M{"email": req.Email}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("invalid email or password")
+			bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(req.Password))
+			return nil, s.loginFailed(ctx, req.Email, nil, client, models.FailedLoginUnknownEmail, ErrInvalidCredentials)
 		}
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
 
 	// Verify password
-	err = s.VerifyPassword(user.Password, req.Password)
+	if err := s.VerifyPassword(user.Password, req.Password); err != nil {
+		return nil, s.loginFailed(ctx, req.Email, &user.ID, client, models.FailedLoginWrongPassword, ErrInvalidCredentials)
+	}
+
+	// Checked after the password so that only the owner learns the address is unverified
+	if s.requireVerifiedEmail && !user.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	// Failed logins keep counting until the code is verified too, so that knowing the
+	// password does not allow unlimited guesses of the code
+	if user.TwoFactorEnabled() {
+		return s.twoFactorChallenge(&user)
+	}
+
+	s.resetLoginAttempts(ctx, &user)
+	return s.CreateSession(ctx, &user, client)
+}
+
+// twoFactorChallenge returns the login response asking a user with two-factor
+// authentication for a code, to be completed with CompleteTwoFactorLogin
+func (s *AuthService) twoFactorChallenge(user *models.User) (*models.AuthResponse, error) {
+	if s.twoFactor == nil {
+		return nil, errors.New("two-factor authentication is not configured")
+	}
+	token, err := s.twoFactor.IssueChallenge(user)
 	if err != nil {
-		return nil, fmt.Errorf("invalid email or password")
+		return nil, err
+	}
+	return &models.AuthResponse{TwoFactorRequired: true, TwoFactorToken: token}, nil
+}
+
+// CompleteTwoFactorLogin finishes a login of a user with two-factor authentication,
+// given the token returned by LoginUser and a TOTP or recovery code
+func (s *AuthService) CompleteTwoFactorLogin(ctx context.Context, req *models.TwoFactorLoginRequest, client *models.ClientInfo) (*models.AuthResponse, error) {
+	if s.twoFactor == nil {
+		return nil, ErrInvalidTwoFactorToken
 	}
 
-	// Generate tokens
-	accessToken, refreshToken, err := s.GenerateTokens(&user)
+	user, err := s.twoFactor.ChallengeUser(ctx, req.TwoFactorToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.lockout != nil {
+		if err := s.lockout.Check(ctx, user.Email, client); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.twoFactor.VerifyCode(ctx, user, req.Code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			return nil, s.loginFailed(ctx, user.Email, &user.ID, client, models.FailedLoginWrongCode, err)
+		}
+		return nil, err
+	}
+
+	s.resetLoginAttempts(ctx, user)
+	return s.CreateSession(ctx, user, client)
+}
+
+// loginFailed records a failed login and returns err, the error shown to the client
+func (s *AuthService) loginFailed(ctx context.Context, email string, userID *primitive.ObjectID, client *models.ClientInfo, reason models.FailedLoginReason, err error) error {
+	if s.lockout != nil {
+		if recordErr := s.lockout.RecordFailure(ctx, email, userID, client, reason); recordErr != nil {
+			return recordErr
+		}
+	}
+	return err
+}
+
+// resetLoginAttempts forgets the failed logins of a user who logged in successfully
+func (s *AuthService) resetLoginAttempts(ctx context.Context, user *models.User) {
+	if s.lockout == nil {
+		return
+	}
+	if err := s.lockout.Reset(ctx, user.Email); err != nil {
+		slog.ErrorContext(ctx, "Failed to reset login attempts", "user_id", user.ID.Hex(), "error", err)
+	}
+}
+
+// CreateSession starts a new session for a user on a client and returns its first tokens
+func (s *AuthService) CreateSession(ctx context.Context, user *models.User, client *models.ClientInfo) (*models.AuthResponse, error) {
+	tokenID, err := utils.GenerateSecureToken(tokenIDBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:         primitive.NewObjectID(),
+		UserID:     user.ID,
+		TokenID:    tokenID,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.refreshTTL),
+	}
+	session.SetClient(client)
+
+	if _, err := s.collections.Sessions.InsertOne(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	accessToken, refreshToken, err := s.GenerateTokens(user, session)
 	if err != nil {
 		return nil, err
 	}
@@ -210,31 +456,123 @@ func (s *AuthService) LoginUser(ctx context.Context, req *models.LoginRequest) (
 	}, nil
 }
 
-// RefreshTokens generates new tokens using a refresh token
-func (s *AuthService) RefreshTokens(ctx context.Context, refreshToken string) (*models.AuthResponse, error) {
+// CheckSession returns ErrSessionRevoked unless the session of an access token is
+// still active, so that logging out also ends the session's access tokens
+func (s *AuthService) CheckSession(ctx context.Context, sessionID string) error {
+	now := s.now()
+
+	s.sessionsMutex.Lock()
+	check, cached := s.sessions[sessionID]
+	s.sessionsMutex.Unlock()
+	if !cached || now.Sub(check.checkedAt) >= sessionCheckInterval {
+		active, err := s.sessionActive(ctx, sessionID, now)
+		if err != nil {
+			return err
+		}
+		check = sessionCheck{active: active, checkedAt: now}
+
+		s.sessionsMutex.Lock()
+		if len(s.sessions) >= maxCachedSessions {
+			s.sessions = make(map[string]sessionCheck)
+		}
+		s.sessions[sessionID] = check
+		s.sessionsMutex.Unlock()
+	}
+
+	if !check.active {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// sessionActive looks up whether a session is active
+func (s *AuthService) sessionActive(ctx context.Context, sessionID string, now time.Time) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return false, nil
+	}
+
+	var session models.Session
+	opts := options.FindOne().SetProjection(bson.M{"revokedAt": 1, "expiresAt": 1})
+	err = s.collections.Sessions.FindOne(ctx, bson.M{"_id": objectID}, opts).Decode(&session)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to find session: %w", err)
+	}
+	return session.IsActive(now), nil
+}
+
+// RefreshTokens rotates a session's refresh token and returns new tokens. Each
+// refresh token can be used once; presenting one again revokes the whole session.
+// The session records the client it was refreshed from.
+func (s *AuthService) RefreshTokens(ctx context.Context, refreshToken string, client *models.ClientInfo) (*models.AuthResponse, error) {
 	// Validate refresh token
 	claims, err := s.ValidateRefreshToken(refreshToken)
 	if err != nil {
 		return nil, fmt.Errorf("invalid refresh token: %w", err)
 	}
 
-	// Get user from database
-	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid user ID in token: %w", err)
+		return nil, fmt.Errorf("invalid session ID in token: %w", err)
+	}
+
+	var session models.Session
+	err = s.collections.Sessions.FindOne(ctx, bson.M{"_id": sessionID}).Decode(&session)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrSessionRevoked
+		}
+		return nil, fmt.Errorf("failed to find session: %w", err)
+	}
+	if !session.IsActive(time.Now()) {
+		return nil, ErrSessionRevoked
+	}
+	if session.TokenID != claims.ID {
+		return nil, s.revokeReusedSession(ctx, &session)
 	}
 
 	var user models.User
-	err = s.collections.Users.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	err = s.collections.Users.FindOne(ctx, bson.M{"_id": session.UserID}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
 
+	// Rotate the token ID. The filter on the presented ID makes the rotation
+	// atomic, so of two concurrent refreshes with the same token only one wins.
+	tokenID, err := utils.GenerateSecureToken(tokenIDBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token ID: %w", err)
+	}
+	now := time.Now()
+	session.SetClient(client)
+	result, err := s.collections.Sessions.UpdateOne(ctx,
+		bson.M{"_id": session.ID, "tokenId": claims.ID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"tokenId":    tokenID,
+			"lastUsedAt": now,
+			"expiresAt":  now.Add(s.refreshTTL),
+			"ip":         session.IP,
+			"userAgent":  session.UserAgent,
+		}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, s.revokeReusedSession(ctx, &session)
+	}
+	session.TokenID = tokenID
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(s.refreshTTL)
+
 	// Generate new tokens
-	newAccessToken, newRefreshToken, err := s.GenerateTokens(&user)
+	newAccessToken, newRefreshToken, err := s.GenerateTokens(&user, &session)
 	if err != nil {
 		return nil, err
 	}
@@ -246,6 +584,111 @@ func (s *AuthService) RefreshTokens(ctx context.Context, refreshToken string) (*
 	}, nil
 }
 
+// revokeReusedSession revokes a session whose rotated refresh token was presented again
+func (s *AuthService) revokeReusedSession(ctx context.Context, session *models.Session) error {
+	slog.WarnContext(ctx, "Refresh token reuse detected, revoking session",
+		"session_id", session.ID.Hex(), "user_id", session.UserID.Hex())
+
+	if _, err := s.revokeSessions(ctx, bson.M{"_id": session.ID}, models.SessionRevokedReuse); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// Logout revokes the session of a refresh token. Logging out of a session that
+// was already revoked succeeds.
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	claims, err := s.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return fmt.Errorf("invalid refresh token: %w", err)
+	}
+
+	sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
+	if err != nil {
+		return fmt.Errorf("invalid session ID in token: %w", err)
+	}
+
+	_, err = s.revokeSessions(ctx, bson.M{"_id": sessionID}, models.SessionRevokedLogout)
+	return err
+}
+
+// LogoutAll revokes every active session of a user and returns how many were revoked
+func (s *AuthService) LogoutAll(ctx context.Context, userID string) (int64, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return 0, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	return s.revokeSessions(ctx, bson.M{"userId": objectID}, models.SessionRevokedLogoutAll)
+}
+
+// ListSessions returns the active sessions of a user, most recently used first
+func (s *AuthService) ListSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	filter := bson.M{
+		"userId":    objectID,
+		"revokedAt": bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}
+	opts := options.Find().SetSort(bson.D{bson.E{Key: "lastUsedAt", Value: -1}})
+	cursor, err := s.collections.Sessions.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sessions: %w", err)
+	}
+
+	sessions := []*models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to decode sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession revokes one active session of a user
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID: %w", err)
+	}
+	sessionObjectID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+
+	revoked, err := s.revokeSessions(ctx, bson.M{"_id": sessionObjectID, "userId": userObjectID}, models.SessionRevokedByUser)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// revokeSessions revokes the active sessions matching filter. Revoked sessions are
+// kept until they expire so that reused refresh tokens are recognised.
+func (s *AuthService) revokeSessions(ctx context.Context, filter bson.M, reason models.SessionRevokeReason) (int64, error) {
+	filter["revokedAt"] = bson.M{"$exists": false}
+	result, err := s.collections.Sessions.UpdateMany(ctx, filter,
+		bson.M{"$set": bson.M{"revokedAt": time.Now(), "revokedReason": reason}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	// The revoked sessions are not known by ID, so forget every cached session state
+	s.sessionsMutex.Lock()
+	s.sessions = make(map[string]sessionCheck)
+	s.sessionsMutex.Unlock()
+
+	return result.ModifiedCount, nil
+}
+
 // GetUserByID retrieves a user by their ID
 func (s *AuthService) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
 	objectID, err := primitive.ObjectIDFromHex(userID)
@@ -257,10 +700,82 @@ func (s *AuthService) GetUserByID(ctx context.Context, userID string) (*models.U
 	err = s.collections.Users.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
 
 	return &user, nil
 }
+
+// GetUserByEmail retrieves a user by their email address
+func (s *AuthService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := s.collections.Users.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// SetPassword replaces a user's password
+func (s *AuthService) SetPassword(ctx context.Context, userID primitive.ObjectID, password string) error {
+	hashedPassword, err := s.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.collections.Users.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"password": hashedPassword, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// ResetTwoFactor turns two-factor authentication off for a user without asking for
+// a code, for administrators helping users who lost their authenticator
+func (s *AuthService) ResetTwoFactor(ctx context.Context, userID primitive.ObjectID) error {
+	result, err := s.collections.Users.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{
+			"$unset": bson.M{"two_factor": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reset two-factor authentication: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// MarkEmailVerified records that a user has verified their email address
+func (s *AuthService) MarkEmailVerified(ctx context.Context, userID primitive.ObjectID) error {
+	now := time.Now()
+	result, err := s.collections.Users.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"email_verified": true, "email_verified_at": now, "updated_at": now}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
@@ -1,14 +1,17 @@
 package services
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
 
+	jwt "github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
 )
 
@@ -147,7 +150,7 @@ func TestAuthService_GenerateTokens(t *testing.T) {
 	}
 
 	t.Run("Generate valid tokens", func(t *testing.T) {
-		accessToken, refreshToken, err := authService.GenerateTokens(testUser)
+		accessToken, refreshToken, err := authService.GenerateTokens(testUser, newTestSession(testUser))
 
 		assert.NoError(t, err)
 		assert.NotEmpty(t, accessToken)
@@ -173,8 +176,8 @@ func TestAuthService_GenerateTokens(t *testing.T) {
 			Name:  "User Two",
 		}
 
-		token1, _, err1 := authService.GenerateTokens(user1)
-		token2, _, err2 := authService.GenerateTokens(user2)
+		token1, _, err1 := authService.GenerateTokens(user1, newTestSession(user1))
+		token2, _, err2 := authService.GenerateTokens(user2, newTestSession(user2))
 
 		assert.NoError(t, err1)
 		assert.NoError(t, err2)
@@ -197,7 +200,7 @@ func TestAuthService_ValidateAccessToken(t *testing.T) {
 		Name:  "Test User",
 	}
 
-	accessToken, _, err := authService.GenerateTokens(testUser)
+	accessToken, _, err := authService.GenerateTokens(testUser, newTestSession(testUser))
 	require.NoError(t, err)
 
 	t.Run("Valid access token", func(t *testing.T) {
@@ -254,7 +257,7 @@ func TestAuthService_ValidateRefreshToken(t *testing.T) {
 		Name:  "Test User",
 	}
 
-	_, refreshToken, err := authService.GenerateTokens(testUser)
+	_, refreshToken, err := authService.GenerateTokens(testUser, newTestSession(testUser))
 	require.NoError(t, err)
 
 	t.Run("Valid refresh token", func(t *testing.T) {
@@ -274,8 +277,25 @@ func TestAuthService_ValidateRefreshToken(t *testing.T) {
 		assert.Nil(t, claims)
 	})
 
+	t.Run("Refresh token without session", func(t *testing.T) {
+		// Refresh tokens issued before sessions existed carry no session or token ID
+		legacyClaims := &Claims{
+			UserID: testUser.ID.Hex(),
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+		legacyToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, legacyClaims).SignedString([]byte(authService.refreshSecret))
+		require.NoError(t, err)
+
+		claims, err := authService.ValidateRefreshToken(legacyToken)
+
+		assert.Error(t, err)
+		assert.Nil(t, claims)
+	})
+
 	t.Run("Access token used as refresh token", func(t *testing.T) {
-		accessToken, _, err := authService.GenerateTokens(testUser)
+		accessToken, _, err := authService.GenerateTokens(testUser, newTestSession(testUser))
 		require.NoError(t, err)
 
 		// Using access token as refresh token should fail due to different secret
@@ -286,6 +306,93 @@ func TestAuthService_ValidateRefreshToken(t *testing.T) {
 	})
 }
 
+func TestAuthService_GenerateTokensSession(t *testing.T) {
+	authService := &AuthService{
+		accessSecret:  "test-access-secret-key-for-testing",
+		refreshSecret: "test-refresh-secret-key-for-testing",
+		accessTTL:     60 * time.Minute,
+		refreshTTL:    7 * 24 * time.Hour,
+	}
+
+	testUser := &models.User{
+		ID:    primitive.NewObjectID(),
+		Email: "test@example.com",
+		Name:  "Test User",
+	}
+	session := newTestSession(testUser)
+
+	accessToken, refreshToken, err := authService.GenerateTokens(testUser, session)
+	require.NoError(t, err)
+
+	t.Run("Access token carries the session", func(t *testing.T) {
+		claims, err := authService.ValidateAccessToken(accessToken)
+
+		require.NoError(t, err)
+		assert.Equal(t, session.ID.Hex(), claims.SessionID)
+		assert.Empty(t, claims.ID)
+	})
+
+	t.Run("Refresh token carries the session and token ID", func(t *testing.T) {
+		claims, err := authService.ValidateRefreshToken(refreshToken)
+
+		require.NoError(t, err)
+		assert.Equal(t, session.ID.Hex(), claims.SessionID)
+		assert.Equal(t, session.TokenID, claims.ID)
+		assert.Equal(t, session.ExpiresAt.Unix(), claims.ExpiresAt.Unix())
+	})
+
+	t.Run("Rotated token ID changes the refresh token", func(t *testing.T) {
+		rotated := *session
+		rotated.TokenID = "rotated-token-id"
+
+		_, rotatedToken, err := authService.GenerateTokens(testUser, &rotated)
+		require.NoError(t, err)
+
+		claims, err := authService.ValidateRefreshToken(rotatedToken)
+		require.NoError(t, err)
+		assert.NotEqual(t, refreshToken, rotatedToken)
+		assert.Equal(t, "rotated-token-id", claims.ID)
+		assert.Equal(t, session.ID.Hex(), claims.SessionID)
+	})
+}
+
+func TestAuthService_CheckSession(t *testing.T) {
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	authService := NewAuthService(&database.Collections{}, "access", "refresh")
+	authService.now = func() time.Time { return now }
+
+	active := primitive.NewObjectID().Hex()
+	revoked := primitive.NewObjectID().Hex()
+	authService.sessions[active] = sessionCheck{active: true, checkedAt: now.Add(-10 * time.Second)}
+	authService.sessions[revoked] = sessionCheck{active: false, checkedAt: now.Add(-10 * time.Second)}
+
+	t.Run("Cached active session", func(t *testing.T) {
+		assert.NoError(t, authService.CheckSession(context.Background(), active))
+	})
+
+	t.Run("Cached revoked session", func(t *testing.T) {
+		assert.ErrorIs(t, authService.CheckSession(context.Background(), revoked), ErrSessionRevoked)
+	})
+
+	t.Run("Token without a session", func(t *testing.T) {
+		assert.ErrorIs(t, authService.CheckSession(context.Background(), ""), ErrSessionRevoked)
+		assert.ErrorIs(t, authService.CheckSession(context.Background(), "not-a-session"), ErrSessionRevoked)
+	})
+}
+
+// newTestSession returns an unsaved session of a user
+func newTestSession(user *models.User) *models.Session {
+	now := time.Now()
+	return &models.Session{
+		ID:         primitive.NewObjectID(),
+		UserID:     user.ID,
+		TokenID:    primitive.NewObjectID().Hex(),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(7 * 24 * time.Hour),
+	}
+}
+
 // Benchmark tests
 func BenchmarkHashPassword(b *testing.B) {
 	authService := &AuthService{
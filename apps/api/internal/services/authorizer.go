@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+)
+
+var (
+	// ErrFormNotFound is returned for forms that do not exist or that the actor cannot see
+	ErrFormNotFound = errors.New("form not found or access denied")
+	// ErrForbidden is returned when the actor can see a form or organization but its role does not allow the action
+	ErrForbidden = errors.New("permission denied")
+	// ErrWorkspaceNotFound is returned for organizations that do not exist or that the user is not a member of
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+)
+
+// Permission is an action on the forms of a workspace
+type Permission string
+
+const (
+	PermissionFormsRead          Permission = "forms:read"          // List and read forms
+	PermissionFormsWrite         Permission = "forms:write"         // Create, update, publish, transfer and delete forms
+	PermissionResponsesRead      Permission = "responses:read"      // Read and export responses
+	PermissionAnalyticsRead      Permission = "analytics:read"      // Read analytics, metrics and trends
+	PermissionMembersManage      Permission = "members:manage"      // Add, remove and change the role of members
+	PermissionOrganizationManage Permission = "organization:manage" // Rename and delete the organization
+)
+
+// rolePermissions lists what each organization role allows. Each role allows
+// everything the role below it does.
+var rolePermissions = map[models.OrganizationRole][]Permission{
+	models.RoleViewer: {PermissionFormsRead, PermissionResponsesRead, PermissionAnalyticsRead},
+	models.RoleEditor: {PermissionFormsRead, PermissionResponsesRead, PermissionAnalyticsRead, PermissionFormsWrite},
+	models.RoleAdmin: {PermissionFormsRead, PermissionResponsesRead, PermissionAnalyticsRead, PermissionFormsWrite,
+		PermissionMembersManage},
+	models.RoleOwner: {PermissionFormsRead, PermissionResponsesRead, PermissionAnalyticsRead, PermissionFormsWrite,
+		PermissionMembersManage, PermissionOrganizationManage},
+}
+
+// RoleAllows reports whether an organization role grants a permission
+func RoleAllows(role models.OrganizationRole, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Actor is a user acting in a workspace: their personal workspace, or an
+// organization they are a member of
+type Actor struct {
+	UserID         string
+	OrganizationID *primitive.ObjectID     // nil in the personal workspace
+	Role           models.OrganizationRole // Role in the organization; empty in the personal workspace
+}
+
+// Can reports whether the actor may do something in its workspace. Users may do
+// everything in their personal workspace.
+func (a *Actor) Can(permission Permission) bool {
+	if a.OrganizationID == nil {
+		return true
+	}
+	return RoleAllows(a.Role, permission)
+}
+
+// Workspace returns the workspace ID the actor acts in
+func (a *Actor) Workspace() string {
+	if a.OrganizationID == nil {
+		return models.PersonalWorkspace
+	}
+	return a.OrganizationID.Hex()
+}
+
+// AccessListener is told when who may read a form changes, so that access granted
+// earlier, such as realtime subscriptions, can be revised
+type AccessListener interface {
+	// FormAccessChanged is called after a form moved to another workspace or was deleted
+	FormAccessChanged(formID string)
+	// UserAccessChanged is called after a user was removed from an organization or their role changed
+	UserAccessChanged(userID string)
+}
+
+// Authorizer decides which forms an actor may see and what it may do with them.
+// A nil actor is the system itself, such as the CLI, and may do everything.
+type Authorizer struct {
+	collections *database.Collections
+	listener    AccessListener
+}
+
+// NewAuthorizer creates a new authorizer
+func NewAuthorizer(collections *database.Collections) *Authorizer {
+	return &Authorizer{
+		collections: collections,
+	}
+}
+
+// SetAccessListener sets the listener told about access changes
+func (a *Authorizer) SetAccessListener(listener AccessListener) {
+	a.listener = listener
+}
+
+// formAccessChanged tells the listener that the readers of a form changed
+func (a *Authorizer) formAccessChanged(formID string) {
+	if a.listener != nil {
+		a.listener.FormAccessChanged(formID)
+	}
+}
+
+// userAccessChanged tells the listener that the forms a user may read changed
+func (a *Authorizer) userAccessChanged(userID string) {
+	if a.listener != nil {
+		a.listener.UserAccessChanged(userID)
+	}
+}
+
+// ResolveActor returns the actor for a user in a workspace. An empty workspace
+// selects the personal workspace.
+func (a *Authorizer) ResolveActor(ctx context.Context, userID, workspace string) (*Actor, error) {
+	if workspace == "" || workspace == models.PersonalWorkspace {
+		return &Actor{UserID: userID}, nil
+	}
+
+	organizationID, err := primitive.ObjectIDFromHex(workspace)
+	if err != nil {
+		return nil, ErrWorkspaceNotFound
+	}
+
+	membership, err := a.membership(ctx, organizationID, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, err
+	}
+
+	return &Actor{
+		UserID:         userID,
+		OrganizationID: &organizationID,
+		Role:           membership.Role,
+	}, nil
+}
+
+// Require returns ErrForbidden unless the actor may do something in its workspace
+func (a *Authorizer) Require(actor *Actor, permission Permission) error {
+	if actor != nil && !actor.Can(permission) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// WorkspaceFilter matches the forms in the actor's workspace
+func (a *Authorizer) WorkspaceFilter(actor *Actor) bson.M {
+	switch {
+	case actor == nil:
+		return bson.M{}
+	case actor.OrganizationID == nil:
+		return bson.M{"ownerId": actor.UserID, "organizationId": bson.M{"$exists": false}}
+	default:
+		return bson.M{"organizationId": *actor.OrganizationID}
+	}
+}
+
+// Form loads a form the actor may use with a permission. Access to a form does
+// not depend on the selected workspace: members of the form's organization
+// reach it from any workspace with their role there.
+func (a *Authorizer) Form(ctx context.Context, actor *Actor, formID primitive.ObjectID, permission Permission) (*models.Form, error) {
+	var form models.Form
+	err := a.collections.Forms.FindOne(ctx, bson.M{"_id": formID}).Decode(&form)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrFormNotFound
+		}
+		return nil, fmt.Errorf("failed to get form: %w", err)
+	}
+
+	if err := a.checkForm(ctx, actor, &form, permission); err != nil {
+		return nil, err
+	}
+	return &form, nil
+}
+
+// AuthorizeForm is Form for callers that only need the decision. The system
+// skips the lookup.
+func (a *Authorizer) AuthorizeForm(ctx context.Context, actor *Actor, formID primitive.ObjectID, permission Permission) error {
+	if actor == nil {
+		return nil
+	}
+	_, err := a.Form(ctx, actor, formID, permission)
+	return err
+}
+
+// checkForm decides whether the actor may use a loaded form with a permission
+func (a *Authorizer) checkForm(ctx context.Context, actor *Actor, form *models.Form, permission Permission) error {
+	if actor == nil {
+		return nil
+	}
+
+	// Personal forms belong to their owner alone
+	if form.OrganizationID == nil {
+		if form.OwnerID == nil || *form.OwnerID != actor.UserID {
+			return ErrFormNotFound
+		}
+		return nil
+	}
+
+	role := actor.Role
+	if actor.OrganizationID == nil || *actor.OrganizationID != *form.OrganizationID {
+		membership, err := a.membership(ctx, *form.OrganizationID, actor.UserID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return ErrFormNotFound
+			}
+			return err
+		}
+		role = membership.Role
+	}
+
+	if !RoleAllows(role, permission) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// membership loads a user's membership of an organization
+func (a *Authorizer) membership(ctx context.Context, organizationID primitive.ObjectID, userID string) (*models.Membership, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	var membership models.Membership
+	err = a.collections.Memberships.FindOne(ctx, bson.M{
+		"organizationId": organizationID,
+		"userId":         userObjectID,
+	}).Decode(&membership)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	return &membership, nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+)
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role    models.OrganizationRole
+		allowed []Permission
+		denied  []Permission
+	}{
+		{
+			role:    models.RoleViewer,
+			allowed: []Permission{PermissionFormsRead, PermissionResponsesRead, PermissionAnalyticsRead},
+			denied:  []Permission{PermissionFormsWrite, PermissionMembersManage, PermissionOrganizationManage},
+		},
+		{
+			role:    models.RoleEditor,
+			allowed: []Permission{PermissionFormsRead, PermissionResponsesRead, PermissionAnalyticsRead, PermissionFormsWrite},
+			denied:  []Permission{PermissionMembersManage, PermissionOrganizationManage},
+		},
+		{
+			role:    models.RoleAdmin,
+			allowed: []Permission{PermissionFormsWrite, PermissionMembersManage},
+			denied:  []Permission{PermissionOrganizationManage},
+		},
+		{
+			role:    models.RoleOwner,
+			allowed: []Permission{PermissionFormsRead, PermissionFormsWrite, PermissionMembersManage, PermissionOrganizationManage},
+		},
+		{
+			role:   models.OrganizationRole("guest"),
+			denied: []Permission{PermissionFormsRead},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			for _, permission := range tt.allowed {
+				assert.True(t, RoleAllows(tt.role, permission), permission)
+			}
+			for _, permission := range tt.denied {
+				assert.False(t, RoleAllows(tt.role, permission), permission)
+			}
+		})
+	}
+}
+
+func TestActor_Can(t *testing.T) {
+	organizationID := primitive.NewObjectID()
+
+	personal := &Actor{UserID: "user"}
+	assert.True(t, personal.Can(PermissionFormsWrite))
+	assert.True(t, personal.Can(PermissionOrganizationManage))
+	assert.Equal(t, models.PersonalWorkspace, personal.Workspace())
+
+	viewer := &Actor{UserID: "user", OrganizationID: &organizationID, Role: models.RoleViewer}
+	assert.True(t, viewer.Can(PermissionAnalyticsRead))
+	assert.False(t, viewer.Can(PermissionFormsWrite))
+	assert.Equal(t, organizationID.Hex(), viewer.Workspace())
+}
+
+func TestAuthorizer_Require(t *testing.T) {
+	a := NewAuthorizer(nil)
+	organizationID := primitive.NewObjectID()
+
+	assert.NoError(t, a.Require(nil, PermissionFormsWrite))
+	assert.NoError(t, a.Require(&Actor{UserID: "user"}, PermissionFormsWrite))
+	assert.ErrorIs(t, a.Require(&Actor{UserID: "user", OrganizationID: &organizationID, Role: models.RoleViewer}, PermissionFormsWrite), ErrForbidden)
+}
+
+func TestAuthorizer_WorkspaceFilter(t *testing.T) {
+	a := NewAuthorizer(nil)
+	organizationID := primitive.NewObjectID()
+
+	assert.Equal(t, bson.M{}, a.WorkspaceFilter(nil))
+	assert.Equal(t,
+		bson.M{"ownerId": "user", "organizationId": bson.M{"$exists": false}},
+		a.WorkspaceFilter(&Actor{UserID: "user"}),
+	)
+	assert.Equal(t,
+		bson.M{"organizationId": organizationID},
+		a.WorkspaceFilter(&Actor{UserID: "user", OrganizationID: &organizationID, Role: models.RoleViewer}),
+	)
+}
+
+func TestAuthorizer_ResolveActor(t *testing.T) {
+	a := NewAuthorizer(nil)
+
+	for _, workspace := range []string{"", models.PersonalWorkspace} {
+		actor, err := a.ResolveActor(context.Background(), "user", workspace)
+		require.NoError(t, err)
+		assert.Equal(t, &Actor{UserID: "user"}, actor)
+	}
+
+	_, err := a.ResolveActor(context.Background(), "user", "not-an-id")
+	assert.ErrorIs(t, err, ErrWorkspaceNotFound)
+}
+
+func TestAuthorizer_CheckForm(t *testing.T) {
+	a := NewAuthorizer(nil)
+	ctx := context.Background()
+	owner := "owner"
+	organizationID := primitive.NewObjectID()
+
+	personalForm := &models.Form{OwnerID: &owner}
+	orphanForm := &models.Form{}
+	organizationForm := &models.Form{OwnerID: &owner, OrganizationID: &organizationID}
+
+	t.Run("System may use every form", func(t *testing.T) {
+		assert.NoError(t, a.checkForm(ctx, nil, orphanForm, PermissionFormsWrite))
+	})
+
+	t.Run("Personal forms belong to their owner", func(t *testing.T) {
+		assert.NoError(t, a.checkForm(ctx, &Actor{UserID: owner}, personalForm, PermissionFormsWrite))
+		assert.ErrorIs(t, a.checkForm(ctx, &Actor{UserID: "other"}, personalForm, PermissionFormsRead), ErrFormNotFound)
+		assert.ErrorIs(t, a.checkForm(ctx, &Actor{UserID: owner}, orphanForm, PermissionFormsRead), ErrFormNotFound)
+	})
+
+	t.Run("Personal forms ignore the selected workspace", func(t *testing.T) {
+		actor := &Actor{UserID: owner, OrganizationID: &organizationID, Role: models.RoleViewer}
+		assert.NoError(t, a.checkForm(ctx, actor, personalForm, PermissionFormsWrite))
+	})
+
+	t.Run("Organization forms follow the member's role", func(t *testing.T) {
+		viewer := &Actor{UserID: "member", OrganizationID: &organizationID, Role: models.RoleViewer}
+		assert.NoError(t, a.checkForm(ctx, viewer, organizationForm, PermissionResponsesRead))
+		assert.ErrorIs(t, a.checkForm(ctx, viewer, organizationForm, PermissionFormsWrite), ErrForbidden)
+
+		editor := &Actor{UserID: "member", OrganizationID: &organizationID, Role: models.RoleEditor}
+		assert.NoError(t, a.checkForm(ctx, editor, organizationForm, PermissionFormsWrite))
+	})
+}
+
+// recordingListener keeps the access changes it is told about
+type recordingListener struct {
+	changes []string
+}
+
+func (l *recordingListener) FormAccessChanged(formID string) {
+	l.changes = append(l.changes, "form:"+formID)
+}
+
+func (l *recordingListener) UserAccessChanged(userID string) {
+	l.changes = append(l.changes, "user:"+userID)
+}
+
+func TestAuthorizer_AccessListener(t *testing.T) {
+	a := NewAuthorizer(nil)
+
+	t.Run("Changes without a listener are ignored", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			a.formAccessChanged("form")
+			a.userAccessChanged("user")
+		})
+	})
+
+	t.Run("Changes reach the listener", func(t *testing.T) {
+		listener := &recordingListener{}
+		a.SetAccessListener(listener)
+
+		a.formAccessChanged("form")
+		a.userAccessChanged("user")
+		assert.Equal(t, []string{"form:form", "user:user"}, listener.changes)
+	})
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+)
+
+// WriteResponsesCSV writes responses as CSV with one column per form field, in
+// the order of the form's fields
+func WriteResponsesCSV(w io.Writer, fields []models.Field, responses []*models.ResponseData) error {
+	writer := csv.NewWriter(w)
+
+	headers := []string{"Response ID", "Submitted At"}
+	for _, field := range fields {
+		headers = append(headers, field.Label)
+	}
+	if err := writer.Write(headers); err != nil {
+		return fmt.Errorf("failed to write CSV headers: %w", err)
+	}
+
+	for _, response := range responses {
+		row := []string{
+			response.ID,
+			response.SubmittedAt.Format("2006-01-02 15:04:05"),
+		}
+
+		// Create answer map for quick lookup
+		answerMap := make(map[string]interface{})
+		for _, answer := range response.Answers {
+			answerMap[answer.FieldID] = answer.Value
+		}
+
+		// Add field values in order
+		for _, field := range fields {
+			value := ""
+			if answer, exists := answerMap[field.ID]; exists {
+				value = formatAnswerForCSV(answer)
+			}
+			row = append(row, value)
+		}
+
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatAnswerForCSV formats an answer value for CSV export
+func formatAnswerForCSV(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	case []interface{}:
+		var strs []string
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				strs = append(strs, str)
+			}
+		}
+		return strings.Join(strs, "; ")
+	case []string:
+		return strings.Join(v, "; ")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
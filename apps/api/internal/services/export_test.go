@@ -0,0 +1,51 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+)
+
+func TestWriteResponsesCSV(t *testing.T) {
+	fields := []models.Field{
+		{ID: "name", Type: models.FieldTypeText, Label: "Name"},
+		{ID: "colors", Type: models.FieldTypeCheckbox, Label: "Colors"},
+		{ID: "rating", Type: models.FieldTypeRating, Label: "Rating, 1-5"},
+	}
+	submittedAt := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	responses := []*models.ResponseData{
+		{
+			ID:          "r1",
+			SubmittedAt: submittedAt,
+			Answers: []models.Answer{
+				{FieldID: "rating", Value: float64(4)},
+				{FieldID: "name", Value: "Ada"},
+				{FieldID: "colors", Value: []interface{}{"red", "blue"}},
+			},
+		},
+		{
+			ID:          "r2",
+			SubmittedAt: submittedAt,
+			Answers:     []models.Answer{{FieldID: "colors", Value: []string{"green"}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteResponsesCSV(&buf, fields, responses))
+
+	expected := "Response ID,Submitted At,Name,Colors,\"Rating, 1-5\"\n" +
+		"r1,2025-01-15 10:30:00,Ada,red; blue,4\n" +
+		"r2,2025-01-15 10:30:00,,green,\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWriteResponsesCSV_NoResponses(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteResponsesCSV(&buf, []models.Field{{ID: "q", Label: "Question"}}, nil))
+	assert.Equal(t, "Response ID,Submitted At,Question\n", buf.String())
+}
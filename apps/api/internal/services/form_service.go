@@ -2,34 +2,49 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/tracing"
 	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrFormInOrganization is returned when transferring a form that already belongs to an organization
+var ErrFormInOrganization = errors.New("form already belongs to an organization")
+
 // FormService handles form-related business logic
 type FormService struct {
 	collections *database.Collections
+	authz       *Authorizer
 }
 
 // NewFormService creates a new form service
-func NewFormService(collections *database.Collections) *FormService {
+func NewFormService(collections *database.Collections, authz *Authorizer) *FormService {
 	return &FormService{
 		collections: collections,
+		authz:       authz,
 	}
 }
 
-// CreateForm creates a new form
-func (s *FormService) CreateForm(ctx context.Context, req *models.CreateFormRequest, ownerID *string) (*models.FormResponse, error) {
+// CreateForm creates a new form in the actor's workspace
+func (s *FormService) CreateForm(ctx context.Context, req *models.CreateFormRequest, actor *Actor) (_ *models.FormResponse, err error) {
+	ctx, span := tracing.Start(ctx, "FormService.CreateForm")
+	defer tracing.End(span, &err)
+
+	if err := s.authz.Require(actor, PermissionFormsWrite); err != nil {
+		return nil, err
+	}
+
 	// Generate form ID first
 	formID := primitive.NewObjectID()
 
@@ -46,19 +61,32 @@ func (s *FormService) CreateForm(ctx context.Context, req *models.CreateFormRequ
 	// Create form document
 	form := &models.Form{
 		ID:          formID,
-		OwnerID:     ownerID,
 		Title:       req.Title,
 		Description: req.Description,
 		Status:      models.FormStatusDraft,
 		ShareSlug:   shareSlug,
 		Fields:      req.Fields,
+		Settings:    req.Settings,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
+	if actor != nil {
+		form.OwnerID = &actor.UserID
+		form.OrganizationID = actor.OrganizationID
+	}
+	if form.OrganizationID != nil {
+		if err := s.countForm(ctx, *form.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Insert form into database
-	_, err := s.collections.Forms.InsertOne(ctx, form)
+	_, err = s.collections.Forms.InsertOne(ctx, form)
 	if err != nil {
+		if form.OrganizationID != nil {
+			s.releaseForm(ctx, *form.OrganizationID)
+		}
 		return nil, fmt.Errorf("failed to create form: %w", err)
 	}
 
@@ -67,47 +95,42 @@ func (s *FormService) CreateForm(ctx context.Context, req *models.CreateFormRequ
 	_, err = s.collections.Analytics.InsertOne(ctx, analytics)
 	if err != nil {
 		// Log error but don't fail form creation
-		log.Printf("WARN: Failed to initialize analytics for form %s: %v", form.ID.Hex(), err)
+		slog.WarnContext(ctx, "Failed to initialize analytics", "form_id", form.ID.Hex(), "error", err)
 	}
 
 	return form.ToResponse(), nil
 }
 
 // GetFormByID retrieves a form by its ID
-func (s *FormService) GetFormByID(ctx context.Context, formID string, ownerID *string) (*models.FormResponse, error) {
+func (s *FormService) GetFormByID(ctx context.Context, formID string, actor *Actor) (_ *models.FormResponse, err error) {
+	ctx, span := tracing.Start(ctx, "FormService.GetFormByID", trace.WithAttributes(tracing.FormID(formID)))
+	defer tracing.End(span, &err)
+
 	formObjectID, err := primitive.ObjectIDFromHex(formID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid form ID: %w", err)
 	}
 
-	filter := bson.M{"_id": formObjectID}
-
-	// If ownerID is provided, add it to filter for access control
-	if ownerID != nil {
-		filter["ownerId"] = *ownerID
-	}
-
-	var form models.Form
-	err = s.collections.Forms.FindOne(ctx, filter).Decode(&form)
+	form, err := s.authz.Form(ctx, actor, formObjectID, PermissionFormsRead)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("form not found")
-		}
-		return nil, fmt.Errorf("failed to get form: %w", err)
+		return nil, err
 	}
 
 	return form.ToResponse(), nil
 }
 
 // GetFormBySlug retrieves a form by its share slug (public access)
-func (s *FormService) GetFormBySlug(ctx context.Context, slug string) (*models.PublicFormResponse, error) {
+func (s *FormService) GetFormBySlug(ctx context.Context, slug string) (_ *models.PublicFormResponse, err error) {
+	ctx, span := tracing.Start(ctx, "FormService.GetFormBySlug")
+	defer tracing.End(span, &err)
+
 	filter := bson.M{
 		"shareSlug": slug,
 		"status":    models.FormStatusPublished,
 	}
 
 	var form models.Form
-	err := s.collections.Forms.FindOne(ctx, filter).Decode(&form)
+	err = s.collections.Forms.FindOne(ctx, filter).Decode(&form)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, fmt.Errorf("form not found or not published")
@@ -119,25 +142,19 @@ func (s *FormService) GetFormBySlug(ctx context.Context, slug string) (*models.P
 }
 
 // UpdateForm updates an existing form
-func (s *FormService) UpdateForm(ctx context.Context, formID string, req *models.UpdateFormRequest, ownerID *string) (*models.FormResponse, error) {
+func (s *FormService) UpdateForm(ctx context.Context, formID string, req *models.UpdateFormRequest, actor *Actor) (_ *models.FormResponse, err error) {
+	ctx, span := tracing.Start(ctx, "FormService.UpdateForm", trace.WithAttributes(tracing.FormID(formID)))
+	defer tracing.End(span, &err)
+
 	objectID, err := primitive.ObjectIDFromHex(formID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid form ID: %w", err)
 	}
 
 	// Get existing form to compare field changes
-	var existingForm models.Form
-	filter := bson.M{"_id": objectID}
-	if ownerID != nil {
-		filter["ownerId"] = *ownerID
-	}
-
-	err = s.collections.Forms.FindOne(ctx, filter).Decode(&existingForm)
+	existingForm, err := s.authz.Form(ctx, actor, objectID, PermissionFormsWrite)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("form not found")
-		}
-		return nil, fmt.Errorf("failed to get form: %w", err)
+		return nil, err
 	}
 
 	// Build update document
@@ -156,6 +173,9 @@ func (s *FormService) UpdateForm(ctx context.Context, formID string, req *models
 	if req.Status != nil {
 		update["$set"].(bson.M)["status"] = *req.Status
 	}
+	if req.Settings != nil {
+		update["$set"].(bson.M)["settings"] = req.Settings
+	}
 	if req.Fields != nil {
 		update["$set"].(bson.M)["fields"] = req.Fields
 
@@ -202,16 +222,15 @@ func (s *FormService) UpdateForm(ctx context.Context, formID string, req *models
 				analytics := models.InitializeAnalytics(objectID, req.Fields)
 				_, err = s.collections.Analytics.InsertOne(ctx, analytics)
 				if err != nil {
-					log.Printf("WARN: Failed to create analytics for form %s: %v", formID, err)
+					slog.WarnContext(ctx, "Failed to create analytics", "form_id", formID, "error", err)
 				}
 			} else {
-				log.Printf("WARN: Failed to get analytics for form %s: %v", formID, err)
+				slog.WarnContext(ctx, "Failed to get analytics", "form_id", formID, "error", err)
 			}
 		} else {
 			if shouldResetAnalytics {
 				// Reset analytics due to incompatible changes
-				log.Printf("INFO: Resetting analytics for form %s due to incompatible changes: %v",
-					formID, incompatibleChanges)
+				slog.InfoContext(ctx, "Resetting analytics due to incompatible changes", "form_id", formID, "changes", incompatibleChanges)
 
 				newAnalytics := models.InitializeAnalytics(objectID, req.Fields)
 				newAnalytics.UpdatedAt = time.Now()
@@ -224,7 +243,7 @@ func (s *FormService) UpdateForm(ctx context.Context, formID string, req *models
 					&options.ReplaceOptions{Upsert: &upsert},
 				)
 				if err != nil {
-					log.Printf("WARN: Failed to reset analytics for form %s: %v", formID, err)
+					slog.WarnContext(ctx, "Failed to reset analytics", "form_id", formID, "error", err)
 				}
 			} else {
 				// Preserve existing analytics data (compatible changes only)
@@ -265,75 +284,79 @@ func (s *FormService) UpdateForm(ctx context.Context, formID string, req *models
 					&options.ReplaceOptions{Upsert: &upsert},
 				)
 				if err != nil {
-					log.Printf("WARN: Failed to update analytics for form %s: %v", formID, err)
+					slog.WarnContext(ctx, "Failed to update analytics", "form_id", formID, "error", err)
 				}
 			}
 		}
 	}
 
-	// Build filter for update
-	filter = bson.M{"_id": objectID}
-	if ownerID != nil {
-		filter["ownerId"] = *ownerID
-	}
-
 	// Update the form
-	result, err := s.collections.Forms.UpdateOne(ctx, filter, update)
+	result, err := s.collections.Forms.UpdateOne(ctx, bson.M{"_id": objectID}, update)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update form: %w", err)
 	}
 
 	if result.MatchedCount == 0 {
-		return nil, fmt.Errorf("form not found")
+		return nil, ErrFormNotFound
 	}
 
 	// Return updated form
-	return s.GetFormByID(ctx, formID, ownerID)
+	return s.GetFormByID(ctx, formID, actor)
 }
 
 // DeleteForm deletes a form and its associated data
-func (s *FormService) DeleteForm(ctx context.Context, formID string, ownerID *string) error {
+func (s *FormService) DeleteForm(ctx context.Context, formID string, actor *Actor) (err error) {
+	ctx, span := tracing.Start(ctx, "FormService.DeleteForm", trace.WithAttributes(tracing.FormID(formID)))
+	defer tracing.End(span, &err)
+
 	objectID, err := primitive.ObjectIDFromHex(formID)
 	if err != nil {
 		return fmt.Errorf("invalid form ID: %w", err)
 	}
 
-	filter := bson.M{"_id": objectID}
-	if ownerID != nil {
-		filter["ownerId"] = *ownerID
+	form, err := s.authz.Form(ctx, actor, objectID, PermissionFormsWrite)
+	if err != nil {
+		return err
 	}
 
 	// Delete form
-	result, err := s.collections.Forms.DeleteOne(ctx, filter)
+	result, err := s.collections.Forms.DeleteOne(ctx, bson.M{"_id": objectID})
 	if err != nil {
 		return fmt.Errorf("failed to delete form: %w", err)
 	}
 
 	if result.DeletedCount == 0 {
-		return fmt.Errorf("form not found")
+		return ErrFormNotFound
+	}
+	if form.OrganizationID != nil {
+		s.releaseForm(ctx, *form.OrganizationID)
 	}
 
 	// Delete associated responses
 	_, err = s.collections.Responses.DeleteMany(ctx, bson.M{"formId": objectID})
 	if err != nil {
-		log.Printf("WARN: Failed to delete responses for form %s: %v", formID, err)
+		slog.WarnContext(ctx, "Failed to delete responses", "form_id", formID, "error", err)
 	}
 
 	// Delete associated analytics
 	_, err = s.collections.Analytics.DeleteOne(ctx, bson.M{"_id": objectID})
 	if err != nil {
-		log.Printf("WARN: Failed to delete analytics for form %s: %v", formID, err)
+		slog.WarnContext(ctx, "Failed to delete analytics", "form_id", formID, "error", err)
 	}
 
+	s.authz.formAccessChanged(formID)
 	return nil
 }
 
-// ListForms lists forms for a user (with pagination)
-func (s *FormService) ListForms(ctx context.Context, ownerID *string, page, limit int) ([]*models.FormResponse, int64, error) {
-	filter := bson.M{}
-	if ownerID != nil {
-		filter["ownerId"] = *ownerID
+// ListForms lists the forms in the actor's workspace (with pagination)
+func (s *FormService) ListForms(ctx context.Context, actor *Actor, page, limit int) (_ []*models.FormResponse, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "FormService.ListForms")
+	defer tracing.End(span, &err)
+
+	if err := s.authz.Require(actor, PermissionFormsRead); err != nil {
+		return nil, 0, err
 	}
+	filter := s.authz.WorkspaceFilter(actor)
 
 	// Get total count
 	total, err := s.collections.Forms.CountDocuments(ctx, filter)
@@ -372,19 +395,109 @@ func (s *FormService) ListForms(ctx context.Context, ownerID *string, page, limi
 }
 
 // PublishForm publishes a draft form
-func (s *FormService) PublishForm(ctx context.Context, formID string, ownerID *string) (*models.FormResponse, error) {
+func (s *FormService) PublishForm(ctx context.Context, formID string, actor *Actor) (_ *models.FormResponse, err error) {
+	ctx, span := tracing.Start(ctx, "FormService.PublishForm", trace.WithAttributes(tracing.FormID(formID)))
+	defer tracing.End(span, &err)
+
 	req := &models.UpdateFormRequest{
 		Status: &[]models.FormStatus{models.FormStatusPublished}[0],
 	}
-	return s.UpdateForm(ctx, formID, req, ownerID)
+	return s.UpdateForm(ctx, formID, req, actor)
 }
 
 // UnpublishForm unpublishes a form (sets to draft)
-func (s *FormService) UnpublishForm(ctx context.Context, formID string, ownerID *string) (*models.FormResponse, error) {
+func (s *FormService) UnpublishForm(ctx context.Context, formID string, actor *Actor) (_ *models.FormResponse, err error) {
+	ctx, span := tracing.Start(ctx, "FormService.UnpublishForm", trace.WithAttributes(tracing.FormID(formID)))
+	defer tracing.End(span, &err)
+
 	req := &models.UpdateFormRequest{
 		Status: &[]models.FormStatus{models.FormStatusDraft}[0],
 	}
-	return s.UpdateForm(ctx, formID, req, ownerID)
+	return s.UpdateForm(ctx, formID, req, actor)
+}
+
+// TransferForm moves a personal form of the actor into an organization where
+// the actor may create forms. The form keeps its owner as its creator.
+func (s *FormService) TransferForm(ctx context.Context, formID, organizationID string, actor *Actor) (_ *models.FormResponse, err error) {
+	ctx, span := tracing.Start(ctx, "FormService.TransferForm", trace.WithAttributes(tracing.FormID(formID)))
+	defer tracing.End(span, &err)
+
+	if actor == nil {
+		return nil, ErrForbidden
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(formID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid form ID: %w", err)
+	}
+
+	form, err := s.authz.Form(ctx, actor, objectID, PermissionFormsWrite)
+	if err != nil {
+		return nil, err
+	}
+	if form.OrganizationID != nil {
+		return nil, ErrFormInOrganization
+	}
+
+	target, err := s.authz.ResolveActor(ctx, actor.UserID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if target.OrganizationID == nil {
+		return nil, ErrWorkspaceNotFound
+	}
+	if err := s.authz.Require(target, PermissionFormsWrite); err != nil {
+		return nil, err
+	}
+	if err := s.countForm(ctx, *target.OrganizationID); err != nil {
+		return nil, err
+	}
+
+	result, err := s.collections.Forms.UpdateOne(ctx,
+		bson.M{"_id": objectID, "organizationId": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"organizationId": *target.OrganizationID, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		s.releaseForm(ctx, *target.OrganizationID)
+		return nil, fmt.Errorf("failed to transfer form: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		s.releaseForm(ctx, *target.OrganizationID)
+		return nil, ErrFormInOrganization
+	}
+	s.authz.formAccessChanged(formID)
+
+	return s.GetFormByID(ctx, formID, target)
+}
+
+// countForm adds one form to the organization's form count before a form is created
+// in or moved into it. The update only matches while the organization exists, so a
+// form cannot be added to an organization that is deleted at the same time.
+func (s *FormService) countForm(ctx context.Context, organizationID primitive.ObjectID) error {
+	result, err := s.collections.Organizations.UpdateOne(ctx,
+		bson.M{"_id": organizationID},
+		bson.M{"$inc": bson.M{"formCount": 1}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update form count: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrWorkspaceNotFound
+	}
+	return nil
+}
+
+// releaseForm takes one form off the organization's form count, after a form was
+// deleted or a counted form was not added. Until then the count is one too high,
+// which only refuses deleting the organization.
+func (s *FormService) releaseForm(ctx context.Context, organizationID primitive.ObjectID) {
+	_, err := s.collections.Organizations.UpdateOne(ctx,
+		bson.M{"_id": organizationID},
+		bson.M{"$inc": bson.M{"formCount": -1}},
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update form count", "organization_id", organizationID.Hex(), "error", err)
+	}
 }
 
 // slugExists checks if a share slug already exists
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+var (
+	// ErrIdempotencyKeyInUse is returned while another request with the same key is still being processed
+	ErrIdempotencyKeyInUse = errors.New("idempotency key is already being processed")
+	// ErrIdempotencyKeyMismatch is returned when a key is reused with a different request body
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was used with a different request")
+)
+
+// idempotencyProcessingTimeout bounds how long an unfinished request holds its key
+const idempotencyProcessingTimeout = time.Minute
+
+// IdempotencyService stores responses of idempotent requests so client retries can be replayed
+type IdempotencyService struct {
+	collections *database.Collections
+	ttl         time.Duration
+}
+
+// NewIdempotencyService creates a new idempotency service
+func NewIdempotencyService(collections *database.Collections, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		collections: collections,
+		ttl:         ttl,
+	}
+}
+
+// Begin claims an idempotency key for a request. If the key was already used for an identical
+// request that has completed, the stored record is returned so its response can be replayed.
+func (s *IdempotencyService) Begin(ctx context.Context, scope, key, requestHash string) (*models.IdempotencyRecord, error) {
+	now := time.Now()
+	record := &models.IdempotencyRecord{
+		ID:          utils.HashSHA256(scope + "|" + key),
+		Scope:       scope,
+		RequestHash: requestHash,
+		Status:      models.IdempotencyStatusProcessing,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(idempotencyProcessingTimeout),
+	}
+
+	// Retry once in case an expired record has not been removed by the TTL monitor yet
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := s.collections.IdempotencyKeys.InsertOne(ctx, record)
+		if err == nil {
+			return record, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("failed to store idempotency key: %w", err)
+		}
+
+		var existing models.IdempotencyRecord
+		err = s.collections.IdempotencyKeys.FindOne(ctx, bson.M{"_id": record.ID}).Decode(&existing)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+
+		if existing.ExpiresAt.Before(now) {
+			_, err = s.collections.IdempotencyKeys.DeleteOne(ctx, bson.M{"_id": existing.ID, "expiresAt": existing.ExpiresAt})
+			if err != nil {
+				return nil, fmt.Errorf("failed to remove expired idempotency key: %w", err)
+			}
+			continue
+		}
+
+		if existing.RequestHash != requestHash {
+			return nil, ErrIdempotencyKeyMismatch
+		}
+		if !existing.IsCompleted() {
+			return nil, ErrIdempotencyKeyInUse
+		}
+
+		return &existing, nil
+	}
+
+	return nil, ErrIdempotencyKeyInUse
+}
+
+// Complete stores the response for a claimed key so that retries replay it
+func (s *IdempotencyService) Complete(ctx context.Context, recordID string, statusCode int, contentType string, body []byte) error {
+	_, err := s.collections.IdempotencyKeys.UpdateOne(ctx, bson.M{"_id": recordID}, bson.M{
+		"$set": bson.M{
+			"status":      models.IdempotencyStatusCompleted,
+			"statusCode":  statusCode,
+			"contentType": contentType,
+			"body":        body,
+			"expiresAt":   time.Now().Add(s.ttl),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release frees a claimed key so the request can be retried (used when processing fails)
+func (s *IdempotencyService) Release(ctx context.Context, recordID string) error {
+	_, err := s.collections.IdempotencyKeys.DeleteOne(ctx, bson.M{
+		"_id":    recordID,
+		"status": models.IdempotencyStatusProcessing,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+)
+
+// ErrTooManyLoginAttempts is returned when logins are refused after repeated failures.
+// It is the same whether or not the email address belongs to an account.
+var ErrTooManyLoginAttempts = errors.New("too many failed login attempts, try again later")
+
+// maxLoginDelay caps the wait between failed logins to an account
+const maxLoginDelay = time.Minute
+
+// LoginThrottledError is returned when a login is refused until RetryAfter has passed
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return ErrTooManyLoginAttempts.Error()
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrTooManyLoginAttempts
+}
+
+// LockoutConfig configures login brute-force protection
+type LockoutConfig struct {
+	MaxAttempts     int           // Failures per account within Window before it is locked, 0 disables
+	IPMaxAttempts   int           // Failures per client IP within Window before it is locked, 0 disables
+	Window          time.Duration // Failures older than this are forgotten
+	LockoutDuration time.Duration // How long a locked account or IP is refused
+	Delay           time.Duration // Wait after the first failure to an account, doubled by each further failure
+	AuditRetention  time.Duration // How long failed logins are kept in the audit collection
+}
+
+// LockoutService tracks failed logins per account and client IP. Accounts must wait
+// a growing delay between failures, and accounts and IPs are locked for a while once
+// they reach their threshold. Every failed or refused login is recorded for audit.
+type LockoutService struct {
+	collections *database.Collections
+	cfg         LockoutConfig
+	now         func() time.Time
+}
+
+// NewLockoutService creates a new lockout service
+func NewLockoutService(collections *database.Collections, cfg LockoutConfig) *LockoutService {
+	return &LockoutService{
+		collections: collections,
+		cfg:         cfg,
+		now:         time.Now,
+	}
+}
+
+// Check returns a *LoginThrottledError if a login to email from client must wait,
+// and records the refused attempt
+func (s *LockoutService) Check(ctx context.Context, email string, client *models.ClientInfo) error {
+	accountKey, ipKey := loginAttemptKeys(email, client)
+	keys := bson.A{accountKey}
+	if ipKey != "" {
+		keys = append(keys, ipKey)
+	}
+
+	cursor, err := s.collections.LoginAttempts.Find(ctx, bson.M{"_id": bson.M{"$in": keys}})
+	if err != nil {
+		return fmt.Errorf("failed to get login attempts: %w", err)
+	}
+	var records []models.LoginAttempts
+	if err := cursor.All(ctx, &records); err != nil {
+		return fmt.Errorf("failed to decode login attempts: %w", err)
+	}
+
+	now := s.now()
+	var retryAt time.Time
+	reason := models.FailedLoginThrottled
+	for i := range records {
+		at, locked := s.retryAt(&records[i], records[i].Key == accountKey, now)
+		if at.After(retryAt) {
+			retryAt = at
+		}
+		if locked {
+			reason = models.FailedLoginLocked
+		}
+	}
+	if !retryAt.After(now) {
+		return nil
+	}
+
+	s.audit(ctx, email, nil, client, reason)
+	return &LoginThrottledError{RetryAfter: retryAt.Sub(now)}
+}
+
+// RecordFailure counts a failed login against the account and client IP and records it.
+// userID is nil when the email address does not belong to a user.
+func (s *LockoutService) RecordFailure(ctx context.Context, email string, userID *primitive.ObjectID, client *models.ClientInfo, reason models.FailedLoginReason) error {
+	accountKey, ipKey := loginAttemptKeys(email, client)
+
+	s.audit(ctx, email, userID, client, reason)
+
+	attempts, err := s.increment(ctx, accountKey, s.cfg.MaxAttempts)
+	if err != nil {
+		return err
+	}
+	if s.cfg.MaxAttempts > 0 && attempts.Failures == s.cfg.MaxAttempts {
+		slog.WarnContext(ctx, "Account locked after failed logins", "email", email, "failures", attempts.Failures, "locked_for", s.cfg.LockoutDuration)
+	}
+
+	if ipKey == "" {
+		return nil
+	}
+	attempts, err = s.increment(ctx, ipKey, s.cfg.IPMaxAttempts)
+	if err != nil {
+		return err
+	}
+	if s.cfg.IPMaxAttempts > 0 && attempts.Failures == s.cfg.IPMaxAttempts {
+		slog.WarnContext(ctx, "Client IP locked after failed logins", "ip", client.IP, "failures", attempts.Failures, "locked_for", s.cfg.LockoutDuration)
+	}
+	return nil
+}
+
+// Reset forgets the failed logins of an account, e.g. after a successful login.
+// Failures from the client IP keep counting.
+func (s *LockoutService) Reset(ctx context.Context, email string) error {
+	accountKey, _ := loginAttemptKeys(email, nil)
+	if _, err := s.collections.LoginAttempts.DeleteOne(ctx, bson.M{"_id": accountKey}); err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+	return nil
+}
+
+// increment atomically counts a failure for a key and locks it once it reaches
+// threshold. Failures restart from one after the window or an expired lockout.
+func (s *LockoutService) increment(ctx context.Context, key string, threshold int) (*models.LoginAttempts, error) {
+	now := s.now()
+
+	// A missing field compares lower than any date, so new and unlocked counters work too
+	fresh := bson.M{"$or": bson.A{
+		bson.M{"$lte": bson.A{"$lastFailureAt", now.Add(-s.cfg.Window)}},
+		bson.M{"$lte": bson.A{bson.M{"$ifNull": bson.A{"$lockedUntil", now.Add(time.Second)}}, now}},
+	}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"fresh": fresh}}},
+		{{Key: "$set", Value: bson.M{
+			"failures":      bson.M{"$cond": bson.A{"$fresh", 1, bson.M{"$add": bson.A{"$failures", 1}}}},
+			"lockedUntil":   bson.M{"$cond": bson.A{"$fresh", "$$REMOVE", "$lockedUntil"}},
+			"lastFailureAt": now,
+			"expiresAt":     now.Add(s.cfg.Window + s.cfg.LockoutDuration),
+		}}},
+		{{Key: "$unset", Value: "fresh"}},
+	}
+	if threshold > 0 {
+		update = append(update, bson.D{{Key: "$set", Value: bson.M{
+			"lockedUntil": bson.M{"$cond": bson.A{
+				bson.M{"$gte": bson.A{"$failures", threshold}},
+				now.Add(s.cfg.LockoutDuration),
+				"$lockedUntil",
+			}},
+		}}})
+	}
+
+	var attempts models.LoginAttempts
+	err := s.collections.LoginAttempts.FindOneAndUpdate(ctx, bson.M{"_id": key}, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&attempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record failed login: %w", err)
+	}
+	return &attempts, nil
+}
+
+// retryAt returns when the next login counted by attempts is allowed and whether it
+// is locked. Only accounts have to wait between failures; users sharing an IP should
+// not be slowed down by each other's typos.
+func (s *LockoutService) retryAt(attempts *models.LoginAttempts, delayed bool, now time.Time) (time.Time, bool) {
+	if attempts.IsLocked(now) {
+		return *attempts.LockedUntil, true
+	}
+	if !delayed || s.cfg.Delay <= 0 || !attempts.LastFailureAt.After(now.Add(-s.cfg.Window)) {
+		return time.Time{}, false
+	}
+	return attempts.LastFailureAt.Add(loginDelay(s.cfg.Delay, attempts.Failures)), false
+}
+
+// audit records a failed or refused login. Failures to write are logged, since they
+// must not change the outcome of the login.
+func (s *LockoutService) audit(ctx context.Context, email string, userID *primitive.ObjectID, client *models.ClientInfo, reason models.FailedLoginReason) {
+	now := s.now()
+	record := &models.FailedLogin{
+		ID:        primitive.NewObjectID(),
+		Email:     email,
+		UserID:    userID,
+		Reason:    reason,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.AuditRetention),
+	}
+	record.SetClient(client)
+
+	if _, err := s.collections.FailedLogins.InsertOne(ctx, record); err != nil {
+		slog.ErrorContext(ctx, "Failed to record failed login", "email", email, "reason", reason, "error", err)
+	}
+}
+
+// loginAttemptKeys returns the counter keys of an account and of the client's IP,
+// which is empty when the IP is unknown
+func loginAttemptKeys(email string, client *models.ClientInfo) (string, string) {
+	accountKey := "email:" + strings.ToLower(strings.TrimSpace(email))
+	if client == nil || client.IP == "" {
+		return accountKey, ""
+	}
+	return accountKey, "ip:" + client.IP
+}
+
+// loginDelay returns the wait after the given number of failures: base after the
+// first, doubled by each further failure, up to maxLoginDelay
+func loginDelay(base time.Duration, failures int) time.Duration {
+	if failures < 1 {
+		return 0
+	}
+	delay := float64(base) * math.Pow(2, float64(failures-1))
+	if delay >= float64(maxLoginDelay) {
+		return maxLoginDelay
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+)
+
+// newTestLockoutService creates a lockout service with the default configuration
+func newTestLockoutService() *LockoutService {
+	return NewLockoutService(nil, LockoutConfig{
+		MaxAttempts:     5,
+		IPMaxAttempts:   20,
+		Window:          15 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+		Delay:           time.Second,
+		AuditRetention:  30 * 24 * time.Hour,
+	})
+}
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{0, 0},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{7, maxLoginDelay},
+		{100, maxLoginDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d failures", tt.failures), func(t *testing.T) {
+			assert.Equal(t, tt.expected, loginDelay(time.Second, tt.failures))
+		})
+	}
+}
+
+func TestLockoutService_RetryAt(t *testing.T) {
+	now := time.Date(2024, 8, 20, 10, 0, 0, 0, time.UTC)
+	service := newTestLockoutService()
+	lockedUntil := now.Add(10 * time.Minute)
+	expiredLock := now.Add(-time.Second)
+
+	tests := []struct {
+		name       string
+		attempts   models.LoginAttempts
+		delayed    bool
+		wantAt     time.Time
+		wantLocked bool
+	}{
+		{
+			name:     "Account waits after a failure",
+			attempts: models.LoginAttempts{Failures: 3, LastFailureAt: now.Add(-time.Second)},
+			delayed:  true,
+			wantAt:   now.Add(3 * time.Second),
+		},
+		{
+			name:     "Delay already passed",
+			attempts: models.LoginAttempts{Failures: 1, LastFailureAt: now.Add(-5 * time.Second)},
+			delayed:  true,
+			wantAt:   now.Add(-4 * time.Second),
+		},
+		{
+			name:     "Failures outside the window are forgotten",
+			attempts: models.LoginAttempts{Failures: 4, LastFailureAt: now.Add(-15 * time.Minute)},
+			delayed:  true,
+		},
+		{
+			name:     "IPs do not wait between failures",
+			attempts: models.LoginAttempts{Failures: 10, LastFailureAt: now},
+			delayed:  false,
+		},
+		{
+			name:       "Locked account",
+			attempts:   models.LoginAttempts{Failures: 5, LastFailureAt: now.Add(-5 * time.Minute), LockedUntil: &lockedUntil},
+			delayed:    true,
+			wantAt:     lockedUntil,
+			wantLocked: true,
+		},
+		{
+			name:       "Locked IP",
+			attempts:   models.LoginAttempts{Failures: 20, LastFailureAt: now.Add(-5 * time.Minute), LockedUntil: &lockedUntil},
+			delayed:    false,
+			wantAt:     lockedUntil,
+			wantLocked: true,
+		},
+		{
+			name:     "Expired lock",
+			attempts: models.LoginAttempts{Failures: 5, LastFailureAt: now.Add(-20 * time.Minute), LockedUntil: &expiredLock},
+			delayed:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, locked := service.retryAt(&tt.attempts, tt.delayed, now)
+			assert.Equal(t, tt.wantAt, at)
+			assert.Equal(t, tt.wantLocked, locked)
+		})
+	}
+
+	t.Run("Delays can be disabled", func(t *testing.T) {
+		service := newTestLockoutService()
+		service.cfg.Delay = 0
+		at, locked := service.retryAt(&models.LoginAttempts{Failures: 3, LastFailureAt: now}, true, now)
+		assert.True(t, at.IsZero())
+		assert.False(t, locked)
+	})
+}
+
+func TestLoginAttemptKeys(t *testing.T) {
+	t.Run("Email addresses are normalized", func(t *testing.T) {
+		account, ip := loginAttemptKeys(" Ada@Example.com ", &models.ClientInfo{IP: "203.0.113.7"})
+		assert.Equal(t, "email:ada@example.com", account)
+		assert.Equal(t, "ip:203.0.113.7", ip)
+	})
+
+	t.Run("Unknown client IP", func(t *testing.T) {
+		_, ip := loginAttemptKeys("ada@example.com", nil)
+		assert.Empty(t, ip)
+
+		_, ip = loginAttemptKeys("ada@example.com", &models.ClientInfo{})
+		assert.Empty(t, ip)
+	})
+}
+
+func TestLoginThrottledError(t *testing.T) {
+	var err error = &LoginThrottledError{RetryAfter: 3 * time.Second}
+	wrapped := fmt.Errorf("login: %w", err)
+
+	assert.ErrorIs(t, wrapped, ErrTooManyLoginAttempts)
+	assert.Equal(t, ErrTooManyLoginAttempts.Error(), err.Error())
+
+	var throttled *LoginThrottledError
+	assert.True(t, errors.As(wrapped, &throttled))
+	assert.Equal(t, 3*time.Second, throttled.RetryAfter)
+}
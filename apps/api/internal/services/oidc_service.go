@@ -0,0 +1,399 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/oidc"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+var (
+	// ErrInvalidOIDCState is returned when the provider redirects back without the
+	// state cookie of the login, with another state, or too late
+	ErrInvalidOIDCState = errors.New("invalid or expired single sign-on state")
+	// ErrOIDCEmailNotVerified is returned when the provider does not vouch for the
+	// email address of a user who has no linked account yet
+	ErrOIDCEmailNotVerified = errors.New("the identity provider did not verify the email address")
+	// ErrOIDCAccountConflict is returned when the account with the email address is
+	// already linked to another identity at the provider
+	ErrOIDCAccountConflict = errors.New("the account is linked to another single sign-on identity")
+	// ErrInvalidOIDCTicket is returned for login tickets that are malformed, expired or used
+	ErrInvalidOIDCTicket = errors.New("invalid or expired single sign-on ticket")
+)
+
+const (
+	// oidcTicketTTL is how long the web app has to redeem a login ticket
+	oidcTicketTTL = time.Minute
+	// oidcTicketPurpose marks used login tickets in used_account_tokens
+	oidcTicketPurpose = "oidc_login"
+	// maxOIDCNameLength matches the longest name users can sign up with
+	maxOIDCNameLength = 50
+)
+
+// oidcState is the encrypted content of the state cookie of a login in progress
+type oidcState struct {
+	State     string `json:"s"`
+	Nonce     string `json:"n"`
+	Verifier  string `json:"v"`
+	CreatedAt int64  `json:"iat"`
+}
+
+// oidcTicketClaims is the signed payload of a login ticket
+type oidcTicketClaims struct {
+	UserID   string `json:"u"`
+	IssuedAt int64  `json:"iat"`
+	Nonce    string `json:"n"`
+}
+
+// OIDCConfig configures single sign-on
+type OIDCConfig struct {
+	ProviderName string        // Shown on the sign-in button
+	StateSecret  string        // Encrypts state cookies and signs login tickets
+	StateTTL     time.Duration // Time to sign in at the provider
+	RedirectURL  string        // Callback registered with the provider
+	AppURL       string        // Web app the callback redirects to
+}
+
+// OIDCService signs users in with an OpenID Connect provider. Users are matched
+// by their identity at the provider, or linked by verified email address on their
+// first single sign-on, and created if they have no account yet.
+type OIDCService struct {
+	collections *database.Collections
+	auth        *AuthService
+	provider    *oidc.Provider
+	cfg         OIDCConfig
+	now         func() time.Time
+}
+
+// NewOIDCService creates a new single sign-on service
+func NewOIDCService(collections *database.Collections, auth *AuthService, provider *oidc.Provider, cfg OIDCConfig) *OIDCService {
+	cfg.AppURL = strings.TrimRight(cfg.AppURL, "/")
+	return &OIDCService{
+		collections: collections,
+		auth:        auth,
+		provider:    provider,
+		cfg:         cfg,
+		now:         time.Now,
+	}
+}
+
+// Begin starts a login. It returns the provider URL to redirect to and the value
+// of the state cookie, which must come back with the callback.
+func (s *OIDCService) Begin(ctx context.Context) (string, string, error) {
+	state, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.GenerateVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	cookie, err := s.sealState(&oidcState{State: state, Nonce: nonce, Verifier: verifier, CreatedAt: s.now().Unix()})
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, cookie, nil
+}
+
+// Callback completes a login with the code and state the provider redirected
+// back with, and returns a ticket the web app exchanges for tokens
+func (s *OIDCService) Callback(ctx context.Context, cookie, state, code string) (string, error) {
+	pending, err := s.openState(cookie)
+	if err != nil {
+		return "", err
+	}
+	if subtle.ConstantTimeCompare([]byte(pending.State), []byte(state)) != 1 {
+		return "", ErrInvalidOIDCState
+	}
+
+	claims, err := s.provider.Exchange(ctx, code, pending.Verifier, pending.Nonce)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.resolveUser(ctx, claims)
+	if err != nil {
+		return "", err
+	}
+
+	return s.issueTicket(user)
+}
+
+// Exchange redeems a login ticket once and starts a session on the client. Users
+// with two-factor authentication get a two-factor token instead, as with passwords.
+func (s *OIDCService) Exchange(ctx context.Context, ticket string, client *models.ClientInfo) (*models.AuthResponse, error) {
+	claims, err := s.parseTicket(ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.auth.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidOIDCTicket
+		}
+		return nil, err
+	}
+
+	_, err = s.collections.UsedAccountTokens.InsertOne(ctx, bson.M{
+		"_id":       claims.Nonce,
+		"purpose":   oidcTicketPurpose,
+		"userId":    claims.UserID,
+		"expiresAt": time.Unix(claims.IssuedAt, 0).Add(oidcTicketTTL),
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrInvalidOIDCTicket
+		}
+		return nil, fmt.Errorf("failed to record used ticket: %w", err)
+	}
+
+	return s.signIn(ctx, user, client)
+}
+
+// signIn starts a session for a user who signed in at the provider. The provider
+// only proves the identity, so users with two-factor authentication still need a code.
+func (s *OIDCService) signIn(ctx context.Context, user *models.User, client *models.ClientInfo) (*models.AuthResponse, error) {
+	if user.TwoFactorEnabled() {
+		return s.auth.twoFactorChallenge(user)
+	}
+	return s.auth.CreateSession(ctx, user, client)
+}
+
+// ProviderName returns the name of the provider shown to users
+func (s *OIDCService) ProviderName() string {
+	return s.cfg.ProviderName
+}
+
+// StateTTL returns how long a login may take at the provider
+func (s *OIDCService) StateTTL() time.Duration {
+	return s.cfg.StateTTL
+}
+
+// SecureCookie reports whether the state cookie must only be sent over HTTPS
+func (s *OIDCService) SecureCookie() bool {
+	return strings.HasPrefix(s.cfg.RedirectURL, "https://")
+}
+
+// CompleteURL returns the web app page that redeems a login ticket
+func (s *OIDCService) CompleteURL(ticket string) string {
+	return s.cfg.AppURL + "/sso/callback?ticket=" + url.QueryEscape(ticket)
+}
+
+// ErrorURL returns the web app login page showing why single sign-on failed
+func (s *OIDCService) ErrorURL(reason string) string {
+	return s.cfg.AppURL + "/login?error=" + url.QueryEscape(reason)
+}
+
+// resolveUser returns the user of a provider identity, linking or creating the
+// account on the first single sign-on
+func (s *OIDCService) resolveUser(ctx context.Context, claims *oidc.Claims) (*models.User, error) {
+	issuer := s.provider.Issuer()
+
+	var user models.User
+	err := s.collections.Users.FindOne(ctx, bson.M{"oidc_issuer": issuer, "oidc_subject": claims.Subject}).Decode(&user)
+	if err == nil {
+		return &user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if claims.Email == "" || !bool(claims.EmailVerified) {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	linked, err := s.linkUser(ctx, issuer, claims)
+	if err != nil || linked != nil {
+		return linked, err
+	}
+
+	created, err := s.createUser(ctx, issuer, claims)
+	if mongo.IsDuplicateKeyError(err) {
+		// Someone signed up with the address meanwhile
+		linked, err = s.linkUser(ctx, issuer, claims)
+		if err == nil && linked == nil {
+			err = ErrOIDCAccountConflict
+		}
+		return linked, err
+	}
+	return created, err
+}
+
+// linkUser links the account with the verified email address of claims to the
+// provider identity. It returns nil if there is no such account.
+func (s *OIDCService) linkUser(ctx context.Context, issuer string, claims *oidc.Claims) (*models.User, error) {
+	var user models.User
+	err := s.collections.Users.FindOne(ctx, bson.M{"email": claims.Email}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if user.OIDCSubject != "" {
+		return nil, ErrOIDCAccountConflict
+	}
+
+	now := s.now()
+	set := bson.M{
+		"oidc_issuer":    issuer,
+		"oidc_subject":   claims.Subject,
+		"email_verified": true,
+		"updated_at":     now,
+	}
+	if user.EmailVerifiedAt == nil {
+		set["email_verified_at"] = now
+	}
+	result, err := s.collections.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "oidc_subject": bson.M{"$exists": false}},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to link user: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrOIDCAccountConflict
+	}
+
+	user.OIDCIssuer = issuer
+	user.OIDCSubject = claims.Subject
+	user.EmailVerified = true
+	slog.InfoContext(ctx, "Linked user to single sign-on identity", "user_id", user.ID.Hex(), "issuer", issuer)
+	return &user, nil
+}
+
+// createUser creates an account for a provider identity. It has no password; the
+// user can set one with a password reset.
+func (s *OIDCService) createUser(ctx context.Context, issuer string, claims *oidc.Claims) (*models.User, error) {
+	now := s.now()
+	user := &models.User{
+		ID:              primitive.NewObjectID(),
+		Email:           claims.Email,
+		Name:            oidcDisplayName(claims),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+		OIDCIssuer:      issuer,
+		OIDCSubject:     claims.Subject,
+	}
+	if _, err := s.collections.Users.InsertOne(ctx, user); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Created user from single sign-on", "user_id", user.ID.Hex(), "issuer", issuer)
+	return user, nil
+}
+
+// sealState encrypts the state of a login for its cookie
+func (s *OIDCService) sealState(state *oidcState) (string, error) {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode single sign-on state: %w", err)
+	}
+	return utils.Encrypt(string(payload), s.cfg.StateSecret)
+}
+
+// openState decrypts a state cookie and checks its age
+func (s *OIDCService) openState(cookie string) (*oidcState, error) {
+	if cookie == "" {
+		return nil, ErrInvalidOIDCState
+	}
+	payload, err := utils.Decrypt(cookie, s.cfg.StateSecret)
+	if err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+
+	var state oidcState
+	if err := json.Unmarshal([]byte(payload), &state); err != nil || state.State == "" || state.Verifier == "" {
+		return nil, ErrInvalidOIDCState
+	}
+	if !s.now().Before(time.Unix(state.CreatedAt, 0).Add(s.cfg.StateTTL)) {
+		return nil, ErrInvalidOIDCState
+	}
+	return &state, nil
+}
+
+// issueTicket returns a signed, short-lived ticket for a user's login
+func (s *OIDCService) issueTicket(user *models.User) (string, error) {
+	nonce, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(oidcTicketClaims{
+		UserID:   user.ID.Hex(),
+		IssuedAt: s.now().Unix(),
+		Nonce:    nonce,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode single sign-on ticket: %w", err)
+	}
+
+	return utils.SignValue(base64.RawURLEncoding.EncodeToString(payload), s.cfg.StateSecret), nil
+}
+
+// parseTicket verifies a ticket's signature and age
+func (s *OIDCService) parseTicket(ticket string) (*oidcTicketClaims, error) {
+	encoded, ok := utils.VerifySignedValue(ticket, s.cfg.StateSecret)
+	if !ok {
+		return nil, ErrInvalidOIDCTicket
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidOIDCTicket
+	}
+
+	var claims oidcTicketClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Nonce == "" {
+		return nil, ErrInvalidOIDCTicket
+	}
+	if !s.now().Before(time.Unix(claims.IssuedAt, 0).Add(oidcTicketTTL)) {
+		return nil, ErrInvalidOIDCTicket
+	}
+
+	return &claims, nil
+}
+
+// oidcDisplayName returns the name of a new user: the name at the provider, or
+// the local part of the email address
+func oidcDisplayName(claims *oidc.Claims) string {
+	name := strings.TrimSpace(claims.Name)
+	if len([]rune(name)) < 2 {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	if runes := []rune(name); len(runes) > maxOIDCNameLength {
+		name = string(runes[:maxOIDCNameLength])
+	}
+	return name
+}
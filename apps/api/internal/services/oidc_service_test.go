@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/oidc"
+)
+
+// newTestOIDCService creates a single sign-on service with a fixed clock
+func newTestOIDCService(now time.Time) *OIDCService {
+	s := NewOIDCService(nil, nil, nil, OIDCConfig{
+		ProviderName: "Acme SSO",
+		StateSecret:  "test-oidc-state-secret-at-least-32-chars",
+		StateTTL:     10 * time.Minute,
+		RedirectURL:  "https://api.example.com/api/auth/oidc/callback",
+		AppURL:       "https://app.example.com/",
+	})
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestOIDCState(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestOIDCService(createdAt)
+
+	cookie, err := s.sealState(&oidcState{State: "state", Nonce: "nonce", Verifier: "verifier", CreatedAt: createdAt.Unix()})
+	require.NoError(t, err)
+	assert.NotContains(t, cookie, "verifier")
+
+	t.Run("valid", func(t *testing.T) {
+		state, err := newTestOIDCService(createdAt.Add(9 * time.Minute)).openState(cookie)
+		require.NoError(t, err)
+		assert.Equal(t, "state", state.State)
+		assert.Equal(t, "nonce", state.Nonce)
+		assert.Equal(t, "verifier", state.Verifier)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		_, err := newTestOIDCService(createdAt.Add(10 * time.Minute)).openState(cookie)
+		assert.ErrorIs(t, err, ErrInvalidOIDCState)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := s.openState("")
+		assert.ErrorIs(t, err, ErrInvalidOIDCState)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		_, err := s.openState("x" + cookie)
+		assert.ErrorIs(t, err, ErrInvalidOIDCState)
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		other := newTestOIDCService(createdAt)
+		other.cfg.StateSecret = "another-oidc-state-secret-of-32-chars"
+		_, err := other.openState(cookie)
+		assert.ErrorIs(t, err, ErrInvalidOIDCState)
+	})
+}
+
+func TestOIDCCallbackRejectsStateMismatch(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestOIDCService(createdAt)
+
+	cookie, err := s.sealState(&oidcState{State: "state", Nonce: "nonce", Verifier: "verifier", CreatedAt: createdAt.Unix()})
+	require.NoError(t, err)
+
+	// Rejected before the provider or the database are used
+	_, err = s.Callback(context.Background(), cookie, "other-state", "code")
+	assert.ErrorIs(t, err, ErrInvalidOIDCState)
+
+	_, err = s.Callback(context.Background(), "", "state", "code")
+	assert.ErrorIs(t, err, ErrInvalidOIDCState)
+}
+
+func TestOIDCTicket(t *testing.T) {
+	issuedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	user := &models.User{ID: primitive.NewObjectID()}
+
+	s := newTestOIDCService(issuedAt)
+	ticket, err := s.issueTicket(user)
+	require.NoError(t, err)
+
+	t.Run("valid", func(t *testing.T) {
+		claims, err := newTestOIDCService(issuedAt.Add(59 * time.Second)).parseTicket(ticket)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID.Hex(), claims.UserID)
+		assert.NotEmpty(t, claims.Nonce)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		_, err := newTestOIDCService(issuedAt.Add(time.Minute)).parseTicket(ticket)
+		assert.ErrorIs(t, err, ErrInvalidOIDCTicket)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		_, err := s.parseTicket("x" + ticket)
+		assert.ErrorIs(t, err, ErrInvalidOIDCTicket)
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		other := newTestOIDCService(issuedAt)
+		other.cfg.StateSecret = "another-oidc-state-secret-of-32-chars"
+		_, err := other.parseTicket(ticket)
+		assert.ErrorIs(t, err, ErrInvalidOIDCTicket)
+	})
+
+	t.Run("unique", func(t *testing.T) {
+		again, err := s.issueTicket(user)
+		require.NoError(t, err)
+		assert.NotEqual(t, ticket, again)
+	})
+}
+
+func TestOIDCSignInRequiresTwoFactor(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	twoFactor := newTestTwoFactorService(now)
+	auth := NewAuthService(nil, "test-access-secret-32-chars-min", "test-refresh-secret-32-chars-min")
+	auth.SetTwoFactor(twoFactor)
+	s := newTestOIDCService(now)
+	s.auth = auth
+
+	user := &models.User{ID: primitive.NewObjectID(), TwoFactor: &models.TwoFactor{Enabled: true}}
+	response, err := s.signIn(context.Background(), user, &models.ClientInfo{})
+	require.NoError(t, err)
+
+	assert.True(t, response.TwoFactorRequired)
+	assert.Empty(t, response.AccessToken)
+	assert.Empty(t, response.RefreshToken)
+
+	claims, err := twoFactor.parseChallenge(response.TwoFactorToken)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.Hex(), claims.UserID)
+}
+
+func TestOIDCURLs(t *testing.T) {
+	s := newTestOIDCService(time.Now())
+
+	assert.Equal(t, "https://app.example.com/sso/callback?ticket=a%2Bb", s.CompleteURL("a+b"))
+	assert.Equal(t, "https://app.example.com/login?error=sso_failed", s.ErrorURL("sso_failed"))
+	assert.True(t, s.SecureCookie())
+
+	s.cfg.RedirectURL = "http://localhost:8080/api/auth/oidc/callback"
+	assert.False(t, s.SecureCookie())
+}
+
+func TestOIDCDisplayName(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims oidc.Claims
+		want   string
+	}{
+		{"provider name", oidc.Claims{Name: " Ada Lovelace ", Email: "ada@example.com"}, "Ada Lovelace"},
+		{"email fallback", oidc.Claims{Email: "ada@example.com"}, "ada"},
+		{"too short", oidc.Claims{Name: "A", Email: "ada@example.com"}, "ada"},
+		{"truncated", oidc.Claims{Name: strings.Repeat("é", 60)}, strings.Repeat("é", maxOIDCNameLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, oidcDisplayName(&tt.claims))
+		})
+	}
+}
@@ -0,0 +1,626 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/mail"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+var (
+	// ErrMemberNotFound is returned when a user is not a member of the organization
+	ErrMemberNotFound = errors.New("member not found")
+	// ErrAlreadyMember is returned when adding a user who is already a member
+	ErrAlreadyMember = errors.New("user is already a member of the organization")
+	// ErrLastOwner is returned when a change would leave an organization without an owner
+	ErrLastOwner = errors.New("an organization needs at least one owner")
+	// ErrOrganizationHasForms is returned when deleting an organization that still owns forms
+	ErrOrganizationHasForms = errors.New("organization still owns forms, delete them first")
+	// ErrInvitationNotFound is returned for invitation tokens that are unknown, expired, revoked or used
+	ErrInvitationNotFound = errors.New("invitation not found or expired")
+	// ErrInvitationForOtherEmail is returned when accepting an invitation sent to another email address
+	ErrInvitationForOtherEmail = errors.New("invitation was sent to another email address")
+)
+
+// invitationTokenBytes is the entropy of invitation tokens
+const invitationTokenBytes = 32
+
+// OrganizationConfig configures organization invitations
+type OrganizationConfig struct {
+	InvitationTTL time.Duration // Lifetime of invitations
+	AppURL        string        // Web app the invitation links point to
+}
+
+// OrganizationService manages organizations and their members. Permissions are
+// checked by the Authorizer, from the requesting user's role.
+type OrganizationService struct {
+	collections *database.Collections
+	authz       *Authorizer
+	mailer      mail.Mailer
+	cfg         OrganizationConfig
+	now         func() time.Time
+}
+
+// NewOrganizationService creates a new organization service
+func NewOrganizationService(collections *database.Collections, authz *Authorizer, mailer mail.Mailer, cfg OrganizationConfig) *OrganizationService {
+	cfg.AppURL = strings.TrimRight(cfg.AppURL, "/")
+	return &OrganizationService{
+		collections: collections,
+		authz:       authz,
+		mailer:      mailer,
+		cfg:         cfg,
+		now:         time.Now,
+	}
+}
+
+// Create creates an organization with the user as its owner
+func (s *OrganizationService) Create(ctx context.Context, userID string, req *models.CreateOrganizationRequest) (*models.OrganizationResponse, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	now := s.now()
+	organization := &models.Organization{
+		ID:         primitive.NewObjectID(),
+		Name:       strings.TrimSpace(req.Name),
+		CreatedBy:  userObjectID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		OwnerCount: 1,
+	}
+	if _, err := s.collections.Organizations.InsertOne(ctx, organization); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	membership := &models.Membership{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: organization.ID,
+		UserID:         userObjectID,
+		Role:           models.RoleOwner,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if _, err := s.collections.Memberships.InsertOne(ctx, membership); err != nil {
+		// An organization without members cannot be reached, so do not keep it
+		if _, deleteErr := s.collections.Organizations.DeleteOne(ctx, bson.M{"_id": organization.ID}); deleteErr != nil {
+			slog.ErrorContext(ctx, "Failed to remove organization without owner", "organization_id", organization.ID.Hex(), "error", deleteErr)
+		}
+		return nil, fmt.Errorf("failed to add organization owner: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Organization created", "organization_id", organization.ID.Hex(), "user_id", userID)
+	return &models.OrganizationResponse{Organization: organization, Role: models.RoleOwner}, nil
+}
+
+// List returns the organizations the user is a member of, by name
+func (s *OrganizationService) List(ctx context.Context, userID string) ([]*models.OrganizationResponse, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	cursor, err := s.collections.Memberships.Find(ctx, bson.M{"userId": userObjectID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find memberships: %w", err)
+	}
+	var memberships []models.Membership
+	if err := cursor.All(ctx, &memberships); err != nil {
+		return nil, fmt.Errorf("failed to decode memberships: %w", err)
+	}
+
+	organizations := []*models.OrganizationResponse{}
+	if len(memberships) == 0 {
+		return organizations, nil
+	}
+
+	roles := make(map[primitive.ObjectID]models.OrganizationRole, len(memberships))
+	ids := make([]primitive.ObjectID, 0, len(memberships))
+	for _, membership := range memberships {
+		roles[membership.OrganizationID] = membership.Role
+		ids = append(ids, membership.OrganizationID)
+	}
+
+	cursor, err = s.collections.Organizations.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find organizations: %w", err)
+	}
+	var found []*models.Organization
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, fmt.Errorf("failed to decode organizations: %w", err)
+	}
+
+	for _, organization := range found {
+		organizations = append(organizations, &models.OrganizationResponse{
+			Organization: organization,
+			Role:         roles[organization.ID],
+		})
+	}
+	sort.Slice(organizations, func(i, j int) bool {
+		return strings.ToLower(organizations[i].Name) < strings.ToLower(organizations[j].Name)
+	})
+
+	return organizations, nil
+}
+
+// Get returns an organization the user is a member of
+func (s *OrganizationService) Get(ctx context.Context, userID, organizationID string) (*models.OrganizationResponse, error) {
+	actor, err := s.authz.ResolveActor(ctx, userID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.OrganizationID == nil {
+		return nil, ErrWorkspaceNotFound
+	}
+
+	organization, err := s.organization(ctx, *actor.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OrganizationResponse{Organization: organization, Role: actor.Role}, nil
+}
+
+// Rename renames an organization
+func (s *OrganizationService) Rename(ctx context.Context, userID, organizationID string, req *models.UpdateOrganizationRequest) (*models.OrganizationResponse, error) {
+	actor, err := s.require(ctx, userID, organizationID, PermissionOrganizationManage)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.collections.Organizations.UpdateOne(ctx,
+		bson.M{"_id": *actor.OrganizationID},
+		bson.M{"$set": bson.M{"name": strings.TrimSpace(req.Name), "updatedAt": s.now()}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename organization: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrWorkspaceNotFound
+	}
+
+	return s.Get(ctx, userID, organizationID)
+}
+
+// Delete deletes an organization and its memberships. Organizations that still
+// own forms cannot be deleted, so that no form is left without anyone to reach it.
+// The delete is conditional on the form count, which forms are counted in before
+// they are added, so a form created or transferred meanwhile refuses the delete.
+func (s *OrganizationService) Delete(ctx context.Context, userID, organizationID string) error {
+	actor, err := s.require(ctx, userID, organizationID, PermissionOrganizationManage)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.collections.Organizations.DeleteOne(ctx, bson.M{"_id": *actor.OrganizationID, "formCount": bson.M{"$lte": 0}})
+	if err != nil {
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrOrganizationHasForms
+	}
+	if _, err := s.collections.Memberships.DeleteMany(ctx, bson.M{"organizationId": *actor.OrganizationID}); err != nil {
+		return fmt.Errorf("failed to delete memberships: %w", err)
+	}
+	if _, err := s.collections.Invitations.DeleteMany(ctx, bson.M{"organizationId": *actor.OrganizationID}); err != nil {
+		return fmt.Errorf("failed to delete invitations: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Organization deleted", "organization_id", organizationID, "user_id", userID)
+	return nil
+}
+
+// Members lists the members of an organization the user is a member of, by email
+func (s *OrganizationService) Members(ctx context.Context, userID, organizationID string) ([]*models.MemberResponse, error) {
+	actor, err := s.require(ctx, userID, organizationID, PermissionFormsRead)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.collections.Memberships.Find(ctx, bson.M{"organizationId": *actor.OrganizationID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find memberships: %w", err)
+	}
+	var memberships []models.Membership
+	if err := cursor.All(ctx, &memberships); err != nil {
+		return nil, fmt.Errorf("failed to decode memberships: %w", err)
+	}
+
+	userIDs := make([]primitive.ObjectID, 0, len(memberships))
+	for _, membership := range memberships {
+		userIDs = append(userIDs, membership.UserID)
+	}
+	cursor, err = s.collections.Users.Find(ctx, bson.M{"_id": bson.M{"$in": userIDs}},
+		options.Find().SetProjection(bson.M{"email": 1, "name": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find users: %w", err)
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("failed to decode users: %w", err)
+	}
+	byID := make(map[primitive.ObjectID]*models.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+
+	members := make([]*models.MemberResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		member := &models.MemberResponse{
+			UserID:   membership.UserID.Hex(),
+			Role:     membership.Role,
+			JoinedAt: membership.CreatedAt,
+		}
+		if user, ok := byID[membership.UserID]; ok {
+			member.Email = user.Email
+			member.Name = user.Name
+		}
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Email < members[j].Email
+	})
+
+	return members, nil
+}
+
+// Invite emails an invitation to join an organization. The response is the same
+// whether or not the address belongs to an account, and nobody becomes a member
+// without accepting. Inviting an address again replaces its pending invitation.
+// Members cannot offer a role above their own.
+func (s *OrganizationService) Invite(ctx context.Context, userID, organizationID string, req *models.InviteMemberRequest) error {
+	actor, err := s.require(ctx, userID, organizationID, PermissionMembersManage)
+	if err != nil {
+		return err
+	}
+	if req.Role.Rank() > actor.Role.Rank() {
+		return ErrForbidden
+	}
+
+	organization, err := s.organization(ctx, *actor.OrganizationID)
+	if err != nil {
+		return err
+	}
+	inviterID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	token, err := utils.GenerateSecureToken(invitationTokenBytes)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	_, err = s.collections.Invitations.UpdateOne(ctx,
+		bson.M{"organizationId": organization.ID, "email": email},
+		bson.M{
+			"$set": bson.M{
+				"role":      req.Role,
+				"tokenHash": utils.HashSHA256(token),
+				"invitedBy": inviterID,
+				"createdAt": now,
+				"expiresAt": now.Add(s.cfg.InvitationTTL),
+			},
+			"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save invitation: %w", err)
+	}
+
+	mailCtx, cancel := context.WithTimeout(ctx, mailTimeout)
+	defer cancel()
+	err = s.mailer.Send(mailCtx, &mail.Message{
+		To:      email,
+		Subject: fmt.Sprintf("You are invited to %s on Dune Forms", organization.Name),
+		Text: fmt.Sprintf("Hi,\n\n"+
+			"You are invited to join %s on Dune Forms as %s. Sign in or create an account with this "+
+			"email address, then open this link within %s to accept:\n\n%s\n\n"+
+			"If you do not want to join, ignore this email.\n",
+			organization.Name, req.Role, formatTTL(s.cfg.InvitationTTL),
+			s.cfg.AppURL+"/invitations/accept?token="+url.QueryEscape(token)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send invitation: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Organization invitation sent", "organization_id", organizationID, "role", req.Role, "user_id", userID)
+	return nil
+}
+
+// Invitations lists the pending invitations of an organization, newest first
+func (s *OrganizationService) Invitations(ctx context.Context, userID, organizationID string) ([]*models.Invitation, error) {
+	actor, err := s.require(ctx, userID, organizationID, PermissionMembersManage)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.collections.Invitations.Find(ctx,
+		bson.M{"organizationId": *actor.OrganizationID, "expiresAt": bson.M{"$gt": s.now()}},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find invitations: %w", err)
+	}
+	invitations := []*models.Invitation{}
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, fmt.Errorf("failed to decode invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation withdraws a pending invitation
+func (s *OrganizationService) RevokeInvitation(ctx context.Context, userID, organizationID, invitationID string) error {
+	actor, err := s.require(ctx, userID, organizationID, PermissionMembersManage)
+	if err != nil {
+		return err
+	}
+	invitationObjectID, err := primitive.ObjectIDFromHex(invitationID)
+	if err != nil {
+		return ErrInvitationNotFound
+	}
+
+	result, err := s.collections.Invitations.DeleteOne(ctx, bson.M{"_id": invitationObjectID, "organizationId": *actor.OrganizationID})
+	if err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrInvitationNotFound
+	}
+
+	slog.InfoContext(ctx, "Organization invitation revoked", "organization_id", organizationID, "invitation_id", invitationID, "user_id", userID)
+	return nil
+}
+
+// AcceptInvitation makes the user a member with the role of an invitation sent
+// to their email address
+func (s *OrganizationService) AcceptInvitation(ctx context.Context, userID, token string) (*models.OrganizationResponse, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	var invitation models.Invitation
+	err = s.collections.Invitations.FindOne(ctx, bson.M{
+		"tokenHash": utils.HashSHA256(token),
+		"expiresAt": bson.M{"$gt": s.now()},
+	}).Decode(&invitation)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("failed to find invitation: %w", err)
+	}
+
+	var user models.User
+	if err := s.collections.Users.FindOne(ctx, bson.M{"_id": userObjectID}).Decode(&user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if !strings.EqualFold(strings.TrimSpace(user.Email), invitation.Email) {
+		return nil, ErrInvitationForOtherEmail
+	}
+
+	organization, err := s.organization(ctx, invitation.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	membership := &models.Membership{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: invitation.OrganizationID,
+		UserID:         userObjectID,
+		Role:           invitation.Role,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if _, err := s.collections.Memberships.InsertOne(ctx, membership); err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("failed to add member: %w", err)
+		}
+		s.deleteInvitation(ctx, invitation.ID)
+		return nil, ErrAlreadyMember
+	}
+	if invitation.Role == models.RoleOwner {
+		s.countOwner(ctx, invitation.OrganizationID)
+	}
+	s.deleteInvitation(ctx, invitation.ID)
+
+	slog.InfoContext(ctx, "Organization invitation accepted", "organization_id", invitation.OrganizationID.Hex(),
+		"role", invitation.Role, "user_id", userID)
+	return &models.OrganizationResponse{Organization: organization, Role: invitation.Role}, nil
+}
+
+// UpdateMember changes the role of a member. Members cannot change the role of
+// members above them, nor grant a role above their own.
+func (s *OrganizationService) UpdateMember(ctx context.Context, userID, organizationID, memberID string, req *models.UpdateMemberRequest) error {
+	actor, err := s.require(ctx, userID, organizationID, PermissionMembersManage)
+	if err != nil {
+		return err
+	}
+
+	member, err := s.member(ctx, *actor.OrganizationID, memberID)
+	if err != nil {
+		return err
+	}
+	if member.Role.Rank() > actor.Role.Rank() || req.Role.Rank() > actor.Role.Rank() {
+		return ErrForbidden
+	}
+
+	demoted := member.Role == models.RoleOwner && req.Role != models.RoleOwner
+	if demoted {
+		if err := s.releaseOwner(ctx, *actor.OrganizationID); err != nil {
+			return err
+		}
+	}
+
+	// The filter on the role read above fails if the member changed meanwhile
+	result, err := s.collections.Memberships.UpdateOne(ctx,
+		bson.M{"_id": member.ID, "role": member.Role},
+		bson.M{"$set": bson.M{"role": req.Role, "updatedAt": s.now()}},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		if demoted {
+			s.countOwner(ctx, *actor.OrganizationID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update member: %w", err)
+		}
+		return ErrMemberNotFound
+	}
+	if req.Role == models.RoleOwner && member.Role != models.RoleOwner {
+		s.countOwner(ctx, *actor.OrganizationID)
+	}
+	s.authz.userAccessChanged(member.UserID.Hex())
+
+	slog.InfoContext(ctx, "Organization member role changed", "organization_id", organizationID, "member_id", memberID,
+		"role", req.Role, "user_id", userID)
+	return nil
+}
+
+// RemoveMember removes a member from an organization. Members may always leave;
+// removing others needs the members permission and is limited to members at or
+// below the remover's role.
+func (s *OrganizationService) RemoveMember(ctx context.Context, userID, organizationID, memberID string) error {
+	permission := PermissionMembersManage
+	if memberID == userID {
+		permission = PermissionFormsRead
+	}
+	actor, err := s.require(ctx, userID, organizationID, permission)
+	if err != nil {
+		return err
+	}
+
+	member, err := s.member(ctx, *actor.OrganizationID, memberID)
+	if err != nil {
+		return err
+	}
+	if member.Role.Rank() > actor.Role.Rank() {
+		return ErrForbidden
+	}
+
+	owner := member.Role == models.RoleOwner
+	if owner {
+		if err := s.releaseOwner(ctx, *actor.OrganizationID); err != nil {
+			return err
+		}
+	}
+
+	// The filter on the role read above fails if the member changed meanwhile
+	result, err := s.collections.Memberships.DeleteOne(ctx, bson.M{"_id": member.ID, "role": member.Role})
+	if err != nil || result.DeletedCount == 0 {
+		if owner {
+			s.countOwner(ctx, *actor.OrganizationID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to remove member: %w", err)
+		}
+		return ErrMemberNotFound
+	}
+	s.authz.userAccessChanged(member.UserID.Hex())
+
+	slog.InfoContext(ctx, "Organization member removed", "organization_id", organizationID, "member_id", memberID, "user_id", userID)
+	return nil
+}
+
+// require resolves the user's role in an organization and checks that it allows a permission
+func (s *OrganizationService) require(ctx context.Context, userID, organizationID string, permission Permission) (*Actor, error) {
+	actor, err := s.authz.ResolveActor(ctx, userID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.OrganizationID == nil {
+		return nil, ErrWorkspaceNotFound
+	}
+	if err := s.authz.Require(actor, permission); err != nil {
+		return nil, err
+	}
+	return actor, nil
+}
+
+// organization loads an organization
+func (s *OrganizationService) organization(ctx context.Context, organizationID primitive.ObjectID) (*models.Organization, error) {
+	var organization models.Organization
+	err := s.collections.Organizations.FindOne(ctx, bson.M{"_id": organizationID}).Decode(&organization)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return &organization, nil
+}
+
+// member loads the membership of a user in an organization
+func (s *OrganizationService) member(ctx context.Context, organizationID primitive.ObjectID, memberID string) (*models.Membership, error) {
+	memberObjectID, err := primitive.ObjectIDFromHex(memberID)
+	if err != nil {
+		return nil, ErrMemberNotFound
+	}
+
+	var membership models.Membership
+	err = s.collections.Memberships.FindOne(ctx, bson.M{"organizationId": organizationID, "userId": memberObjectID}).Decode(&membership)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+	return &membership, nil
+}
+
+// deleteInvitation removes an invitation once it was used
+func (s *OrganizationService) deleteInvitation(ctx context.Context, invitationID primitive.ObjectID) {
+	if _, err := s.collections.Invitations.DeleteOne(ctx, bson.M{"_id": invitationID}); err != nil {
+		slog.WarnContext(ctx, "Failed to delete used invitation", "invitation_id", invitationID.Hex(), "error", err)
+	}
+}
+
+// releaseOwner takes one owner off the organization's owner count before an owner
+// is demoted or removed. The update only matches while another owner remains, so
+// of two concurrent changes to the last two owners only one succeeds.
+func (s *OrganizationService) releaseOwner(ctx context.Context, organizationID primitive.ObjectID) error {
+	result, err := s.collections.Organizations.UpdateOne(ctx,
+		bson.M{"_id": organizationID, "ownerCount": bson.M{"$gt": 1}},
+		bson.M{"$inc": bson.M{"ownerCount": -1}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update owner count: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// countOwner adds one owner to the organization's owner count, after an owner was
+// added or a released owner was kept. Until then the count is one too low, which only
+// refuses changes that would otherwise have been allowed.
+func (s *OrganizationService) countOwner(ctx context.Context, organizationID primitive.ObjectID) {
+	_, err := s.collections.Organizations.UpdateOne(ctx,
+		bson.M{"_id": organizationID},
+		bson.M{"$inc": bson.M{"ownerCount": 1}},
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update owner count", "organization_id", organizationID.Hex(), "error", err)
+	}
+}
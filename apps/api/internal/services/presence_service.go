@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+// ErrFormNotPublished is returned for presence heartbeats on forms that are not public
+var ErrFormNotPublished = errors.New("form not found or not published")
+
+const (
+	// PresenceHeartbeatInterval is how often open form pages are expected to send a heartbeat
+	PresenceHeartbeatInterval = 15 * time.Second
+
+	// presenceTimeout is how long a session counts as active after its last heartbeat
+	presenceTimeout = 3 * PresenceHeartbeatInterval
+)
+
+// PresenceService tracks respondents that currently have a public form open.
+// Sessions are stored in MongoDB so counts are shared by all API instances.
+type PresenceService struct {
+	collections *database.Collections
+	now         func() time.Time
+
+	// Last counts reported per form, used to detect changes
+	mutex   sync.Mutex
+	tracked map[string]*models.PresenceCounts
+}
+
+// NewPresenceService creates a new presence service
+func NewPresenceService(collections *database.Collections) *PresenceService {
+	return &PresenceService{
+		collections: collections,
+		now:         time.Now,
+		tracked:     make(map[string]*models.PresenceCounts),
+	}
+}
+
+// Heartbeat records a session heartbeat and returns the form's current counts.
+// changed reports whether the counts differ from the last ones reported for the form.
+func (s *PresenceService) Heartbeat(ctx context.Context, formID string, req *models.PresenceHeartbeatRequest) (*models.PresenceCounts, bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(formID)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid form ID: %w", err)
+	}
+
+	err = s.collections.Forms.FindOne(ctx, bson.M{
+		"_id":    objectID,
+		"status": models.FormStatusPublished,
+	}, options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, false, ErrFormNotPublished
+		}
+		return nil, false, fmt.Errorf("failed to get form: %w", err)
+	}
+
+	sessionID := utils.HashSHA256(formID + "|" + req.SessionID)
+	now := s.now()
+
+	if req.State == models.PresenceLeft {
+		if _, err := s.collections.Presence.DeleteOne(ctx, bson.M{"_id": sessionID}); err != nil {
+			return nil, false, fmt.Errorf("failed to remove presence session: %w", err)
+		}
+	} else {
+		_, err = s.collections.Presence.UpdateOne(ctx,
+			bson.M{"_id": sessionID},
+			bson.M{"$set": bson.M{
+				"formId":    objectID,
+				"state":     req.State,
+				"lastSeen":  now,
+				"expiresAt": now.Add(presenceTimeout),
+			}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to record presence heartbeat: %w", err)
+		}
+	}
+
+	counts, err := s.Counts(ctx, formID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return counts, s.track(counts), nil
+}
+
+// Counts returns the number of active and filling respondents of a form
+func (s *PresenceService) Counts(ctx context.Context, formID string) (*models.PresenceCounts, error) {
+	objectID, err := primitive.ObjectIDFromHex(formID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid form ID: %w", err)
+	}
+
+	now := s.now()
+	active, filling, err := countPresence(ctx, s.collections, objectID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PresenceCounts{
+		FormID:    formID,
+		Active:    active,
+		Filling:   filling,
+		UpdatedAt: now,
+	}, nil
+}
+
+// Watch periodically recounts forms with an audience so that sessions that time out
+// without saying goodbye are reported too. notify is called for every changed count.
+func (s *PresenceService) Watch(ctx context.Context, interval time.Duration, notify func(*models.PresenceCounts)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, counts := range s.sweep(ctx) {
+				notify(counts)
+			}
+		}
+	}
+}
+
+// sweep recounts all tracked forms and returns the counts that changed
+func (s *PresenceService) sweep(ctx context.Context) []*models.PresenceCounts {
+	s.mutex.Lock()
+	formIDs := make([]string, 0, len(s.tracked))
+	for formID := range s.tracked {
+		formIDs = append(formIDs, formID)
+	}
+	s.mutex.Unlock()
+
+	var changed []*models.PresenceCounts
+	for _, formID := range formIDs {
+		counts, err := s.Counts(ctx, formID)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to count presence", "form_id", formID, "error", err)
+			continue
+		}
+		if s.track(counts) {
+			changed = append(changed, counts)
+		}
+	}
+	return changed
+}
+
+// track stores the latest counts of a form and reports whether they changed.
+// Forms without an audience are no longer tracked.
+func (s *PresenceService) track(counts *models.PresenceCounts) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	previous := s.tracked[counts.FormID]
+	if previous == nil {
+		previous = &models.PresenceCounts{FormID: counts.FormID}
+	}
+
+	if counts.Active == 0 {
+		delete(s.tracked, counts.FormID)
+	} else {
+		s.tracked[counts.FormID] = counts
+	}
+
+	return !previous.SameAs(counts)
+}
+
+// countPresence counts the sessions of a form seen within the presence timeout
+func countPresence(ctx context.Context, collections *database.Collections, formID primitive.ObjectID, now time.Time) (active, filling int, err error) {
+	cursor, err := collections.Presence.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"formId":   formID,
+			"lastSeen": bson.M{"$gte": now.Add(-presenceTimeout)},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$state",
+			"count": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count presence: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var group struct {
+			State models.PresenceState `bson:"_id"`
+			Count int                  `bson:"count"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return 0, 0, fmt.Errorf("failed to decode presence count: %w", err)
+		}
+
+		active += group.Count
+		if group.State == models.PresenceFilling {
+			filling += group.Count
+		}
+	}
+
+	return active, filling, cursor.Err()
+}
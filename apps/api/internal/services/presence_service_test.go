@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+)
+
+func TestPresenceService_Track(t *testing.T) {
+	service := NewPresenceService(nil)
+
+	counts := func(active, filling int) *models.PresenceCounts {
+		return &models.PresenceCounts{FormID: testFormID, Active: active, Filling: filling}
+	}
+
+	steps := []struct {
+		name    string
+		counts  *models.PresenceCounts
+		changed bool
+		tracked bool
+	}{
+		{name: "Empty form is not a change", counts: counts(0, 0), changed: false, tracked: false},
+		{name: "First viewer", counts: counts(1, 0), changed: true, tracked: true},
+		{name: "Same audience", counts: counts(1, 0), changed: false, tracked: true},
+		{name: "Viewer starts filling", counts: counts(1, 1), changed: true, tracked: true},
+		{name: "Second viewer", counts: counts(2, 1), changed: true, tracked: true},
+		{name: "Everyone left", counts: counts(0, 0), changed: true, tracked: false},
+		{name: "Still empty", counts: counts(0, 0), changed: false, tracked: false},
+	}
+
+	for _, step := range steps {
+		assert.Equal(t, step.changed, service.track(step.counts), step.name)
+
+		_, tracked := service.tracked[testFormID]
+		assert.Equal(t, step.tracked, tracked, step.name)
+	}
+}
@@ -2,49 +2,66 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
 	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/tracing"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrDuplicateResponse is returned when a respondent has already answered a form limited to one response
+	ErrDuplicateResponse = errors.New("respondent has already submitted a response")
+	// ErrRespondentIdentityRequired is returned when the form's respondent identity cannot be resolved
+	ErrRespondentIdentityRequired = errors.New("respondent identity required")
+	// ErrFormNotAcceptingResponses is returned when submitting to a form that is not published
+	ErrFormNotAcceptingResponses = errors.New("form is not published")
 )
 
 // ResponseService handles response-related business logic
 type ResponseService struct {
 	collections *database.Collections
+	authz       *Authorizer
 }
 
 // NewResponseService creates a new response service
-func NewResponseService(collections *database.Collections) *ResponseService {
+func NewResponseService(collections *database.Collections, authz *Authorizer) *ResponseService {
 	return &ResponseService{
 		collections: collections,
+		authz:       authz,
 	}
 }
 
 // SubmitResponse submits a new form response
-func (s *ResponseService) SubmitResponse(ctx context.Context, formID string, req *models.SubmitResponseRequest) (*models.ResponseData, []models.ValidationError, error) {
+func (s *ResponseService) SubmitResponse(ctx context.Context, formID string, req *models.SubmitResponseRequest, respondent *models.Respondent) (_ *models.ResponseData, _ []models.ValidationError, err error) {
+	ctx, span := tracing.Start(ctx, "ResponseService.SubmitResponse", trace.WithAttributes(tracing.FormID(formID)))
+	defer tracing.End(span, &err)
+
 	objectID, err := primitive.ObjectIDFromHex(formID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("invalid form ID: %w", err)
+		return nil, nil, ErrFormNotFound
 	}
 
 	// Get the form to validate against
 	var form models.Form
-	err = s.collections.Forms.FindOne(ctx, bson.M{
-		"_id":    objectID,
-		"status": models.FormStatusPublished,
-	}).Decode(&form)
+	err = s.collections.Forms.FindOne(ctx, bson.M{"_id": objectID}).Decode(&form)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, nil, fmt.Errorf("form not found or not published")
+			return nil, nil, ErrFormNotFound
 		}
 		return nil, nil, fmt.Errorf("failed to get form: %w", err)
 	}
+	if form.Status != models.FormStatusPublished {
+		return nil, nil, ErrFormNotAcceptingResponses
+	}
 
 	// Validate the response
 	validationErrors := s.validateResponse(&form, req.Answers)
@@ -61,9 +78,22 @@ func (s *ResponseService) SubmitResponse(ctx context.Context, formID string, req
 		Meta:        req.Meta,
 	}
 
+	// Tag the response with the respondent key when the form is limited to one response;
+	// the unique (formId, respondentKey) index rejects repeat submissions atomically
+	if form.Settings != nil && form.Settings.LimitOneResponse {
+		key, ok := respondent.Key(form.Settings.EffectiveRespondentIdentity())
+		if !ok {
+			return nil, nil, ErrRespondentIdentityRequired
+		}
+		response.RespondentKey = &key
+	}
+
 	// Insert response into database
 	_, err = s.collections.Responses.InsertOne(ctx, response)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) && response.RespondentKey != nil {
+			return nil, nil, ErrDuplicateResponse
+		}
 		return nil, nil, fmt.Errorf("failed to submit response: %w", err)
 	}
 
@@ -71,25 +101,17 @@ func (s *ResponseService) SubmitResponse(ctx context.Context, formID string, req
 }
 
 // GetResponses retrieves responses for a form with pagination
-func (s *ResponseService) GetResponses(ctx context.Context, formID string, page, limit int, ownerID *string) ([]*models.ResponseData, int64, error) {
+func (s *ResponseService) GetResponses(ctx context.Context, formID string, page, limit int, actor *Actor) (_ []*models.ResponseData, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "ResponseService.GetResponses", trace.WithAttributes(tracing.FormID(formID)))
+	defer tracing.End(span, &err)
+
 	objectID, err := primitive.ObjectIDFromHex(formID)
 	if err != nil {
 		return nil, 0, fmt.Errorf("invalid form ID: %w", err)
 	}
 
-	// Verify form ownership if ownerID is provided
-	if ownerID != nil {
-		var form models.Form
-		err = s.collections.Forms.FindOne(ctx, bson.M{
-			"_id":     objectID,
-			"ownerId": *ownerID,
-		}).Decode(&form)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				return nil, 0, fmt.Errorf("form not found or access denied")
-			}
-			return nil, 0, fmt.Errorf("failed to verify form ownership: %w", err)
-		}
+	if err := s.authz.AuthorizeForm(ctx, actor, objectID, PermissionResponsesRead); err != nil {
+		return nil, 0, err
 	}
 
 	filter := bson.M{"formId": objectID}
@@ -129,25 +151,17 @@ func (s *ResponseService) GetResponses(ctx context.Context, formID string, page,
 }
 
 // GetResponsesForExport retrieves all responses for a form (for export)
-func (s *ResponseService) GetResponsesForExport(ctx context.Context, formID string, startDate, endDate *time.Time, ownerID *string) ([]*models.ResponseData, error) {
+func (s *ResponseService) GetResponsesForExport(ctx context.Context, formID string, startDate, endDate *time.Time, actor *Actor) (_ []*models.ResponseData, err error) {
+	ctx, span := tracing.Start(ctx, "ResponseService.GetResponsesForExport", trace.WithAttributes(tracing.FormID(formID)))
+	defer tracing.End(span, &err)
+
 	objectID, err := primitive.ObjectIDFromHex(formID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid form ID: %w", err)
 	}
 
-	// Verify form ownership if ownerID is provided
-	if ownerID != nil {
-		var form models.Form
-		err = s.collections.Forms.FindOne(ctx, bson.M{
-			"_id":     objectID,
-			"ownerId": *ownerID,
-		}).Decode(&form)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				return nil, fmt.Errorf("form not found or access denied")
-			}
-			return nil, fmt.Errorf("failed to verify form ownership: %w", err)
-		}
+	if err := s.authz.AuthorizeForm(ctx, actor, objectID, PermissionResponsesRead); err != nil {
+		return nil, err
 	}
 
 	// Build filter
@@ -212,6 +226,7 @@ func (s *ResponseService) validateResponse(form *models.Form, answers []models.A
 				errors = append(errors, models.ValidationError{
 					Field:   field.ID,
 					Message: fmt.Sprintf("Field '%s' is required", field.Label),
+					Reason:  models.ValidationReasonRequired,
 				})
 				continue
 			}
@@ -221,6 +236,7 @@ func (s *ResponseService) validateResponse(form *models.Form, answers []models.A
 				errors = append(errors, models.ValidationError{
 					Field:   field.ID,
 					Message: fmt.Sprintf("Field '%s' cannot be empty", field.Label),
+					Reason:  models.ValidationReasonEmpty,
 				})
 			}
 		}
@@ -233,6 +249,7 @@ func (s *ResponseService) validateResponse(form *models.Form, answers []models.A
 			errors = append(errors, models.ValidationError{
 				Field:   answer.FieldID,
 				Message: "Invalid field ID",
+				Reason:  models.ValidationReasonUnknownField,
 			})
 			continue
 		}
@@ -276,12 +293,14 @@ func (s *ResponseService) validateFieldValue(field models.Field, value interface
 					errors = append(errors, models.ValidationError{
 						Field:   field.ID,
 						Message: fmt.Sprintf("Minimum length is %d characters", *field.Validation.MinLen),
+						Reason:  models.ValidationReasonMinLength,
 					})
 				}
 				if field.Validation.MaxLen != nil && len(str) > *field.Validation.MaxLen {
 					errors = append(errors, models.ValidationError{
 						Field:   field.ID,
 						Message: fmt.Sprintf("Maximum length is %d characters", *field.Validation.MaxLen),
+						Reason:  models.ValidationReasonMaxLength,
 					})
 				}
 			}
@@ -289,6 +308,7 @@ func (s *ResponseService) validateFieldValue(field models.Field, value interface
 			errors = append(errors, models.ValidationError{
 				Field:   field.ID,
 				Message: "Invalid text value",
+				Reason:  models.ValidationReasonInvalidValue,
 			})
 		}
 
@@ -300,12 +320,14 @@ func (s *ResponseService) validateFieldValue(field models.Field, value interface
 					errors = append(errors, models.ValidationError{
 						Field:   field.ID,
 						Message: fmt.Sprintf("Minimum rating is %d", *field.Validation.Min),
+						Reason:  models.ValidationReasonMin,
 					})
 				}
 				if field.Validation.Max != nil && intVal > *field.Validation.Max {
 					errors = append(errors, models.ValidationError{
 						Field:   field.ID,
 						Message: fmt.Sprintf("Maximum rating is %d", *field.Validation.Max),
+						Reason:  models.ValidationReasonMax,
 					})
 				}
 			}
@@ -313,6 +335,7 @@ func (s *ResponseService) validateFieldValue(field models.Field, value interface
 			errors = append(errors, models.ValidationError{
 				Field:   field.ID,
 				Message: "Invalid rating value",
+				Reason:  models.ValidationReasonInvalidValue,
 			})
 		}
 
@@ -330,12 +353,14 @@ func (s *ResponseService) validateFieldValue(field models.Field, value interface
 				errors = append(errors, models.ValidationError{
 					Field:   field.ID,
 					Message: "Invalid option selected",
+					Reason:  models.ValidationReasonInvalidOption,
 				})
 			}
 		} else {
 			errors = append(errors, models.ValidationError{
 				Field:   field.ID,
 				Message: "Invalid multiple choice value",
+				Reason:  models.ValidationReasonInvalidValue,
 			})
 		}
 
@@ -350,6 +375,7 @@ func (s *ResponseService) validateFieldValue(field models.Field, value interface
 					errors = append(errors, models.ValidationError{
 						Field:   field.ID,
 						Message: "Invalid checkbox value",
+						Reason:  models.ValidationReasonInvalidValue,
 					})
 					return errors
 				}
@@ -366,6 +392,7 @@ func (s *ResponseService) validateFieldValue(field models.Field, value interface
 					errors = append(errors, models.ValidationError{
 						Field:   field.ID,
 						Message: "Invalid checkbox option selected",
+						Reason:  models.ValidationReasonInvalidOption,
 					})
 				}
 			}
@@ -373,6 +400,7 @@ func (s *ResponseService) validateFieldValue(field models.Field, value interface
 			errors = append(errors, models.ValidationError{
 				Field:   field.ID,
 				Message: "Invalid checkbox value format",
+				Reason:  models.ValidationReasonInvalidValue,
 			})
 		}
 	}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -41,15 +42,17 @@ func TestNewAuthService(t *testing.T) {
 func TestNewFormService(t *testing.T) {
 	t.Run("Create new form service", func(t *testing.T) {
 		collections := &database.Collections{}
+		authz := NewAuthorizer(collections)
 
-		service := NewFormService(collections)
+		service := NewFormService(collections, authz)
 
 		assert.NotNil(t, service)
 		assert.Equal(t, collections, service.collections)
+		assert.Same(t, authz, service.authz)
 	})
 
 	t.Run("Create form service with nil collections", func(t *testing.T) {
-		service := NewFormService(nil)
+		service := NewFormService(nil, nil)
 
 		assert.NotNil(t, service)
 		assert.Nil(t, service.collections)
@@ -59,15 +62,17 @@ func TestNewFormService(t *testing.T) {
 func TestNewResponseService(t *testing.T) {
 	t.Run("Create new response service", func(t *testing.T) {
 		collections := &database.Collections{}
+		authz := NewAuthorizer(collections)
 
-		service := NewResponseService(collections)
+		service := NewResponseService(collections, authz)
 
 		assert.NotNil(t, service)
 		assert.Equal(t, collections, service.collections)
+		assert.Same(t, authz, service.authz)
 	})
 
 	t.Run("Create response service with nil collections", func(t *testing.T) {
-		service := NewResponseService(nil)
+		service := NewResponseService(nil, nil)
 
 		assert.NotNil(t, service)
 		assert.Nil(t, service.collections)
@@ -77,17 +82,36 @@ func TestNewResponseService(t *testing.T) {
 func TestNewAnalyticsService(t *testing.T) {
 	t.Run("Create new analytics service", func(t *testing.T) {
 		collections := &database.Collections{}
+		authz := NewAuthorizer(collections)
 
-		service := NewAnalyticsService(collections)
+		service := NewAnalyticsService(collections, authz)
 
 		assert.NotNil(t, service)
 		assert.Equal(t, collections, service.collections)
+		assert.Same(t, authz, service.authz)
 	})
 
 	t.Run("Create analytics service with nil collections", func(t *testing.T) {
-		service := NewAnalyticsService(nil)
+		service := NewAnalyticsService(nil, nil)
 
 		assert.NotNil(t, service)
 		assert.Nil(t, service.collections)
 	})
 }
+
+func TestNewOrganizationService(t *testing.T) {
+	collections := &database.Collections{}
+	authz := NewAuthorizer(collections)
+	mailer := &recordingMailer{}
+
+	service := NewOrganizationService(collections, authz, mailer, OrganizationConfig{
+		InvitationTTL: 7 * 24 * time.Hour,
+		AppURL:        "https://forms.example.com/",
+	})
+
+	assert.NotNil(t, service)
+	assert.Equal(t, collections, service.collections)
+	assert.Same(t, authz, service.authz)
+	assert.Same(t, mailer, service.mailer)
+	assert.Equal(t, "https://forms.example.com", service.cfg.AppURL)
+}
@@ -0,0 +1,457 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+var (
+	// ErrNoSigningKey is returned when tokens are issued before any key has activated
+	ErrNoSigningKey = errors.New("no active signing key")
+	// ErrUnknownSigningKey is returned for tokens signed with a key that is unknown or expired
+	ErrUnknownSigningKey = errors.New("unknown signing key")
+)
+
+// Asymmetric algorithms tokens can be signed with
+const (
+	SigningAlgorithmRS256 = "RS256"
+	SigningAlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKeyCheckInterval is how often instances reload the signing keys and
+// rotate them when due
+const SigningKeyCheckInterval = time.Minute
+
+const (
+	// accessTokenType and refreshTokenType are the "typ" headers of tokens signed
+	// with signing keys, so that a refresh token is never accepted as access token
+	accessTokenType  = "at+jwt"
+	refreshTokenType = "rt+jwt"
+	// signingKeyReloadInterval limits reloads for tokens with unknown key IDs
+	signingKeyReloadInterval = 5 * time.Second
+	// rsaKeyBits is the size of generated RSA keys
+	rsaKeyBits = 2048
+)
+
+// SigningKeyConfig configures token signing keys and their rotation
+type SigningKeyConfig struct {
+	Algorithm        string        // Algorithm of new keys, RS256 or EdDSA
+	Secret           string        // Encrypts private keys at rest
+	RotationInterval time.Duration // How long a key signs tokens
+	PublishAhead     time.Duration // How long a key is published before it signs
+	TokenTTL         time.Duration // Lifetime of the longest-lived token
+}
+
+// signingKey is a decrypted signing key
+type signingKey struct {
+	id          string
+	method      jwt.SigningMethod
+	private     crypto.Signer
+	public      crypto.PublicKey
+	activatesAt time.Time
+	expiresAt   *time.Time
+}
+
+// SigningKeyService manages the key pairs that sign access and refresh tokens.
+// Keys are shared by all instances through the database. Each signs tokens for a
+// rotation interval, is published in the JWKS ahead of that so that other services
+// know it before the first token arrives, and verifies tokens until the last one
+// it signed has expired.
+type SigningKeyService struct {
+	collections *database.Collections
+	cfg         SigningKeyConfig
+	now         func() time.Time
+
+	mu       sync.RWMutex
+	keys     []*signingKey // Oldest activation first
+	loadedAt time.Time
+
+	reloadMu sync.Mutex // Serializes reloads for unknown key IDs
+}
+
+// NewSigningKeyService creates a new signing key service
+func NewSigningKeyService(collections *database.Collections, cfg SigningKeyConfig) *SigningKeyService {
+	return &SigningKeyService{
+		collections: collections,
+		cfg:         cfg,
+		now:         time.Now,
+	}
+}
+
+// Start loads the keys and creates the first one if there is none yet
+func (s *SigningKeyService) Start(ctx context.Context) error {
+	return s.rotate(ctx)
+}
+
+// Run periodically rotates the keys when due and picks up keys created by other
+// instances, until ctx is cancelled
+func (s *SigningKeyService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.rotate(ctx); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Failed to rotate signing keys", "error", err)
+			}
+		}
+	}
+}
+
+// JWKS returns the public keys that verify tokens: the current key, keys that
+// will activate soon and previous keys whose tokens may not have expired yet
+func (s *SigningKeyService) JWKS() *models.JSONWebKeySet {
+	now := s.now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := &models.JSONWebKeySet{Keys: []models.JSONWebKey{}}
+	for _, key := range s.keys {
+		if key.valid(now) {
+			set.Keys = append(set.Keys, key.jwk())
+		}
+	}
+	return set
+}
+
+// sign signs claims with the current key
+func (s *SigningKeyService) sign(claims jwt.Claims, typ string) (string, error) {
+	key, err := s.current()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	token.Header["typ"] = typ
+	return token.SignedString(key.private)
+}
+
+// publicKey returns the key that verifies a token of the given type
+func (s *SigningKeyService) publicKey(token *jwt.Token, typ string) (interface{}, error) {
+	if token.Header["typ"] != typ {
+		return nil, fmt.Errorf("unexpected token type: %v", token.Header["typ"])
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, err := s.verificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+// current returns the key that signs new tokens, the last one that has activated
+func (s *SigningKeyService) current() (*signingKey, error) {
+	now := s.now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if !s.keys[i].activatesAt.After(now) {
+			return s.keys[i], nil
+		}
+	}
+	return nil, ErrNoSigningKey
+}
+
+// verificationKey returns the published key with the ID. Unknown IDs reload the
+// keys, at most once per signingKeyReloadInterval, in case another instance has
+// just created the key.
+func (s *SigningKeyService) verificationKey(kid string) (*signingKey, error) {
+	if key := s.lookup(kid); key != nil {
+		return key, nil
+	}
+
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	if key := s.lookup(kid); key != nil {
+		return key, nil
+	}
+	s.mu.RLock()
+	stale := s.now().Sub(s.loadedAt) >= signingKeyReloadInterval
+	s.mu.RUnlock()
+	if !stale {
+		return nil, ErrUnknownSigningKey
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+	if key := s.lookup(kid); key != nil {
+		return key, nil
+	}
+	return nil, ErrUnknownSigningKey
+}
+
+// lookup returns the unexpired key with the ID, or nil
+func (s *SigningKeyService) lookup(kid string) *signingKey {
+	now := s.now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.keys {
+		if key.id == kid && key.valid(now) {
+			return key
+		}
+	}
+	return nil
+}
+
+// rotate reloads the keys and creates the next key when the current one is due
+func (s *SigningKeyService) rotate(ctx context.Context) error {
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+
+	activatesAt, due := s.nextActivation(s.now())
+	if !due {
+		return nil
+	}
+	if err := s.create(ctx, activatesAt); err != nil {
+		return err
+	}
+	return s.load(ctx)
+}
+
+// nextActivation reports whether the next key must be created and when it
+// activates. The time follows from the current key, so instances rotating at the
+// same time agree on it and only one of them inserts the key.
+func (s *SigningKeyService) nextActivation(now time.Time) (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var current *signingKey
+	for _, key := range s.keys {
+		if key.activatesAt.After(now) {
+			// The next key is already published
+			return time.Time{}, false
+		}
+		current = key
+	}
+	if current == nil {
+		return now, true
+	}
+
+	next := current.activatesAt.Add(s.cfg.RotationInterval)
+	if current.method.Alg() != s.cfg.Algorithm {
+		// The configured algorithm changed; replace the key as soon as possible
+		next = now
+	}
+	if now.Before(next.Add(-s.cfg.PublishAhead)) {
+		return time.Time{}, false
+	}
+	// Instances that were down past the rotation still publish the key ahead
+	if next.Before(now.Add(s.cfg.PublishAhead / 2)) {
+		next = now.Add(s.cfg.PublishAhead)
+	}
+	return next, true
+}
+
+// create stores a new key that activates at the given time. Previous keys are
+// kept until the last token they can sign before then has expired.
+func (s *SigningKeyService) create(ctx context.Context, activatesAt time.Time) error {
+	doc, err := s.generate(activatesAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.collections.SigningKeys.InsertOne(ctx, doc); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			// Another instance created the key
+			return nil
+		}
+		return fmt.Errorf("failed to store signing key: %w", err)
+	}
+
+	_, err = s.collections.SigningKeys.UpdateMany(ctx,
+		bson.M{"activatesAt": bson.M{"$lt": activatesAt}, "expiresAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"expiresAt": activatesAt.Add(s.cfg.TokenTTL)}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to expire previous signing keys: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Created signing key", "kid", doc.ID, "algorithm", doc.Algorithm, "activates_at", activatesAt)
+	return nil
+}
+
+// load replaces the keys with the unexpired keys in the database. Keys that
+// cannot be decrypted, such as after the secret changed, are skipped.
+func (s *SigningKeyService) load(ctx context.Context) error {
+	now := s.now()
+	cursor, err := s.collections.SigningKeys.Find(ctx,
+		bson.M{"$or": []bson.M{
+			{"expiresAt": bson.M{"$exists": false}},
+			{"expiresAt": bson.M{"$gt": now}},
+		}},
+		options.Find().SetSort(bson.D{{Key: "activatesAt", Value: 1}, {Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+	var docs []models.SigningKey
+	if err := cursor.All(ctx, &docs); err != nil {
+		return fmt.Errorf("failed to decode signing keys: %w", err)
+	}
+
+	s.mu.RLock()
+	known := make(map[string]*signingKey, len(s.keys))
+	for _, key := range s.keys {
+		known[key.id] = key
+	}
+	s.mu.RUnlock()
+
+	keys := make([]*signingKey, 0, len(docs))
+	for i := range docs {
+		if previous, ok := known[docs[i].ID]; ok {
+			// Only the expiry changes; skip decrypting the key again
+			key := *previous
+			key.expiresAt = docs[i].ExpiresAt
+			keys = append(keys, &key)
+			continue
+		}
+
+		key, err := s.parse(&docs[i])
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping unusable signing key", "kid", docs[i].ID, "error", err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.loadedAt = now
+	s.mu.Unlock()
+	return nil
+}
+
+// generate creates a key pair with the configured algorithm
+func (s *SigningKeyService) generate(activatesAt time.Time) (*models.SigningKey, error) {
+	var private crypto.Signer
+	switch s.cfg.Algorithm {
+	case SigningAlgorithmRS256:
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate RSA key: %w", err)
+		}
+		private = key
+	case SigningAlgorithmEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate Ed25519 key: %w", err)
+		}
+		private = key
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", s.cfg.Algorithm)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signing key: %w", err)
+	}
+	encrypted, err := utils.Encrypt(base64.StdEncoding.EncodeToString(der), s.cfg.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt signing key: %w", err)
+	}
+	kid, err := utils.GenerateSecureToken(12)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SigningKey{
+		ID:          kid,
+		Algorithm:   s.cfg.Algorithm,
+		PrivateKey:  encrypted,
+		CreatedAt:   s.now(),
+		ActivatesAt: activatesAt,
+	}, nil
+}
+
+// parse decrypts a stored key
+func (s *SigningKeyService) parse(doc *models.SigningKey) (*signingKey, error) {
+	encoded, err := utils.Decrypt(doc.PrivateKey, s.cfg.Secret)
+	if err != nil {
+		return nil, err
+	}
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signing key: %w", err)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+
+	var method jwt.SigningMethod
+	switch parsed.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+	}
+	if method == nil || method.Alg() != doc.Algorithm {
+		return nil, fmt.Errorf("key does not match algorithm %s", doc.Algorithm)
+	}
+
+	private := parsed.(crypto.Signer)
+	return &signingKey{
+		id:          doc.ID,
+		method:      method,
+		private:     private,
+		public:      private.Public(),
+		activatesAt: doc.ActivatesAt,
+		expiresAt:   doc.ExpiresAt,
+	}, nil
+}
+
+// valid reports whether the key still verifies tokens
+func (k *signingKey) valid(now time.Time) bool {
+	return k.expiresAt == nil || now.Before(*k.expiresAt)
+}
+
+// jwk returns the public key as JSON Web Key
+func (k *signingKey) jwk() models.JSONWebKey {
+	jwk := models.JSONWebKey{Kid: k.id, Use: "sig", Alg: k.method.Alg()}
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return jwk
+}
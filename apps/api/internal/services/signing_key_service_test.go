@@ -0,0 +1,343 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+)
+
+// newTestSigningKeyService creates a signing key service with a fixed clock. Its
+// keys count as just loaded, so unknown key IDs do not reach the database.
+func newTestSigningKeyService(algorithm string, now time.Time) *SigningKeyService {
+	s := NewSigningKeyService(nil, SigningKeyConfig{
+		Algorithm:        algorithm,
+		Secret:           "test-signing-key-secret-at-least-32-chars",
+		RotationInterval: 30 * 24 * time.Hour,
+		PublishAhead:     24 * time.Hour,
+		TokenTTL:         RefreshTokenTTL,
+	})
+	s.now = func() time.Time { return now }
+	s.loadedAt = now
+	return s
+}
+
+// addTestSigningKey generates a key like create does and adds it as load would
+func addTestSigningKey(t *testing.T, s *SigningKeyService, activatesAt time.Time, expiresAt *time.Time) *signingKey {
+	t.Helper()
+
+	doc, err := s.generate(activatesAt)
+	require.NoError(t, err)
+	doc.ExpiresAt = expiresAt
+
+	key, err := s.parse(doc)
+	require.NoError(t, err)
+	s.keys = append(s.keys, key)
+	return key
+}
+
+func TestSigningKeyGenerate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, algorithm := range []string{SigningAlgorithmRS256, SigningAlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			s := newTestSigningKeyService(algorithm, now)
+
+			doc, err := s.generate(now)
+			require.NoError(t, err)
+			assert.NotEmpty(t, doc.ID)
+			assert.Equal(t, algorithm, doc.Algorithm)
+			assert.Equal(t, now, doc.ActivatesAt)
+			assert.NotContains(t, doc.PrivateKey, "PRIVATE KEY")
+
+			key, err := s.parse(doc)
+			require.NoError(t, err)
+			assert.Equal(t, doc.ID, key.id)
+			assert.Equal(t, algorithm, key.method.Alg())
+
+			other := newTestSigningKeyService(algorithm, now)
+			other.cfg.Secret = "another-signing-key-secret-of-32-chars"
+			_, err = other.parse(doc)
+			assert.Error(t, err, "keys must not decrypt with another secret")
+		})
+	}
+
+	t.Run("algorithm mismatch", func(t *testing.T) {
+		s := newTestSigningKeyService(SigningAlgorithmRS256, now)
+		doc, err := s.generate(now)
+		require.NoError(t, err)
+
+		doc.Algorithm = SigningAlgorithmEdDSA
+		_, err = s.parse(doc)
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := newTestSigningKeyService("HS256", now).generate(now)
+		assert.Error(t, err)
+	})
+}
+
+func TestSigningKeyNextActivation(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	t.Run("no keys", func(t *testing.T) {
+		activatesAt, due := newTestSigningKeyService(SigningAlgorithmEdDSA, now).nextActivation(now)
+		assert.True(t, due)
+		assert.Equal(t, now, activatesAt)
+	})
+
+	t.Run("not due", func(t *testing.T) {
+		s := newTestSigningKeyService(SigningAlgorithmEdDSA, now)
+		addTestSigningKey(t, s, now.Add(-28*day), nil)
+
+		_, due := s.nextActivation(now)
+		assert.False(t, due)
+	})
+
+	t.Run("published ahead of rotation", func(t *testing.T) {
+		s := newTestSigningKeyService(SigningAlgorithmEdDSA, now)
+		current := addTestSigningKey(t, s, now.Add(-29*day-time.Minute), nil)
+
+		activatesAt, due := s.nextActivation(now)
+		assert.True(t, due)
+		assert.Equal(t, current.activatesAt.Add(30*day), activatesAt, "instances must agree on the activation")
+	})
+
+	t.Run("next key already published", func(t *testing.T) {
+		s := newTestSigningKeyService(SigningAlgorithmEdDSA, now)
+		addTestSigningKey(t, s, now.Add(-29*day-time.Minute), nil)
+		addTestSigningKey(t, s, now.Add(day-time.Minute), nil)
+
+		_, due := s.nextActivation(now)
+		assert.False(t, due)
+	})
+
+	t.Run("overdue", func(t *testing.T) {
+		s := newTestSigningKeyService(SigningAlgorithmEdDSA, now)
+		addTestSigningKey(t, s, now.Add(-90*day), nil)
+
+		activatesAt, due := s.nextActivation(now)
+		assert.True(t, due)
+		assert.Equal(t, now.Add(day), activatesAt, "the key must still be published ahead")
+	})
+
+	t.Run("algorithm changed", func(t *testing.T) {
+		s := newTestSigningKeyService(SigningAlgorithmRS256, now)
+		addTestSigningKey(t, s, now.Add(-day), nil)
+		s.cfg.Algorithm = SigningAlgorithmEdDSA
+
+		activatesAt, due := s.nextActivation(now)
+		assert.True(t, due)
+		assert.Equal(t, now.Add(day), activatesAt)
+	})
+}
+
+func TestSigningKeyCurrent(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestSigningKeyService(SigningAlgorithmEdDSA, now)
+
+	_, err := s.current()
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+
+	expiresAt := now.Add(RefreshTokenTTL)
+	addTestSigningKey(t, s, now.Add(-time.Hour), &expiresAt)
+	active := addTestSigningKey(t, s, now.Add(-time.Minute), nil)
+	addTestSigningKey(t, s, now.Add(time.Hour), nil)
+
+	current, err := s.current()
+	require.NoError(t, err)
+	assert.Equal(t, active.id, current.id, "the last activated key signs, not the published next one")
+}
+
+func TestSigningKeyJWKS(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestSigningKeyService(SigningAlgorithmRS256, now)
+
+	expired := now.Add(-time.Second)
+	addTestSigningKey(t, s, now.Add(-60*24*time.Hour), &expired)
+	rsaKey := addTestSigningKey(t, s, now.Add(-time.Hour), nil)
+	s.cfg.Algorithm = SigningAlgorithmEdDSA
+	edKey := addTestSigningKey(t, s, now.Add(time.Hour), nil)
+
+	set := s.JWKS()
+	require.Len(t, set.Keys, 2, "expired keys are not published")
+
+	rsaJWK := set.Keys[0]
+	assert.Equal(t, models.JSONWebKey{Kty: "RSA", Kid: rsaKey.id, Use: "sig", Alg: "RS256", N: rsaJWK.N, E: "AQAB"}, rsaJWK)
+	n, err := base64.RawURLEncoding.DecodeString(rsaJWK.N)
+	require.NoError(t, err)
+	assert.Equal(t, 0, new(big.Int).SetBytes(n).Cmp(rsaKey.public.(*rsa.PublicKey).N))
+
+	edJWK := set.Keys[1]
+	assert.Equal(t, "OKP", edJWK.Kty)
+	assert.Equal(t, "Ed25519", edJWK.Crv)
+	assert.Equal(t, "EdDSA", edJWK.Alg)
+	assert.Equal(t, edKey.id, edJWK.Kid)
+	x, err := base64.RawURLEncoding.DecodeString(edJWK.X)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(edKey.public.(ed25519.PublicKey)), x)
+
+	assert.Empty(t, newTestSigningKeyService(SigningAlgorithmRS256, now).JWKS().Keys)
+}
+
+func TestAuthServiceSigningKeys(t *testing.T) {
+	// Tokens are checked against the real clock
+	now := time.Now()
+	user := &models.User{ID: primitive.NewObjectID(), Email: "test@example.com", Name: "Test User"}
+
+	newAuthService := func(signingKeys *SigningKeyService) *AuthService {
+		authService := NewAuthService(nil, "test-access-secret-key-for-testing", "test-refresh-secret-key-for-testing")
+		authService.SetSigningKeys(signingKeys)
+		return authService
+	}
+
+	for _, algorithm := range []string{SigningAlgorithmRS256, SigningAlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			signingKeys := newTestSigningKeyService(algorithm, now)
+			key := addTestSigningKey(t, signingKeys, now.Add(-time.Minute), nil)
+			authService := newAuthService(signingKeys)
+
+			accessToken, refreshToken, err := authService.GenerateTokens(user, newTestSession(user))
+			require.NoError(t, err)
+
+			header := parseTestTokenHeader(t, accessToken)
+			assert.Equal(t, algorithm, header.Method.Alg())
+			assert.Equal(t, key.id, header.Header["kid"])
+			assert.Equal(t, accessTokenType, header.Header["typ"])
+
+			claims, err := authService.ValidateAccessToken(accessToken)
+			require.NoError(t, err)
+			assert.Equal(t, user.ID.Hex(), claims.UserID)
+
+			_, err = authService.ValidateRefreshToken(refreshToken)
+			require.NoError(t, err)
+
+			_, err = authService.ValidateAccessToken(refreshToken)
+			assert.Error(t, err, "refresh tokens must not be accepted as access tokens")
+			_, err = authService.ValidateRefreshToken(accessToken)
+			assert.Error(t, err, "access tokens must not be accepted as refresh tokens")
+		})
+	}
+
+	t.Run("rotation", func(t *testing.T) {
+		signingKeys := newTestSigningKeyService(SigningAlgorithmEdDSA, now)
+		addTestSigningKey(t, signingKeys, now.Add(-time.Minute), nil)
+		authService := newAuthService(signingKeys)
+
+		oldToken, _, err := authService.GenerateTokens(user, newTestSession(user))
+		require.NoError(t, err)
+
+		// The next key takes over; the previous one verifies until its tokens expire
+		expiresAt := now.Add(RefreshTokenTTL)
+		signingKeys.keys[0].expiresAt = &expiresAt
+		next := addTestSigningKey(t, signingKeys, now, nil)
+
+		newToken, _, err := authService.GenerateTokens(user, newTestSession(user))
+		require.NoError(t, err)
+		assert.Equal(t, next.id, parseTestTokenHeader(t, newToken).Header["kid"])
+
+		_, err = authService.ValidateAccessToken(oldToken)
+		assert.NoError(t, err)
+		_, err = authService.ValidateAccessToken(newToken)
+		assert.NoError(t, err)
+
+		// Once removed, tokens of the previous key are rejected
+		expiresAt = now.Add(-time.Second)
+		_, err = authService.ValidateAccessToken(oldToken)
+		assert.ErrorIs(t, err, ErrUnknownSigningKey)
+	})
+
+	t.Run("HS256 tokens issued before switching", func(t *testing.T) {
+		accessToken, _, err := newAuthService(nil).GenerateTokens(user, newTestSession(user))
+		require.NoError(t, err)
+
+		signingKeys := newTestSigningKeyService(SigningAlgorithmEdDSA, now)
+		addTestSigningKey(t, signingKeys, now.Add(-time.Minute), nil)
+
+		_, err = newAuthService(signingKeys).ValidateAccessToken(accessToken)
+		assert.Error(t, err, "HS256 tokens are rejected without a switchover window")
+
+		authService := newAuthService(signingKeys)
+		authService.SetHS256Switchover(now.Add(time.Minute))
+		_, err = authService.ValidateAccessToken(accessToken)
+		assert.NoError(t, err)
+
+		authService.SetHS256Switchover(now.Add(-time.Minute))
+		_, err = authService.ValidateAccessToken(accessToken)
+		assert.Error(t, err, "HS256 tokens issued after the switchover are rejected")
+
+		authService.SetHS256Switchover(now.Add(time.Minute))
+		authService.now = func() time.Time { return now.Add(RefreshTokenTTL + 2*time.Minute) }
+		_, err = authService.ValidateAccessToken(accessToken)
+		assert.Error(t, err, "HS256 tokens are rejected once the switchover window has passed")
+	})
+
+	t.Run("HS256 tokens outliving the switchover window", func(t *testing.T) {
+		signingKeys := newTestSigningKeyService(SigningAlgorithmEdDSA, now)
+		addTestSigningKey(t, signingKeys, now.Add(-time.Minute), nil)
+		authService := newAuthService(signingKeys)
+		authService.SetHS256Switchover(now.Add(time.Minute))
+
+		claims := &Claims{
+			UserID: user.ID.Hex(),
+			RegisteredClaims: jwt.RegisteredClaims{
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(365 * 24 * time.Hour)),
+			},
+		}
+		forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(authService.accessSecret))
+		require.NoError(t, err)
+
+		_, err = authService.ValidateAccessToken(forged)
+		assert.Error(t, err)
+	})
+
+	t.Run("asymmetric tokens without signing keys", func(t *testing.T) {
+		signingKeys := newTestSigningKeyService(SigningAlgorithmEdDSA, now)
+		addTestSigningKey(t, signingKeys, now.Add(-time.Minute), nil)
+		accessToken, _, err := newAuthService(signingKeys).GenerateTokens(user, newTestSession(user))
+		require.NoError(t, err)
+
+		_, err = newAuthService(nil).ValidateAccessToken(accessToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		signingKeys := newTestSigningKeyService(SigningAlgorithmEdDSA, now)
+		addTestSigningKey(t, signingKeys, now.Add(-time.Minute), nil)
+		accessToken, _, err := newAuthService(signingKeys).GenerateTokens(user, newTestSession(user))
+		require.NoError(t, err)
+
+		other := newTestSigningKeyService(SigningAlgorithmEdDSA, now)
+		addTestSigningKey(t, other, now.Add(-time.Minute), nil)
+		_, err = newAuthService(other).ValidateAccessToken(accessToken)
+		assert.ErrorIs(t, err, ErrUnknownSigningKey)
+	})
+
+	t.Run("no active key", func(t *testing.T) {
+		_, _, err := newAuthService(newTestSigningKeyService(SigningAlgorithmEdDSA, now)).GenerateTokens(user, newTestSession(user))
+		assert.ErrorIs(t, err, ErrNoSigningKey)
+	})
+}
+
+// parseTestTokenHeader decodes a token without verifying it
+func parseTestTokenHeader(t *testing.T, token string) *jwt.Token {
+	t.Helper()
+	require.Equal(t, 3, len(strings.Split(token, ".")))
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	require.NoError(t, err)
+	return parsed
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/database"
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/totp"
+	"github.com/tabrezdn1/dune-form-analytics/api/pkg/utils"
+)
+
+var (
+	// ErrTwoFactorAlreadyEnabled is returned when enrolling a user who already uses two-factor authentication
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnabled is returned when disabling two-factor authentication for a user without it
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrTwoFactorSetupRequired is returned when confirming an enrollment that was not started
+	ErrTwoFactorSetupRequired = errors.New("two-factor setup has not been started")
+	// ErrInvalidTwoFactorCode is returned for wrong, reused or malformed TOTP and recovery codes
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrInvalidTwoFactorToken is returned when the token of a pending two-factor login is invalid or expired
+	ErrInvalidTwoFactorToken = errors.New("two-factor login has expired, log in again")
+)
+
+const (
+	// totpSkew is the number of steps before and after the current one whose codes are
+	// accepted, to allow for clock drift between server and phone
+	totpSkew = 1
+	// recoveryCodeCount is the number of recovery codes issued on enrollment
+	recoveryCodeCount = 10
+	// recoveryCodeLength is the number of base32 characters of a recovery code (50 bits)
+	recoveryCodeLength = 10
+)
+
+// recoveryCodeAlphabet is lowercase base32, which avoids characters that are easily confused
+const recoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+// twoFactorChallengeClaims is the signed payload of a pending two-factor login
+type twoFactorChallengeClaims struct {
+	UserID   string `json:"u"`
+	IssuedAt int64  `json:"iat"`
+	Nonce    string `json:"n"`
+}
+
+// TwoFactorConfig configures TOTP two-factor authentication
+type TwoFactorConfig struct {
+	Secret   string        // Encrypts TOTP secrets and signs pending logins
+	Issuer   string        // Shown by authenticator apps next to the account
+	LoginTTL time.Duration // Time allowed between the password and the code
+}
+
+// TwoFactorService manages TOTP (RFC 6238) two-factor authentication: enrollment,
+// code and recovery code verification, and the tokens of logins awaiting a code
+type TwoFactorService struct {
+	collections *database.Collections
+	cfg         TwoFactorConfig
+	now         func() time.Time
+}
+
+// NewTwoFactorService creates a new two-factor authentication service
+func NewTwoFactorService(collections *database.Collections, cfg TwoFactorConfig) *TwoFactorService {
+	return &TwoFactorService{
+		collections: collections,
+		cfg:         cfg,
+		now:         time.Now,
+	}
+}
+
+// Setup starts enrollment by generating a new secret. Two-factor authentication
+// is only enabled once Enable receives a code generated from it.
+func (s *TwoFactorService) Setup(ctx context.Context, userID string) (*models.TwoFactorSetup, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := utils.Encrypt(secret, s.cfg.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+
+	result, err := s.collections.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "two_factor.enabled": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"two_factor": &models.TwoFactor{PendingSecret: encrypted}}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start two-factor setup: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	return &models.TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, s.cfg.Issuer, user.Email),
+	}, nil
+}
+
+// Enable confirms enrollment with a code from the authenticator app and returns
+// the recovery codes, which are only shown this once
+func (s *TwoFactorService) Enable(ctx context.Context, userID, code string) (*models.TwoFactorRecoveryCodes, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TwoFactor == nil || user.TwoFactor.PendingSecret == "" {
+		return nil, ErrTwoFactorSetupRequired
+	}
+
+	secret, err := utils.Decrypt(user.TwoFactor.PendingSecret, s.cfg.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	now := s.now()
+	step, ok := totp.Validate(secret, normalizeTwoFactorCode(code), now, totpSkew)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, digests, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	// The pending secret in the filter makes a concurrent restart of the setup win
+	result, err := s.collections.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "two_factor.pending_secret": user.TwoFactor.PendingSecret},
+		bson.M{"$set": bson.M{
+			"two_factor": &models.TwoFactor{
+				Enabled:       true,
+				Secret:        user.TwoFactor.PendingSecret,
+				RecoveryCodes: digests,
+				LastUsedStep:  step,
+				EnabledAt:     &now,
+			},
+			"updated_at": now,
+		}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrTwoFactorSetupRequired
+	}
+
+	slog.InfoContext(ctx, "Two-factor authentication enabled", "user_id", userID)
+	return &models.TwoFactorRecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// Disable turns two-factor authentication off. The user must confirm with their
+// password and a current code or recovery code.
+func (s *TwoFactorService) Disable(ctx context.Context, userID, password, code string) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	if err := s.VerifyCode(ctx, user, code); err != nil {
+		return err
+	}
+
+	_, err = s.collections.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{
+			"$unset": bson.M{"two_factor": ""},
+			"$set":   bson.M{"updated_at": s.now()},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Two-factor authentication disabled", "user_id", userID)
+	return nil
+}
+
+// VerifyCode checks a TOTP code or uses up a recovery code of a user with two-factor
+// authentication enabled. Each TOTP code is accepted once.
+func (s *TwoFactorService) VerifyCode(ctx context.Context, user *models.User, code string) error {
+	if !user.TwoFactorEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+
+	code = normalizeTwoFactorCode(code)
+	if len(code) == totp.Digits {
+		return s.verifyTOTP(ctx, user, code)
+	}
+	return s.useRecoveryCode(ctx, user, code)
+}
+
+// IssueChallenge returns the token of a login that passed the password check and
+// now needs a code
+func (s *TwoFactorService) IssueChallenge(user *models.User) (string, error) {
+	nonce, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(twoFactorChallengeClaims{
+		UserID:   user.ID.Hex(),
+		IssuedAt: s.now().Unix(),
+		Nonce:    nonce,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode two-factor token: %w", err)
+	}
+
+	return utils.SignValue(base64.RawURLEncoding.EncodeToString(payload), s.cfg.Secret), nil
+}
+
+// ChallengeUser returns the user of a pending login token
+func (s *TwoFactorService) ChallengeUser(ctx context.Context, token string) (*models.User, error) {
+	claims, err := s.parseChallenge(token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.findUser(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidTwoFactorToken
+		}
+		return nil, err
+	}
+	if !user.TwoFactorEnabled() {
+		return nil, ErrInvalidTwoFactorToken
+	}
+	return user, nil
+}
+
+// parseChallenge verifies a pending login token's signature and age
+func (s *TwoFactorService) parseChallenge(token string) (*twoFactorChallengeClaims, error) {
+	encoded, ok := utils.VerifySignedValue(token, s.cfg.Secret)
+	if !ok {
+		return nil, ErrInvalidTwoFactorToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidTwoFactorToken
+	}
+
+	var claims twoFactorChallengeClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Nonce == "" {
+		return nil, ErrInvalidTwoFactorToken
+	}
+	if !s.now().Before(time.Unix(claims.IssuedAt, 0).Add(s.cfg.LoginTTL)) {
+		return nil, ErrInvalidTwoFactorToken
+	}
+
+	return &claims, nil
+}
+
+// verifyTOTP checks a TOTP code and records its time step, so that the code and
+// older ones cannot be replayed
+func (s *TwoFactorService) verifyTOTP(ctx context.Context, user *models.User, code string) error {
+	secret, err := utils.Decrypt(user.TwoFactor.Secret, s.cfg.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+
+	step, ok := totp.Validate(secret, code, s.now(), totpSkew)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	result, err := s.collections.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "two_factor.enabled": true, "two_factor.last_used_step": bson.M{"$lt": step}},
+		bson.M{"$set": bson.M{"two_factor.last_used_step": step}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record two-factor code: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// useRecoveryCode removes a recovery code from the user's unused codes
+func (s *TwoFactorService) useRecoveryCode(ctx context.Context, user *models.User, code string) error {
+	if len(code) != recoveryCodeLength {
+		return ErrInvalidTwoFactorCode
+	}
+
+	digest := recoveryCodeDigest(code)
+	result, err := s.collections.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "two_factor.enabled": true, "two_factor.recovery_codes": digest},
+		bson.M{"$pull": bson.M{"two_factor.recovery_codes": digest}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if result.ModifiedCount == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+
+	slog.WarnContext(ctx, "Recovery code used", "user_id", user.ID.Hex(), "remaining", len(user.TwoFactor.RecoveryCodes)-1)
+	return nil
+}
+
+// findUser returns a user by ID
+func (s *TwoFactorService) findUser(ctx context.Context, userID string) (*models.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	var user models.User
+	if err := s.collections.Users.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &user, nil
+}
+
+// generateRecoveryCodes returns new recovery codes formatted as "xxxxx-xxxxx" and their digests
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	digests := make([]string, recoveryCodeCount)
+
+	b := make([]byte, recoveryCodeLength)
+	for i := range codes {
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		for j := range b {
+			b[j] = recoveryCodeAlphabet[b[j]%byte(len(recoveryCodeAlphabet))]
+		}
+		code := string(b)
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		digests[i] = recoveryCodeDigest(code)
+	}
+	return codes, digests, nil
+}
+
+// recoveryCodeDigest returns the stored form of a normalized recovery code
+func recoveryCodeDigest(code string) string {
+	return utils.HashSHA256("recovery_code|" + code)
+}
+
+// normalizeTwoFactorCode removes the separators people type or copy with codes
+func normalizeTwoFactorCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/models"
+)
+
+// newTestTwoFactorService creates a two-factor service with a fixed clock
+func newTestTwoFactorService(now time.Time) *TwoFactorService {
+	s := NewTwoFactorService(nil, TwoFactorConfig{
+		Secret:   "test-two-factor-secret-at-least-32-chars",
+		Issuer:   "Dune Forms",
+		LoginTTL: 5 * time.Minute,
+	})
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestTwoFactorChallenge(t *testing.T) {
+	issuedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	user := &models.User{ID: primitive.NewObjectID()}
+
+	s := newTestTwoFactorService(issuedAt)
+	token, err := s.IssueChallenge(user)
+	require.NoError(t, err)
+
+	t.Run("valid", func(t *testing.T) {
+		claims, err := newTestTwoFactorService(issuedAt.Add(4 * time.Minute)).parseChallenge(token)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID.Hex(), claims.UserID)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		_, err := newTestTwoFactorService(issuedAt.Add(5 * time.Minute)).parseChallenge(token)
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorToken)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		_, err := s.parseChallenge("x" + token)
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorToken)
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		other := newTestTwoFactorService(issuedAt)
+		other.cfg.Secret = "another-two-factor-secret-of-32-chars"
+		_, err := other.parseChallenge(token)
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorToken)
+	})
+
+	t.Run("unique", func(t *testing.T) {
+		again, err := s.IssueChallenge(user)
+		require.NoError(t, err)
+		assert.NotEqual(t, token, again)
+	})
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, digests, err := generateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Len(t, digests, recoveryCodeCount)
+
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, code)
+		assert.False(t, seen[code], "duplicate recovery code %s", code)
+		seen[code] = true
+
+		// Users may type codes without the dash or in upper case
+		assert.Equal(t, digests[i], recoveryCodeDigest(normalizeTwoFactorCode(code)))
+		assert.Equal(t, digests[i], recoveryCodeDigest(normalizeTwoFactorCode(strings.ToUpper(strings.ReplaceAll(code, "-", "")))))
+	}
+}
+
+func TestNormalizeTwoFactorCode(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected string
+	}{
+		{"123456", "123456"},
+		{" 123 456 ", "123456"},
+		{"ABCDE-FGHIJ", "abcdefghij"},
+		{"abcde fghij", "abcdefghij"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			assert.Equal(t, tt.expected, normalizeTwoFactorCode(tt.code))
+		})
+	}
+}
+
+func TestVerifyCodeRequiresTwoFactor(t *testing.T) {
+	s := newTestTwoFactorService(time.Now())
+
+	err := s.VerifyCode(context.Background(), &models.User{ID: primitive.NewObjectID()}, "123456")
+	assert.ErrorIs(t, err, ErrTwoFactorNotEnabled)
+
+	err = s.VerifyCode(context.Background(), &models.User{ID: primitive.NewObjectID(), TwoFactor: &models.TwoFactor{PendingSecret: "pending"}}, "123456")
+	assert.ErrorIs(t, err, ErrTwoFactorNotEnabled)
+}
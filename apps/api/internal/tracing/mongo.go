@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// commandKey identifies a running command
+type commandKey struct {
+	connectionID string
+	requestID    int64
+}
+
+// CommandMonitor returns a MongoDB command monitor recording a client span per
+// command. Only commands issued within a trace are recorded, which leaves out
+// background work such as the backplane's tailing cursor.
+func CommandMonitor() *event.CommandMonitor {
+	var spans sync.Map
+
+	finish := func(connectionID string, requestID int64, err error) {
+		value, ok := spans.LoadAndDelete(commandKey{connectionID, requestID})
+		if !ok {
+			return
+		}
+		span := value.(trace.Span)
+		if err != nil {
+			RecordError(span, err)
+		}
+		span.End()
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			if !trace.SpanContextFromContext(ctx).IsValid() {
+				return
+			}
+
+			name := evt.CommandName
+			attrs := []attribute.KeyValue{
+				attribute.String("db.system", "mongodb"),
+				attribute.String("db.namespace", evt.DatabaseName),
+				attribute.String("db.operation.name", evt.CommandName),
+			}
+			if collection := commandCollection(evt.Command, evt.CommandName); collection != "" {
+				name += " " + collection
+				attrs = append(attrs, attribute.String("db.collection.name", collection))
+			}
+
+			_, span := Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+			if !span.IsRecording() {
+				return
+			}
+			spans.Store(commandKey{evt.ConnectionID, evt.RequestID}, span)
+		},
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			finish(evt.ConnectionID, evt.RequestID, nil)
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			finish(evt.ConnectionID, evt.RequestID, errors.New(evt.Failure))
+		},
+	}
+}
+
+// commandCollection returns the collection a command operates on. Commands such as
+// find and insert name it as the value of their first element. The command itself
+// is not recorded because it can hold respondents' answers.
+func commandCollection(command bson.Raw, commandName string) string {
+	value, err := command.LookupErr(commandName)
+	if err != nil {
+		return ""
+	}
+	collection, ok := value.StringValueOK()
+	if !ok {
+		return ""
+	}
+	return collection
+}
@@ -0,0 +1,173 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tabrezdn1/dune-form-analytics/api/internal/config"
+)
+
+// recordSpans installs a tracer provider recording every span for the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+// attributeValue returns the value of the span attribute key
+func attributeValue(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestEnd(t *testing.T) {
+	recorder := recordSpans(t)
+
+	traced := func(fail bool) (err error) {
+		_, span := Start(context.Background(), "FormService.Test")
+		defer End(span, &err)
+		if fail {
+			return errors.New("form not found")
+		}
+		return nil
+	}
+
+	require.NoError(t, traced(false))
+	require.Error(t, traced(true))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "form not found", spans[1].Status().Description)
+	require.Len(t, spans[1].Events(), 1)
+	assert.Equal(t, "exception", spans[1].Events()[0].Name)
+}
+
+func TestDetach(t *testing.T) {
+	recordSpans(t)
+
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, span := Start(parent, "HTTP request")
+	defer span.End()
+	cancel()
+
+	detached := Detach(ctx)
+	assert.NoError(t, detached.Err())
+	assert.Equal(t, span.SpanContext(), trace.SpanContextFromContext(detached))
+}
+
+func TestCommandMonitor(t *testing.T) {
+	recorder := recordSpans(t)
+	monitor := CommandMonitor()
+
+	ctx, parent := Start(context.Background(), "FormService.GetFormByID")
+
+	t.Run("Commands within a trace get a child span", func(t *testing.T) {
+		command, err := bson.Marshal(bson.D{{Key: "find", Value: "forms"}, {Key: "filter", Value: bson.M{"_id": 1}}})
+		require.NoError(t, err)
+
+		monitor.Started(ctx, &event.CommandStartedEvent{
+			Command:      command,
+			DatabaseName: "dune_forms",
+			CommandName:  "find",
+			RequestID:    1,
+			ConnectionID: "conn-1",
+		})
+		monitor.Succeeded(ctx, &event.CommandSucceededEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 1, ConnectionID: "conn-1"},
+		})
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		span := spans[0]
+		assert.Equal(t, "find forms", span.Name())
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		assert.Equal(t, "mongodb", attributeValue(span, "db.system"))
+		assert.Equal(t, "forms", attributeValue(span, "db.collection.name"))
+		assert.Equal(t, codes.Unset, span.Status().Code)
+	})
+
+	t.Run("Failed commands mark the span as failed", func(t *testing.T) {
+		command, err := bson.Marshal(bson.D{{Key: "insert", Value: "responses"}})
+		require.NoError(t, err)
+
+		monitor.Started(ctx, &event.CommandStartedEvent{Command: command, CommandName: "insert", RequestID: 2, ConnectionID: "conn-1"})
+		monitor.Failed(ctx, &event.CommandFailedEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "insert", RequestID: 2, ConnectionID: "conn-1"},
+			Failure:              "duplicate key",
+		})
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 2)
+		assert.Equal(t, "insert responses", spans[1].Name())
+		assert.Equal(t, codes.Error, spans[1].Status().Code)
+		assert.Equal(t, "duplicate key", spans[1].Status().Description)
+	})
+
+	t.Run("Commands outside a trace are not recorded", func(t *testing.T) {
+		command, err := bson.Marshal(bson.D{{Key: "getMore", Value: int64(42)}, {Key: "collection", Value: "realtime_events"}})
+		require.NoError(t, err)
+
+		monitor.Started(context.Background(), &event.CommandStartedEvent{Command: command, CommandName: "getMore", RequestID: 3, ConnectionID: "conn-2"})
+		monitor.Succeeded(context.Background(), &event.CommandSucceededEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "getMore", RequestID: 3, ConnectionID: "conn-2"},
+		})
+
+		assert.Len(t, recorder.Ended(), 2)
+	})
+
+	parent.End()
+}
+
+func TestSetup(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	t.Run("Disabled tracing only installs the propagator", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), config.TracingConfig{Enabled: false}, "test")
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+		assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+	})
+
+	t.Run("Enabled tracing installs a recording provider", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), config.TracingConfig{
+			Enabled:     true,
+			Exporter:    "stdout",
+			SampleRatio: 1,
+			ServiceName: "dune-api-test",
+		}, "test")
+		require.NoError(t, err)
+
+		_, span := Start(context.Background(), "test")
+		assert.True(t, span.IsRecording())
+		span.End()
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+	t.Run("Unknown exporters are rejected", func(t *testing.T) {
+		_, err := Setup(context.Background(), config.TracingConfig{Enabled: true, Exporter: "zipkin"}, "test")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,80 @@
+package version
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// Version is the release version. Release builds set it with
+// -ldflags "-X github.com/tabrezdn1/dune-form-analytics/api/internal/version.Version=v1.2.3";
+// other builds fall back to the module version and VCS revision from the build info.
+var Version = ""
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	BuildTime string `json:"buildTime,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+var (
+	info     Info
+	infoOnce sync.Once
+)
+
+// Get returns information about the running build
+func Get() Info {
+	infoOnce.Do(func() {
+		info = read(Version)
+	})
+	return info
+}
+
+// String returns the version of the running build
+func String() string {
+	return Get().Version
+}
+
+// read assembles the build information, preferring the version set at link time
+func read(linked string) Info {
+	result := Info{Version: linked}
+
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		if result.Version == "" {
+			result.Version = "unknown"
+		}
+		return result
+	}
+
+	result.GoVersion = buildInfo.GoVersion
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			result.Revision = setting.Value
+		case "vcs.time":
+			result.BuildTime = setting.Value
+		case "vcs.modified":
+			result.Modified = setting.Value == "true"
+		}
+	}
+
+	if result.Version == "" {
+		result.Version = fallbackVersion(buildInfo.Main.Version, result.Revision)
+	}
+	return result
+}
+
+// fallbackVersion derives a version from the module version or, for local builds
+// reported as "(devel)", from the VCS revision
+func fallbackVersion(moduleVersion, revision string) string {
+	if moduleVersion != "" && moduleVersion != "(devel)" {
+		return moduleVersion
+	}
+	if len(revision) >= 12 {
+		return "dev-" + revision[:12]
+	}
+	return "dev"
+}
@@ -0,0 +1,41 @@
+package version
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+	t.Run("Link-time version takes precedence", func(t *testing.T) {
+		info := read("v1.4.0")
+		assert.Equal(t, "v1.4.0", info.Version)
+		assert.Equal(t, runtime.Version(), info.GoVersion)
+	})
+
+	t.Run("Builds without a link-time version get a fallback", func(t *testing.T) {
+		info := read("")
+		assert.NotEmpty(t, info.Version)
+	})
+}
+
+func TestFallbackVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		moduleVersion string
+		revision      string
+		expected      string
+	}{
+		{"Module version", "v1.2.3", "0123456789abcdef", "v1.2.3"},
+		{"Local build with revision", "(devel)", "0123456789abcdef", "dev-0123456789ab"},
+		{"Local build without revision", "(devel)", "", "dev"},
+		{"Empty module version", "", "", "dev"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, fallbackVersion(tt.moduleVersion, tt.revision))
+		})
+	}
+}
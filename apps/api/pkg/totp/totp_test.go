@@ -0,0 +1,131 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcKey is the SHA-1 test key of RFC 4226 and RFC 6238
+var rfcKey = []byte("12345678901234567890")
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226, Appendix D
+	expected := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+	for counter, code := range expected {
+		assert.Equal(t, code, hotp(rfcKey, uint64(counter), 6), "counter %d", counter)
+	}
+}
+
+func TestTOTP_RFC6238Vectors(t *testing.T) {
+	// RFC 6238, Appendix B (SHA-1, 8 digits)
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		step := Step(time.Unix(tt.unix, 0))
+		assert.Equal(t, tt.code, hotp(rfcKey, uint64(step), 8), "time %d", tt.unix)
+	}
+}
+
+func TestCode(t *testing.T) {
+	secret := encoding.EncodeToString(rfcKey)
+
+	code, err := Code(secret, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code, "6-digit code of step 1")
+
+	t.Run("Secrets are read as typed", func(t *testing.T) {
+		code, err := Code("gezd gnbv gy3t qojq gezd gnbv gy3t qojq", time.Unix(59, 0))
+		require.NoError(t, err)
+		assert.Equal(t, "287082", code)
+	})
+
+	t.Run("Invalid secret", func(t *testing.T) {
+		_, err := Code("not base32!", time.Unix(59, 0))
+		assert.ErrorIs(t, err, ErrInvalidSecret)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	secret := encoding.EncodeToString(rfcKey)
+	now := time.Unix(105, 0) // Step 3; steps 1 to 3 have the codes 287082, 359152 and 969429
+
+	t.Run("Current code", func(t *testing.T) {
+		step, ok := Validate(secret, "969429", now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, int64(3), step)
+	})
+
+	t.Run("Code of the previous step within skew", func(t *testing.T) {
+		step, ok := Validate(secret, "359152", now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, int64(2), step)
+	})
+
+	t.Run("Code outside skew", func(t *testing.T) {
+		_, ok := Validate(secret, "287082", now, 1)
+		assert.False(t, ok)
+
+		step, ok := Validate(secret, "287082", now, 2)
+		assert.True(t, ok)
+		assert.Equal(t, int64(1), step)
+	})
+
+	t.Run("Without skew", func(t *testing.T) {
+		_, ok := Validate(secret, "359152", now, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("Malformed codes", func(t *testing.T) {
+		for _, code := range []string{"", "96942", "9694290", "abcdef"} {
+			_, ok := Validate(secret, code, now, 1)
+			assert.False(t, ok, code)
+		}
+	})
+
+	t.Run("Invalid secret", func(t *testing.T) {
+		_, ok := Validate("not base32!", "969429", now, 1)
+		assert.False(t, ok)
+	})
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32, "20 bytes encode to 32 base32 characters")
+	assert.Regexp(t, "^[A-Z2-7]+$", secret)
+
+	other, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("JBSWY3DPEHPK3PXP", "Dune Forms", "ada@example.com")
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", parsed.Scheme)
+	assert.Equal(t, "totp", parsed.Host)
+	assert.Equal(t, "/Dune Forms:ada@example.com", parsed.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", parsed.Query().Get("secret"))
+	assert.Equal(t, "Dune Forms", parsed.Query().Get("issuer"))
+	assert.Equal(t, "6", parsed.Query().Get("digits"))
+	assert.Equal(t, "30", parsed.Query().Get("period"))
+}
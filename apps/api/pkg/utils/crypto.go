@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDecryptionFailed is returned for ciphertexts that were tampered with or
+// encrypted with another secret
+var ErrDecryptionFailed = errors.New("decryption failed")
+
+// GenerateSecureToken generates a URL-safe random token with the given number of random bytes
+func GenerateSecureToken(numBytes int) (string, error) {
+	b := make([]byte, numBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secure token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashSHA256 returns the hex-encoded SHA-256 digest of a string
+func HashSHA256(input string) string {
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:])
+}
+
+// SignValue appends an HMAC-SHA256 signature to a value ("value.signature")
+func SignValue(value, secret string) string {
+	return value + "." + computeSignature(value, secret)
+}
+
+// VerifySignedValue checks a value produced by SignValue and returns the original value
+func VerifySignedValue(signed, secret string) (string, bool) {
+	idx := strings.LastIndex(signed, ".")
+	if idx <= 0 || idx == len(signed)-1 {
+		return "", false
+	}
+
+	value, signature := signed[:idx], signed[idx+1:]
+	expected := computeSignature(value, secret)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "", false
+	}
+
+	return value, true
+}
+
+// computeSignature returns the base64url-encoded HMAC-SHA256 of value
+func computeSignature(value, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Encrypt encrypts a value with AES-256-GCM under a key derived from secret and
+// returns it base64url-encoded, nonce first
+func Encrypt(plaintext, secret string) (string, error) {
+	gcm, err := newGCM(secret)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt
+func Decrypt(ciphertext, secret string) (string, error) {
+	gcm, err := newGCM(secret)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", ErrDecryptionFailed
+	}
+
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrDecryptionFailed
+	}
+	return string(plaintext), nil
+}
+
+// newGCM returns an AES-256-GCM cipher keyed with the SHA-256 digest of secret
+func newGCM(secret string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
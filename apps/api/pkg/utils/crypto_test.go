@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSecureToken(t *testing.T) {
+	t.Run("Generates URL-safe tokens", func(t *testing.T) {
+		token, err := GenerateSecureToken(32)
+		require.NoError(t, err)
+
+		assert.Len(t, token, 43, "32 bytes should encode to 43 base64url characters")
+		assert.Regexp(t, "^[A-Za-z0-9_-]+$", token)
+	})
+
+	t.Run("Generates unique tokens", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			token, err := GenerateSecureToken(16)
+			require.NoError(t, err)
+			assert.False(t, seen[token], "Token should be unique")
+			seen[token] = true
+		}
+	})
+}
+
+func TestHashSHA256(t *testing.T) {
+	assert.Equal(t,
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		HashSHA256(""),
+	)
+	assert.Equal(t, HashSHA256("192.168.1.1|Mozilla"), HashSHA256("192.168.1.1|Mozilla"))
+	assert.NotEqual(t, HashSHA256("192.168.1.1|Mozilla"), HashSHA256("192.168.1.2|Mozilla"))
+}
+
+func TestSignValue(t *testing.T) {
+	secret := "test_signing_secret_32_characters_min"
+
+	tests := []struct {
+		name   string
+		value  string
+		mutate func(string) string
+		valid  bool
+	}{
+		{
+			name:   "Valid signature",
+			value:  "respondent-123",
+			mutate: func(s string) string { return s },
+			valid:  true,
+		},
+		{
+			name:   "Value containing dots",
+			value:  "a.b.c",
+			mutate: func(s string) string { return s },
+			valid:  true,
+		},
+		{
+			name:   "Tampered value",
+			value:  "respondent-123",
+			mutate: func(s string) string { return "respondent-124" + s[len("respondent-123"):] },
+			valid:  false,
+		},
+		{
+			name:   "Tampered signature",
+			value:  "respondent-123",
+			mutate: func(s string) string { return s[:len(s)-1] + "x" },
+			valid:  false,
+		},
+		{
+			name:   "Missing signature",
+			value:  "respondent-123",
+			mutate: func(s string) string { return "respondent-123" },
+			valid:  false,
+		},
+		{
+			name:   "Empty signature",
+			value:  "respondent-123",
+			mutate: func(s string) string { return "respondent-123." },
+			valid:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed := tt.mutate(SignValue(tt.value, secret))
+
+			value, ok := VerifySignedValue(signed, secret)
+			assert.Equal(t, tt.valid, ok)
+			if tt.valid {
+				assert.Equal(t, tt.value, value)
+			} else {
+				assert.Empty(t, value)
+			}
+		})
+	}
+
+	t.Run("Different secret fails verification", func(t *testing.T) {
+		signed := SignValue("respondent-123", secret)
+		_, ok := VerifySignedValue(signed, "another_signing_secret_32_characters")
+		assert.False(t, ok)
+	})
+}
+
+func TestEncrypt(t *testing.T) {
+	secret := "test_encryption_secret_32_characters"
+
+	t.Run("Round trip", func(t *testing.T) {
+		ciphertext, err := Encrypt("JBSWY3DPEHPK3PXP", secret)
+		require.NoError(t, err)
+		assert.NotContains(t, ciphertext, "JBSWY3DPEHPK3PXP")
+
+		plaintext, err := Decrypt(ciphertext, secret)
+		require.NoError(t, err)
+		assert.Equal(t, "JBSWY3DPEHPK3PXP", plaintext)
+	})
+
+	t.Run("Nonces are random", func(t *testing.T) {
+		first, err := Encrypt("value", secret)
+		require.NoError(t, err)
+		second, err := Encrypt("value", secret)
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("Different secret fails decryption", func(t *testing.T) {
+		ciphertext, err := Encrypt("value", secret)
+		require.NoError(t, err)
+
+		_, err = Decrypt(ciphertext, "another_encryption_secret_32_chars")
+		assert.ErrorIs(t, err, ErrDecryptionFailed)
+	})
+
+	t.Run("Tampered ciphertext fails decryption", func(t *testing.T) {
+		ciphertext, err := Encrypt("value", secret)
+		require.NoError(t, err)
+
+		tampered := []byte(ciphertext)
+		tampered[len(tampered)/2] ^= 'A' ^ 'B'
+		_, err = Decrypt(string(tampered), secret)
+		assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+		_, err = Decrypt("short", secret)
+		assert.ErrorIs(t, err, ErrDecryptionFailed)
+	})
+}
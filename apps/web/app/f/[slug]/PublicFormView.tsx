@@ -1,10 +1,13 @@
 'use client';
 
-import React, { useState } from 'react';
-import { PublicForm } from '@/lib/types';
+import React, { useRef, useState } from 'react';
+import { useRouter } from 'next/navigation';
+import { ChallengeSolution, PublicForm } from '@/lib/types';
 import { FormRenderer } from '@/components/forms/FormRenderer';
 import { ThemeToggle } from '@/components/ui/ThemeToggle';
 import { api } from '@/lib/api';
+import { useFormPresence } from '@/lib/presence';
+import { useChallengeSolution } from '@/lib/challenge';
 import toast from 'react-hot-toast';
 
 interface PublicFormViewProps {
@@ -14,6 +17,18 @@ interface PublicFormViewProps {
 export function PublicFormView({ form }: PublicFormViewProps) {
   const [isSubmitted, setIsSubmitted] = useState(false);
   const [isSubmitting, setIsSubmitting] = useState(false);
+  const { markFilling, markLeft } = useFormPresence(form.id);
+  const router = useRouter();
+  const { challenge } = form;
+  const { getNonce } = useChallengeSolution(challenge);
+  const honeypotRef = useRef<HTMLInputElement>(null);
+
+  // A challenge is single use, so fetch a fresh one after it was spent
+  const renewChallenge = () => {
+    if (challenge) {
+      router.refresh();
+    }
+  };
 
   const handleSubmit = async (data: {
     answers: Array<{ fieldId: string; value: any }>;
@@ -21,16 +36,33 @@ export function PublicFormView({ form }: PublicFormViewProps) {
     setIsSubmitting(true);
 
     try {
+      const answers = [...data.answers];
+      let solution: ChallengeSolution | undefined;
+      if (challenge) {
+        // People never see the honeypot input, so only bots fill it
+        const honeypot = honeypotRef.current?.value ?? '';
+        if (honeypot !== '') {
+          answers.push({ fieldId: challenge.honeypotField, value: honeypot });
+        }
+        solution = {
+          token: challenge.token,
+          nonce: (await getNonce()) ?? undefined,
+        };
+      }
+
       const result = await api.submitResponse(form.id, {
-        answers: data.answers,
+        answers,
         meta: {
           // Add any additional metadata
           referrer: document.referrer || undefined,
         },
+        challenge: solution,
       });
 
       if (result.success) {
         setIsSubmitted(true);
+        markLeft();
+        renewChallenge();
         toast.success(
           'Thank you! Your response has been submitted successfully.'
         );
@@ -52,6 +84,7 @@ export function PublicFormView({ form }: PublicFormViewProps) {
         throw error;
       }
 
+      renewChallenge();
       toast.error('Something went wrong. Please try again.');
     } finally {
       setIsSubmitting(false);
@@ -182,7 +215,21 @@ export function PublicFormView({ form }: PublicFormViewProps) {
           </div>
 
           {/* Form container */}
-          <div className='bg-white/80 dark:bg-gray-800/80 backdrop-blur-xl rounded-xl shadow-xl border border-gray-200/50 dark:border-gray-700/50 p-6 sm:p-8'>
+          <div
+            className='bg-white/80 dark:bg-gray-800/80 backdrop-blur-xl rounded-xl shadow-xl border border-gray-200/50 dark:border-gray-700/50 p-6 sm:p-8'
+            onFocusCapture={markFilling}
+          >
+            {challenge && (
+              <input
+                ref={honeypotRef}
+                type='text'
+                name={challenge.honeypotField}
+                tabIndex={-1}
+                autoComplete='off'
+                aria-hidden='true'
+                className='absolute -left-[9999px] w-px h-px opacity-0'
+              />
+            )}
             <FormRenderer
               fields={form.fields}
               onSubmit={handleSubmit}
@@ -0,0 +1,101 @@
+'use client';
+
+import React, { useState } from 'react';
+import Link from 'next/link';
+
+import toast from 'react-hot-toast';
+
+export default function ForgotPasswordPage() {
+  const [email, setEmail] = useState('');
+  const [isLoading, setIsLoading] = useState(false);
+  const [sent, setSent] = useState(false);
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setIsLoading(true);
+
+    try {
+      const response = await fetch(
+        `${process.env.NEXT_PUBLIC_API_URL}/api/auth/password/forgot`,
+        {
+          method: 'POST',
+          headers: {
+            'Content-Type': 'application/json',
+          },
+          body: JSON.stringify({ email }),
+        }
+      );
+
+      const data = await response.json();
+
+      if (!response.ok) {
+        throw new Error(data.error || 'Failed to request password reset');
+      }
+
+      setSent(true);
+    } catch (error) {
+      toast.error(
+        error instanceof Error ? error.message : 'Failed to request password reset'
+      );
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  return (
+    <div className='flex items-center justify-center py-12 px-4 sm:px-6 lg:px-8'>
+      <div className='max-w-md w-full space-y-8'>
+        <div>
+          <h2 className='mt-6 text-center text-3xl font-extrabold text-gray-900 dark:text-white'>
+            Reset your password
+          </h2>
+          <p className='mt-2 text-center text-sm text-gray-600 dark:text-gray-400'>
+            Remembered it?{' '}
+            <Link
+              href='/login'
+              className='font-medium text-blue-600 hover:text-blue-500 dark:text-blue-400'
+            >
+              Sign in
+            </Link>
+          </p>
+        </div>
+
+        {sent ? (
+          <p className='text-center text-sm text-gray-700 dark:text-gray-300'>
+            If an account exists for {email}, we sent it a link to choose a new
+            password. The link expires in one hour.
+          </p>
+        ) : (
+          <form className='mt-8 space-y-6' onSubmit={handleSubmit}>
+            <div>
+              <label htmlFor='email' className='sr-only'>
+                Email address
+              </label>
+              <input
+                id='email'
+                name='email'
+                type='email'
+                autoComplete='email'
+                required
+                value={email}
+                onChange={e => setEmail(e.target.value)}
+                className='appearance-none relative block w-full px-3 py-2 border border-gray-300 dark:border-gray-600 placeholder-gray-500 dark:placeholder-gray-400 text-gray-900 dark:text-white rounded-md focus:outline-none focus:ring-blue-500 focus:border-blue-500 focus:z-10 sm:text-sm bg-white dark:bg-gray-800'
+                placeholder='Email address'
+              />
+            </div>
+
+            <div>
+              <button
+                type='submit'
+                disabled={isLoading}
+                className='group relative w-full flex justify-center py-2 px-4 border border-transparent text-sm font-medium rounded-md text-white bg-blue-600 hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500 disabled:opacity-50 disabled:cursor-not-allowed'
+              >
+                {isLoading ? 'Sending...' : 'Send reset link'}
+              </button>
+            </div>
+          </form>
+        )}
+      </div>
+    </div>
+  );
+}
@@ -2,8 +2,17 @@
 
 import React, { useState, useEffect, useRef } from 'react';
 import Link from 'next/link';
-import { Form, Analytics, FieldAnalytics, AnalyticsUpdate } from '@/lib/types';
-import { useFormAnalyticsWebSocket } from '@/lib/websocket';
+import {
+  Form,
+  Analytics,
+  FieldAnalytics,
+  AnalyticsUpdate,
+  PresenceUpdate,
+} from '@/lib/types';
+import {
+  useFormAnalyticsStream,
+  useFormAnalyticsWebSocket,
+} from '@/lib/websocket';
 import { AnalyticsCard } from '@/components/charts/AnalyticsCard';
 import { api, formUtils } from '@/lib/api';
 import toast from 'react-hot-toast';
@@ -24,6 +33,7 @@ export function FormAnalytics({ form, initialAnalytics }: FormAnalyticsProps) {
     initialAnalytics?.totalResponses || 0
   );
   const [showExportDropdown, setShowExportDropdown] = useState(false);
+  const [presence, setPresence] = useState<PresenceUpdate | null>(null);
   const hasLoadedRef = useRef(false);
   const exportDropdownRef = useRef<HTMLDivElement>(null);
 
@@ -76,11 +86,52 @@ export function FormAnalytics({ form, initialAnalytics }: FormAnalyticsProps) {
   };
 
   // WebSocket connection for real-time updates
+  // Missed updates that could not be replayed after a reconnect are refetched
   const { connectionStatus } = useFormAnalyticsWebSocket(
     form.id,
-    handleAnalyticsUpdate
+    handleAnalyticsUpdate,
+    setPresence,
+    () => loadAnalytics()
   );
 
+  // Fall back to Server-Sent Events when WebSockets are blocked (e.g. by a proxy)
+  const [useStreamFallback, setUseStreamFallback] = useState(false);
+  useEffect(() => {
+    if (connectionStatus === 'error') {
+      setUseStreamFallback(true);
+    }
+  }, [connectionStatus]);
+  const { isConnected: isStreamConnected } = useFormAnalyticsStream(
+    form.id,
+    useStreamFallback,
+    {
+      onAnalyticsUpdate: (_formId, analytics) =>
+        handleAnalyticsUpdate(analytics),
+      onPresenceUpdate: setPresence,
+      onResync: () => loadAnalytics(),
+    }
+  );
+  const isLive = connectionStatus === 'connected' || isStreamConnected;
+
+  // Load the current audience; later changes arrive as presence:update
+  useEffect(() => {
+    api
+      .getRealTimeMetrics(form.id)
+      .then(response => {
+        if (response.data) {
+          setPresence({
+            formId: form.id,
+            active: response.data.activeUsers,
+            filling: response.data.activeFilling,
+            updatedAt: response.data.lastUpdate,
+          });
+        }
+      })
+      .catch(() => {
+        // Presence is optional; the indicator stays hidden
+      });
+  }, [form.id]);
+
   // Provide user feedback for connection status changes
   React.useEffect(() => {
     if (connectionStatus === 'error') {
@@ -405,25 +456,30 @@ export function FormAnalytics({ form, initialAnalytics }: FormAnalyticsProps) {
                 {/* Connection Status */}
                 <div
                   className={`flex items-center space-x-2 px-3 py-1.5 rounded-full ${
-                    connectionStatus === 'connected'
+                    isLive
                       ? 'bg-emerald-100 dark:bg-emerald-500/10 border border-emerald-300 dark:border-emerald-500/30'
                       : 'bg-red-100 dark:bg-red-500/10 border border-red-300 dark:border-red-500/30'
                   }`}
                 >
                   <div
                     className={`w-2 h-2 rounded-full ${
-                      connectionStatus === 'connected'
+                      isLive
                         ? 'bg-emerald-500 dark:bg-emerald-400 animate-pulse'
                         : 'bg-red-500 dark:bg-red-400'
                     }`}
                   />
                   <span className='text-gray-700 dark:text-gray-200 text-sm font-medium'>
-                    {connectionStatus === 'connected'
-                      ? 'Live Updates'
-                      : 'Offline'}
+                    {isLive ? 'Live Updates' : 'Offline'}
                   </span>
                 </div>
 
+                {/* Live Audience */}
+                {presence && form.status === 'published' && (
+                  <span className='px-3 py-1.5 rounded-full text-sm font-medium bg-blue-100 dark:bg-blue-500/10 border border-blue-300 dark:border-blue-500/30 text-blue-700 dark:text-blue-300'>
+                    {presence.active} viewing · {presence.filling} filling
+                  </span>
+                )}
+
                 {/* Form Status */}
                 <span
                   className={`px-3 py-1.5 rounded-full text-sm font-semibold ${
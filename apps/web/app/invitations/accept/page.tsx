@@ -0,0 +1,109 @@
+'use client';
+
+import React, { Suspense, useEffect, useRef, useState } from 'react';
+import Link from 'next/link';
+import { useSearchParams } from 'next/navigation';
+import { api } from '@/lib/api';
+
+type Status = 'signed-out' | 'accepting' | 'accepted' | 'failed';
+
+function AcceptInvitationStatus() {
+  const token = useSearchParams().get('token') || '';
+  const [status, setStatus] = useState<Status>(token ? 'accepting' : 'failed');
+  const [error, setError] = useState('This invitation link is incomplete.');
+  const [organization, setOrganization] = useState('');
+  const requested = useRef(false);
+
+  useEffect(() => {
+    // Strict mode runs effects twice in development; accept once
+    if (!token || requested.current) {
+      return;
+    }
+    if (!localStorage.getItem('authToken')) {
+      setStatus('signed-out');
+      return;
+    }
+    requested.current = true;
+
+    const accept = async () => {
+      try {
+        const response = await api.acceptInvitation(token);
+        setOrganization(response.data?.name || '');
+        setStatus('accepted');
+      } catch (err) {
+        setError(
+          err instanceof Error ? err.message : 'Failed to accept invitation'
+        );
+        setStatus('failed');
+      }
+    };
+
+    accept();
+  }, [token]);
+
+  if (status === 'accepting') {
+    return (
+      <p className='text-center text-sm text-gray-700 dark:text-gray-300'>
+        Accepting your invitation...
+      </p>
+    );
+  }
+
+  if (status === 'signed-out') {
+    return (
+      <p className='text-center text-sm text-gray-700 dark:text-gray-300'>
+        <Link
+          href='/login'
+          className='font-medium text-blue-600 hover:text-blue-500 dark:text-blue-400'
+        >
+          Sign in
+        </Link>{' '}
+        or{' '}
+        <Link
+          href='/signup'
+          className='font-medium text-blue-600 hover:text-blue-500 dark:text-blue-400'
+        >
+          create an account
+        </Link>{' '}
+        with the invited email address, then open the invitation link again.
+      </p>
+    );
+  }
+
+  if (status === 'accepted') {
+    return (
+      <p className='text-center text-sm text-gray-700 dark:text-gray-300'>
+        You joined {organization || 'the organization'}.{' '}
+        <Link
+          href='/dashboard'
+          className='font-medium text-blue-600 hover:text-blue-500 dark:text-blue-400'
+        >
+          Go to dashboard
+        </Link>
+      </p>
+    );
+  }
+
+  return (
+    <p className='text-center text-sm text-red-600 dark:text-red-400'>
+      {error}
+    </p>
+  );
+}
+
+export default function AcceptInvitationPage() {
+  return (
+    <div className='flex items-center justify-center py-12 px-4 sm:px-6 lg:px-8'>
+      <div className='max-w-md w-full space-y-8'>
+        <h2 className='mt-6 text-center text-3xl font-extrabold text-gray-900 dark:text-white'>
+          Join organization
+        </h2>
+
+        {/* useSearchParams needs a Suspense boundary to be prerendered */}
+        <Suspense fallback={null}>
+          <AcceptInvitationStatus />
+        </Suspense>
+      </div>
+    </div>
+  );
+}
@@ -1,12 +1,35 @@
 'use client';
 
-import React, { useState } from 'react';
+import React, { Suspense, useEffect, useState } from 'react';
 import Link from 'next/link';
-import { useRouter } from 'next/navigation';
+import { useRouter, useSearchParams } from 'next/navigation';
 import { useAppContext } from '@/lib/contexts/AppContext';
 
 import toast from 'react-hot-toast';
 
+// Messages for the reasons the single sign-on callback redirects back with
+const singleSignOnErrors: Record<string, string> = {
+  sso_cancelled: 'Single sign-on was cancelled.',
+  sso_email_not_verified:
+    'Your identity provider has not verified your email address.',
+  sso_account_conflict:
+    'This email address is linked to another single sign-on account.',
+  sso_failed: 'Single sign-on failed, please try again.',
+};
+
+function SingleSignOnError() {
+  const reason = useSearchParams().get('error');
+  if (!reason) {
+    return null;
+  }
+
+  return (
+    <p className='text-center text-sm text-red-600 dark:text-red-400'>
+      {singleSignOnErrors[reason] || singleSignOnErrors.sso_failed}
+    </p>
+  );
+}
+
 export default function LoginPage() {
   const router = useRouter();
   const { state, actions } = useAppContext();
@@ -14,12 +37,42 @@ export default function LoginPage() {
     email: '',
     password: '',
   });
+  // Set once the password is accepted and a two-factor code is needed
+  const [twoFactorToken, setTwoFactorToken] = useState<string | null>(null);
+  const [code, setCode] = useState('');
+  // Name of the single sign-on provider, when one is configured
+  const [providerName, setProviderName] = useState<string | null>(null);
+
+  useEffect(() => {
+    const loadProvider = async () => {
+      try {
+        const response = await fetch(
+          `${process.env.NEXT_PUBLIC_API_URL}/api/auth/oidc`
+        );
+        const data = await response.json();
+        if (response.ok && data.data.enabled) {
+          setProviderName(data.data.name);
+        }
+      } catch {
+        // Password sign-in keeps working without the provider
+      }
+    };
+
+    loadProvider();
+  }, []);
 
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
 
     try {
-      await actions.login(formData.email, formData.password);
+      const pendingToken = await actions.login(
+        formData.email,
+        formData.password
+      );
+      if (pendingToken) {
+        setTwoFactorToken(pendingToken);
+        return;
+      }
       toast.success('Login successful!');
       router.push('/dashboard');
     } catch (error) {
@@ -27,6 +80,21 @@ export default function LoginPage() {
     }
   };
 
+  const handleVerify = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!twoFactorToken) return;
+
+    try {
+      await actions.verifyTwoFactor(twoFactorToken, code);
+      toast.success('Login successful!');
+      router.push('/dashboard');
+    } catch (error) {
+      toast.error(
+        error instanceof Error ? error.message : 'Verification failed'
+      );
+    }
+  };
+
   const handleChange = (e: React.ChangeEvent<HTMLInputElement>) => {
     setFormData(prev => ({
       ...prev,
@@ -46,6 +114,68 @@ export default function LoginPage() {
     return null;
   }
 
+  if (twoFactorToken) {
+    return (
+      <div className='flex items-center justify-center py-12 px-4 sm:px-6 lg:px-8'>
+        <div className='max-w-md w-full space-y-8'>
+          <div>
+            <h2 className='mt-6 text-center text-3xl font-extrabold text-gray-900 dark:text-white'>
+              Two-factor authentication
+            </h2>
+            <p className='mt-2 text-center text-sm text-gray-600 dark:text-gray-400'>
+              Enter the code from your authenticator app, or one of your
+              recovery codes
+            </p>
+          </div>
+
+          <form className='mt-8 space-y-6' onSubmit={handleVerify}>
+            <div>
+              <label htmlFor='code' className='sr-only'>
+                Code
+              </label>
+              <input
+                id='code'
+                name='code'
+                type='text'
+                inputMode='text'
+                autoComplete='one-time-code'
+                autoFocus
+                required
+                value={code}
+                onChange={e => setCode(e.target.value)}
+                className='appearance-none relative block w-full px-3 py-2 border border-gray-300 dark:border-gray-600 placeholder-gray-500 dark:placeholder-gray-400 text-gray-900 dark:text-white rounded-md focus:outline-none focus:ring-blue-500 focus:border-blue-500 sm:text-sm bg-white dark:bg-gray-800'
+                placeholder='123456'
+              />
+            </div>
+
+            <div className='flex items-center justify-end'>
+              <button
+                type='button'
+                onClick={() => {
+                  setTwoFactorToken(null);
+                  setCode('');
+                }}
+                className='text-sm font-medium text-blue-600 hover:text-blue-500 dark:text-blue-400'
+              >
+                Back to sign in
+              </button>
+            </div>
+
+            <div>
+              <button
+                type='submit'
+                disabled={state.auth.isLoading}
+                className='group relative w-full flex justify-center py-2 px-4 border border-transparent text-sm font-medium rounded-md text-white bg-blue-600 hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500 disabled:opacity-50 disabled:cursor-not-allowed'
+              >
+                {state.auth.isLoading ? 'Verifying...' : 'Verify'}
+              </button>
+            </div>
+          </form>
+        </div>
+      </div>
+    );
+  }
+
   return (
     <div className='flex items-center justify-center py-12 px-4 sm:px-6 lg:px-8'>
       <div className='max-w-md w-full space-y-8'>
@@ -64,6 +194,11 @@ export default function LoginPage() {
           </p>
         </div>
 
+        {/* useSearchParams needs a Suspense boundary to be prerendered */}
+        <Suspense fallback={null}>
+          <SingleSignOnError />
+        </Suspense>
+
         <form className='mt-8 space-y-6' onSubmit={handleSubmit}>
           <div className='rounded-md shadow-sm -space-y-px'>
             <div>
@@ -100,6 +235,15 @@ export default function LoginPage() {
             </div>
           </div>
 
+          <div className='flex items-center justify-end'>
+            <Link
+              href='/forgot-password'
+              className='text-sm font-medium text-blue-600 hover:text-blue-500 dark:text-blue-400'
+            >
+              Forgot your password?
+            </Link>
+          </div>
+
           <div>
             <button
               type='submit'
@@ -110,6 +254,15 @@ export default function LoginPage() {
             </button>
           </div>
         </form>
+
+        {providerName && (
+          <a
+            href={`${process.env.NEXT_PUBLIC_API_URL}/api/auth/oidc/login`}
+            className='w-full flex justify-center py-2 px-4 border border-gray-300 dark:border-gray-600 text-sm font-medium rounded-md text-gray-700 dark:text-gray-200 bg-white dark:bg-gray-800 hover:bg-gray-50 dark:hover:bg-gray-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500'
+          >
+            Sign in with {providerName}
+          </a>
+        )}
       </div>
     </div>
   );
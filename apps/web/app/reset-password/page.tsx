@@ -0,0 +1,149 @@
+'use client';
+
+import React, { Suspense, useState } from 'react';
+import Link from 'next/link';
+import { useRouter, useSearchParams } from 'next/navigation';
+
+import toast from 'react-hot-toast';
+
+function ResetPasswordForm() {
+  const router = useRouter();
+  const token = useSearchParams().get('token') || '';
+  const [formData, setFormData] = useState({
+    password: '',
+    confirmPassword: '',
+  });
+  const [isLoading, setIsLoading] = useState(false);
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+
+    if (formData.password !== formData.confirmPassword) {
+      toast.error('Passwords do not match');
+      return;
+    }
+
+    setIsLoading(true);
+
+    try {
+      const response = await fetch(
+        `${process.env.NEXT_PUBLIC_API_URL}/api/auth/password/reset`,
+        {
+          method: 'POST',
+          headers: {
+            'Content-Type': 'application/json',
+          },
+          body: JSON.stringify({ token, password: formData.password }),
+        }
+      );
+
+      const data = await response.json();
+
+      if (!response.ok) {
+        throw new Error(data.error || 'Failed to reset password');
+      }
+
+      toast.success('Password reset, sign in with your new password');
+      router.push('/login');
+    } catch (error) {
+      toast.error(
+        error instanceof Error ? error.message : 'Failed to reset password'
+      );
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const handleChange = (e: React.ChangeEvent<HTMLInputElement>) => {
+    setFormData(prev => ({
+      ...prev,
+      [e.target.name]: e.target.value,
+    }));
+  };
+
+  if (!token) {
+    return (
+      <p className='text-center text-sm text-gray-700 dark:text-gray-300'>
+        This reset link is incomplete.{' '}
+        <Link
+          href='/forgot-password'
+          className='font-medium text-blue-600 hover:text-blue-500 dark:text-blue-400'
+        >
+          Request a new one
+        </Link>
+      </p>
+    );
+  }
+
+  return (
+    <form className='mt-8 space-y-6' onSubmit={handleSubmit}>
+      <div className='rounded-md shadow-sm -space-y-px'>
+        <div>
+          <label htmlFor='password' className='sr-only'>
+            New password
+          </label>
+          <input
+            id='password'
+            name='password'
+            type='password'
+            autoComplete='new-password'
+            required
+            minLength={6}
+            value={formData.password}
+            onChange={handleChange}
+            className='appearance-none rounded-none relative block w-full px-3 py-2 border border-gray-300 dark:border-gray-600 placeholder-gray-500 dark:placeholder-gray-400 text-gray-900 dark:text-white rounded-t-md focus:outline-none focus:ring-blue-500 focus:border-blue-500 focus:z-10 sm:text-sm bg-white dark:bg-gray-800'
+            placeholder='New password'
+          />
+        </div>
+        <div>
+          <label htmlFor='confirmPassword' className='sr-only'>
+            Confirm new password
+          </label>
+          <input
+            id='confirmPassword'
+            name='confirmPassword'
+            type='password'
+            autoComplete='new-password'
+            required
+            value={formData.confirmPassword}
+            onChange={handleChange}
+            className='appearance-none rounded-none relative block w-full px-3 py-2 border border-gray-300 dark:border-gray-600 placeholder-gray-500 dark:placeholder-gray-400 text-gray-900 dark:text-white rounded-b-md focus:outline-none focus:ring-blue-500 focus:border-blue-500 focus:z-10 sm:text-sm bg-white dark:bg-gray-800'
+            placeholder='Confirm new password'
+          />
+        </div>
+      </div>
+
+      <div>
+        <button
+          type='submit'
+          disabled={isLoading}
+          className='group relative w-full flex justify-center py-2 px-4 border border-transparent text-sm font-medium rounded-md text-white bg-blue-600 hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500 disabled:opacity-50 disabled:cursor-not-allowed'
+        >
+          {isLoading ? 'Saving...' : 'Set new password'}
+        </button>
+      </div>
+    </form>
+  );
+}
+
+export default function ResetPasswordPage() {
+  return (
+    <div className='flex items-center justify-center py-12 px-4 sm:px-6 lg:px-8'>
+      <div className='max-w-md w-full space-y-8'>
+        <div>
+          <h2 className='mt-6 text-center text-3xl font-extrabold text-gray-900 dark:text-white'>
+            Choose a new password
+          </h2>
+          <p className='mt-2 text-center text-sm text-gray-600 dark:text-gray-400'>
+            You will be signed out of all your devices.
+          </p>
+        </div>
+
+        {/* useSearchParams needs a Suspense boundary to be prerendered */}
+        <Suspense fallback={null}>
+          <ResetPasswordForm />
+        </Suspense>
+      </div>
+    </div>
+  );
+}
@@ -55,7 +55,16 @@ export default function SignupPage() {
     }
 
     try {
-      await actions.signup(formData.email, formData.password, formData.name);
+      const signedIn = await actions.signup(
+        formData.email,
+        formData.password,
+        formData.name
+      );
+      if (!signedIn) {
+        toast.success('Account created! Check your email to verify it.');
+        router.push('/login');
+        return;
+      }
       toast.success('Account created successfully!');
       router.push('/dashboard');
     } catch (error) {
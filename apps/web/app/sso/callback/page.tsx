@@ -0,0 +1,133 @@
+'use client';
+
+import React, { Suspense, useEffect, useRef, useState } from 'react';
+import Link from 'next/link';
+import { useRouter, useSearchParams } from 'next/navigation';
+import { useAppContext } from '@/lib/contexts/AppContext';
+
+import toast from 'react-hot-toast';
+
+function SingleSignOnStatus() {
+  const router = useRouter();
+  const { state, actions } = useAppContext();
+  const ticket = useSearchParams().get('ticket') || '';
+  const [error, setError] = useState(
+    ticket ? '' : 'This sign-in link is incomplete.'
+  );
+  // Set when the account requires a two-factor code to finish signing in
+  const [twoFactorToken, setTwoFactorToken] = useState<string | null>(null);
+  const [code, setCode] = useState('');
+  const requested = useRef(false);
+
+  useEffect(() => {
+    // Strict mode runs effects twice in development; tickets work once
+    if (!ticket || requested.current) {
+      return;
+    }
+    requested.current = true;
+
+    const complete = async () => {
+      try {
+        const pendingToken = await actions.completeSingleSignOn(ticket);
+        if (pendingToken) {
+          setTwoFactorToken(pendingToken);
+          return;
+        }
+        router.replace('/dashboard');
+      } catch (err) {
+        setError(err instanceof Error ? err.message : 'Single sign-on failed');
+      }
+    };
+
+    complete();
+  }, [ticket, actions, router]);
+
+  const handleVerify = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!twoFactorToken) return;
+
+    try {
+      await actions.verifyTwoFactor(twoFactorToken, code);
+      router.replace('/dashboard');
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Verification failed');
+    }
+  };
+
+  if (twoFactorToken) {
+    return (
+      <form className='space-y-6' onSubmit={handleVerify}>
+        <p className='text-center text-sm text-gray-600 dark:text-gray-400'>
+          Enter the code from your authenticator app, or one of your recovery
+          codes
+        </p>
+
+        <div>
+          <label htmlFor='code' className='sr-only'>
+            Code
+          </label>
+          <input
+            id='code'
+            name='code'
+            type='text'
+            inputMode='text'
+            autoComplete='one-time-code'
+            autoFocus
+            required
+            value={code}
+            onChange={e => setCode(e.target.value)}
+            className='appearance-none relative block w-full px-3 py-2 border border-gray-300 dark:border-gray-600 placeholder-gray-500 dark:placeholder-gray-400 text-gray-900 dark:text-white rounded-md focus:outline-none focus:ring-blue-500 focus:border-blue-500 sm:text-sm bg-white dark:bg-gray-800'
+            placeholder='123456'
+          />
+        </div>
+
+        <div>
+          <button
+            type='submit'
+            disabled={state.auth.isLoading}
+            className='group relative w-full flex justify-center py-2 px-4 border border-transparent text-sm font-medium rounded-md text-white bg-blue-600 hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500 disabled:opacity-50 disabled:cursor-not-allowed'
+          >
+            {state.auth.isLoading ? 'Verifying...' : 'Verify'}
+          </button>
+        </div>
+      </form>
+    );
+  }
+
+  if (!error) {
+    return (
+      <p className='text-center text-sm text-gray-700 dark:text-gray-300'>
+        Signing you in...
+      </p>
+    );
+  }
+
+  return (
+    <p className='text-center text-sm text-red-600 dark:text-red-400'>
+      {error}{' '}
+      <Link
+        href='/login'
+        className='font-medium text-blue-600 hover:text-blue-500 dark:text-blue-400'
+      >
+        Back to sign in
+      </Link>
+    </p>
+  );
+}
+
+export default function SingleSignOnCallbackPage() {
+  return (
+    <div className='flex items-center justify-center py-12 px-4 sm:px-6 lg:px-8'>
+      <div className='max-w-md w-full space-y-8'>
+        <h2 className='mt-6 text-center text-3xl font-extrabold text-gray-900 dark:text-white'>
+          Single sign-on
+        </h2>
+
+        {/* useSearchParams needs a Suspense boundary to be prerendered */}
+        <Suspense fallback={null}>
+          <SingleSignOnStatus />
+        </Suspense>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,97 @@
+'use client';
+
+import React, { Suspense, useEffect, useRef, useState } from 'react';
+import Link from 'next/link';
+import { useSearchParams } from 'next/navigation';
+
+type Status = 'verifying' | 'verified' | 'failed';
+
+function VerifyEmailStatus() {
+  const token = useSearchParams().get('token') || '';
+  const [status, setStatus] = useState<Status>(token ? 'verifying' : 'failed');
+  const [error, setError] = useState('This verification link is incomplete.');
+  const requested = useRef(false);
+
+  useEffect(() => {
+    // Strict mode runs effects twice in development; verify once
+    if (!token || requested.current) {
+      return;
+    }
+    requested.current = true;
+
+    const verify = async () => {
+      try {
+        const response = await fetch(
+          `${process.env.NEXT_PUBLIC_API_URL}/api/auth/email/verify`,
+          {
+            method: 'POST',
+            headers: {
+              'Content-Type': 'application/json',
+            },
+            body: JSON.stringify({ token }),
+          }
+        );
+
+        const data = await response.json();
+
+        if (!response.ok) {
+          throw new Error(data.error || 'Failed to verify email address');
+        }
+
+        setStatus('verified');
+      } catch (err) {
+        setError(
+          err instanceof Error ? err.message : 'Failed to verify email address'
+        );
+        setStatus('failed');
+      }
+    };
+
+    verify();
+  }, [token]);
+
+  if (status === 'verifying') {
+    return (
+      <p className='text-center text-sm text-gray-700 dark:text-gray-300'>
+        Verifying your email address...
+      </p>
+    );
+  }
+
+  if (status === 'verified') {
+    return (
+      <p className='text-center text-sm text-gray-700 dark:text-gray-300'>
+        Your email address is verified.{' '}
+        <Link
+          href='/login'
+          className='font-medium text-blue-600 hover:text-blue-500 dark:text-blue-400'
+        >
+          Sign in
+        </Link>
+      </p>
+    );
+  }
+
+  return (
+    <p className='text-center text-sm text-red-600 dark:text-red-400'>
+      {error}
+    </p>
+  );
+}
+
+export default function VerifyEmailPage() {
+  return (
+    <div className='flex items-center justify-center py-12 px-4 sm:px-6 lg:px-8'>
+      <div className='max-w-md w-full space-y-8'>
+        <h2 className='mt-6 text-center text-3xl font-extrabold text-gray-900 dark:text-white'>
+          Verify your email
+        </h2>
+
+        {/* useSearchParams needs a Suspense boundary to be prerendered */}
+        <Suspense fallback={null}>
+          <VerifyEmailStatus />
+        </Suspense>
+      </div>
+    </div>
+  );
+}
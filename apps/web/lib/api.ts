@@ -6,6 +6,9 @@ import {
   FormResponse,
   SubmitResponseResult,
   Analytics,
+  PresenceState,
+  ChallengeSolution,
+  Organization,
 } from './types';
 
 // Use internal Docker network URL for server-side requests, public URL for client-side
@@ -128,6 +131,14 @@ class ApiClient {
     });
   }
 
+  // Organization endpoints
+  async acceptInvitation(token: string): Promise<ApiResponse<Organization>> {
+    return this.request('/api/invitations/accept', {
+      method: 'POST',
+      body: JSON.stringify({ token }),
+    });
+  }
+
   // Public form endpoints
   async getPublicForm(slug: string): Promise<ApiResponse<PublicForm>> {
     // Force fresh data for public forms (no caching)
@@ -145,6 +156,7 @@ class ApiClient {
     data: {
       answers: Array<{ fieldId: string; value: any }>;
       meta?: any;
+      challenge?: ChallengeSolution;
     }
   ): Promise<SubmitResponseResult> {
     return this.request(`/api/forms/${formId}/submit`, {
@@ -153,6 +165,21 @@ class ApiClient {
     });
   }
 
+  // Presence heartbeat from an open public form page. keepalive lets the
+  // final "left" heartbeat outlive the page.
+  async sendPresence(
+    formId: string,
+    sessionId: string,
+    state: PresenceState,
+    keepalive = false
+  ): Promise<ApiResponse<{ heartbeatInterval: number }>> {
+    return this.request(`/api/forms/${formId}/presence`, {
+      method: 'POST',
+      body: JSON.stringify({ sessionId, state }),
+      keepalive,
+    });
+  }
+
   async getResponses(
     formId: string,
     page = 1,
@@ -238,6 +265,7 @@ class ApiClient {
   async getRealTimeMetrics(formId: string): Promise<
     ApiResponse<{
       activeUsers: number;
+      activeFilling: number;
       responsesToday: number;
       responsesThisHour: number;
       lastUpdate: string;
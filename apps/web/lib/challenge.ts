@@ -0,0 +1,75 @@
+'use client';
+
+import { useCallback, useEffect, useRef } from 'react';
+import { SubmissionChallenge } from './types';
+
+// Hashes between yields to the event loop while solving, so typing stays responsive
+const HASHES_PER_BATCH = 2000;
+
+function leadingZeroBits(bytes: Uint8Array): number {
+  let zeros = 0;
+  for (let i = 0; i < bytes.length; i++) {
+    const byte = bytes[i];
+    if (byte === 0) {
+      zeros += 8;
+      continue;
+    }
+    return zeros + Math.clz32(byte) - 24;
+  }
+  return zeros;
+}
+
+// Finds a nonce such that SHA-256(token + ":" + nonce) has at least difficulty
+// leading zero bits, matching the API's proof-of-work check
+export async function solveProofOfWork(
+  token: string,
+  difficulty: number,
+  isCancelled: () => boolean = () => false
+): Promise<string | null> {
+  const encoder = new TextEncoder();
+  for (let nonce = 0; !isCancelled(); nonce++) {
+    const digest = await crypto.subtle.digest(
+      'SHA-256',
+      encoder.encode(`${token}:${nonce}`)
+    );
+    if (leadingZeroBits(new Uint8Array(digest)) >= difficulty) {
+      return nonce.toString();
+    }
+    if (nonce % HASHES_PER_BATCH === 0) {
+      await new Promise(resolve => setTimeout(resolve, 0));
+    }
+  }
+  return null;
+}
+
+// Solves the proof-of-work of a submission challenge in the background while
+// the respondent fills the form. getNonce resolves once the nonce is found;
+// challenges without proof-of-work need none.
+export function useChallengeSolution(challenge?: SubmissionChallenge) {
+  const solutionRef = useRef<Promise<string | null> | null>(null);
+
+  useEffect(() => {
+    solutionRef.current = null;
+    if (!challenge?.powDifficulty) {
+      return;
+    }
+
+    let cancelled = false;
+    solutionRef.current = solveProofOfWork(
+      challenge.token,
+      challenge.powDifficulty,
+      () => cancelled
+    );
+
+    return () => {
+      cancelled = true;
+    };
+  }, [challenge?.token, challenge?.powDifficulty]);
+
+  const getNonce = useCallback(
+    () => solutionRef.current ?? Promise.resolve(null),
+    []
+  );
+
+  return { getNonce };
+}
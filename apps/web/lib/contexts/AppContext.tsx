@@ -181,8 +181,14 @@ interface AppContextValue {
     setAuthUser: (user: User | null) => void;
     setAuthTokens: (token: string, refreshToken: string) => void;
     setAuthLoading: (loading: boolean) => void;
-    login: (email: string, password: string) => Promise<void>;
-    signup: (email: string, password: string, name: string) => Promise<void>;
+    // Resolves to a two-factor token when the login must be completed with a code
+    login: (email: string, password: string) => Promise<string | null>;
+    verifyTwoFactor: (twoFactorToken: string, code: string) => Promise<void>;
+    // Redeems the ticket the single sign-on callback redirected with. Resolves to a
+    // two-factor token when the login must be completed with a code.
+    completeSingleSignOn: (ticket: string) => Promise<string | null>;
+    // Resolves to false when the email address must be verified before signing in
+    signup: (email: string, password: string, name: string) => Promise<boolean>;
     logout: () => void;
     refreshToken: () => Promise<void>;
     setGlobalLoading: (loading: boolean) => void;
@@ -252,6 +258,11 @@ export function AppProvider({ children }: { children: ReactNode }) {
           throw new Error(data.error || 'Login failed');
         }
 
+        // The API returns no tokens until the two-factor code is verified
+        if (data.data.twoFactorRequired) {
+          return data.data.twoFactorToken as string;
+        }
+
         // Set user and tokens
         dispatch({ type: 'SET_AUTH_USER', payload: data.data.user });
         dispatch({
@@ -264,6 +275,7 @@ export function AppProvider({ children }: { children: ReactNode }) {
 
         localStorage.setItem('authToken', data.data.accessToken);
         localStorage.setItem('refreshToken', data.data.refreshToken);
+        return null;
       } catch (error) {
         dispatch({
           type: 'SET_GLOBAL_ERROR',
@@ -275,6 +287,105 @@ export function AppProvider({ children }: { children: ReactNode }) {
       }
     }, []),
 
+    verifyTwoFactor: useCallback(
+      async (twoFactorToken: string, code: string) => {
+        dispatch({ type: 'SET_AUTH_LOADING', payload: true });
+
+        try {
+          const response = await fetch(
+            `${process.env.NEXT_PUBLIC_API_URL}/api/auth/2fa/verify`,
+            {
+              method: 'POST',
+              headers: {
+                'Content-Type': 'application/json',
+              },
+              body: JSON.stringify({ twoFactorToken, code }),
+            }
+          );
+
+          const data = await response.json();
+
+          if (!response.ok) {
+            throw new Error(data.error || 'Verification failed');
+          }
+
+          // Set user and tokens
+          dispatch({ type: 'SET_AUTH_USER', payload: data.data.user });
+          dispatch({
+            type: 'SET_AUTH_TOKENS',
+            payload: {
+              token: data.data.accessToken,
+              refreshToken: data.data.refreshToken,
+            },
+          });
+
+          localStorage.setItem('authToken', data.data.accessToken);
+          localStorage.setItem('refreshToken', data.data.refreshToken);
+        } catch (error) {
+          dispatch({
+            type: 'SET_GLOBAL_ERROR',
+            payload:
+              error instanceof Error ? error.message : 'Verification failed',
+          });
+          throw error;
+        } finally {
+          dispatch({ type: 'SET_AUTH_LOADING', payload: false });
+        }
+      },
+      []
+    ),
+
+    completeSingleSignOn: useCallback(async (ticket: string) => {
+      dispatch({ type: 'SET_AUTH_LOADING', payload: true });
+
+      try {
+        const response = await fetch(
+          `${process.env.NEXT_PUBLIC_API_URL}/api/auth/oidc/exchange`,
+          {
+            method: 'POST',
+            headers: {
+              'Content-Type': 'application/json',
+            },
+            body: JSON.stringify({ ticket }),
+          }
+        );
+
+        const data = await response.json();
+
+        if (!response.ok) {
+          throw new Error(data.error || 'Single sign-on failed');
+        }
+
+        // The API returns no tokens until the two-factor code is verified
+        if (data.data.twoFactorRequired) {
+          return data.data.twoFactorToken as string;
+        }
+
+        // Set user and tokens
+        dispatch({ type: 'SET_AUTH_USER', payload: data.data.user });
+        dispatch({
+          type: 'SET_AUTH_TOKENS',
+          payload: {
+            token: data.data.accessToken,
+            refreshToken: data.data.refreshToken,
+          },
+        });
+
+        localStorage.setItem('authToken', data.data.accessToken);
+        localStorage.setItem('refreshToken', data.data.refreshToken);
+        return null;
+      } catch (error) {
+        dispatch({
+          type: 'SET_GLOBAL_ERROR',
+          payload:
+            error instanceof Error ? error.message : 'Single sign-on failed',
+        });
+        throw error;
+      } finally {
+        dispatch({ type: 'SET_AUTH_LOADING', payload: false });
+      }
+    }, []),
+
     signup: useCallback(
       async (email: string, password: string, name: string) => {
         dispatch({ type: 'SET_AUTH_LOADING', payload: true });
@@ -297,6 +408,11 @@ export function AppProvider({ children }: { children: ReactNode }) {
             throw new Error(data.error || 'Signup failed');
           }
 
+          // The API returns no tokens when login requires a verified email
+          if (!data.data.accessToken) {
+            return false;
+          }
+
           // Set user and tokens
           dispatch({ type: 'SET_AUTH_USER', payload: data.data.user });
           dispatch({
@@ -309,6 +425,7 @@ export function AppProvider({ children }: { children: ReactNode }) {
 
           localStorage.setItem('authToken', data.data.accessToken);
           localStorage.setItem('refreshToken', data.data.refreshToken);
+          return true;
         } catch (error) {
           dispatch({
             type: 'SET_GLOBAL_ERROR',
@@ -323,6 +440,18 @@ export function AppProvider({ children }: { children: ReactNode }) {
     ),
 
     logout: useCallback(() => {
+      // Revoke the session on the server; local state is cleared either way
+      const refreshToken = localStorage.getItem('refreshToken');
+      if (refreshToken) {
+        fetch(`${process.env.NEXT_PUBLIC_API_URL}/api/auth/logout`, {
+          method: 'POST',
+          headers: {
+            'Content-Type': 'application/json',
+          },
+          body: JSON.stringify({ refreshToken }),
+        }).catch(() => {});
+      }
+
       dispatch({ type: 'LOGOUT' });
       localStorage.removeItem('authToken');
       localStorage.removeItem('refreshToken');
@@ -64,6 +64,18 @@ export function useAuth() {
   );
 
   const logout = useCallback(() => {
+    // Revoke the session on the server; local state is cleared either way
+    const refreshToken = localStorage.getItem('refreshToken');
+    if (refreshToken) {
+      fetch(`${process.env.NEXT_PUBLIC_API_URL}/api/auth/logout`, {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ refreshToken }),
+      }).catch(() => {});
+    }
+
     localStorage.removeItem('authToken');
     localStorage.removeItem('refreshToken');
   }, []);
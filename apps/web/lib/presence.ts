@@ -0,0 +1,89 @@
+'use client';
+
+import { useCallback, useEffect, useRef } from 'react';
+import { api } from './api';
+import { PresenceState } from './types';
+
+// Fallback until the server tells us its heartbeat interval
+const DEFAULT_HEARTBEAT_INTERVAL_MS = 15000;
+
+// Random per-page-load session ID; presence is not tied to the respondent
+function createSessionId(): string {
+  const bytes = new Uint8Array(16);
+  crypto.getRandomValues(bytes);
+  return Array.from(bytes, b => b.toString(16).padStart(2, '0')).join('');
+}
+
+// Keeps the API informed that a public form is open so the owner's dashboard
+// can show live viewers. Call markFilling when the respondent starts answering
+// and markLeft once the response is submitted.
+export function useFormPresence(formId: string) {
+  const sessionIdRef = useRef<string>('');
+  const stateRef = useRef<PresenceState>('viewing');
+
+  const send = useCallback(
+    (state: PresenceState, keepalive = false) => {
+      if (!sessionIdRef.current) {
+        return Promise.resolve(null);
+      }
+      return api
+        .sendPresence(formId, sessionIdRef.current, state, keepalive)
+        .catch(() => null); // Presence is best effort
+    },
+    [formId]
+  );
+
+  useEffect(() => {
+    sessionIdRef.current = createSessionId();
+    stateRef.current = 'viewing';
+
+    let timeoutId: NodeJS.Timeout | null = null;
+    let stopped = false;
+
+    const beat = async () => {
+      if (stopped || stateRef.current === 'left') {
+        return;
+      }
+      const result = await send(stateRef.current);
+      const interval =
+        (result?.data?.heartbeatInterval ?? 0) * 1000 ||
+        DEFAULT_HEARTBEAT_INTERVAL_MS;
+      if (!stopped) {
+        timeoutId = setTimeout(beat, interval);
+      }
+    };
+    beat();
+
+    const handlePageHide = () => {
+      if (stateRef.current !== 'left') {
+        send('left', true);
+      }
+    };
+    window.addEventListener('pagehide', handlePageHide);
+
+    return () => {
+      stopped = true;
+      if (timeoutId) {
+        clearTimeout(timeoutId);
+      }
+      window.removeEventListener('pagehide', handlePageHide);
+      handlePageHide();
+    };
+  }, [formId, send]);
+
+  const markFilling = useCallback(() => {
+    if (stateRef.current === 'viewing') {
+      stateRef.current = 'filling';
+      send('filling');
+    }
+  }, [send]);
+
+  const markLeft = useCallback(() => {
+    if (stateRef.current !== 'left') {
+      stateRef.current = 'left';
+      send('left');
+    }
+  }, [send]);
+
+  return { markFilling, markLeft };
+}
@@ -39,6 +39,19 @@ export interface PublicForm {
   title: string;
   description?: string;
   fields: FormField[];
+  challenge?: SubmissionChallenge;
+}
+
+// Issued with forms that have anti-abuse protection and echoed back on submit
+export interface SubmissionChallenge {
+  token: string;
+  honeypotField: string;
+  powDifficulty?: number;
+}
+
+export interface ChallengeSolution {
+  token: string;
+  nonce?: string;
 }
 
 // Response Types
@@ -99,7 +112,12 @@ export interface Analytics {
 }
 
 // WebSocket Analytics Update Type
+// Realtime analytics:update data. Snapshots carry every field; deltas only the
+// fields and counters that changed. seq increases by one per update and source.
 export interface AnalyticsUpdate {
+  seq?: number;
+  source?: string;
+  snapshot?: boolean;
   byField?: Record<string, FieldAnalytics>;
   totalResponses?: number;
   completionRate?: number;
@@ -107,9 +125,20 @@ export interface AnalyticsUpdate {
   updatedAt?: string;
 }
 
+export type PresenceState = 'viewing' | 'filling' | 'left';
+
+// Live audience of a public form, pushed as presence:update
+export interface PresenceUpdate {
+  formId: string;
+  active: number;
+  filling: number;
+  updatedAt: string;
+}
+
 // WebSocket Types
 export interface WebSocketMessage {
   type: string;
+  id?: string;
   formId?: string;
   data?: any;
 }
@@ -132,6 +161,18 @@ export type FormAction =
   | { type: 'RESET_FORM' }
   | { type: 'SET_ERRORS'; payload: Record<string, string> };
 
+// Organization Types
+export type OrganizationRole = 'owner' | 'admin' | 'editor' | 'viewer';
+
+export interface Organization {
+  id: string;
+  name: string;
+  createdBy: string;
+  createdAt: string;
+  updatedAt: string;
+  role: OrganizationRole; // Role of the signed-in user
+}
+
 // API Response Types
 export interface ApiResponse<T = any> {
   success: boolean;
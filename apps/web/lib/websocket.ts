@@ -1,26 +1,34 @@
 'use client';
 
 import { useEffect, useRef, useState } from 'react';
-import { WebSocketMessage, AnalyticsUpdate } from './types';
+import { WebSocketMessage, AnalyticsUpdate, PresenceUpdate } from './types';
 
 interface UseWebSocketOptions {
   onMessage?: (data: any) => void;
   onConnect?: () => void;
+  onOpen?: (send: (message: any) => void) => void;
   onDisconnect?: () => void;
   onError?: (error: Event) => void;
   reconnectAttempts?: number;
   reconnectInterval?: number;
+  getAuthToken?: () => string | null;
 }
 
+// Close codes used by the server for authentication and authorization failures
+const WS_CLOSE_UNAUTHORIZED = 4401;
+const WS_CLOSE_FORBIDDEN = 4403;
+
 // Custom React hook for WebSocket connections
 export function useWebSocket(url: string, options: UseWebSocketOptions = {}) {
   const {
     onMessage,
     onConnect,
+    onOpen,
     onDisconnect,
     onError,
     reconnectAttempts = 3,
     reconnectInterval = 5000,
+    getAuthToken,
   } = options;
 
   const [isConnected, setIsConnected] = useState(false);
@@ -57,6 +65,13 @@ export function useWebSocket(url: string, options: UseWebSocketOptions = {}) {
         wsRef.current = new WebSocket(url);
 
         wsRef.current.onopen = () => {
+          // Authenticate with the first message so the token stays out of the URL
+          const token = getAuthToken?.();
+          if (token) {
+            wsRef.current?.send(JSON.stringify({ type: 'auth', token }));
+          }
+          onOpen?.(message => wsRef.current?.send(JSON.stringify(message)));
+
           isConnectingRef.current = false;
           setIsConnected(true);
           setConnectionStatus('connected');
@@ -83,6 +98,8 @@ export function useWebSocket(url: string, options: UseWebSocketOptions = {}) {
           if (
             !isManualCloseRef.current &&
             event.code !== 1000 &&
+            event.code !== WS_CLOSE_UNAUTHORIZED &&
+            event.code !== WS_CLOSE_FORBIDDEN &&
             reconnectCountRef.current < reconnectAttempts
           ) {
             reconnectCountRef.current++;
@@ -173,44 +190,240 @@ export function useWebSocket(url: string, options: UseWebSocketOptions = {}) {
   };
 }
 
-// Hook for form analytics WebSocket connection
-export function useFormAnalyticsWebSocket(
-  formId: string,
-  onAnalyticsUpdate?: (analytics: AnalyticsUpdate) => void
+interface AnalyticsSubscription {
+  formIds?: string[];
+  allForms?: boolean;
+}
+
+interface AnalyticsSubscriptionHandlers {
+  onAnalyticsUpdate?: (formId: string, analytics: AnalyticsUpdate) => void;
+  onPresenceUpdate?: (presence: PresenceUpdate) => void;
+  // Called when missed updates could not be replayed; refetch over REST
+  onResync?: (formId?: string) => void;
+}
+
+// Returns a function reporting whether an analytics update skipped a sequence
+// number, meaning a delta was missed and the analytics must be refetched
+function createSequenceTracker() {
+  const lastSeq = new Map<string, number>();
+  return (formId: string, update: AnalyticsUpdate) => {
+    if (update.seq === undefined) {
+      return false;
+    }
+    const key = `${formId}:${update.source ?? ''}`;
+    const previous = lastSeq.get(key);
+    lastSeq.set(key, update.seq);
+    return (
+      !update.snapshot && previous !== undefined && update.seq !== previous + 1
+    );
+  };
+}
+
+// Hook following several forms (or all of the user's forms) over one
+// connection. Reconnects resume from the last received event, so the server
+// replays missed updates instead of the page refetching everything.
+export function useAnalyticsSubscriptions(
+  subscription: AnalyticsSubscription,
+  handlers: AnalyticsSubscriptionHandlers = {}
 ) {
-  // Use the same environment config as the rest of the app for consistency
   const wsUrl = process.env.NEXT_PUBLIC_WS_URL || 'ws://localhost:8080';
-  const url = `${wsUrl}/ws/forms/${formId}`;
+  const url = `${wsUrl}/ws`;
+  const lastEventIdRef = useRef<string | null>(null);
+  const handlersRef = useRef(handlers);
+  handlersRef.current = handlers;
+  const missedUpdateRef = useRef(createSequenceTracker());
 
-  return useWebSocket(url, {
+  const formIds = subscription.formIds ?? [];
+  const allForms = subscription.allForms ?? false;
+  const subscriptionRef = useRef({ formIds, allForms });
+  const { sendMessage, ...connection } = useWebSocket(url, {
+    onOpen: send => {
+      send({
+        type: 'subscribe',
+        formIds: subscriptionRef.current.formIds,
+        allForms: subscriptionRef.current.allForms,
+        ...(lastEventIdRef.current && {
+          lastEventId: lastEventIdRef.current,
+        }),
+      });
+    },
     onMessage: (data: WebSocketMessage) => {
-      // eslint-disable-next-line no-console
-      console.log(
-        `🔗 WebSocket message received for form ${formId}:`,
-        data.type
-      );
-      if (data.type === 'analytics:update') {
-        onAnalyticsUpdate?.(data.data);
-      } else if (data.type === 'connected') {
+      if (data.id) {
+        lastEventIdRef.current = data.id;
+      }
+
+      if (data.type === 'analytics:update' && data.formId) {
+        handlersRef.current.onAnalyticsUpdate?.(data.formId, data.data);
+        if (missedUpdateRef.current(data.formId, data.data)) {
+          handlersRef.current.onResync?.(data.formId);
+        }
+      } else if (data.type === 'presence:update') {
+        handlersRef.current.onPresenceUpdate?.(data.data);
+      } else if (data.type === 'replay:gap') {
+        handlersRef.current.onResync?.(data.formId);
+      } else if (data.type === 'error') {
         // eslint-disable-next-line no-console
-        console.log(`✅ WebSocket connected to form ${formId} analytics`);
+        console.warn('WebSocket subscription error:', data);
       }
     },
-    onConnect: () => {
-      // eslint-disable-next-line no-console
-      console.log(`🚀 WebSocket connected to form ${formId}`);
-    },
-    onDisconnect: () => {
-      // eslint-disable-next-line no-console
-      console.warn(`🔌 WebSocket disconnected from form ${formId}`);
-    },
-    onError: error => {
-      // eslint-disable-next-line no-console
-      console.error(`❌ WebSocket connection error for form ${formId}:`, error);
-      // eslint-disable-next-line no-console
-      console.error(`WebSocket URL: ${url}`);
-    },
     reconnectAttempts: 5,
     reconnectInterval: 3000,
+    getAuthToken: () =>
+      typeof window !== 'undefined' ? localStorage.getItem('authToken') : null,
   });
+
+  // Follow changes to the subscribed forms on the open connection
+  const formIdsKey = formIds.join(',');
+  useEffect(() => {
+    const previous = subscriptionRef.current;
+    const next = { formIds, allForms };
+    subscriptionRef.current = next;
+
+    const added = next.formIds.filter(id => !previous.formIds.includes(id));
+    const removed = previous.formIds.filter(id => !next.formIds.includes(id));
+    if (added.length > 0 || (allForms && !previous.allForms)) {
+      sendMessage({
+        type: 'subscribe',
+        formIds: added,
+        allForms: allForms && !previous.allForms,
+      });
+    }
+    if (removed.length > 0 || (!allForms && previous.allForms)) {
+      sendMessage({
+        type: 'unsubscribe',
+        formIds: removed,
+        allForms: !allForms && previous.allForms,
+      });
+    }
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [formIdsKey, allForms]);
+
+  return { ...connection, sendMessage };
+}
+
+// Hook for form analytics WebSocket connection
+export function useFormAnalyticsWebSocket(
+  formId: string,
+  onAnalyticsUpdate?: (analytics: AnalyticsUpdate) => void,
+  onPresenceUpdate?: (presence: PresenceUpdate) => void,
+  onResync?: () => void
+) {
+  return useAnalyticsSubscriptions(
+    { formIds: [formId] },
+    {
+      onAnalyticsUpdate: (_formId, analytics) => onAnalyticsUpdate?.(analytics),
+      onPresenceUpdate,
+      onResync,
+    }
+  );
+}
+
+// Hook reading the Server-Sent Events fallback stream of a form, for networks
+// that block WebSockets. EventSource cannot send an Authorization header, so
+// the stream is read with fetch. Reconnects resume from the last event ID.
+export function useFormAnalyticsStream(
+  formId: string,
+  enabled: boolean,
+  handlers: AnalyticsSubscriptionHandlers = {}
+) {
+  const [isConnected, setIsConnected] = useState(false);
+  const handlersRef = useRef(handlers);
+  handlersRef.current = handlers;
+
+  useEffect(() => {
+    if (!enabled) {
+      return;
+    }
+
+    const apiUrl = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080';
+    const controller = new AbortController();
+    let lastEventId: string | null = null;
+    let retryDelay = 3000;
+    const missedUpdate = createSequenceTracker();
+
+    const handleEvent = (event: string) => {
+      let data = '';
+      for (const line of event.split('\n')) {
+        if (line.startsWith('id: ')) {
+          lastEventId = line.slice(4);
+        } else if (line.startsWith('data: ')) {
+          data += line.slice(6);
+        } else if (line.startsWith('retry: ')) {
+          retryDelay = Number(line.slice(7)) || retryDelay;
+        }
+      }
+      if (!data) {
+        return;
+      }
+
+      const message: WebSocketMessage = JSON.parse(data);
+      if (message.type === 'analytics:update') {
+        handlersRef.current.onAnalyticsUpdate?.(formId, message.data);
+        if (missedUpdate(formId, message.data)) {
+          handlersRef.current.onResync?.(formId);
+        }
+      } else if (message.type === 'presence:update') {
+        handlersRef.current.onPresenceUpdate?.(message.data);
+      } else if (message.type === 'replay:gap') {
+        handlersRef.current.onResync?.(formId);
+      }
+    };
+
+    const read = async () => {
+      while (!controller.signal.aborted) {
+        try {
+          const token = localStorage.getItem('authToken');
+          const response = await fetch(
+            `${apiUrl}/api/forms/${formId}/analytics/stream`,
+            {
+              headers: {
+                Accept: 'text/event-stream',
+                ...(token && { Authorization: `Bearer ${token}` }),
+                ...(lastEventId && { 'Last-Event-ID': lastEventId }),
+              },
+              signal: controller.signal,
+            }
+          );
+          if (response.status === 401 || response.status === 404) {
+            return;
+          }
+          if (!response.ok || !response.body) {
+            throw new Error(`HTTP ${response.status}`);
+          }
+
+          setIsConnected(true);
+          const reader = response.body.getReader();
+          const decoder = new TextDecoder();
+          let buffer = '';
+          for (;;) {
+            const { done, value } = await reader.read();
+            if (done) {
+              break;
+            }
+            buffer += decoder.decode(value, { stream: true });
+            const events = buffer.split('\n\n');
+            buffer = events.pop() ?? '';
+            events.forEach(handleEvent);
+          }
+        } catch (error) {
+          if (controller.signal.aborted) {
+            return;
+          }
+          // eslint-disable-next-line no-console
+          console.warn(`Analytics stream for form ${formId} failed:`, error);
+        }
+
+        setIsConnected(false);
+        await new Promise(resolve => setTimeout(resolve, retryDelay));
+      }
+    };
+
+    read();
+    return () => {
+      controller.abort();
+      setIsConnected(false);
+    };
+  }, [formId, enabled]);
+
+  return { isConnected };
 }